@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
 
@@ -30,8 +36,169 @@ Example:
 	},
 }
 
+var accountsBrandsCmd = &cobra.Command{
+	Use:   "brands",
+	Short: "Show accepted card brands and account capabilities",
+	Long: `Show the card brands accepted by the account, whether each brand supports
+3D Secure on PAY.JP, and whether live mode is enabled.
+
+With --require-live or --require-brands the command exits with an error when
+the account does not meet the requirement, so it can gate deploys.
+
+Example:
+  payjp accounts brands
+  payjp accounts brands -o json
+  payjp accounts brands --require-live --require-brands Visa,MasterCard,JCB`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		requireLive, _ := cmd.Flags().GetBool("require-live")
+		requireBrands, _ := cmd.Flags().GetStringSlice("require-brands")
+
+		account, err := client.GetAccount().Retrieve()
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		result := summarizeCapabilities(account)
+		missing := missingBrands(result.Brands, requireBrands)
+		result.Ready = result.LiveModeEnabled && len(missing) == 0 && acceptsAnyBrand(result.Brands)
+
+		if getOutputFormat() == "table" {
+			if err := outputResult(result.Brands); err != nil {
+				return err
+			}
+			live := "disabled"
+			if result.LiveModeEnabled {
+				live = "enabled"
+				if result.LiveModeActivatedAt != "" {
+					live += " (since " + result.LiveModeActivatedAt + ")"
+				}
+			}
+			fmt.Printf("Live mode: %s\n", live)
+			fmt.Printf("Details submitted: %v\n", result.DetailsSubmitted)
+			fmt.Printf("Currencies: %s\n", strings.Join(result.Currencies, ", "))
+		} else if err := outputResult(result); err != nil {
+			return err
+		}
+
+		if requireLive && !result.LiveModeEnabled {
+			return i18n.Errorf("live mode is not enabled for account %s", result.AccountID)
+		}
+		if len(missing) > 0 {
+			return i18n.Errorf("card brands not accepted: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	},
+}
+
+// threeDSecureBrands are the card brands for which PAY.JP supports 3D Secure
+var threeDSecureBrands = map[string]bool{
+	"Visa":             true,
+	"MasterCard":       true,
+	"JCB":              true,
+	"American Express": true,
+	"Diners Club":      true,
+	"Discover":         true,
+}
+
+// knownBrands are the card brands PAY.JP can accept, in display order
+var knownBrands = []string{"Visa", "MasterCard", "JCB", "American Express", "Diners Club", "Discover"}
+
+// accountCapabilities describes what the account can accept
+type accountCapabilities struct {
+	AccountID           string            `json:"account_id"`
+	LiveModeEnabled     bool              `json:"livemode_enabled"`
+	LiveModeActivatedAt string            `json:"livemode_activated_at,omitempty"`
+	DetailsSubmitted    bool              `json:"details_submitted"`
+	Currencies          []string          `json:"currencies_supported"`
+	Brands              []brandCapability `json:"brands"`
+	// Ready is true when live mode is enabled, a brand is accepted and required brands are accepted
+	Ready bool `json:"ready"`
+}
+
+// brandCapability describes whether a card brand is accepted and supports 3D Secure
+type brandCapability struct {
+	Brand        string `json:"brand"`
+	Accepted     bool   `json:"accepted"`
+	ThreeDSecure bool   `json:"three_d_secure"`
+}
+
+// TableColumns returns the fields shown in list view
+func (brandCapability) TableColumns() []string {
+	return []string{"Brand", "Accepted", "ThreeDSecure"}
+}
+
+// summarizeCapabilities builds the capabilities of an account
+// Known brands are always listed; accepted brands that are not known are appended
+func summarizeCapabilities(account *payjp.AccountResponse) accountCapabilities {
+	merchant := account.Merchant
+	result := accountCapabilities{
+		AccountID:        account.ID,
+		LiveModeEnabled:  merchant.LiveModeEnabled,
+		DetailsSubmitted: merchant.DetailsSubmitted,
+		Currencies:       merchant.CurrenciesSupported,
+		Brands:           []brandCapability{},
+	}
+	if merchant.RawLiveModeActivatedAt != nil {
+		result.LiveModeActivatedAt = formatDate(merchant.LiveModeActivatedAt)
+	}
+
+	accepted := map[string]bool{}
+	for _, brand := range merchant.BrandsAccepted {
+		accepted[brand] = true
+	}
+	brands := append([]string{}, knownBrands...)
+	for _, brand := range merchant.BrandsAccepted {
+		if !slices.Contains(knownBrands, brand) {
+			brands = append(brands, brand)
+		}
+	}
+	for _, brand := range brands {
+		result.Brands = append(result.Brands, brandCapability{
+			Brand:        brand,
+			Accepted:     accepted[brand],
+			ThreeDSecure: threeDSecureBrands[brand],
+		})
+	}
+	return result
+}
+
+// acceptsAnyBrand reports whether at least one brand is accepted
+func acceptsAnyBrand(brands []brandCapability) bool {
+	for _, brand := range brands {
+		if brand.Accepted {
+			return true
+		}
+	}
+	return false
+}
+
+// missingBrands returns the required brands that are not accepted, compared case-insensitively
+func missingBrands(brands []brandCapability, required []string) []string {
+	missing := []string{}
+	for _, want := range required {
+		want = strings.TrimSpace(want)
+		found := false
+		for _, brand := range brands {
+			if strings.EqualFold(brand.Brand, want) && brand.Accepted {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, want)
+		}
+	}
+	return missing
+}
+
 func init() {
 	rootCmd.AddCommand(accountsCmd)
 
 	accountsCmd.AddCommand(accountsGetCmd)
+	accountsCmd.AddCommand(accountsBrandsCmd)
+
+	// Brands flags
+	accountsBrandsCmd.Flags().Bool("require-live", false, "Exit with an error if live mode is not enabled")
+	accountsBrandsCmd.Flags().StringSlice("require-brands", nil, "Exit with an error if any of these brands is not accepted (e.g. Visa,JCB)")
 }
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/manifest"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply a declarative resource manifest",
+	Long: `Compare the plans described in a YAML manifest with the plans in the
+account and create or update them to match.
+
+Amount, currency, interval, trial_days and billing_day cannot be changed on an
+existing plan; such differences are reported as conflicts and nothing is
+applied. With --prune, plans that are not in the manifest are deleted;
+protected plans (see payjp protect) are only deleted with --force.
+
+-f also accepts a directory: every .yaml and .yml file directly in it is read
+as part of one manifest, such as the files written by payjp plans dump.
+
+Manifest format:
+  plans:
+    - id: basic-monthly
+      name: Basic
+      amount: 980
+      currency: jpy
+      interval: month
+      trial_days: 14
+      metadata:
+        tier: basic
+
+Example:
+  payjp apply -f resources.yaml --dry-run
+  payjp apply -f resources.yaml
+  payjp apply -f resources.yaml --prune
+  payjp apply -f plans/ --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("filename")
+		prune, _ := cmd.Flags().GetBool("prune")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		m, err := manifest.Load(file)
+		if err != nil {
+			return err
+		}
+
+		plans, err := fetchAll("plans", func(limit, offset int) ([]*payjp.PlanResponse, bool, error) {
+			return client.GetPlan().List().Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		changes := manifest.DiffPlans(m.Plans, plans, prune)
+
+		conflicts := 0
+		deletes := 0
+		for _, change := range changes {
+			switch change.Action {
+			case manifest.ActionConflict:
+				conflicts++
+			case manifest.ActionDelete:
+				deletes++
+			}
+		}
+
+		table := getOutputFormat() == "table"
+		if table {
+			printChanges(changes)
+		}
+
+		if conflicts > 0 {
+			if !table {
+				if err := outputResult(changes); err != nil {
+					return err
+				}
+			}
+			return i18n.Errorf("%d plan(s) change immutable fields; create a plan with a new id instead", conflicts)
+		}
+
+		if dryRun || len(changes) == 0 {
+			for i := range changes {
+				changes[i].Status = "planned"
+			}
+			if table {
+				printStatus("%d change(s), nothing applied", len(changes))
+				return nil
+			}
+			return outputResult(changes)
+		}
+
+		if deletes > 0 {
+			ids := []string{}
+			for _, change := range changes {
+				if change.Action == manifest.ActionDelete {
+					ids = append(ids, change.ID)
+				}
+			}
+			if err := checkProtected(cmd, ids...); err != nil {
+				return err
+			}
+			if err := confirmAction(cmd, fmt.Sprintf("Delete %d plan(s) not in the manifest?", deletes)); err != nil {
+				return err
+			}
+		}
+
+		for i := range changes {
+			change := &changes[i]
+			if err := applyChange(change); err != nil {
+				handleError(err)
+				return nil
+			}
+			change.Status = "applied"
+			printStatus("Applied: %s plan %s", change.Action, change.ID)
+		}
+
+		if table {
+			printSuccess("%d change(s) applied", len(changes))
+			return nil
+		}
+		return outputResult(changes)
+	},
+}
+
+// printChanges prints a summary of the changes, one line per resource
+func printChanges(changes []manifest.Change) {
+	if len(changes) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+
+	symbols := map[string]string{
+		manifest.ActionCreate:   "+",
+		manifest.ActionUpdate:   "~",
+		manifest.ActionDelete:   "-",
+		manifest.ActionConflict: "!",
+	}
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s %s", symbols[change.Action], change.Kind, change.ID)
+		switch change.Action {
+		case manifest.ActionUpdate:
+			line += fmt.Sprintf(" (%s)", strings.Join(change.Fields, ", "))
+		case manifest.ActionConflict:
+			line += fmt.Sprintf(" (immutable: %s)", strings.Join(change.Fields, ", "))
+		}
+		fmt.Println(line)
+	}
+}
+
+// applyChange performs the API call for a change
+func applyChange(change *manifest.Change) error {
+	switch change.Action {
+	case manifest.ActionCreate:
+		plan := change.Plan
+		_, err := client.GetPlan().Create(payjp.Plan{
+			ID:         plan.ID,
+			Name:       plan.Name,
+			Amount:     plan.Amount,
+			Currency:   plan.Currency,
+			Interval:   plan.Interval,
+			TrialDays:  plan.TrialDays,
+			BillingDay: plan.BillingDay,
+			Metadata:   plan.Metadata,
+		})
+		return err
+	case manifest.ActionUpdate:
+		// Removed metadata keys are cleared by sending an empty value
+		metadata := make(map[string]string, len(change.Plan.Metadata)+len(change.RemovedMetadata))
+		for key, value := range change.Plan.Metadata {
+			metadata[key] = value
+		}
+		for _, key := range change.RemovedMetadata {
+			metadata[key] = ""
+		}
+		_, err := client.GetPlan().Update(change.ID, payjp.Plan{
+			Name:     change.Plan.Name,
+			Metadata: metadata,
+		})
+		return err
+	case manifest.ActionDelete:
+		return client.GetPlan().Delete(change.ID)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringP("filename", "f", "", "Manifest file or directory (- for stdin)")
+	applyCmd.Flags().Bool("prune", false, "Delete plans that are not in the manifest")
+	applyCmd.Flags().Bool("dry-run", false, "Show the changes without applying them")
+	applyCmd.MarkFlagRequired("filename")
+	addForceFlag(applyCmd)
+}
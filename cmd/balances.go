@@ -3,8 +3,8 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/payjp/payjp-cli/internal/capability"
 	"github.com/payjp/payjp-cli/internal/client"
-	"github.com/payjp/payjp-cli/internal/util"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
@@ -14,6 +14,9 @@ var balancesCmd = &cobra.Command{
 	Aliases: []string{"balance"},
 	Short:   "Manage balances",
 	Long:    `Retrieve and list account balances.`,
+	Annotations: map[string]string{
+		annotationCapability: capability.Balances,
+	},
 }
 
 var balancesGetCmd = &cobra.Command{
@@ -45,12 +48,17 @@ var balancesListCmd = &cobra.Command{
 Example:
   payjp balances list --limit 10
   payjp balances list --owner merchant`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
-		since, _ := cmd.Flags().GetString("since")
-		until, _ := cmd.Flags().GetString("until")
 		owner, _ := cmd.Flags().GetString("owner")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
 
 		params := payjp.BalanceListParams{}
 
@@ -60,25 +68,18 @@ Example:
 		if offset > 0 {
 			params.Offset = payjp.Int(offset)
 		}
-		if since != "" {
-			ts, err := util.ParseTimestamp(since)
-			if err != nil {
-				return err
-			}
-			// Note: int conversion is safe for Unix timestamps (valid until year 2038 on 32-bit)
-			// The PAY.JP API expects int values for timestamps
-			params.Since = payjp.Int(int(ts))
+		// Note: int conversion is safe for Unix timestamps (valid until year 2038 on 32-bit)
+		// The PAY.JP API expects int values for timestamps
+		if !since.IsZero() {
+			params.Since = payjp.Int(int(since.Unix()))
 		}
-		if until != "" {
-			ts, err := util.ParseTimestamp(until)
-			if err != nil {
-				return err
-			}
-			params.Until = payjp.Int(int(ts))
+		if !until.IsZero() {
+			params.Until = payjp.Int(int(until.Unix()))
 		}
 		if owner != "" {
 			params.Owner = payjp.String(owner)
 		}
+		params.Tenant = client.TenantParam()
 
 		result, _, err := client.GetBalance().All(&params)
 		if err != nil {
@@ -136,7 +137,6 @@ func init() {
 	// List flags
 	balancesListCmd.Flags().Int("limit", 10, "Number of items to return")
 	balancesListCmd.Flags().Int("offset", 0, "Offset for pagination")
-	balancesListCmd.Flags().String("since", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
-	balancesListCmd.Flags().String("until", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
+	addTimeRangeFlags(balancesListCmd)
 	balancesListCmd.Flags().String("owner", "", "Filter by owner type (merchant, tenant)")
 }
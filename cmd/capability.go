@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/capability"
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// annotationCapability names the capability that a command and its subcommands depend on
+const annotationCapability = "payjp:capability"
+
+// commandCapability is the capability the running command depends on, if any
+// It is used by handleError to explain a 403 or 404 caused by a missing feature
+var commandCapability string
+
+// setCommandCapability records the capability of a command from its annotation or --tenant
+func setCommandCapability(cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		if name, ok := c.Annotations[annotationCapability]; ok {
+			commandCapability = name
+			return
+		}
+	}
+	if tenantID == "" {
+		return
+	}
+	if cmd == transfersListCmd {
+		commandCapability = capability.TenantTransfers
+		return
+	}
+	commandCapability = capability.Tenants
+}
+
+// unsupportedError replaces a 403 or 404 API error with a clear error when the probe of the
+// capability shows that the account or the API does not have the feature
+// Any other error, or a failure of a supported feature (e.g. an unknown ID), is returned as is
+func unsupportedError(name string, err error) error {
+	payjpErr, ok := err.(*payjp.Error)
+	if name == "" || !ok || capability.StatusOf(payjpErr.Status) != capability.StatusUnsupported {
+		return err
+	}
+	c, ok := capability.Lookup(name)
+	if !ok {
+		return err
+	}
+	result := detectCapability(c, false)
+	if result.Status != capability.StatusUnsupported {
+		return err
+	}
+	printVerbose("Original error: %s (status %d)", payjpErr.Message, payjpErr.Status)
+	return i18n.Errorf("%s is not supported by your account or the API (GET /v1%s returned %d); run 'payjp debug capabilities' to see what is available",
+		c.Name, c.Path, result.HTTPStatus)
+}
+
+// detectCapability returns whether the account of the current API key supports a capability
+// Results are cached per profile and mode; refresh probes again regardless of the cache
+func detectCapability(c capability.Capability, refresh bool) capability.Result {
+	profile, _ := config.GetCurrentProfile()
+	account := capability.AccountKey(profile, client.IsLiveKey())
+	path := capability.Path(config.ConfigDir())
+	cache := capability.Load(path)
+	if result, ok := cache.Get(account, c.Name); ok && !refresh && result.Fresh(time.Now()) {
+		return result
+	}
+
+	printVerbose("Probing %s: GET /v1%s", c.Name, c.Path)
+	result := capability.Result{Status: capability.StatusUnknown, CheckedAt: time.Now()}
+	probe, err := client.Probe(c.Path)
+	if err != nil {
+		printVerbose("Probe of %s failed: %v", c.Name, err)
+		return result
+	}
+	result.HTTPStatus = probe.Status
+	result.Status = capability.StatusOf(probe.Status)
+	if probe.Status == http.StatusUnauthorized {
+		printVerbose("Probe of %s was not authorized; check the API key with payjp debug auth", c.Name)
+	}
+	if result.Status != capability.StatusUnknown {
+		cache.Set(account, c.Name, result)
+		// Failing to cache the result only means the feature is probed again
+		cache.Save(path)
+	}
+	return result
+}
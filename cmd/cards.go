@@ -2,8 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
 	"github.com/payjp/payjp-cli/internal/util"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
@@ -29,20 +35,15 @@ Example:
 		card, _ := cmd.Flags().GetString("card")
 
 		if card == "" {
-			return fmt.Errorf("--card is required")
+			return i18n.Errorf("--card is required")
 		}
 
-		customer, err := client.GetCustomer().Retrieve(customerID)
-		if err != nil {
-			handleError(err)
-			return nil
-		}
-
-		result, err := customer.AddCardToken(card)
+		result, err := client.GetCustomer().AddCardToken(customerID, card)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		forgetCustomer(customerID)
 
 		return outputResult(result)
 	},
@@ -60,13 +61,7 @@ Example:
 		customerID := args[0]
 		cardID := args[1]
 
-		customer, err := client.GetCustomer().Retrieve(customerID)
-		if err != nil {
-			handleError(err)
-			return nil
-		}
-
-		result, err := customer.GetCard(cardID)
+		result, err := client.GetCustomer().GetCard(customerID, cardID)
 		if err != nil {
 			handleError(err)
 			return nil
@@ -89,21 +84,15 @@ Example:
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
 
-		customer, err := client.GetCustomer().Retrieve(customerID)
-		if err != nil {
-			handleError(err)
-			return nil
-		}
-
-		caller := customer.ListCard()
+		params := url.Values{}
 		if limit > 0 {
-			caller.Limit(limit)
+			params.Set("limit", strconv.Itoa(limit))
 		}
 		if offset > 0 {
-			caller.Offset(offset)
+			params.Set("offset", strconv.Itoa(offset))
 		}
 
-		result, _, err := caller.Do()
+		result, _, err := client.ListCards(customerID, params)
 		if err != nil {
 			handleError(err)
 			return nil
@@ -120,7 +109,13 @@ var cardsUpdateCmd = &cobra.Command{
 
 Example:
   payjp cards update cus_xxxxx car_xxxxx --name "PAY TARO"
-  payjp cards update cus_xxxxx car_xxxxx --address-zip "1000001"`,
+  payjp cards update cus_xxxxx car_xxxxx --address-zip "1000001"
+  payjp cards update cus_xxxxx car_xxxxx --country us --address-zip 94105
+
+--country must be an ISO 3166-1 alpha-2 code and is sent in upper case.
+--address-zip is checked against the format of the country for JP, US, CA
+and GB; without --country, the country the card already has is used. Use
+--no-validate to send the values as given.`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		customerID := args[0]
@@ -133,11 +128,31 @@ Example:
 		addressLine2, _ := cmd.Flags().GetString("address-line2")
 		country, _ := cmd.Flags().GetString("country")
 		metadata, _ := cmd.Flags().GetString("metadata")
-
-		customer, err := client.GetCustomer().Retrieve(customerID)
-		if err != nil {
-			handleError(err)
-			return nil
+		noValidate, _ := cmd.Flags().GetBool("no-validate")
+
+		// Typos in the address are caught before the request; --no-validate sends them as given
+		if !noValidate {
+			if country != "" {
+				normalized, err := util.NormalizeCountryCode(country)
+				if err != nil {
+					return err
+				}
+				country = normalized
+			}
+			if addressZip != "" {
+				zipCountry := country
+				if zipCountry == "" {
+					existing, err := client.GetCustomer().GetCard(customerID, cardID)
+					if err != nil {
+						handleError(err)
+						return nil
+					}
+					zipCountry = strings.ToUpper(existing.Country)
+				}
+				if err := util.ValidatePostalCode(addressZip, zipCountry); err != nil {
+					return err
+				}
+			}
 		}
 
 		card := payjp.Card{}
@@ -167,11 +182,12 @@ Example:
 			card.Metadata = util.ParseMetadata(metadata)
 		}
 
-		result, err := customer.UpdateCard(cardID, card)
+		result, err := client.GetCustomer().UpdateCard(customerID, cardID, card)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		forgetCustomer(customerID)
 
 		return outputResult(result)
 	},
@@ -189,17 +205,19 @@ Example:
 		customerID := args[0]
 		cardID := args[1]
 
-		customer, err := client.GetCustomer().Retrieve(customerID)
-		if err != nil {
-			handleError(err)
-			return nil
+		if err := checkProtected(cmd, customerID, cardID); err != nil {
+			return err
+		}
+		if err := confirmAction(cmd, fmt.Sprintf("Delete card %s from customer %s?", cardID, customerID)); err != nil {
+			return err
 		}
 
-		err = customer.DeleteCard(cardID)
+		err := client.GetCustomer().DeleteCard(customerID, cardID)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		forgetCustomer(customerID)
 
 		if quiet {
 			return nil
@@ -212,6 +230,158 @@ Example:
 	},
 }
 
+var cardsExpiringCmd = &cobra.Command{
+	Use:   "expiring",
+	Short: "List customers whose default card is expiring",
+	Long: `Page through customers and list those whose default card expires within
+the given window (already expired cards are included).
+
+Example:
+  payjp cards expiring --within 60d
+  payjp cards expiring --within 8w -o csv > expiring.csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		within, _ := cmd.Flags().GetString("within")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		window, err := util.ParseDuration(within)
+		if err != nil {
+			return err
+		}
+		if concurrency < 1 {
+			return i18n.Errorf("concurrency must be at least 1")
+		}
+
+		deadline := time.Now().Add(window)
+
+		customers, err := listAllCustomers()
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		cards, err := fetchDefaultCards(customers, concurrency)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		result := []expiringCard{}
+		for _, customer := range customers {
+			card, ok := cards[customer.ID]
+			if !ok {
+				continue
+			}
+			expiresAt := cardExpiry(card.ExpYear, card.ExpMonth)
+			if expiresAt.After(deadline) {
+				continue
+			}
+			result = append(result, expiringCard{
+				CustomerID: customer.ID,
+				Email:      customer.Email,
+				CardID:     card.ID,
+				Brand:      card.Brand,
+				Last4:      card.Last4,
+				ExpMonth:   card.ExpMonth,
+				ExpYear:    card.ExpYear,
+				Expired:    expiresAt.Before(time.Now()),
+			})
+		}
+
+		return outputResult(result)
+	},
+}
+
+// expiringCard is a row of the expiring cards report
+type expiringCard struct {
+	CustomerID string `json:"customer_id"`
+	Email      string `json:"email"`
+	CardID     string `json:"card_id"`
+	Brand      string `json:"brand"`
+	Last4      string `json:"last4"`
+	ExpMonth   int    `json:"exp_month"`
+	ExpYear    int    `json:"exp_year"`
+	Expired    bool   `json:"expired"`
+}
+
+// TableColumns returns the fields shown in list view
+func (expiringCard) TableColumns() []string {
+	return []string{"CustomerID", "Email", "CardID", "Brand", "Last4", "ExpMonth", "ExpYear", "Expired"}
+}
+
+// cardExpiry returns the moment a card expires (the end of its expiry month)
+func cardExpiry(year, month int) time.Time {
+	return time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.Local)
+}
+
+// listAllCustomers pages through all customers
+func listAllCustomers() ([]*payjp.CustomerResponse, error) {
+	return fetchAll("customers", func(limit, offset int) ([]*payjp.CustomerResponse, bool, error) {
+		return client.GetCustomer().List().Limit(limit).Offset(offset).Do()
+	})
+}
+
+// fetchDefaultCards returns the default card of each customer keyed by customer ID
+// Cards embedded in the customer object are used when present, others are fetched concurrently
+func fetchDefaultCards(customers []*payjp.CustomerResponse, concurrency int) (map[string]*payjp.CardResponse, error) {
+	cards := make(map[string]*payjp.CardResponse)
+	missing := []*payjp.CustomerResponse{}
+
+	for _, customer := range customers {
+		if customer.DefaultCard == "" {
+			continue
+		}
+		found := false
+		for _, card := range customer.Cards {
+			if card != nil && card.ID == customer.DefaultCard {
+				cards[customer.ID] = card
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, customer)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	queue := make(chan *payjp.CustomerResponse)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for customer := range queue {
+				printVerbose("Fetching card %s for customer %s", customer.DefaultCard, customer.ID)
+				card, err := client.GetCustomer().GetCard(customer.ID, customer.DefaultCard)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					cards[customer.ID] = card
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, customer := range missing {
+		queue <- customer
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return cards, nil
+}
+
 func init() {
 	rootCmd.AddCommand(cardsCmd)
 
@@ -220,6 +390,7 @@ func init() {
 	cardsCmd.AddCommand(cardsListCmd)
 	cardsCmd.AddCommand(cardsUpdateCmd)
 	cardsCmd.AddCommand(cardsDeleteCmd)
+	cardsCmd.AddCommand(cardsExpiringCmd)
 
 	// Create flags
 	cardsCreateCmd.Flags().String("card", "", "Token ID (required)")
@@ -238,4 +409,12 @@ func init() {
 	cardsUpdateCmd.Flags().String("address-line2", "", "Address line 2")
 	cardsUpdateCmd.Flags().String("country", "", "Country code (e.g., JP)")
 	cardsUpdateCmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
+	cardsUpdateCmd.Flags().Bool("no-validate", false, "Send --country and --address-zip without checking their format")
+
+	// Expiring flags
+	cardsExpiringCmd.Flags().String("within", "30d", "Expiry window (e.g. 30d, 8w)")
+	cardsExpiringCmd.Flags().Int("concurrency", 4, "Number of concurrent card requests")
+
+	// Delete flags
+	addForceFlag(cardsDeleteCmd)
 }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/changelog"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+// releaseNotesURL is the release notes file of a tagged release
+const releaseNotesURL = "https://raw.githubusercontent.com/payjp/payjp-cli/%s/internal/changelog/changelog.yaml"
+
+// changelogRow is one change in the changelog output
+type changelogRow struct {
+	Version  string `json:"version"`
+	Date     string `json:"date"`
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Summary  string `json:"summary"`
+	Breaking bool   `json:"breaking"`
+}
+
+// TableColumns returns the fields shown in list view
+func (changelogRow) TableColumns() []string {
+	return []string{"Version", "Date", "Type", "Scope", "Summary", "Breaking"}
+}
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Show release notes",
+	Long: `Show the changes of each release, newest first.
+
+The release notes are built into the CLI, so by default the changes up to
+the installed version are shown. --since shows only the changes made after a
+version, e.g. to review what an upgrade from that version brings. With
+--latest the release notes of the latest release are downloaded and the
+changes between the installed version and the latest release are shown.
+
+Each change has a type (added, changed, fixed, deprecated, removed or
+security), a scope (the command, or global for flags and settings shared by
+all commands) and whether it is breaking. Use -o json for tooling.
+
+Example:
+  payjp changelog
+  payjp changelog --since v1.2.0
+  payjp changelog --since v1.2.0 --breaking -o json
+  payjp changelog --latest`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, _ := cmd.Flags().GetString("since")
+		latest, _ := cmd.Flags().GetBool("latest")
+		breakingOnly, _ := cmd.Flags().GetBool("breaking")
+
+		if since != "" && !changelog.IsRelease(since) {
+			return i18n.Errorf("invalid version: %s (use a release version such as v1.2.0)", since)
+		}
+
+		var releases []changelog.Release
+		var until string
+		if latest {
+			tag, err := fetchLatestVersion()
+			if err != nil {
+				return fmt.Errorf("failed to check for updates: %w", err)
+			}
+			data, err := fetchReleaseNotes(tag)
+			if err != nil {
+				return fmt.Errorf("failed to download the release notes of %s: %w", tag, err)
+			}
+			if releases, err = changelog.Parse(data); err != nil {
+				return err
+			}
+			if since == "" && changelog.IsRelease(Version) {
+				since = Version
+			}
+			until = tag
+			printStatus("Changes from %s to %s", Version, tag)
+		} else {
+			var err error
+			if releases, err = changelog.Embedded(); err != nil {
+				return err
+			}
+			// Development builds also show the changes that are not released yet
+			if changelog.IsRelease(Version) {
+				until = Version
+			}
+		}
+
+		rows := []changelogRow{}
+		for _, release := range changelog.Between(releases, since, until) {
+			for _, change := range release.Changes {
+				if breakingOnly && !change.Breaking {
+					continue
+				}
+				rows = append(rows, changelogRow{
+					Version:  release.Version,
+					Date:     release.Date,
+					Type:     change.Type,
+					Scope:    change.Scope,
+					Summary:  change.Summary,
+					Breaking: change.Breaking,
+				})
+			}
+		}
+		if len(rows) == 0 {
+			printStatus("No changes")
+		}
+
+		return outputResult(rows)
+	},
+}
+
+// fetchReleaseNotes downloads the release notes file of a tagged release
+func fetchReleaseNotes(tag string) ([]byte, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Get(fmt.Sprintf(releaseNotesURL, tag))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().String("since", "", "Show only changes made after this version (e.g. v1.2.0)")
+	changelogCmd.Flags().Bool("latest", false, "Download the notes of the latest release and show the changes since the installed version")
+	changelogCmd.Flags().Bool("breaking", false, "Show only breaking changes")
+}
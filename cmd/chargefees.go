@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/capability"
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-go/v1"
+)
+
+// chargeFeeRow is a captured charge with its fee breakdown in charges list --fee-breakdown
+type chargeFeeRow struct {
+	ID                  string `json:"id"`
+	Tenant              string `json:"tenant"`
+	Currency            string `json:"currency"`
+	Amount              int    `json:"amount"`
+	AmountRefunded      int    `json:"amount_refunded"`
+	FeeRate             string `json:"fee_rate"`
+	ProcessingFeeAmount int    `json:"processing_fee_amount"`
+	PlatformFeeRate     string `json:"platform_fee_rate"`
+	PlatformFeeAmount   int    `json:"platform_fee_amount"`
+	TenantNetAmount     int    `json:"tenant_net_amount"`
+}
+
+// TableColumns returns the fields shown in list view
+func (chargeFeeRow) TableColumns() []string {
+	return []string{"ID", "Tenant", "Currency", "Amount", "AmountRefunded", "FeeRate", "ProcessingFeeAmount", "PlatformFeeRate", "PlatformFeeAmount", "TenantNetAmount"}
+}
+
+// tenantFeeTotal is the sum of the fee breakdowns of the charges of a tenant in one currency
+type tenantFeeTotal struct {
+	Tenant              string `json:"tenant"`
+	Currency            string `json:"currency"`
+	Charges             int    `json:"charges"`
+	Amount              int    `json:"amount"`
+	AmountRefunded      int    `json:"amount_refunded"`
+	ProcessingFeeAmount int    `json:"processing_fee_amount"`
+	PlatformFeeAmount   int    `json:"platform_fee_amount"`
+	TenantNetAmount     int    `json:"tenant_net_amount"`
+}
+
+// TableColumns returns the fields shown in list view
+func (tenantFeeTotal) TableColumns() []string {
+	return []string{"Tenant", "Currency", "Charges", "Amount", "AmountRefunded", "ProcessingFeeAmount", "PlatformFeeAmount", "TenantNetAmount"}
+}
+
+// chargeFeeBreakdown pages through charges and computes their fee breakdowns and totals per tenant
+type chargeFeeBreakdown struct {
+	fallbackRate string
+	// tenantRates caches the platform_fee_rate of tenants for charges without platform_fee
+	tenantRates map[string]string
+	rows        []chargeFeeRow
+	totals      map[string]*tenantFeeTotal
+}
+
+// newChargeFeeBreakdown creates a breakdown using fallbackRate for charges without fee_rate
+func newChargeFeeBreakdown(fallbackRate string) *chargeFeeBreakdown {
+	return &chargeFeeBreakdown{
+		fallbackRate: fallbackRate,
+		tenantRates:  map[string]string{},
+		totals:       map[string]*tenantFeeTotal{},
+	}
+}
+
+// add computes the fee breakdown of a charge and adds it to the total of its tenant
+// Only captured charges carry fees; failed and uncaptured charges are skipped
+func (b *chargeFeeBreakdown) add(charge *payjp.ChargeResponse, platform *client.PlatformFields) error {
+	if !charge.Paid || !charge.Captured {
+		return nil
+	}
+	if platform.Tenant != "" && platform.PlatformFee == nil && platform.PlatformFeeRate == "" {
+		rate, ok := b.tenantRates[platform.Tenant]
+		if !ok {
+			printVerbose("Retrieving platform fee rate of tenant %s", platform.Tenant)
+			var err error
+			if rate, err = client.RetrieveTenantFeeRate(platform.Tenant); err != nil {
+				return err
+			}
+			b.tenantRates[platform.Tenant] = rate
+		}
+		platform.PlatformFeeRate = rate
+	}
+
+	fees, err := computeChargeFees(charge, platform, b.fallbackRate)
+	if err != nil {
+		return err
+	}
+	b.rows = append(b.rows, chargeFeeRow{
+		ID:                  charge.ID,
+		Tenant:              fees.Tenant,
+		Currency:            fees.Currency,
+		Amount:              fees.Amount,
+		AmountRefunded:      fees.AmountRefunded,
+		FeeRate:             fees.FeeRate,
+		ProcessingFeeAmount: fees.ProcessingFeeAmount,
+		PlatformFeeRate:     fees.PlatformFeeRate,
+		PlatformFeeAmount:   fees.PlatformFeeAmount,
+		TenantNetAmount:     fees.NetAmount,
+	})
+
+	key := fees.Tenant + "\x00" + fees.Currency
+	total, ok := b.totals[key]
+	if !ok {
+		total = &tenantFeeTotal{Tenant: fees.Tenant, Currency: fees.Currency}
+		b.totals[key] = total
+	}
+	total.Charges++
+	total.Amount += fees.Amount
+	total.AmountRefunded += fees.AmountRefunded
+	total.ProcessingFeeAmount += fees.ProcessingFeeAmount
+	total.PlatformFeeAmount += fees.PlatformFeeAmount
+	total.TenantNetAmount += fees.NetAmount
+	return nil
+}
+
+// totalRows returns the totals sorted by tenant and currency
+func (b *chargeFeeBreakdown) totalRows() []tenantFeeTotal {
+	rows := make([]tenantFeeTotal, 0, len(b.totals))
+	for _, total := range b.totals {
+		rows = append(rows, *total)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Tenant != rows[j].Tenant {
+			return rows[i].Tenant < rows[j].Tenant
+		}
+		return rows[i].Currency < rows[j].Currency
+	})
+	return rows
+}
+
+// chargeListParams returns the query of a charges listing that is sent without the SDK
+func chargeListParams(since, until time.Time, customer, subscription string) url.Values {
+	params := url.Values{}
+	if !since.IsZero() {
+		params.Set("since", strconv.FormatInt(since.Unix(), 10))
+	}
+	if !until.IsZero() {
+		params.Set("until", strconv.FormatInt(until.Unix(), 10))
+	}
+	if customer != "" {
+		params.Set("customer", customer)
+	}
+	if subscription != "" {
+		params.Set("subscription", subscription)
+	}
+	if tenant := client.Tenant(); tenant != "" {
+		params.Set("tenant", tenant)
+	}
+	return params
+}
+
+// listChargeFees pages through every charge matching params and keep and outputs their fee
+// breakdowns, or with totals only the totals per tenant
+// Table output shows the totals after the charges
+func listChargeFees(params url.Values, keep func(*payjp.ChargeResponse) bool, fallbackRate string, totals bool) error {
+	breakdown := newChargeFeeBreakdown(fallbackRate)
+	var addErr error
+	err := forEach("charges", func(limit, offset int) ([]client.ChargeWithPlatform, bool, error) {
+		if addErr != nil {
+			return nil, false, nil
+		}
+		params.Set("limit", strconv.Itoa(limit))
+		params.Set("offset", strconv.Itoa(offset))
+		return client.ListChargesWithPlatform(params)
+	}, func(item client.ChargeWithPlatform) {
+		if addErr != nil || (keep != nil && !keep(item.Charge)) {
+			return
+		}
+		addErr = breakdown.add(item.Charge, item.Platform)
+	})
+	if err != nil {
+		handleError(err)
+		return nil
+	}
+	// A failed tenant lookup is an API error; a charge without fee_rate is a usage error
+	if _, ok := addErr.(*payjp.Error); ok {
+		handleError(unsupportedError(capability.Tenants, addErr))
+		return nil
+	}
+	if addErr != nil {
+		return addErr
+	}
+
+	if totals {
+		return outputResult(breakdown.totalRows())
+	}
+	if err := outputResult(breakdown.rows); err != nil {
+		return err
+	}
+	if getOutputFormat() == "table" {
+		fmt.Println("Totals per tenant:")
+		return outputResult(breakdown.totalRows())
+	}
+	return nil
+}
@@ -1,10 +1,22 @@
 package cmd
 
 import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/payjp/payjp-cli/internal/capability"
+	"github.com/payjp/payjp-cli/internal/checkpoint"
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/spend"
 	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-cli/internal/workflow"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
@@ -24,7 +36,15 @@ var chargesCreateCmd = &cobra.Command{
 Example:
   payjp charges create --amount 1000 --currency jpy --card tok_xxxxx
   payjp charges create --amount 1000 --currency jpy --customer cus_xxxxx
-  payjp charges create --amount 1000 --currency jpy --card tok_xxxxx --capture=false`,
+  payjp charges create --amount 1000 --currency jpy --card tok_xxxxx --capture=false
+  payjp charges create --amount 1000 --card tok_xxxxx --customer-email user@example.com
+  payjp charges create --amount 1000 --customer-email user@example.com --create-if-missing=false
+  payjp charges create --amount 1000 --customer cus_xxxxx --idempotency-key order-1234
+
+With --idempotency-key the key is sent as the Idempotency-Key header and the
+created charge is recorded in the local ledger of the profile (see payjp
+ledger). Running the command again with the same key prints the charge that
+was already created instead of creating another one.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		amount, _ := cmd.Flags().GetInt("amount")
 		currency, _ := cmd.Flags().GetString("currency")
@@ -35,6 +55,10 @@ Example:
 		expiryDays, _ := cmd.Flags().GetInt("expiry-days")
 		metadata, _ := cmd.Flags().GetString("metadata")
 		threeDSecure, _ := cmd.Flags().GetBool("three-d-secure")
+		overrideLimit, _ := cmd.Flags().GetBool("override-limit")
+		customerEmail, _ := cmd.Flags().GetString("customer-email")
+		createIfMissing, _ := cmd.Flags().GetBool("create-if-missing")
+		idempotencyKey, _ := cmd.Flags().GetString("idempotency-key")
 
 		if err := util.ValidateAmount(amount); err != nil {
 			return err
@@ -42,17 +66,63 @@ Example:
 		if err := util.ValidateCurrency(currency); err != nil {
 			return err
 		}
+		if customerEmail != "" && customer != "" {
+			return i18n.Errorf("--customer and --customer-email cannot be used together")
+		}
+
+		profileName, _ := config.GetCurrentProfile()
+		if idempotencyKey != "" {
+			created, ok, err := ledgerCharge(profileName, idempotencyKey, amount, currency)
+			if err != nil {
+				return err
+			}
+			if ok {
+				printStatus("Charge %s was already created with idempotency key %s", created, idempotencyKey)
+				result, err := client.GetCharge().Retrieve(created)
+				if err != nil {
+					handleError(err)
+					return nil
+				}
+				return outputResult(result)
+			}
+		}
+
+		// Live charges are recorded in the spend ledger, which stays locked until then
+		live := config.IsLiveMode() || client.IsLiveKey()
+		var ledger *spend.Locked
+		if live {
+			var err error
+			if ledger, err = spend.Lock(spend.Path(config.ConfigDir())); err != nil {
+				return err
+			}
+			defer ledger.Unlock()
+		}
+		if live && !overrideLimit {
+			if err := checkChargeLimits(ledger, amount, currency); err != nil {
+				return err
+			}
+		}
 
 		charge := payjp.Charge{
 			Currency: currency,
 			Capture:  capture,
 		}
 
-		if card != "" {
-			charge.CardToken = card
-		}
-		if customer != "" {
-			charge.CustomerID = customer
+		if customerEmail != "" {
+			customerID, cardID, err := resolveChargeCustomer(customerEmail, card, createIfMissing)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			charge.CustomerID = customerID
+			charge.CustomerCardID = cardID
+		} else {
+			if card != "" {
+				charge.CardToken = card
+			}
+			if customer != "" {
+				charge.CustomerID = customer
+			}
 		}
 		if description != "" {
 			charge.Description = description
@@ -68,37 +138,352 @@ Example:
 			charge.ThreeDSecure = &tds
 		}
 
-		result, err := client.GetCharge().Create(amount, charge)
+		var result *payjp.ChargeResponse
+		var err error
+		if idempotencyKey != "" {
+			err = client.Idempotent(idempotencyKey, func() error {
+				result, err = client.GetCharge().Create(amount, charge)
+				return err
+			})
+		} else {
+			result, err = client.GetCharge().Create(amount, charge)
+		}
 		if err != nil {
 			handleError(err)
 			return nil
 		}
 
+		if idempotencyKey != "" {
+			if err := recordLedgerCharge(profileName, idempotencyKey, result); err != nil {
+				printStatus("Warning: failed to record the charge in the idempotency ledger: %v", err)
+			}
+		}
+		if ledger != nil {
+			if err := ledger.Record(profileName, currency, amount); err != nil {
+				printStatus("Warning: failed to record spend: %v", err)
+			}
+		}
+
 		return outputResult(result)
 	},
 }
 
+// resolveChargeCustomer finds the customer with the given email, creating it if allowed,
+// and attaches the token as a card unless the customer already has the same card. It returns
+// the customer ID and the card ID to charge (empty to use the default card)
+func resolveChargeCustomer(email, token string, createIfMissing bool) (string, string, error) {
+	matches, err := findCustomersByEmail(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch len(matches) {
+	case 0:
+		if !createIfMissing {
+			return "", "", i18n.Errorf("no customer found with email %s", email)
+		}
+		if token == "" {
+			return "", "", i18n.Errorf("no customer found with email %s; --card is required to create one", email)
+		}
+		created, err := client.GetCustomer().Create(payjp.Customer{
+			Email:     email,
+			CardToken: token,
+		})
+		if err != nil {
+			return "", "", err
+		}
+		printStatus("Created customer %s (%s)", created.ID, email)
+		rememberCustomerEmail(email, created.ID)
+		// The token becomes the default card of the new customer
+		return created.ID, "", nil
+	case 1:
+		customer := matches[0]
+		printStatus("Using existing customer %s (%s)", customer.ID, email)
+		if token == "" {
+			return customer.ID, "", nil
+		}
+		cardID, err := attachChargeCard(customer.ID, token)
+		if err != nil {
+			return "", "", err
+		}
+		return customer.ID, cardID, nil
+	default:
+		ids := make([]string, len(matches))
+		for i, c := range matches {
+			ids[i] = c.ID
+		}
+		return "", "", i18n.Errorf("multiple customers found with email %s: %s (use --customer)", email, strings.Join(ids, ", "))
+	}
+}
+
+// attachChargeCard returns the card of a customer with the fingerprint of the token's card,
+// or adds the token as a new card, so that charging a returning customer does not save the
+// same card again
+func attachChargeCard(customerID, token string) (string, error) {
+	tok, err := client.GetToken().Retrieve(token)
+	if err != nil {
+		return "", err
+	}
+	cards, err := fetchAll("cards", func(limit, offset int) ([]*payjp.CardResponse, bool, error) {
+		return client.GetCustomer().ListCard(customerID).Limit(limit).Offset(offset).Do()
+	})
+	if err != nil {
+		return "", err
+	}
+	if ids := matchingCards(tok.Card.Fingerprint, cards); len(ids) > 0 {
+		printStatus("Card is already saved on %s as %s; charging it", customerID, ids[0])
+		return ids[0], nil
+	}
+
+	card, err := client.GetCustomer().AddCardToken(customerID, token)
+	if err != nil {
+		return "", err
+	}
+	forgetCustomer(customerID)
+	printVerbose("Added card %s to customer %s", card.ID, customerID)
+	return card.ID, nil
+}
+
+// checkChargeLimits enforces the configured per-charge cap and daily total for the current profile
+// The daily total is kept per currency; the caller holds the lock of the ledger until the charge
+// is recorded, so that concurrent charges cannot both pass the check
+func checkChargeLimits(ledger *spend.Locked, amount int, currency string) error {
+	limits := config.GetLimitsConfig()
+
+	if limits.MaxChargeAmount > 0 && amount > limits.MaxChargeAmount {
+		return i18n.Errorf("amount %d exceeds limits.max_charge_amount (%d); use --override-limit to proceed", amount, limits.MaxChargeAmount)
+	}
+
+	if limits.DailyChargeTotal > 0 {
+		totals, err := ledger.Load()
+		if err != nil {
+			return err
+		}
+		profileName, _ := config.GetCurrentProfile()
+		total := totals.Total(spend.Day(time.Now()), profileName, currency)
+		if total+amount > limits.DailyChargeTotal {
+			return i18n.Errorf("charging %d %s would exceed limits.daily_charge_total (%d charged today of %d) for profile '%s'; use --override-limit to proceed",
+				amount, currency, total, limits.DailyChargeTotal, profileName)
+		}
+	}
+
+	return nil
+}
+
 var chargesGetCmd = &cobra.Command{
 	Use:   "get <charge_id>",
 	Short: "Get charge information",
 	Long: `Retrieve information about a specific charge.
 
+With --with-fees, a fee breakdown (processing fee, platform fee and the net
+amount the merchant receives) is computed from the charge's fee_rate and, for
+PAY.JP Platform charges, the platform fee or the tenant's platform_fee_rate.
+Fees are computed on the charge amount and rounded down; --fee-rate is used
+when the charge has no fee_rate.
+
 Example:
-  payjp charges get ch_xxxxx`,
+  payjp charges get ch_xxxxx
+  payjp charges get ch_xxxxx --with-fees
+  payjp charges get ch_xxxxx --with-fees --fee-rate 3.6 -o json`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		chargeID := args[0]
+		withFees, _ := cmd.Flags().GetBool("with-fees")
+		feeRate, _ := cmd.Flags().GetString("fee-rate")
+
+		if !withFees {
+			result, err := client.GetCharge().Retrieve(chargeID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+
+			return outputResult(result)
+		}
 
-		result, err := client.GetCharge().Retrieve(chargeID)
+		charge, platform, err := client.RetrieveChargeWithPlatform(chargeID)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		if platform.Tenant != "" && platform.PlatformFee == nil && platform.PlatformFeeRate == "" {
+			printVerbose("Retrieving platform fee rate of tenant %s", platform.Tenant)
+			rate, err := client.RetrieveTenantFeeRate(platform.Tenant)
+			if err != nil {
+				handleError(unsupportedError(capability.Tenants, err))
+				return nil
+			}
+			platform.PlatformFeeRate = rate
+		}
 
-		return outputResult(result)
+		fees, err := computeChargeFees(charge, platform, feeRate)
+		if err != nil {
+			return err
+		}
+
+		if getOutputFormat() == "table" {
+			if err := outputResult(charge); err != nil {
+				return err
+			}
+			fmt.Println("Fee breakdown:")
+			return outputResult(fees)
+		}
+
+		return outputResult(chargeWithFees{ChargeResponse: charge, Fees: fees})
+	},
+}
+
+// chargeWithFees is a charge with its fee breakdown for structured output
+type chargeWithFees struct {
+	*payjp.ChargeResponse
+	Fees chargeFees `json:"fees"`
+}
+
+// chargeFees is the fee breakdown of a charge
+type chargeFees struct {
+	Currency            string `json:"currency"`
+	Amount              int    `json:"amount"`
+	AmountRefunded      int    `json:"amount_refunded"`
+	FeeRate             string `json:"fee_rate"`
+	ProcessingFeeAmount int    `json:"processing_fee_amount"`
+	Tenant              string `json:"tenant,omitempty"`
+	PlatformFeeRate     string `json:"platform_fee_rate,omitempty"`
+	PlatformFeeAmount   int    `json:"platform_fee_amount"`
+	NetAmount           int    `json:"net_amount"`
+}
+
+// computeChargeFees computes the fee breakdown of a charge
+// The platform fee returned on the charge takes precedence over one computed from the rate
+func computeChargeFees(charge *payjp.ChargeResponse, platform *client.PlatformFields, fallbackRate string) (chargeFees, error) {
+	fees := chargeFees{
+		Currency:       charge.Currency,
+		Amount:         charge.Amount,
+		AmountRefunded: charge.AmountRefunded,
+		FeeRate:        charge.FeeRate,
+		Tenant:         platform.Tenant,
+	}
+	if fees.FeeRate == "" {
+		fees.FeeRate = fallbackRate
+	}
+	if fees.FeeRate == "" {
+		return fees, i18n.Errorf("charge %s has no fee_rate (use --fee-rate)", charge.ID)
+	}
+
+	processing, err := feeAmount(charge.Amount, fees.FeeRate)
+	if err != nil {
+		return fees, err
+	}
+	fees.ProcessingFeeAmount = processing
+
+	if platform.PlatformFee != nil {
+		fees.PlatformFeeRate = platform.PlatformFeeRate
+		fees.PlatformFeeAmount = *platform.PlatformFee
+	} else if platform.PlatformFeeRate != "" {
+		fees.PlatformFeeRate = platform.PlatformFeeRate
+		if fees.PlatformFeeAmount, err = feeAmount(charge.Amount, platform.PlatformFeeRate); err != nil {
+			return fees, err
+		}
+	}
+
+	fees.NetAmount = charge.Amount - charge.AmountRefunded - fees.ProcessingFeeAmount - fees.PlatformFeeAmount
+	return fees, nil
+}
+
+// feeAmount applies a percentage rate such as "3.00" to an amount, rounding down
+func feeAmount(amount int, rate string) (int, error) {
+	r, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(rate), "%"), 64)
+	if err != nil || r < 0 || r > 100 {
+		return 0, i18n.Errorf("invalid fee rate: %s (use a percentage such as 3.6)", rate)
+	}
+	// Round the rate to basis points to avoid float error (e.g. 3.6% of 1000)
+	return amount * int(math.Round(r*100)) / 10000, nil
+}
+
+var chargesEstimateFeesCmd = &cobra.Command{
+	Use:   "estimate-fees",
+	Short: "Estimate the fees and net amount of a hypothetical charge",
+	Long: `Estimate the processing fee, platform fee and net amount of a charge
+amount without creating anything.
+
+The processing fee rate is --fee-rate, or else the fee_rate of the most
+recent of the last 100 charges (made with a --card-brand card when given, as
+rates differ by brand). To always use a fixed rate, set it as
+defaults.charges.estimate-fees.fee-rate in the config file.
+
+With --tenant (PAY.JP Platform), the platform fee is computed from the
+tenant's platform_fee_rate; --platform-fee-rate tries another rate. Fees are
+rounded down as in charges get --with-fees.
+
+Example:
+  payjp charges estimate-fees --amount 10000
+  payjp charges estimate-fees --amount 10000 --card-brand JCB
+  payjp charges estimate-fees --amount 10000 --fee-rate 3.0 --platform-fee-rate 10
+  payjp charges estimate-fees --amount 10000 --tenant ten_xxxxx -o json`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, _ := cmd.Flags().GetInt("amount")
+		currency, _ := cmd.Flags().GetString("currency")
+		feeRate, _ := cmd.Flags().GetString("fee-rate")
+		brand, _ := cmd.Flags().GetString("card-brand")
+		platformRate, _ := cmd.Flags().GetString("platform-fee-rate")
+
+		if amount <= 0 {
+			return i18n.Errorf("amount must be greater than 0")
+		}
+
+		if feeRate == "" {
+			printVerbose("Looking up the fee rate of recent charges")
+			charge, err := recentChargeWithFeeRate(brand)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			if charge == nil {
+				return i18n.Errorf("no recent charge has a fee_rate (use --fee-rate)")
+			}
+			feeRate = charge.FeeRate
+			printStatus("Using the fee rate %s%% of charge %s (%s)", feeRate, charge.ID, charge.Card.Brand)
+		}
+
+		platform := &client.PlatformFields{Tenant: tenantID, PlatformFeeRate: platformRate}
+		if tenantID != "" && platformRate == "" {
+			printVerbose("Retrieving platform fee rate of tenant %s", tenantID)
+			rate, err := client.RetrieveTenantFeeRate(tenantID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			platform.PlatformFeeRate = rate
+		}
+
+		charge := &payjp.ChargeResponse{Amount: amount, Currency: strings.ToLower(currency), FeeRate: feeRate}
+		fees, err := computeChargeFees(charge, platform, "")
+		if err != nil {
+			return err
+		}
+		return outputResult(fees)
 	},
 }
 
+// recentChargeWithFeeRate returns the most recent of the last 100 charges that has a fee rate
+// When brand is given only charges made with a card of that brand are considered
+func recentChargeWithFeeRate(brand string) (*payjp.ChargeResponse, error) {
+	charges, _, err := client.GetCharge().List().Limit(100).Do()
+	if err != nil {
+		return nil, err
+	}
+	for _, charge := range charges {
+		if charge.FeeRate == "" || (brand != "" && !strings.EqualFold(charge.Card.Brand, brand)) {
+			continue
+		}
+		return charge, nil
+	}
+	return nil, nil
+}
+
 var chargesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List charges",
@@ -106,14 +491,75 @@ var chargesListCmd = &cobra.Command{
 
 Example:
   payjp charges list --limit 10
-  payjp charges list --customer cus_xxxxx`,
+  payjp charges list --customer cus_xxxxx
+  payjp charges list --metadata order_id=1234
+  payjp charges list --metadata "order_id=2024-*"
+  payjp charges list --all --card-brand Visa --last4 4242
+  payjp charges list --all --fingerprint xxxxx --since 2024-06-01
+  payjp charges list --all --output csv > charges.csv
+  payjp charges list --group-by day --range 2024-06-01..2024-06-30
+  payjp charges list --group-by status --since 2024-06-01
+  payjp charges list --all --has-3ds --since 2024-06-01
+  payjp charges list --all --3ds-status failed,error
+  payjp charges list --tenant ten_xxxxx --fee-breakdown --range 2024-06-01..2024-06-30
+  payjp charges list --fee-breakdown --totals --range 2024-06-01..2024-06-30 -o csv > tenant_fees.csv
+
+With --group-by, all charges matching the filters are paged through and
+counts and sums per bucket (and currency) are shown instead of rows. Failed
+charges are counted separately and not included in the amounts. --limit
+cannot be given; narrow the period with --since/--until instead.
+Status is one of succeeded, authorized, partially_refunded, refunded or failed.
+
+--has-3ds keeps charges that went through 3D Secure, and --3ds-status keeps
+charges with any of the given three_d_secure_status values (none for
+charges without 3D Secure). See report 3ds for a summary.
+
+Metadata, card and 3D Secure filters (--card-brand, --last4, --card-country,
+--fingerprint, --has-3ds, --3ds-status) are applied while paging through
+charges, so --limit counts matching charges. Narrow the period with
+--since/--until to fetch fewer pages.
+
+With --fee-breakdown, all captured charges matching the filters are paged
+through and listed with their processing fee, platform fee and tenant net
+amount, computed as in charges get --with-fees, followed by totals per tenant
+and currency. --totals outputs only the totals, e.g. as CSV to check the
+monthly transfers to tenants. Failed and uncaptured charges are skipped.`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
-		since, _ := cmd.Flags().GetString("since")
-		until, _ := cmd.Flags().GetString("until")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
 		customer, _ := cmd.Flags().GetString("customer")
 		subscription, _ := cmd.Flags().GetString("subscription")
+		metadata, _ := cmd.Flags().GetString("metadata")
+		all, _ := cmd.Flags().GetBool("all")
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		feeBreakdown, _ := cmd.Flags().GetBool("fee-breakdown")
+		totals, _ := cmd.Flags().GetBool("totals")
+		feeRate, _ := cmd.Flags().GetString("fee-rate")
+
+		if feeBreakdown {
+			if groupBy != "" || offset > 0 {
+				return i18n.Errorf("--fee-breakdown cannot be used with --group-by or --offset")
+			}
+		} else if totals {
+			return i18n.Errorf("--totals requires --fee-breakdown")
+		}
+		if groupBy != "" {
+			if !slices.Contains(chargeGroupKeys, groupBy) {
+				return i18n.Errorf("invalid group-by: %s (supported: %s)", groupBy, strings.Join(chargeGroupKeys, ", "))
+			}
+			// Every matching charge is aggregated, so a limit given on the command line would
+			// silently be ignored; a limit from the defaults is for plain listing
+			if all || offset > 0 || flagOnCommandLine(cmd, "limit") {
+				return i18n.Errorf("--group-by cannot be used with --all, --offset or --limit")
+			}
+		}
 
 		caller := client.GetCharge().List()
 
@@ -123,19 +569,11 @@ Example:
 		if offset > 0 {
 			caller.Offset(offset)
 		}
-		if since != "" {
-			ts, err := util.ParseTimestamp(since)
-			if err != nil {
-				return err
-			}
-			caller.Since(time.Unix(ts, 0))
+		if !since.IsZero() {
+			caller.Since(since)
 		}
-		if until != "" {
-			ts, err := util.ParseTimestamp(until)
-			if err != nil {
-				return err
-			}
-			caller.Until(time.Unix(ts, 0))
+		if !until.IsZero() {
+			caller.Until(until)
 		}
 		if customer != "" {
 			caller.CustomerID(customer)
@@ -143,6 +581,78 @@ Example:
 		if subscription != "" {
 			caller.SubscriptionID(subscription)
 		}
+		caller.Tenant = client.TenantParam()
+
+		var filter map[string]string
+		if metadata != "" {
+			filter = util.ParseMetadata(metadata)
+			if filter == nil {
+				return i18n.Errorf("invalid metadata filter: %s (use key=value or key=prefix*)", metadata)
+			}
+		}
+		card, err := parseChargeCardFilter(cmd)
+		if err != nil {
+			return err
+		}
+		tds, err := parseChargeTdsFilter(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Metadata, card attributes and 3D Secure status cannot be queried, so they are matched client-side
+		var keep func(*payjp.ChargeResponse) bool
+		if filter != nil || card != nil || tds != nil {
+			keep = func(charge *payjp.ChargeResponse) bool {
+				if filter != nil && !util.MatchMetadata(charge.Metadata, filter) {
+					return false
+				}
+				if tds != nil && !tds[chargeTdsStatus(charge)] {
+					return false
+				}
+				return card == nil || card.match(&charge.Card)
+			}
+		}
+
+		if feeBreakdown {
+			return listChargeFees(chargeListParams(since, until, customer, subscription), keep, feeRate, totals)
+		}
+
+		if groupBy != "" {
+			groups := newChargeGroups(groupBy)
+			err := forEach("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, func(charge *payjp.ChargeResponse) {
+				if keep == nil || keep(charge) {
+					groups.add(charge)
+				}
+			})
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			return outputResult(groups.rows())
+		}
+
+		if all {
+			err := streamAll("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, keep)
+			if err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
+		if keep != nil {
+			result, err := listFiltered("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, keep, limit, offset)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			return outputResult(result)
+		}
 
 		result, _, err := caller.Do()
 		if err != nil {
@@ -154,6 +664,91 @@ Example:
 	},
 }
 
+// chargeCardFilter matches the card a charge was made with; empty fields match any card
+type chargeCardFilter struct {
+	brand       string
+	last4       string
+	country     string
+	fingerprint string
+}
+
+// parseChargeCardFilter reads the card attribute flags, returning nil when none is given
+func parseChargeCardFilter(cmd *cobra.Command) (*chargeCardFilter, error) {
+	f := &chargeCardFilter{}
+	f.brand, _ = cmd.Flags().GetString("card-brand")
+	f.last4, _ = cmd.Flags().GetString("last4")
+	f.country, _ = cmd.Flags().GetString("card-country")
+	f.fingerprint, _ = cmd.Flags().GetString("fingerprint")
+
+	if *f == (chargeCardFilter{}) {
+		return nil, nil
+	}
+	if f.last4 != "" && (len(f.last4) != 4 || strings.Trim(f.last4, "0123456789") != "") {
+		return nil, i18n.Errorf("invalid last4: %s (use the last 4 digits of the card number)", f.last4)
+	}
+	if f.country != "" && len(f.country) != 2 {
+		return nil, i18n.Errorf("invalid card country: %s (use a 2-letter ISO country code such as JP)", f.country)
+	}
+	return f, nil
+}
+
+// match reports whether a card has all the attributes of the filter
+// Brand and country are compared case-insensitively, so "visa" matches "Visa"
+func (f *chargeCardFilter) match(card *payjp.CardResponse) bool {
+	return (f.brand == "" || strings.EqualFold(card.Brand, f.brand)) &&
+		(f.last4 == "" || card.Last4 == f.last4) &&
+		(f.country == "" || strings.EqualFold(card.Country, f.country)) &&
+		(f.fingerprint == "" || card.Fingerprint == f.fingerprint)
+}
+
+// tdsStatuses are the 3D Secure statuses of a charge; none is a charge without 3D Secure
+var tdsStatuses = []string{"verified", "attempted", "failed", "error", "unverified", "in_progress", "abandoned", "not_supported", "none"}
+
+// chargeTdsStatus returns the three_d_secure_status of a charge, or none when 3D Secure was not used
+func chargeTdsStatus(charge *payjp.ChargeResponse) string {
+	if charge.ThreeDSecureStatus == nil || *charge.ThreeDSecureStatus == "" {
+		return "none"
+	}
+	return *charge.ThreeDSecureStatus
+}
+
+// awaitingTdsFinish reports whether 3D Secure authentication of a charge has finished but
+// tds-finish has not been called, so the charge is not paid yet
+func awaitingTdsFinish(charge *payjp.ChargeResponse) bool {
+	status := chargeTdsStatus(charge)
+	return (status == "verified" || status == "attempted") && !charge.Paid && charge.FailureCode == ""
+}
+
+// parseChargeTdsFilter reads --has-3ds and --3ds-status into the set of statuses to keep
+// It returns nil when neither is given
+func parseChargeTdsFilter(cmd *cobra.Command) (map[string]bool, error) {
+	has3DS, _ := cmd.Flags().GetBool("has-3ds")
+	statuses, _ := cmd.Flags().GetStringSlice("3ds-status")
+	if !has3DS && len(statuses) == 0 {
+		return nil, nil
+	}
+
+	keep := map[string]bool{}
+	for _, status := range statuses {
+		status = strings.ToLower(strings.TrimSpace(status))
+		if !slices.Contains(tdsStatuses, status) {
+			return nil, i18n.Errorf("invalid 3D Secure status: %s (use %s)", status, strings.Join(tdsStatuses, ", "))
+		}
+		keep[status] = true
+	}
+	if has3DS {
+		if keep["none"] {
+			return nil, i18n.Errorf("--has-3ds cannot be used with --3ds-status none")
+		}
+		if len(keep) == 0 {
+			for _, status := range tdsStatuses {
+				keep[status] = status != "none"
+			}
+		}
+	}
+	return keep, nil
+}
+
 var chargesUpdateCmd = &cobra.Command{
 	Use:   "update <charge_id>",
 	Short: "Update charge information",
@@ -191,6 +786,9 @@ var chargesCaptureCmd = &cobra.Command{
 	Short: "Capture an authorized charge",
 	Long: `Capture an authorized charge.
 
+When --amount is given, it must not exceed the authorized amount. The
+authorized, captured, and released amounts are reported on stderr.
+
 Example:
   payjp charges capture ch_xxxxx
   payjp charges capture ch_xxxxx --amount 500`,
@@ -203,54 +801,415 @@ Example:
 		var err error
 
 		if amount > 0 {
+			// Validate the partial capture against the authorization before calling the API
+			charge, err := client.GetCharge().Retrieve(chargeID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			if charge.Captured {
+				return i18n.Errorf("charge %s is already captured", chargeID)
+			}
+			if amount > charge.Amount {
+				return i18n.Errorf("capture amount %d exceeds authorized amount %d", amount, charge.Amount)
+			}
+
 			result, err = client.GetCharge().Capture(chargeID, amount)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+
+			if !quiet {
+				printStatus("Authorized: %s", util.FormatAmount(charge.Amount, charge.Currency))
+				printStatus("Captured:   %s", util.FormatAmount(amount, charge.Currency))
+				printStatus("Released:   %s", util.FormatAmount(charge.Amount-amount, charge.Currency))
+			}
 		} else {
 			result, err = client.GetCharge().Capture(chargeID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
 		}
 
+		return outputResult(result)
+	},
+}
+
+var chargesReauthorizeCmd = &cobra.Command{
+	Use:   "reauthorize <charge_id>",
+	Short: "Replace an expiring authorization with a new one",
+	Long: `Extend an authorization hold by authorizing the remaining amount again.
+
+A new uncaptured charge for the remaining (not yet released) amount is created
+on the same customer's card, then the old authorization is voided. If voiding
+the old authorization fails, the new one is voided again so that the card never
+carries both holds. An already expired authorization is not voided.
+
+The charges are linked through the reauthorized_from and reauthorized_to
+metadata keys. Only charges made with a customer's card can be reauthorized.
+
+The steps are printed as a plan before confirming, and --dry-run only prints
+the plan. Completed steps are recorded in a checkpoint file. With --no-rollback
+a failed run keeps the new authorization instead of voiding it, and --resume
+continues from the failed step.
+
+Example:
+  payjp charges reauthorize ch_xxxxx
+  payjp charges reauthorize ch_xxxxx --expiry-days 30
+  payjp charges reauthorize ch_xxxxx --dry-run
+  payjp charges reauthorize ch_xxxxx --resume ~/.payjp/checkpoints/charges-reauthorize-20240601-120000.jsonl`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		chargeID := args[0]
+		expiryDays, _ := cmd.Flags().GetInt("expiry-days")
+		overrideLimit, _ := cmd.Flags().GetBool("override-limit")
+
+		if expiryDays < 1 || expiryDays > 60 {
+			return i18n.Errorf("--expiry-days must be between 1 and 60")
+		}
+
+		cp, err := openWorkflowCheckpoint(cmd)
+		if err != nil {
+			return err
+		}
+		if cp != nil {
+			defer cp.Close()
+		}
+
+		charge, err := client.GetCharge().Retrieve(chargeID)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		amount := charge.Amount - charge.AmountRefunded
+		expired := charge.RawExpiredAt != nil && charge.ExpiredAt.Before(time.Now())
 
-		return outputResult(result)
+		// Live charges are recorded in the spend ledger, which stays locked until the command ends
+		var ledger *spend.Locked
+		if config.IsLiveMode() || client.IsLiveKey() {
+			if ledger, err = spend.Lock(spend.Path(config.ConfigDir())); err != nil {
+				return err
+			}
+			defer ledger.Unlock()
+		}
+
+		var created *payjp.ChargeResponse
+		steps := []workflow.Step{{
+			Name:        "authorize",
+			Description: fmt.Sprintf("Authorize %s on card %s of %s", util.FormatAmount(amount, charge.Currency), charge.Card.ID, charge.CustomerID),
+			Run: func() (string, error) {
+				metadata := make(map[string]string, len(charge.Metadata)+1)
+				for key, value := range charge.Metadata {
+					metadata[key] = value
+				}
+				metadata[reauthorizedFromKey] = chargeID
+
+				var err error
+				created, err = client.GetCharge().Create(amount, payjp.Charge{
+					Currency:       charge.Currency,
+					CustomerID:     charge.CustomerID,
+					CustomerCardID: charge.Card.ID,
+					Capture:        false,
+					Description:    charge.Description,
+					ExpireDays:     expiryDays,
+					Metadata:       metadata,
+				})
+				if err != nil {
+					return "", err
+				}
+				if ledger != nil {
+					profileName, _ := config.GetCurrentProfile()
+					if err := ledger.Record(profileName, charge.Currency, amount); err != nil {
+						printStatus("Warning: failed to record spend: %v", err)
+					}
+				}
+				printStatus("Authorized %s as %s (expires %s)", util.FormatAmount(amount, charge.Currency), created.ID, created.ExpiredAt.Local().Format(time.RFC3339))
+				return created.ID, nil
+			},
+			// Void the new authorization so that the card does not carry both holds
+			Compensate: func(newID string) error {
+				_, err := client.GetCharge().Refund(newID, "reauthorization rolled back")
+				return err
+			},
+		}}
+		if expired {
+			printStatus("%s has already expired; nothing to void", chargeID)
+		} else {
+			steps = append(steps, workflow.Step{
+				Name:        "void",
+				Description: fmt.Sprintf("Void %s", chargeID),
+				Run: func() (string, error) {
+					if _, err := client.GetCharge().Refund(chargeID, "reauthorized as "+created.ID); err != nil {
+						return "", err
+					}
+					printSuccess("Voided %s", chargeID)
+					return "", nil
+				},
+			})
+		}
+		// Linking the old charge is informational only
+		steps = append(steps, workflow.Step{
+			Name:        "link",
+			Description: fmt.Sprintf("Link %s to the new charge with the %s metadata", chargeID, reauthorizedToKey),
+			Run: func() (string, error) {
+				_, err := client.UpdateChargeMetadata(chargeID, map[string]string{reauthorizedToKey: created.ID})
+				return "", err
+			},
+			Optional: true,
+		})
+		w := newWorkflow(chargeID, cp, steps...)
+
+		// A resumed run has already authorized the new charge, which replaces the checks of the old one
+		if newID := w.Value("authorize"); newID != "" {
+			created, err = client.GetCharge().Retrieve(newID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			amount = created.Amount
+		} else {
+			if err := checkReauthorizable(charge); err != nil {
+				return err
+			}
+			if ledger != nil && !overrideLimit {
+				if err := checkChargeLimits(ledger, amount, charge.Currency); err != nil {
+					return err
+				}
+			}
+		}
+
+		printPlan(w)
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			return nil
+		}
+
+		message := fmt.Sprintf("Authorize %s again on card %s (%s ****%s) of %s and void %s?",
+			util.FormatAmount(amount, charge.Currency), charge.Card.ID, charge.Card.Brand, charge.Card.Last4, charge.CustomerID, chargeID)
+		if expired {
+			message = fmt.Sprintf("Authorize %s again on card %s (%s ****%s) of %s? (%s has already expired)",
+				util.FormatAmount(amount, charge.Currency), charge.Card.ID, charge.Card.Brand, charge.Card.Last4, charge.CustomerID, chargeID)
+		}
+		if err := confirmAction(cmd, message); err != nil {
+			return err
+		}
+
+		if err := runWorkflow(cmd, w, cp); err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(reauthorization{
+			OldChargeID: chargeID,
+			NewChargeID: created.ID,
+			Amount:      amount,
+			Currency:    charge.Currency,
+			ExpiredAt:   created.ExpiredAt,
+			OldVoided:   !expired,
+		})
 	},
 }
 
+// Metadata keys linking a reauthorized charge and its replacement
+const (
+	reauthorizedFromKey = "reauthorized_from"
+	reauthorizedToKey   = "reauthorized_to"
+)
+
+// reauthorization is the result of charges reauthorize
+type reauthorization struct {
+	OldChargeID string    `json:"old_charge_id"`
+	NewChargeID string    `json:"new_charge_id"`
+	Amount      int       `json:"amount"`
+	Currency    string    `json:"currency"`
+	ExpiredAt   time.Time `json:"expired_at"`
+	OldVoided   bool      `json:"old_voided"`
+}
+
+// checkOpenAuthorization returns an error if the charge is not an uncaptured, unreleased authorization
+func checkOpenAuthorization(charge *payjp.ChargeResponse) error {
+	if charge.Captured {
+		return i18n.Errorf("charge %s is already captured", charge.ID)
+	}
+	if !charge.Paid {
+		return i18n.Errorf("charge %s was not authorized", charge.ID)
+	}
+	if charge.Refunded || charge.AmountRefunded >= charge.Amount {
+		return i18n.Errorf("charge %s has already been voided", charge.ID)
+	}
+	return nil
+}
+
+// checkReauthorizable returns an error if the charge is not an open authorization on a customer's card
+func checkReauthorizable(charge *payjp.ChargeResponse) error {
+	if err := checkOpenAuthorization(charge); err != nil {
+		return err
+	}
+	if charge.CustomerID == "" || charge.Card.ID == "" {
+		return i18n.Errorf("charge %s was not made with a customer's card and cannot be charged again", charge.ID)
+	}
+	return nil
+}
+
 var chargesRefundCmd = &cobra.Command{
 	Use:   "refund <charge_id>",
 	Short: "Refund a charge",
 	Long: `Refund a captured charge.
 
+To release an authorization that has not been captured, use charges void.
+
 Example:
   payjp charges refund ch_xxxxx
   payjp charges refund ch_xxxxx --amount 500
-  payjp charges refund ch_xxxxx --refund-reason "Customer request"`,
+  payjp charges refund ch_xxxxx --refund-reason "Customer request"
+  payjp charges refund ch_xxxxx --reason-code duplicate --metadata ticket=1234
+
+The charge is retrieved first so that it is never refunded twice: a charge
+that is already fully refunded is skipped (exit code 0), and --amount is
+reduced to the amount not yet refunded.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		chargeID := args[0]
 		amount, _ := cmd.Flags().GetInt("amount")
 		refundReason, _ := cmd.Flags().GetString("refund-reason")
+		reasonCode, _ := cmd.Flags().GetString("reason-code")
+		metadata, _ := cmd.Flags().GetString("metadata")
 
-		var result *payjp.ChargeResponse
-		var err error
+		refundMetadata := util.ParseMetadata(metadata)
+		if reasonCode != "" {
+			if err := util.ValidateRefundReasonCode(reasonCode); err != nil {
+				return err
+			}
+			if refundMetadata == nil {
+				refundMetadata = make(map[string]string)
+			}
+			refundMetadata[refundReasonCodeKey] = reasonCode
+
+			// Tag the free-form reason with the code
+			if refundReason != "" {
+				refundReason = reasonCode + ": " + refundReason
+			} else {
+				refundReason = reasonCode
+			}
+		}
+
+		charge, amount, err := checkRefund(chargeID, amount)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		if amount == 0 {
+			printStatus("Skipped %s: already fully refunded", chargeID)
+			return outputResult(charge)
+		}
+
+		if err := confirmAction(cmd, fmt.Sprintf("Refund %s of charge %s?", util.FormatAmount(amount, charge.Currency), chargeID)); err != nil {
+			return err
+		}
 
 		// SDK signature: Refund(chargeID, reason string, amount ...int)
-		if amount > 0 {
-			result, err = client.GetCharge().Refund(chargeID, refundReason, amount)
-		} else {
-			result, err = client.GetCharge().Refund(chargeID, refundReason)
+		result, err := client.GetCharge().Refund(chargeID, refundReason, amount)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		if len(refundMetadata) > 0 {
+			printVerbose("Storing refund metadata on charge %s", chargeID)
+			// The refund has been made, so failing to label it must not report the command as failed
+			if updated, err := client.UpdateChargeMetadata(chargeID, refundMetadata); err != nil {
+				printStatus("Warning: charge %s was refunded, but storing the refund metadata failed: %v", chargeID, err)
+			} else {
+				result = updated
+			}
 		}
 
+		return outputResult(result)
+	},
+}
+
+var chargesVoidCmd = &cobra.Command{
+	Use:   "void <charge_id>",
+	Short: "Release an uncaptured authorization",
+	Long: `Release the hold of an authorized charge that has not been captured.
+
+The charge is checked first: captured charges must be refunded with charges
+refund instead, and charges that were never authorized, are already voided or
+whose authorization has expired are rejected.
+
+Example:
+  payjp charges void ch_xxxxx
+  payjp charges void ch_xxxxx --reason "Order cancelled"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		chargeID := args[0]
+		reason, _ := cmd.Flags().GetString("reason")
+
+		charge, err := client.GetCharge().Retrieve(chargeID)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		if charge.Captured {
+			return i18n.Errorf("charge %s is already captured; use charges refund to return the money", chargeID)
+		}
+		if err := checkOpenAuthorization(charge); err != nil {
+			return err
+		}
+		if charge.RawExpiredAt != nil && charge.ExpiredAt.Before(time.Now()) {
+			return i18n.Errorf("the authorization of charge %s has already expired", chargeID)
+		}
+
+		amount := charge.Amount - charge.AmountRefunded
+		if err := confirmAction(cmd, fmt.Sprintf("Void the authorization of %s for %s?", chargeID, util.FormatAmount(amount, charge.Currency))); err != nil {
+			return err
+		}
+
+		result, err := client.GetCharge().Refund(chargeID, reason)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		printSuccess("Released %s held by %s", util.FormatAmount(amount, charge.Currency), chargeID)
 
 		return outputResult(result)
 	},
 }
 
+// checkRefund retrieves a charge and returns the amount to refund, so that retried scripts and
+// bulk refunds never refund more than was paid
+// A charge that is already fully refunded returns 0 and must be skipped
+func checkRefund(chargeID string, amount int) (*payjp.ChargeResponse, int, error) {
+	charge, err := client.GetCharge().Retrieve(chargeID)
+	if err != nil {
+		return nil, 0, err
+	}
+	refund := refundAmount(charge, amount)
+	if refund > 0 && refund < amount {
+		printStatus("Warning: %s of charge %s is already refunded; refunding the remaining %s instead of %s",
+			util.FormatAmount(charge.AmountRefunded, charge.Currency), chargeID,
+			util.FormatAmount(refund, charge.Currency), util.FormatAmount(amount, charge.Currency))
+	}
+	return charge, refund, nil
+}
+
+// refundAmount reduces amount to what has not been refunded from the charge yet (all of it when
+// amount is 0)
+func refundAmount(charge *payjp.ChargeResponse, amount int) int {
+	remaining := charge.Amount - charge.AmountRefunded
+	if remaining <= 0 {
+		return 0
+	}
+	if amount == 0 || amount > remaining {
+		return remaining
+	}
+	return amount
+}
+
+// refundReasonCodeKey is the metadata key used to store the refund reason code
+const refundReasonCodeKey = "refund_reason_code"
+
 var chargesTdsFinishCmd = &cobra.Command{
 	Use:   "tds-finish <charge_id>",
 	Short: "Complete 3D Secure authentication",
@@ -272,6 +1231,306 @@ Example:
 	},
 }
 
+var chargesDedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find likely duplicate charges",
+	Long: `Scan charges for likely duplicates: paid charges for the same customer
+(or card, if there is no customer), amount, currency and order_id metadata
+created within the given window of each other.
+
+With --refund, each duplicate (the later charge) can be refunded after
+confirmation. Refunds are tagged with the duplicate reason code. Completed
+refunds are recorded in a checkpoint file; an interrupted run can be resumed
+with --resume so that charges are not refunded twice.
+Charges from the last 7 days are scanned unless a range is given.
+
+Example:
+  payjp charges dedupe --window 5m
+  payjp charges dedupe --window 10m --range fiscal_q1 -o csv
+  payjp charges dedupe --refund
+  payjp charges dedupe --refund --resume ~/.payjp/checkpoints/charges-dedupe-20240601-120000.jsonl`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		windowArg, _ := cmd.Flags().GetString("window")
+		orderKey, _ := cmd.Flags().GetString("order-key")
+		refund, _ := cmd.Flags().GetBool("refund")
+
+		window, err := util.ParseDuration(windowArg)
+		if err != nil {
+			return err
+		}
+		deadline, err := commandDeadline(cmd)
+		if err != nil {
+			return err
+		}
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+		if since.IsZero() && until.IsZero() {
+			since = time.Now().AddDate(0, 0, -7)
+		}
+
+		caller := client.GetCharge().List()
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+		charges, err := fetchAll("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+			return caller.Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		duplicates := findDuplicateCharges(charges, window, orderKey)
+
+		var cp *checkpoint.Checkpoint
+		remaining := 0
+		if refund && len(duplicates) > 0 {
+			if cp, err = openCheckpoint(cmd); err != nil {
+				return err
+			}
+			defer cp.Close()
+
+			for i := range duplicates {
+				dup := &duplicates[i]
+				progress := fmt.Sprintf("[%d/%d]", i+1, len(duplicates))
+				if cp.Done(dup.DuplicateID) {
+					dup.Refunded = true
+					printStatus("%s Skipped %s (completed in checkpoint)", progress, dup.DuplicateID)
+					continue
+				}
+				if deadlinePassed(deadline) {
+					remaining++
+					continue
+				}
+
+				message := fmt.Sprintf("Refund %s (%s, %s after %s)?",
+					dup.DuplicateID, util.FormatAmount(dup.Amount, dup.Currency), dup.Gap, dup.OriginalID)
+				if err := confirmAction(cmd, message); err != nil {
+					if err == errAborted {
+						continue
+					}
+					return err
+				}
+
+				// The listing can be stale (e.g. refunded by hand since, or on --resume), so the
+				// charge is checked again and only what has not been refunded yet is refunded
+				_, amount, err := checkRefund(dup.DuplicateID, 0)
+				if err != nil {
+					handleError(err)
+					return nil
+				}
+				if amount == 0 {
+					if err := cp.Mark(dup.DuplicateID); err != nil {
+						return err
+					}
+					dup.Skipped = true
+					printStatus("%s Skipped %s (already fully refunded)", progress, dup.DuplicateID)
+					continue
+				}
+				if _, err := client.GetCharge().Refund(dup.DuplicateID, "duplicate", amount); err != nil {
+					handleError(err)
+					return nil
+				}
+				if _, err := client.UpdateChargeMetadata(dup.DuplicateID, map[string]string{refundReasonCodeKey: "duplicate"}); err != nil {
+					printStatus("Warning: %s was refunded, but storing the refund reason code failed: %v", dup.DuplicateID, err)
+				}
+				if err := cp.Mark(dup.DuplicateID); err != nil {
+					return err
+				}
+				dup.Refunded = true
+				printStatus("%s Refunded %s", progress, dup.DuplicateID)
+			}
+		}
+
+		if len(duplicates) == 0 && getOutputFormat() == "table" {
+			printStatus("No duplicate charges found.")
+			return nil
+		}
+
+		if err := outputResult(duplicates); err != nil {
+			return err
+		}
+		return deadlineError(remaining, cp)
+	},
+}
+
+// duplicateCharge is a pair of charges that look like a double charge
+type duplicateCharge struct {
+	OriginalID  string `json:"original_id"`
+	DuplicateID string `json:"duplicate_id"`
+	Customer    string `json:"customer"`
+	Amount      int    `json:"amount"`
+	Currency    string `json:"currency"`
+	OrderID     string `json:"order_id"`
+	Gap         string `json:"gap"`
+	Refunded    bool   `json:"refunded"`
+	// Skipped is set when the duplicate was found already fully refunded at refund time
+	Skipped bool `json:"skipped"`
+}
+
+// TableColumns returns the fields shown in list view
+func (duplicateCharge) TableColumns() []string {
+	return []string{"OriginalID", "DuplicateID", "Customer", "Amount", "Currency", "OrderID", "Gap", "Refunded", "Skipped"}
+}
+
+// findDuplicateCharges groups paid, not fully refunded charges by payer, amount, currency and
+// order ID, and returns each charge created within window of the previous charge in its group
+func findDuplicateCharges(charges []*payjp.ChargeResponse, window time.Duration, orderKey string) []duplicateCharge {
+	groups := map[string][]*payjp.ChargeResponse{}
+	keys := []string{}
+	for _, charge := range charges {
+		if !charge.Paid || charge.AmountRefunded >= charge.Amount {
+			continue
+		}
+		payer := charge.CustomerID
+		if payer == "" {
+			payer = charge.Card.Fingerprint
+		}
+		key := strings.Join([]string{payer, strconv.Itoa(charge.Amount), charge.Currency, charge.Metadata[orderKey]}, "\x00")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], charge)
+	}
+
+	result := []duplicateCharge{}
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].CreatedAt.Before(group[j].CreatedAt)
+		})
+		for i := 1; i < len(group); i++ {
+			prev, charge := group[i-1], group[i]
+			gap := charge.CreatedAt.Sub(prev.CreatedAt)
+			if gap > window {
+				continue
+			}
+			customer := charge.CustomerID
+			if customer == "" {
+				customer = charge.Card.ID
+			}
+			result = append(result, duplicateCharge{
+				OriginalID:  prev.ID,
+				DuplicateID: charge.ID,
+				Customer:    customer,
+				Amount:      charge.Amount,
+				Currency:    charge.Currency,
+				OrderID:     charge.Metadata[orderKey],
+				Gap:         gap.String(),
+			})
+		}
+	}
+	return result
+}
+
+// chargeGroupKeys are the supported --group-by values of charges list
+var chargeGroupKeys = []string{"day", "week", "month", "status", "currency"}
+
+// chargeGroupRow is the aggregate of charges in a bucket and currency
+type chargeGroupRow struct {
+	Group          string `json:"group"`
+	Currency       string `json:"currency"`
+	Count          int    `json:"count"`
+	Failed         int    `json:"failed"`
+	Amount         int    `json:"amount"`
+	AmountRefunded int    `json:"amount_refunded"`
+	NetAmount      int    `json:"net_amount"`
+}
+
+// TableColumns returns the fields shown in list view
+func (chargeGroupRow) TableColumns() []string {
+	return []string{"Group", "Currency", "Count", "Failed", "Amount", "AmountRefunded", "NetAmount"}
+}
+
+// chargeGroups aggregates charges per bucket and currency as they are paged through
+type chargeGroups struct {
+	by     string
+	groups map[string]*chargeGroupRow
+}
+
+// newChargeGroups creates an aggregator for a --group-by value
+func newChargeGroups(by string) *chargeGroups {
+	return &chargeGroups{by: by, groups: map[string]*chargeGroupRow{}}
+}
+
+// add adds a charge to its bucket
+// Failed charges are counted but not included in the amounts
+func (g *chargeGroups) add(charge *payjp.ChargeResponse) {
+	group := chargeGroup(g.by, charge)
+	key := group + "\x00" + charge.Currency
+	row, ok := g.groups[key]
+	if !ok {
+		row = &chargeGroupRow{Group: group, Currency: charge.Currency}
+		g.groups[key] = row
+	}
+	row.Count++
+	if !charge.Paid {
+		row.Failed++
+		return
+	}
+	row.Amount += charge.Amount
+	row.AmountRefunded += charge.AmountRefunded
+	row.NetAmount = row.Amount - row.AmountRefunded
+}
+
+// rows returns the buckets sorted by group and currency
+func (g *chargeGroups) rows() []chargeGroupRow {
+	rows := make([]chargeGroupRow, 0, len(g.groups))
+	for _, row := range g.groups {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Group != rows[j].Group {
+			return rows[i].Group < rows[j].Group
+		}
+		return rows[i].Currency < rows[j].Currency
+	})
+	return rows
+}
+
+// chargeGroup returns the bucket of a charge
+// Time buckets use local time; weeks start on Monday and are labeled with that date
+func chargeGroup(by string, charge *payjp.ChargeResponse) string {
+	created := charge.CreatedAt.Local()
+	switch by {
+	case "day":
+		return created.Format("2006-01-02")
+	case "week":
+		offset := (int(created.Weekday()) + 6) % 7
+		return created.AddDate(0, 0, -offset).Format("2006-01-02")
+	case "month":
+		return created.Format("2006-01")
+	case "status":
+		return chargeStatus(charge)
+	default:
+		return charge.Currency
+	}
+}
+
+// chargeStatus summarizes the state of a charge
+func chargeStatus(charge *payjp.ChargeResponse) string {
+	switch {
+	case !charge.Paid:
+		return "failed"
+	case charge.Refunded || (charge.Amount > 0 && charge.AmountRefunded >= charge.Amount):
+		return "refunded"
+	case charge.AmountRefunded > 0:
+		return "partially_refunded"
+	case !charge.Captured:
+		return "authorized"
+	default:
+		return "succeeded"
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(chargesCmd)
 
@@ -280,8 +1539,12 @@ func init() {
 	chargesCmd.AddCommand(chargesListCmd)
 	chargesCmd.AddCommand(chargesUpdateCmd)
 	chargesCmd.AddCommand(chargesCaptureCmd)
+	chargesCmd.AddCommand(chargesReauthorizeCmd)
 	chargesCmd.AddCommand(chargesRefundCmd)
+	chargesCmd.AddCommand(chargesVoidCmd)
 	chargesCmd.AddCommand(chargesTdsFinishCmd)
+	chargesCmd.AddCommand(chargesDedupeCmd)
+	chargesCmd.AddCommand(chargesEstimateFeesCmd)
 
 	// Create flags
 	chargesCreateCmd.Flags().Int("amount", 0, "Amount in smallest currency unit (required)")
@@ -293,15 +1556,31 @@ func init() {
 	chargesCreateCmd.Flags().Int("expiry-days", 0, "Expiry days for authorization")
 	chargesCreateCmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
 	chargesCreateCmd.Flags().Bool("three-d-secure", false, "Enable 3D Secure")
+	chargesCreateCmd.Flags().Bool("override-limit", false, "Ignore configured live-mode spending limits")
+	chargesCreateCmd.Flags().String("customer-email", "", "Charge the customer with this email, attaching --card to it")
+	chargesCreateCmd.Flags().Bool("create-if-missing", true, "Create the customer when no customer has the --customer-email")
+	chargesCreateCmd.Flags().String("idempotency-key", "", "Idempotency key; a charge already created with it is printed instead of creating another")
 	chargesCreateCmd.MarkFlagRequired("amount")
 
 	// List flags
 	chargesListCmd.Flags().Int("limit", 10, "Number of items to return")
 	chargesListCmd.Flags().Int("offset", 0, "Offset for pagination")
-	chargesListCmd.Flags().String("since", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
-	chargesListCmd.Flags().String("until", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
+	chargesListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	addTimeRangeFlags(chargesListCmd)
 	chargesListCmd.Flags().String("customer", "", "Filter by customer ID")
 	chargesListCmd.Flags().String("subscription", "", "Filter by subscription ID")
+	chargesListCmd.Flags().String("metadata", "", "Filter by metadata (key=value for exact match, key=prefix* for prefix match)")
+	chargesListCmd.Flags().String("card-brand", "", "Filter by card brand (e.g. Visa, MasterCard, JCB)")
+	chargesListCmd.Flags().String("last4", "", "Filter by the last 4 digits of the card number")
+	chargesListCmd.Flags().String("card-country", "", "Filter by card issuing country (2-letter ISO code)")
+	chargesListCmd.Flags().String("fingerprint", "", "Filter by card fingerprint (the same card number has the same fingerprint)")
+	chargesListCmd.Flags().Bool("has-3ds", false, "Only charges that went through 3D Secure")
+	chargesListCmd.Flags().StringSlice("3ds-status", nil, "Filter by 3D Secure status ("+strings.Join(tdsStatuses, ", ")+"; can be repeated)")
+	markClientFilters(chargesListCmd, "metadata", "card-brand", "last4", "card-country", "fingerprint", "has-3ds", "3ds-status")
+	chargesListCmd.Flags().Bool("fee-breakdown", false, "List captured charges with their fees and tenant net amount, and totals per tenant")
+	chargesListCmd.Flags().Bool("totals", false, "With --fee-breakdown, output only the totals per tenant")
+	chargesListCmd.Flags().String("fee-rate", "", "With --fee-breakdown, fee rate in percent for charges without fee_rate")
+	chargesListCmd.Flags().String("group-by", "", "Aggregate counts and sums per bucket instead of listing ("+strings.Join(chargeGroupKeys, ", ")+")")
 
 	// Update flags
 	chargesUpdateCmd.Flags().String("description", "", "New description")
@@ -310,8 +1589,37 @@ func init() {
 	// Capture flags
 	chargesCaptureCmd.Flags().Int("amount", 0, "Amount to capture (partial capture)")
 
+	// Reauthorize flags
+	chargesReauthorizeCmd.Flags().Int("expiry-days", 7, "Days until the new authorization expires (1-60)")
+	chargesReauthorizeCmd.Flags().Bool("override-limit", false, "Ignore configured live-mode spending limits")
+	addWorkflowFlags(chargesReauthorizeCmd)
+
 	// Refund flags
 	chargesRefundCmd.Flags().Int("amount", 0, "Amount to refund (partial refund)")
 	chargesRefundCmd.Flags().String("refund-reason", "", "Reason for refund")
-}
+	chargesRefundCmd.Flags().String("reason-code", "", "Refund reason code (duplicate, fraud, customer_request, other)")
+	chargesRefundCmd.Flags().String("metadata", "", "Metadata to store on the charge (key1=value1,key2=value2)")
+
+	// Void flags
+	chargesVoidCmd.Flags().String("reason", "", "Reason for voiding (stored as the refund reason)")
 
+	// Get flags
+	chargesGetCmd.Flags().Bool("with-fees", false, "Show the fee breakdown and net amount")
+	chargesGetCmd.Flags().String("fee-rate", "", "Processing fee rate in percent used when the charge has no fee_rate")
+
+	// Estimate fees flags
+	chargesEstimateFeesCmd.Flags().Int("amount", 0, "Amount in smallest currency unit (required)")
+	chargesEstimateFeesCmd.Flags().String("currency", "jpy", "Currency code")
+	chargesEstimateFeesCmd.Flags().String("fee-rate", "", "Processing fee rate in percent (default is the rate of the most recent charge)")
+	chargesEstimateFeesCmd.Flags().String("card-brand", "", "Use the fee rate of a recent charge made with this card brand (e.g. Visa, JCB)")
+	chargesEstimateFeesCmd.Flags().String("platform-fee-rate", "", "Platform fee rate in percent (default is the rate of the --tenant)")
+	chargesEstimateFeesCmd.MarkFlagRequired("amount")
+
+	// Dedupe flags
+	chargesDedupeCmd.Flags().String("window", "5m", "Maximum time between duplicate charges (e.g. 5m, 1h)")
+	chargesDedupeCmd.Flags().String("order-key", "order_id", "Metadata key identifying the order")
+	chargesDedupeCmd.Flags().Bool("refund", false, "Offer to refund each duplicate after confirmation")
+	addCheckpointFlags(chargesDedupeCmd)
+	addDeadlineFlag(chargesDedupeCmd)
+	addTimeRangeFlags(chargesDedupeCmd)
+}
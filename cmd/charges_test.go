@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/spend"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/viper"
+)
+
+func TestRefundAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		paid     int
+		refunded int
+		amount   int
+		want     int
+	}{
+		{"full refund", 1000, 0, 0, 1000},
+		{"partial refund", 1000, 0, 300, 300},
+		{"exactly the amount paid", 1000, 0, 1000, 1000},
+		{"more than paid", 1000, 0, 1001, 1000},
+		{"rest of a partly refunded charge", 1000, 400, 0, 600},
+		{"exactly what remains", 1000, 400, 600, 600},
+		{"more than remains", 1000, 400, 601, 600},
+		{"less than remains", 1000, 400, 599, 599},
+		{"fully refunded", 1000, 1000, 0, 0},
+		{"fully refunded with an amount", 1000, 1000, 1, 0},
+		{"refunded more than paid", 1000, 1200, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charge := &payjp.ChargeResponse{Amount: tt.paid, AmountRefunded: tt.refunded}
+			if got := refundAmount(charge, tt.amount); got != tt.want {
+				t.Errorf("refundAmount(paid %d, refunded %d, %d) = %d, want %d", tt.paid, tt.refunded, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+// initLimitsConfig loads a config file with the given limits for the default profile
+func initLimitsConfig(t *testing.T, limits string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("default_profile: default\nlimits:\n"+limits), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PAYJP_PROFILE", "")
+	viper.Reset()
+	if err := config.Init(path); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(viper.Reset)
+}
+
+func TestCheckChargeLimits(t *testing.T) {
+	initLimitsConfig(t, "  max_charge_amount: 10000\n  daily_charge_total: 50000\n")
+
+	path := spend.Path(t.TempDir())
+	today := spend.Day(time.Now())
+	yesterday := spend.Day(time.Now().AddDate(0, 0, -1))
+	// 45000 yen was charged today; yesterday's total and the other profile do not count
+	ledger := `{
+  "` + today + `": {"default": {"jpy": 45000, "usd": 100}, "other": {"jpy": 50000}},
+  "` + yesterday + `": {"default": {"jpy": 50000}}
+}`
+	if err := os.WriteFile(path, []byte(ledger), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		amount   int
+		currency string
+		ok       bool
+	}{
+		{"within both limits", 1000, "jpy", true},
+		{"reaches the daily total", 5000, "jpy", true},
+		{"one over the daily total", 5001, "jpy", false},
+		{"currency in upper case", 5001, "JPY", false},
+		{"other currency has its own total", 9000, "usd", true},
+		{"at the charge cap", 10000, "usd", true},
+		{"one over the charge cap", 10001, "usd", false},
+		{"currency without charges today", 10000, "eur", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked, err := spend.Lock(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer locked.Unlock()
+			if err := checkChargeLimits(locked, tt.amount, tt.currency); (err == nil) != tt.ok {
+				t.Errorf("checkChargeLimits(%d, %q) error = %v, want ok %v", tt.amount, tt.currency, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckChargeLimitsDisabled(t *testing.T) {
+	initLimitsConfig(t, "  max_charge_amount: 0\n  daily_charge_total: 0\n")
+
+	locked, err := spend.Lock(spend.Path(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locked.Unlock()
+	if err := checkChargeLimits(locked, 9999999, "jpy"); err != nil {
+		t.Errorf("checkChargeLimits() error = %v, want nil without limits", err)
+	}
+}
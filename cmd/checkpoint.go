@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/checkpoint"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+// addCheckpointFlags adds the --checkpoint and --resume flags to a bulk command
+func addCheckpointFlags(cmd *cobra.Command) {
+	cmd.Flags().String("checkpoint", "", "Checkpoint file recording completed items (default is a new file in the config directory)")
+	cmd.Flags().String("resume", "", "Resume from a checkpoint file, skipping completed items")
+}
+
+// openCheckpoint opens the checkpoint for a bulk command from --checkpoint or --resume
+// Without either flag a new checkpoint file is created in the config directory
+func openCheckpoint(cmd *cobra.Command) (*checkpoint.Checkpoint, error) {
+	path, _ := cmd.Flags().GetString("checkpoint")
+	resume, _ := cmd.Flags().GetString("resume")
+	name := commandName(cmd)
+
+	if resume != "" {
+		if path != "" {
+			return nil, i18n.Errorf("--resume cannot be used with --checkpoint")
+		}
+		cp, err := checkpoint.Resume(resume, name)
+		if err != nil {
+			return nil, err
+		}
+		printStatus("Resuming from %s (%d completed)", cp.Path, cp.Len())
+		return cp, nil
+	}
+
+	if path == "" {
+		path = checkpoint.NewPath(config.ConfigDir(), name, time.Now())
+	}
+	cp, err := checkpoint.Open(path, name)
+	if err != nil {
+		return nil, err
+	}
+	printStatus("Checkpoint: %s (resume with --resume %s)", cp.Path, cp.Path)
+	return cp, nil
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/jsondiff"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// resourcePaths maps resource types to their API paths
+var resourcePaths = map[string]string{
+	"charge":       "/charges/",
+	"customer":     "/customers/",
+	"plan":         "/plans/",
+	"subscription": "/subscriptions/",
+	"transfer":     "/transfers/",
+	"event":        "/events/",
+	"token":        "/tokens/",
+	"balance":      "/balances/",
+	"statement":    "/statements/",
+	"term":         "/terms/",
+	"tenant":       "/tenants/",
+}
+
+// idPrefixes maps ID prefixes to resource types
+var idPrefixes = map[string]string{
+	"ch_":   "charge",
+	"cus_":  "customer",
+	"pln_":  "plan",
+	"sub_":  "subscription",
+	"tr_":   "transfer",
+	"evnt_": "event",
+	"tok_":  "token",
+	"ba_":   "balance",
+	"st_":   "statement",
+	"tm_":   "term",
+	"ten_":  "tenant",
+}
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <id1> <id2>",
+	Short: "Show a field-level diff between two resources",
+	Long: `Fetch two resources of the same type and show the fields that differ.
+
+The resource type is detected from the ID prefix (ch_, cus_, pln_, sub_, tr_,
+evnt_, tok_, ba_, st_, tm_, ten_). Use --type for IDs without a known prefix,
+such as plans created with a custom ID. Nested objects are compared field by
+field; arrays are compared as a whole.
+
+With --patch, the diff is printed as a JSON Patch (RFC 6902) that turns the
+first resource into the second. It is printed as JSON unless -o yaml or
+-o ndjson is given, and --fields and --envelope apply to it.
+
+Example:
+  payjp compare pln_basic pln_basic_v2
+  payjp compare ch_xxxxx ch_yyyyy --ignore id,created,captured_at
+  payjp compare basic-monthly basic-yearly --type plan --patch`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resourceType, _ := cmd.Flags().GetString("type")
+		ignore, _ := cmd.Flags().GetStringSlice("ignore")
+		patch, _ := cmd.Flags().GetBool("patch")
+
+		if resourceType == "" {
+			left, right := resourceTypeOf(args[0]), resourceTypeOf(args[1])
+			if left == "" || right == "" {
+				return i18n.Errorf("cannot detect the resource type from the IDs (use --type)")
+			}
+			if left != right {
+				return i18n.Errorf("cannot compare a %s with a %s", left, right)
+			}
+			resourceType = left
+		}
+		path, ok := resourcePaths[resourceType]
+		if !ok {
+			return i18n.Errorf("unsupported resource type: %s (supported: %s)", resourceType, strings.Join(resourceTypes(), ", "))
+		}
+
+		bodies := make([][]byte, 2)
+		for i, id := range args {
+			printVerbose("Retrieving %s %s", resourceType, id)
+			body, err := client.Request(http.MethodGet, path+url.PathEscape(id), nil)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			bodies[i] = body
+		}
+
+		changes, err := jsondiff.Compare(bodies[0], bodies[1])
+		if err != nil {
+			return err
+		}
+		changes = ignoreChanges(changes, ignore)
+
+		if patch {
+			ops, err := jsondiff.Patch(changes)
+			if err != nil {
+				return err
+			}
+			// A JSON Patch has no table form, so table and CSV output fall back to JSON
+			if format := output.Format(getOutputFormat()); format == output.FormatTable || format == output.FormatCSV {
+				outputFmt, outputFmtChanged = string(output.FormatJSON), true
+			}
+			return outputResult(ops)
+		}
+
+		if len(changes) == 0 && getOutputFormat() == "table" {
+			printStatus("No differences.")
+			return nil
+		}
+
+		rows := make([]compareRow, 0, len(changes))
+		for _, change := range changes {
+			rows = append(rows, compareRow{
+				Field:  strings.TrimPrefix(change.Path, "/"),
+				Change: change.Op,
+				Left:   compareValue(change.Op != jsondiff.OpAdd, change.From),
+				Right:  compareValue(change.Op != jsondiff.OpRemove, change.To),
+			})
+		}
+		return outputResult(rows)
+	},
+}
+
+// compareRow is a field that differs between two resources
+type compareRow struct {
+	Field  string `json:"field"`
+	Change string `json:"change"`
+	Left   string `json:"left"`
+	Right  string `json:"right"`
+}
+
+// TableColumns returns the fields shown in list view
+func (compareRow) TableColumns() []string {
+	return []string{"Field", "Change", "Left", "Right"}
+}
+
+// resourceTypeOf returns the resource type for an ID prefix, or an empty string
+func resourceTypeOf(id string) string {
+	for prefix, resourceType := range idPrefixes {
+		if strings.HasPrefix(id, prefix) {
+			return resourceType
+		}
+	}
+	return ""
+}
+
+// resourceTypes returns the supported resource types in sorted order
+func resourceTypes() []string {
+	types := make([]string, 0, len(resourcePaths))
+	for resourceType := range resourcePaths {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ignoreChanges drops changes to the given top-level fields and their children
+func ignoreChanges(changes []jsondiff.Change, fields []string) []jsondiff.Change {
+	if len(fields) == 0 {
+		return changes
+	}
+	result := []jsondiff.Change{}
+	for _, change := range changes {
+		ignored := false
+		for _, field := range fields {
+			prefix := "/" + strings.TrimSpace(field)
+			if change.Path == prefix || strings.HasPrefix(change.Path, prefix+"/") {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// compareValue formats a value for display: strings as-is, other values as compact JSON
+// A missing value is shown as an empty string
+func compareValue(present bool, v interface{}) string {
+	if !present {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().String("type", "", "Resource type when it cannot be detected from the IDs (e.g. plan)")
+	compareCmd.Flags().StringSlice("ignore", nil, "Top-level fields to ignore (e.g. id,created)")
+	compareCmd.Flags().Bool("patch", false, "Print the diff as a JSON Patch (RFC 6902)")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// enumFlagValues returns the values completed for a flag of a command, or nil if the flag
+// takes free-form values
+// --type means something different for each command, so its values depend on the command
+func enumFlagValues(cmd *cobra.Command, name string) []string {
+	switch name {
+	case "currency":
+		return util.Currencies
+	case "interval":
+		if cmd.Flags().Lookup(name).Value.Type() == "string" {
+			return util.Intervals
+		}
+	case "owner":
+		return []string{"merchant", "tenant"}
+	case "bank-account-type":
+		return []string{"普通", "当座"}
+	case "reason-code":
+		return util.RefundReasonCodes
+	case "3ds-status":
+		return tdsStatuses
+	case "group-by":
+		return chargeGroupKeys
+	case "type", "exclude-type", "handled":
+		if cmd.Parent() == eventsCmd {
+			return knownEventTypes
+		}
+		if cmd == compareCmd {
+			types := make([]string, 0, len(resourcePaths))
+			for t := range resourcePaths {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			return types
+		}
+	case "status":
+		if cmd == tenantTransfersListCmd {
+			return tenantTransferStatuses
+		}
+	case "by":
+		if cmd == eventsStatsCmd {
+			return eventStatsKeys
+		}
+	}
+	return nil
+}
+
+// enableCompletion sets up the completion command and shell completion of enumerated flag values
+// It runs after all commands have defined their flags
+func enableCompletion(root *cobra.Command) {
+	// Cobra adds the completion command on Execute; it is added here so that it can be marked
+	// as not needing an API key, like the hidden command that the completion scripts call
+	root.InitDefaultCompletionCmd()
+	for _, c := range root.Commands() {
+		if c.Name() == "completion" {
+			c.Annotations = map[string]string{annotationNoClient: "true"}
+		}
+	}
+	enableEnumCompletions(root)
+}
+
+// isCompletionRequest reports whether a command is the hidden command that completion scripts
+// call to get completions
+func isCompletionRequest(cmd *cobra.Command) bool {
+	return cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+// enableEnumCompletions registers shell completion of enumerated flag values on every command,
+// so that payjp completion scripts offer e.g. jpy and usd for --currency
+func enableEnumCompletions(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		enableEnumCompletions(c)
+	}
+	root.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		values := enumFlagValues(root, flag.Name)
+		if values == nil {
+			return
+		}
+		root.RegisterFlagCompletionFunc(flag.Name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			// Repeatable flags take comma separated values, so only the last value is completed
+			prefix := ""
+			if i := strings.LastIndex(toComplete, ","); i >= 0 && flag.Value.Type() == "stringSlice" {
+				prefix = toComplete[:i+1]
+			}
+			completions := []string{}
+			for _, v := range values {
+				completions = append(completions, prefix+v)
+			}
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		})
+	})
+}
@@ -2,10 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/payjp/payjp-cli/internal/audit"
+	"github.com/payjp/payjp-cli/internal/client"
 	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
 	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
 var configCmd = &cobra.Command{
@@ -20,44 +30,77 @@ var configSetCmd = &cobra.Command{
 	Long: `Set a configuration value.
 
 Available keys:
-  api-key      Set the API key for the default profile
-  output       Set the default output format (json, table, yaml)
+  api-key      Set the API key for the default profile (- reads it from stdin)
+  output       Set the default output format (json, table, yaml, csv, ndjson)
+  locale       Set the locale used to format amounts in table output (e.g. ja-JP, en-US)
+  time-format  Set the timestamp format in table/csv output (unix, rfc3339, relative, or a Go layout)
+
+The API key is cleaned of surrounding whitespace and quotes, checked for
+a valid format and verified against the API before it is saved. Use
+--no-verify to skip the API check (e.g. when offline).
 
 Example:
   payjp config set api-key sk_test_xxxxx
-  payjp config set output json`,
-	Args: cobra.ExactArgs(2),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return config.Init(cfgFile)
-	},
+  pbpaste | payjp config set api-key -
+  payjp config set output json
+  payjp config set locale ja-JP
+  payjp config set time-format rfc3339`,
+	Args:    cobra.ExactArgs(2),
+	PreRunE: initConfigFile,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 		value := args[1]
 
 		switch key {
 		case "api-key":
+			apiKey, err := prepareAPIKey(cmd, value)
+			if err != nil {
+				return err
+			}
 			profileName := config.Get().DefaultProfile
 			if profileName == "" {
 				profileName = "default"
 			}
-			if err := config.SetAPIKey(profileName, value); err != nil {
+			if err := config.SetAPIKey(profileName, apiKey); err != nil {
 				return err
 			}
-			fmt.Printf("API key set for profile '%s'\n", profileName)
+			printSuccess("API key set for profile '%s'", profileName)
 
 		case "output":
-			if value != "json" && value != "table" && value != "yaml" {
-				return fmt.Errorf("invalid output format: %s (use json, table, or yaml)", value)
+			if value != "json" && value != "table" && value != "yaml" && value != "csv" && value != "ndjson" {
+				return i18n.Errorf("invalid output format: %s (use json, table, yaml, csv, or ndjson)", value)
 			}
 			cfg := config.Get()
 			cfg.Output.Format = value
 			if err := config.Save(); err != nil {
 				return err
 			}
-			fmt.Printf("Output format set to '%s'\n", value)
+			printSuccess("Output format set to '%s'", value)
+
+		case "locale":
+			if _, err := language.Parse(value); err != nil {
+				return i18n.Errorf("invalid locale: %s (use a language tag such as ja-JP or en-US)", value)
+			}
+			cfg := config.Get()
+			cfg.Output.Locale = value
+			if err := config.Save(); err != nil {
+				return err
+			}
+			printSuccess("Locale set to '%s'", value)
+
+		case "time-format":
+			if err := output.ValidateTimeFormat(value); err != nil {
+				return err
+			}
+			cfg := config.Get()
+			cfg.Output.TimeFormat = value
+			if err := config.Save(); err != nil {
+				return err
+			}
+			printSuccess("Time format set to '%s'", value)
 
 		default:
-			return fmt.Errorf("unknown configuration key: %s", key)
+			return i18n.Errorf("unknown configuration key: %s", key)
 		}
 
 		return nil
@@ -67,19 +110,41 @@ Example:
 var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current configuration",
-	Long:  `Display the current CLI configuration.`,
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return config.Init(cfgFile)
-	},
+	Long: `Display the current CLI configuration.
+
+With -o json or -o yaml, the whole config file is printed as a document with
+the config file path, the current profile and the settings keyed as in the
+file, for tools that check how a machine is set up. API keys, the Slack
+webhook URL and the SMTP password are masked. The settings are those stored
+in the config file; use config effective to see the values in use.
+
+Example:
+  payjp config show
+  payjp config show -o json | jq '.settings.profiles | keys'`,
+	PreRunE: initConfigFile,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.Get()
 
+		if format := getOutputFormat(); format != "table" {
+			doc, err := newConfigDocument(cfg)
+			if err != nil {
+				return err
+			}
+			return outputResult(doc)
+		}
+
 		fmt.Println("Configuration:")
 		fmt.Println("==============")
-		fmt.Printf("Config file: %s\n", config.DefaultConfigPath())
+		fmt.Printf("Config file: %s\n", config.ConfigFilePath())
 		fmt.Printf("Default profile: %s\n", cfg.DefaultProfile)
 		fmt.Printf("Output format: %s\n", cfg.Output.Format)
 		fmt.Printf("Color output: %v\n", cfg.Output.Color)
+		if cfg.Output.Locale != "" {
+			fmt.Printf("Locale: %s\n", cfg.Output.Locale)
+		}
+		if cfg.Output.TimeFormat != "" {
+			fmt.Printf("Time format: %s\n", cfg.Output.TimeFormat)
+		}
 		fmt.Println()
 
 		fmt.Println("Retry settings:")
@@ -95,7 +160,11 @@ var configShowCmd = &cobra.Command{
 				current = " (current)"
 			}
 			fmt.Printf("  %s%s:\n", name, current)
-			fmt.Printf("    API key: %s\n", util.MaskAPIKey(profile.APIKey))
+			if profile.Keychain {
+				fmt.Println("    API key: (OS keychain)")
+			} else {
+				fmt.Printf("    API key: %s\n", util.MaskAPIKey(profile.APIKey))
+			}
 			fmt.Printf("    Mode: %s\n", profile.Mode)
 		}
 
@@ -107,6 +176,63 @@ var configShowCmd = &cobra.Command{
 	},
 }
 
+// configDocument is the structured output of config show
+// It has yaml tags as well, since tools read it as YAML too
+type configDocument struct {
+	ConfigFile     string                 `json:"config_file" yaml:"config_file"`
+	ProjectFile    string                 `json:"project_file,omitempty" yaml:"project_file,omitempty"`
+	CurrentProfile string                 `json:"current_profile" yaml:"current_profile"`
+	Corrupt        string                 `json:"corrupt,omitempty" yaml:"corrupt,omitempty"`
+	Settings       map[string]interface{} `json:"settings" yaml:"settings"`
+}
+
+// newConfigDocument returns the config file settings keyed as in the file with secrets masked
+func newConfigDocument(cfg *config.Config) (*configDocument, error) {
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	settings := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &settings); err != nil {
+		return nil, err
+	}
+	maskSecrets(settings, "")
+
+	doc := &configDocument{
+		ConfigFile:     config.ConfigFilePath(),
+		CurrentProfile: config.CurrentProfileName(),
+		Settings:       settings,
+	}
+	if project := config.Project(); project != nil {
+		doc.ProjectFile = project.Path
+	}
+	if err := config.Corrupt(); err != nil {
+		doc.Corrupt = err.Error()
+	}
+	return doc, nil
+}
+
+// maskSecrets masks the API keys and notification credentials in config settings
+// Profiles can override the notify section, so secrets are masked at any depth
+func maskSecrets(settings map[string]interface{}, parent string) {
+	for key, value := range settings {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			maskSecrets(v, key)
+		case string:
+			if v == "" {
+				continue
+			}
+			switch {
+			case key == "api_key":
+				settings[key] = util.MaskAPIKey(v)
+			case key == "slack_webhook_url", parent == "smtp" && key == "password":
+				settings[key] = "********"
+			}
+		}
+	}
+}
+
 var configSetProfileCmd = &cobra.Command{
 	Use:   "set-profile <name>",
 	Short: "Create or update a profile",
@@ -115,17 +241,19 @@ var configSetProfileCmd = &cobra.Command{
 Example:
   payjp config set-profile production --api-key sk_live_xxxxx
   payjp config set-profile development --api-key sk_test_xxxxx`,
-	Args: cobra.ExactArgs(1),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return config.Init(cfgFile)
-	},
+	Args:    cobra.ExactArgs(1),
+	PreRunE: initConfigFile,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		profileAPIKey, _ := cmd.Flags().GetString("api-key")
 		mode, _ := cmd.Flags().GetString("mode")
 
 		if profileAPIKey == "" {
-			return fmt.Errorf("--api-key is required")
+			return i18n.Errorf("--api-key is required")
+		}
+		profileAPIKey, err := prepareAPIKey(cmd, profileAPIKey)
+		if err != nil {
+			return err
 		}
 
 		if mode == "" {
@@ -136,19 +264,19 @@ Example:
 				mode = "test"
 			}
 		} else if mode != "test" && mode != "live" {
-			return fmt.Errorf("invalid mode: %s (use 'test' or 'live')", mode)
+			return i18n.Errorf("invalid mode: %s (use 'test' or 'live')", mode)
 		}
 
-		profile := config.Profile{
-			APIKey: profileAPIKey,
-			Mode:   mode,
-		}
+		// Keep the settings the profile overrides
+		profile := config.Get().Profiles[name]
+		profile.APIKey = profileAPIKey
+		profile.Mode = mode
 
 		if err := config.SetProfile(name, profile); err != nil {
 			return err
 		}
 
-		fmt.Printf("Profile '%s' saved (mode: %s)\n", name, mode)
+		printSuccess("Profile '%s' saved (mode: %s)", name, mode)
 		return nil
 	},
 }
@@ -160,10 +288,8 @@ var configUseProfileCmd = &cobra.Command{
 
 Example:
   payjp config use-profile production`,
-	Args: cobra.ExactArgs(1),
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return config.Init(cfgFile)
-	},
+	Args:    cobra.ExactArgs(1),
+	PreRunE: initConfigFile,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
@@ -171,18 +297,130 @@ Example:
 			return err
 		}
 
-		fmt.Printf("Now using profile '%s'\n", name)
+		printSuccess("Now using profile '%s'", name)
 		return nil
 	},
 }
 
-var configListProfilesCmd = &cobra.Command{
-	Use:   "list-profiles",
-	Short: "List all profiles",
-	Long:  `Display a list of all configured profiles.`,
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return config.Init(cfgFile)
+var configRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key <new-key>",
+	Short: "Replace the API key of a profile after rotating it",
+	Long: `Replace the API key of a profile with a newly issued key.
+
+The new key is only saved when it belongs to the same account as the
+current key: both keys are used to retrieve the account and the account IDs
+are compared. If the current key has already been revoked, give the expected
+account ID with --account. The new key must also be of the same mode (test
+or live) as the current one. The config file is replaced atomically.
+
+With --check-revoked, the old key is tried once more after the new key is
+saved and a warning is printed if it still works. Every rotation is recorded
+with masked keys in audit.jsonl in the config directory.
+
+Example:
+  payjp config rotate-key sk_live_yyyyy --profile production
+  pbpaste | payjp config rotate-key - --check-revoked
+  payjp config rotate-key sk_test_yyyyy --account acct_xxxxx`,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: initConfigFile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profileName, _ := cmd.Flags().GetString("profile")
+		accountID, _ := cmd.Flags().GetString("account")
+		checkRevoked, _ := cmd.Flags().GetBool("check-revoked")
+
+		if profileName == "" {
+			profileName = config.CurrentProfileName()
+		}
+		profile, ok := config.Get().Profiles[profileName]
+		if !ok || profile.APIKey == "" && !profile.Keychain {
+			return i18n.Errorf("profile '%s' has no API key (use 'payjp config set-profile' to create it)", profileName)
+		}
+		oldKey, err := config.ProfileAPIKey(profileName, profile)
+		if err != nil {
+			return err
+		}
+
+		newKey, err := readAPIKeyArg(args[0])
+		if err != nil {
+			return err
+		}
+		if newKey == oldKey {
+			return i18n.Errorf("the new API key is the same as the current one")
+		}
+		if keyMode(newKey) != keyMode(oldKey) {
+			return i18n.Errorf("the new API key is a %s key but profile '%s' uses a %s key", keyMode(newKey), profileName, keyMode(oldKey))
+		}
+
+		if accountID == "" {
+			account, err := retrieveAccount(oldKey)
+			if err != nil {
+				if isKeyRejected(err) {
+					return i18n.Errorf("the current API key %s was rejected; give the expected account ID with --account", util.MaskAPIKey(oldKey))
+				}
+				return i18n.Errorf("failed to check the current API key: %w", err)
+			}
+			accountID = account.ID
+		}
+
+		account, err := retrieveAccount(newKey)
+		if err != nil {
+			if isKeyRejected(err) {
+				return i18n.Errorf("API key %s was rejected by PAY.JP; it was not saved", util.MaskAPIKey(newKey))
+			}
+			return i18n.Errorf("failed to verify API key: %w", err)
+		}
+		if account.ID != accountID {
+			return i18n.Errorf("the new API key belongs to account %s, not %s; it was not saved", account.ID, accountID)
+		}
+
+		if err := config.SetAPIKey(profileName, newKey); err != nil {
+			return err
+		}
+		printSuccess("API key of profile '%s' replaced: %s -> %s (account %s)", profileName, util.MaskAPIKey(oldKey), util.MaskAPIKey(newKey), account.ID)
+
+		entry := audit.Entry{
+			Time:    time.Now(),
+			Action:  audit.ActionRotateKey,
+			Profile: profileName,
+			Account: account.ID,
+			OldKey:  util.MaskAPIKey(oldKey),
+			NewKey:  util.MaskAPIKey(newKey),
+		}
+		if checkRevoked {
+			_, err := retrieveAccount(oldKey)
+			switch {
+			case isKeyRejected(err):
+				entry.Revoked = util.BoolPtr(true)
+				printSuccess("The old API key %s is revoked", util.MaskAPIKey(oldKey))
+			case err == nil:
+				entry.Revoked = util.BoolPtr(false)
+				printStatus("Warning: the old API key %s still works; roll it in the PAY.JP dashboard", util.MaskAPIKey(oldKey))
+			default:
+				printStatus("Warning: could not check whether the old API key is revoked: %v", err)
+			}
+		}
+
+		// The key is already saved, so a failure to write the audit log is not fatal
+		if err := audit.Append(audit.Path(config.ConfigDir()), entry); err != nil {
+			printStatus("Warning: %v", err)
+		}
+		return nil
 	},
+}
+
+// keyMode returns "live" or "test" for a secret key
+func keyMode(key string) string {
+	if strings.HasPrefix(key, "sk_live_") {
+		return "live"
+	}
+	return "test"
+}
+
+var configListProfilesCmd = &cobra.Command{
+	Use:     "list-profiles",
+	Short:   "List all profiles",
+	Long:    `Display a list of all configured profiles.`,
+	PreRunE: initConfigFile,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.Get()
 		profiles := config.ListProfiles()
@@ -207,6 +445,227 @@ var configListProfilesCmd = &cobra.Command{
 	},
 }
 
+var configEffectiveCmd = &cobra.Command{
+	Use:   "effective",
+	Short: "Show the resolved settings and where each value came from",
+	Long: `Show every setting as the next command would use it, with the layer it
+came from. Settings are resolved in this order, each layer overriding the
+ones before it:
+
+  default   built-in defaults
+  config    the config file
+  profile   the current profile (profiles.<name>.output, .retry, ...)
+  project   the project file (.payjp.yaml)
+  env       environment variables (e.g. PAYJP_OUTPUT, PAYJP_PROFILE)
+  flag      global flags given on the command line
+
+detail names the file, profile, environment variable or flag of the
+source. A profile can override the output, theme, retry, http, limits,
+notify, automation, stats and cache sections. Secrets are masked.
+
+Example:
+  payjp config effective
+  payjp config effective --live --locale en-US
+  PAYJP_PROFILE=production payjp config effective -o json`,
+	Args:    cobra.NoArgs,
+	PreRunE: initConfigFile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := map[string]config.FlagSetting{}
+		for _, f := range []struct {
+			flag  string
+			key   string
+			value interface{}
+		}{
+			{"output", "output.format", outputFmt},
+			{"no-color", "output.color", !noColor},
+			{"sort-keys", "output.sort_keys", sortKeys},
+			{"expand-maps", "output.expand_maps", expandMaps},
+			{"locale", "output.locale", locale},
+			{"time-format", "output.time_format", timeFmt},
+			{"theme", "theme.name", themeName},
+			{"live", "mode", "live"},
+			{"non-interactive", "non_interactive", nonInteractive},
+		} {
+			if cmd.Flags().Changed(f.flag) && !projectFlags[f.flag] {
+				flags[f.key] = config.FlagSetting{Flag: f.flag, Value: f.value}
+			}
+		}
+
+		settings, err := config.Effective(flags)
+		if err != nil {
+			return err
+		}
+		key, err := apiKeySetting()
+		if err != nil {
+			return err
+		}
+		settings = append([]config.Setting{key}, settings...)
+
+		printStatus("Config file: %s", config.ConfigFilePath())
+		if project := config.Project(); project != nil {
+			printStatus("Project file: %s", project.Path)
+		}
+		return outputResult(settings)
+	},
+}
+
+// apiKeySetting returns the masked API key with the layer it came from
+func apiKeySetting() (config.Setting, error) {
+	setting := config.Setting{Key: "api_key", Value: "", Source: config.SourceDefault}
+
+	key, _, err := apiKeyOverride()
+	if err != nil {
+		return setting, err
+	}
+	switch {
+	case key == "":
+		resolved := config.ResolveAPIKey()
+		if resolved.Key == "" {
+			return setting, nil
+		}
+		setting.Value = util.MaskAPIKey(resolved.Key)
+		if resolved.Profile != "" {
+			setting.Source, setting.Detail = config.SourceProfile, resolved.Profile
+		} else {
+			setting.Source, setting.Detail = config.SourceEnv, "PAYJP_API_KEY"
+		}
+	case keyStdin:
+		setting.Value, setting.Source, setting.Detail = util.MaskAPIKey(key), config.SourceFlag, "--api-key-stdin"
+	case apiKey != "":
+		setting.Value, setting.Source, setting.Detail = util.MaskAPIKey(key), config.SourceFlag, "--api-key"
+	default:
+		setting.Value, setting.Source, setting.Detail = util.MaskAPIKey(key), config.SourceEnv, "PAYJP_API_KEY_FILE"
+	}
+	return setting, nil
+}
+
+var configRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Rebuild a corrupt config file from its readable settings",
+	Long: `Rebuild a config file that can no longer be parsed.
+
+While the config file is corrupt, commands keep working with the default
+settings and the environment variables (e.g. PAYJP_API_KEY) and print a
+warning, but settings cannot be changed. repair salvages every setting that
+can still be read: a block that does not parse only loses the setting it
+belongs to, and values of the wrong type are dropped. The original file is
+kept next to the config file as config.yaml.corrupt-<time> before the file
+is rewritten.
+
+Use --dry-run to list the settings that would be recovered and dropped
+without changing anything.
+
+Example:
+  payjp config repair --dry-run
+  payjp config repair`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return config.Init(cfgFile)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		path := config.ConfigFilePath()
+		corrupt := config.Corrupt()
+		if corrupt == nil {
+			printStatus("Config file %s is valid; nothing to repair", path)
+			return nil
+		}
+		printStatus("Config file %s is corrupt: %v", path, corrupt)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rec := config.Recover(data)
+		for _, key := range rec.Recovered {
+			printStatus("  recovered: %s", key)
+		}
+		for _, key := range rec.Dropped {
+			printStatus("  dropped:   %s", key)
+		}
+		if dryRun {
+			return nil
+		}
+
+		backup, err := config.Repair(rec)
+		if backup != "" {
+			printStatus("Saved the corrupt file as %s", backup)
+		}
+		if err != nil {
+			return err
+		}
+		printSuccess("Config file repaired (%d recovered, %d dropped)", len(rec.Recovered), len(rec.Dropped))
+		return nil
+	},
+}
+
+// initConfigFile loads the config file for config subcommands
+func initConfigFile(cmd *cobra.Command, args []string) error {
+	if err := config.Init(cfgFile); err != nil {
+		return err
+	}
+	warnCorruptConfig()
+	return nil
+}
+
+// prepareAPIKey reads, normalizes and verifies an API key before it is saved
+// A value of "-" reads the key from stdin; --no-verify skips the API check
+func prepareAPIKey(cmd *cobra.Command, value string) (string, error) {
+	key, err := readAPIKeyArg(value)
+	if err != nil {
+		return "", err
+	}
+
+	noVerify, _ := cmd.Flags().GetBool("no-verify")
+	if noVerify {
+		return key, nil
+	}
+
+	if _, err := retrieveAccount(key); err != nil {
+		if isKeyRejected(err) {
+			return "", i18n.Errorf("API key %s was rejected by PAY.JP; it was not saved", util.MaskAPIKey(key))
+		}
+		return "", i18n.Errorf("failed to verify API key (use --no-verify to save it without checking): %w", err)
+	}
+	return key, nil
+}
+
+// readAPIKeyArg normalizes an API key given as an argument, reading it from stdin for "-"
+func readAPIKeyArg(value string) (string, error) {
+	if value == "-" {
+		key, err := client.ReadAPIKey(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		value = key
+	}
+
+	key, err := util.NormalizeAPIKey(value)
+	if err != nil {
+		return "", err
+	}
+	if key != value {
+		printStatus("Removed whitespace or quotes around the API key")
+	}
+	return key, nil
+}
+
+// retrieveAccount retrieves the account that an API key belongs to
+func retrieveAccount(key string) (*payjp.AccountResponse, error) {
+	printVerbose("Verifying API key %s", util.MaskAPIKey(key))
+	if err := client.Init(client.WithAPIKey(key), client.WithLogf(printVerbose)); err != nil {
+		return nil, err
+	}
+	return client.GetAccount().Retrieve()
+}
+
+// isKeyRejected reports whether the API refused an API key
+func isKeyRejected(err error) bool {
+	payjpErr, ok := err.(*payjp.Error)
+	return ok && payjpErr.Status == 401
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 
@@ -215,8 +674,22 @@ func init() {
 	configCmd.AddCommand(configSetProfileCmd)
 	configCmd.AddCommand(configUseProfileCmd)
 	configCmd.AddCommand(configListProfilesCmd)
+	configCmd.AddCommand(configRotateKeyCmd)
+	configCmd.AddCommand(configRepairCmd)
+	configCmd.AddCommand(configEffectiveCmd)
 
 	// Flags for set-profile
 	configSetProfileCmd.Flags().String("api-key", "", "API key for the profile")
 	configSetProfileCmd.Flags().String("mode", "", "Mode (test or live, auto-detected from key if not specified)")
+	configSetProfileCmd.Flags().Bool("no-verify", false, "Save the API key without checking it against the API")
+
+	// Flags for rotate-key
+	configRotateKeyCmd.Flags().String("profile", "", "Profile whose key is replaced (default is the current profile)")
+	configRotateKeyCmd.Flags().String("account", "", "Expected account ID, for when the current key is already revoked")
+	configRotateKeyCmd.Flags().Bool("check-revoked", false, "Check that the old key no longer works after saving the new one")
+
+	// Flags for set
+	configRepairCmd.Flags().Bool("dry-run", false, "List the settings that would be recovered and dropped without changing anything")
+
+	configSetCmd.Flags().Bool("no-verify", false, "Save the API key without checking it against the API")
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+// countOnly is set by --count on list commands; only the number of items is printed
+var countOnly bool
+
+// enableCountFlags adds --count to every list command that calls the API
+// With --all every page is fetched and the items are counted without being formatted
+func enableCountFlags(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		enableCountFlags(c)
+	}
+	if root.Name() != "list" || root.RunE == nil || !requiresClient(root) {
+		return
+	}
+
+	run := root.RunE
+	root.Flags().BoolVar(&countOnly, "count", false, "Print only the number of matching items (all pages with --all)")
+	root.RunE = func(cmd *cobra.Command, args []string) error {
+		if countOnly {
+			if quiet || rawOutput || fieldsArg != "" || outputFmtChanged {
+				return i18n.Errorf("--count cannot be used with --quiet, --raw, --fields or --output")
+			}
+			if flag := cmd.Flags().Lookup("group-by"); flag != nil && flag.Changed {
+				return i18n.Errorf("--count cannot be used with --group-by")
+			}
+		}
+		return run(cmd, args)
+	}
+}
+
+// outputCount prints the number of items in data
+func outputCount(data interface{}) error {
+	return printCount(countItems(data))
+}
+
+// countItems returns the length of a slice, or 1 for a single value
+func countItems(data interface{}) int {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		return v.Len()
+	}
+	if !v.IsValid() || v.Kind() == reflect.Pointer && v.IsNil() {
+		return 0
+	}
+	return 1
+}
+
+// printCount writes the count on its own line
+func printCount(n int) error {
+	_, err := fmt.Println(n)
+	return err
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/cache"
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+)
+
+// customerCache is a read-through cache of customers for the running command
+// With cache.customers in the config file, customers are also kept on disk for that long,
+// so that commands run one after another do not retrieve the same customer again
+var customerCache = struct {
+	sync.Mutex
+	customers map[string]*payjp.CustomerResponse
+	disk      cache.Cache
+}{customers: map[string]*payjp.CustomerResponse{}}
+
+// customerCacheTTL returns how long customers are kept on disk; 0 means they are not
+func customerCacheTTL() (time.Duration, error) {
+	ttl := config.GetCacheConfig().Customers
+	if ttl == "" {
+		return 0, nil
+	}
+	return util.ParseDuration(ttl)
+}
+
+// customerCacheKey returns the on-disk cache key of a customer of the current account
+func customerCacheKey(id string) string {
+	profile, _ := config.GetCurrentProfile()
+	return cache.Key(profile, client.IsLiveKey(), "customer", id)
+}
+
+// lookupCustomer returns a customer from the cache, retrieving it on a miss
+// The customer is only data: its methods cannot be used, so call the customer service instead
+func lookupCustomer(id string) (*payjp.CustomerResponse, error) {
+	ttl, err := customerCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	if customer, ok := cachedCustomer(id, ttl); ok {
+		return customer, nil
+	}
+
+	// The lock is not held during the request, so that concurrent lookups are not serialized
+	body, err := client.Request(http.MethodGet, "/customers/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	customer := &payjp.CustomerResponse{}
+	if err := json.Unmarshal(body, customer); err != nil {
+		return nil, err
+	}
+
+	customerCache.Lock()
+	defer customerCache.Unlock()
+	customerCache.customers[id] = customer
+	if ttl > 0 {
+		now := time.Now()
+		customerCache.disk.Prune(ttl, now)
+		customerCache.disk.Put(customerCacheKey(id), body, now)
+		// Failing to write the cache only means the customer is retrieved again next time
+		customerCache.disk.Save(cache.Path(config.ConfigDir()))
+	}
+	return customer, nil
+}
+
+// cachedCustomer returns a customer kept in memory or, when ttl is set, on disk
+func cachedCustomer(id string, ttl time.Duration) (*payjp.CustomerResponse, bool) {
+	customerCache.Lock()
+	defer customerCache.Unlock()
+	if customer, ok := customerCache.customers[id]; ok {
+		printVerbose("Customer %s from the cache", id)
+		return customer, true
+	}
+	if ttl == 0 {
+		return nil, false
+	}
+
+	if customerCache.disk == nil {
+		customerCache.disk = cache.Load(cache.Path(config.ConfigDir()))
+	}
+	body, ok := customerCache.disk.Get(customerCacheKey(id), ttl, time.Now())
+	if !ok {
+		return nil, false
+	}
+	customer := &payjp.CustomerResponse{}
+	if err := json.Unmarshal(body, customer); err != nil {
+		return nil, false
+	}
+	printVerbose("Customer %s from the cache on disk", id)
+	customerCache.customers[id] = customer
+	return customer, true
+}
+
+// forgetCustomer drops a customer from the cache after it or its cards were changed
+func forgetCustomer(id string) {
+	customerCache.Lock()
+	defer customerCache.Unlock()
+	delete(customerCache.customers, id)
+
+	if ttl, err := customerCacheTTL(); err != nil || ttl == 0 {
+		return
+	}
+	path := cache.Path(config.ConfigDir())
+	if customerCache.disk == nil {
+		customerCache.disk = cache.Load(path)
+	}
+	key := customerCacheKey(id)
+	if _, ok := customerCache.disk[key]; !ok {
+		return
+	}
+	delete(customerCache.disk, key)
+	customerCache.disk.Save(path)
+}
+
+// customerEmailKey returns the on-disk cache key of the customer ID found for an email
+func customerEmailKey(email string) string {
+	profile, _ := config.GetCurrentProfile()
+	return cache.Key(profile, client.IsLiveKey(), "customer-email", strings.ToLower(email))
+}
+
+// findCustomersByEmail returns the customers with an email, compared case-insensitively
+// The API cannot filter customers by email, so all customers are listed; with cache.customers
+// the ID of a single match is kept on disk, and the next search retrieves only that customer
+func findCustomersByEmail(email string) ([]*payjp.CustomerResponse, error) {
+	ttl, err := customerCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		customerCache.Lock()
+		if customerCache.disk == nil {
+			customerCache.disk = cache.Load(cache.Path(config.ConfigDir()))
+		}
+		body, ok := customerCache.disk.Get(customerEmailKey(email), ttl, time.Now())
+		customerCache.Unlock()
+
+		var id string
+		if ok && json.Unmarshal(body, &id) == nil {
+			// The customer may have been deleted or changed its email since
+			if customer, err := lookupCustomer(id); err == nil && strings.EqualFold(customer.Email, email) {
+				printVerbose("Customer %s for %s from the cache", id, email)
+				return []*payjp.CustomerResponse{customer}, nil
+			}
+		}
+	}
+
+	customers, err := listAllCustomers()
+	if err != nil {
+		return nil, err
+	}
+	matches := []*payjp.CustomerResponse{}
+	for _, c := range customers {
+		if strings.EqualFold(c.Email, email) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 1 {
+		rememberCustomerEmail(email, matches[0].ID)
+	}
+	return matches, nil
+}
+
+// rememberCustomerEmail keeps the customer ID of an email on disk when cache.customers is set
+func rememberCustomerEmail(email, id string) {
+	if ttl, err := customerCacheTTL(); err != nil || ttl == 0 {
+		return
+	}
+	body, _ := json.Marshal(id)
+
+	customerCache.Lock()
+	defer customerCache.Unlock()
+	path := cache.Path(config.ConfigDir())
+	if customerCache.disk == nil {
+		customerCache.disk = cache.Load(path)
+	}
+	customerCache.disk.Put(customerEmailKey(email), body, time.Now())
+	// Failing to write the cache only means the customers are listed again next time
+	customerCache.disk.Save(path)
+}
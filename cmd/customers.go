@@ -1,9 +1,21 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/payjp/payjp-cli/internal/checkpoint"
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
 	"github.com/payjp/payjp-cli/internal/util"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
@@ -87,12 +99,16 @@ var customersListCmd = &cobra.Command{
 	Long: `List all customers with optional filters.
 
 Example:
-  payjp customers list --limit 10`,
+  payjp customers list --limit 10
+  payjp customers list --all --output csv > customers.csv`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
-		since, _ := cmd.Flags().GetString("since")
-		until, _ := cmd.Flags().GetString("until")
+		all, _ := cmd.Flags().GetBool("all")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
 
 		caller := client.GetCustomer().List()
 
@@ -102,19 +118,21 @@ Example:
 		if offset > 0 {
 			caller.Offset(offset)
 		}
-		if since != "" {
-			ts, err := util.ParseTimestamp(since)
-			if err != nil {
-				return err
-			}
-			caller.Since(time.Unix(ts, 0))
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
 		}
-		if until != "" {
-			ts, err := util.ParseTimestamp(until)
+
+		if all {
+			err := streamAll("customers", func(limit, offset int) ([]*payjp.CustomerResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, nil)
 			if err != nil {
-				return err
+				handleError(err)
 			}
-			caller.Until(time.Unix(ts, 0))
+			return nil
 		}
 
 		result, _, err := caller.Do()
@@ -163,6 +181,7 @@ Example:
 			handleError(err)
 			return nil
 		}
+		forgetCustomer(customerID)
 
 		return outputResult(result)
 	},
@@ -179,11 +198,19 @@ Example:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		customerID := args[0]
 
+		if err := checkProtected(cmd, customerID); err != nil {
+			return err
+		}
+		if err := confirmDelete(cmd, "customer", customerID, previewCustomer); err != nil {
+			return err
+		}
+
 		err := client.GetCustomer().Delete(customerID)
 		if err != nil {
 			handleError(err)
 			return nil
 		}
+		forgetCustomer(customerID)
 
 		if quiet {
 			return nil
@@ -196,6 +223,657 @@ Example:
 	},
 }
 
+var customersLTVCmd = &cobra.Command{
+	Use:   "ltv <customer_id>",
+	Short: "Summarize a customer's lifetime value",
+	Long: `Sum all captured charges minus refunds for a customer and list the
+contributing charges. Uncaptured and failed charges are ignored.
+
+Example:
+  payjp customers ltv cus_xxxxx
+  payjp customers ltv cus_xxxxx --since 2024-01-01T00:00:00+09:00
+  payjp customers ltv cus_xxxxx -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		customerID := args[0]
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		caller := client.GetCharge().List().CustomerID(customerID)
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+
+		charges, err := fetchAll("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+			return caller.Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		result := summarizeLTV(customerID, charges)
+
+		if getOutputFormat() == "table" {
+			if err := outputResult(result.Charges); err != nil {
+				return err
+			}
+			for _, total := range result.Totals {
+				fmt.Printf("Lifetime value (%s): %s (%d charges, captured %s, refunded %s)\n",
+					total.Currency,
+					output.FormatCurrency(int64(total.LifetimeAmount), total.Currency),
+					total.Charges,
+					output.FormatCurrency(int64(total.CapturedAmount), total.Currency),
+					output.FormatCurrency(int64(total.RefundedAmount), total.Currency))
+			}
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+// customerLTV is the lifetime value summary of a customer
+type customerLTV struct {
+	CustomerID string      `json:"customer_id"`
+	Totals     []ltvTotal  `json:"totals"`
+	Charges    []ltvCharge `json:"charges"`
+}
+
+// ltvTotal is the lifetime value of a customer in one currency
+type ltvTotal struct {
+	Currency       string `json:"currency"`
+	Charges        int    `json:"charges"`
+	CapturedAmount int    `json:"captured_amount"`
+	RefundedAmount int    `json:"refunded_amount"`
+	LifetimeAmount int    `json:"lifetime_amount"`
+}
+
+// ltvCharge is a charge contributing to a customer's lifetime value
+type ltvCharge struct {
+	ID             string    `json:"id"`
+	Created        time.Time `json:"created"`
+	Currency       string    `json:"currency"`
+	Amount         int       `json:"amount"`
+	AmountRefunded int       `json:"amount_refunded"`
+	NetAmount      int       `json:"net_amount"`
+	Description    string    `json:"description"`
+}
+
+// TableColumns returns the fields shown in list view
+func (ltvCharge) TableColumns() []string {
+	return []string{"ID", "Created", "Currency", "Amount", "AmountRefunded", "NetAmount", "Description"}
+}
+
+// summarizeLTV sums captured charges minus refunds per currency
+func summarizeLTV(customerID string, charges []*payjp.ChargeResponse) customerLTV {
+	result := customerLTV{
+		CustomerID: customerID,
+		Totals:     []ltvTotal{},
+		Charges:    []ltvCharge{},
+	}
+
+	totals := map[string]*ltvTotal{}
+	currencies := []string{}
+	for _, charge := range charges {
+		if !charge.Captured {
+			continue
+		}
+
+		net := charge.Amount - charge.AmountRefunded
+		result.Charges = append(result.Charges, ltvCharge{
+			ID:             charge.ID,
+			Created:        charge.CreatedAt,
+			Currency:       charge.Currency,
+			Amount:         charge.Amount,
+			AmountRefunded: charge.AmountRefunded,
+			NetAmount:      net,
+			Description:    charge.Description,
+		})
+
+		total, ok := totals[charge.Currency]
+		if !ok {
+			total = &ltvTotal{Currency: charge.Currency}
+			totals[charge.Currency] = total
+			currencies = append(currencies, charge.Currency)
+		}
+		total.Charges++
+		total.CapturedAmount += charge.Amount
+		total.RefundedAmount += charge.AmountRefunded
+		total.LifetimeAmount += net
+	}
+
+	for _, currency := range currencies {
+		result.Totals = append(result.Totals, *totals[currency])
+	}
+	return result
+}
+
+var customersBulkUpdateCmd = &cobra.Command{
+	Use:   "bulk-update",
+	Short: "Update customers from a CSV file",
+	Long: `Update the email, description and metadata of many customers from a CSV file.
+
+The first row is a header. The customer_id column is required; email,
+description and metadata.<key> columns are optional. Empty cells are left
+unchanged. The whole file is validated before any customer is updated.
+
+  customer_id,email,metadata.segment
+  cus_xxxxx,new@example.com,vip
+  cus_yyyyy,,churned
+
+Updates run concurrently and a result is shown for each row. Completed rows
+are recorded in a checkpoint so an interrupted run can be resumed.
+
+Example:
+  payjp customers bulk-update --file updates.csv --dry-run
+  payjp customers bulk-update --file updates.csv --concurrency 8 -o csv > results.csv
+  payjp customers bulk-update --file updates.csv --resume ~/.payjp/checkpoints/customers-bulk-update-20240601-120000.jsonl`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if concurrency < 1 {
+			return i18n.Errorf("--concurrency must be at least 1")
+		}
+		deadline, err := commandDeadline(cmd)
+		if err != nil {
+			return err
+		}
+
+		updates, err := readCustomerUpdatesFile(file)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			results := make([]customerUpdateResult, len(updates))
+			for i, update := range updates {
+				if len(update.Changes) == 0 {
+					results[i] = update.result("skipped", "no changes")
+				} else {
+					results[i] = update.result("dry-run", "")
+				}
+			}
+			return outputResult(results)
+		}
+
+		if err := confirmAction(cmd, fmt.Sprintf("Update %d customer(s) from %s?", len(updates), file)); err != nil {
+			if err == errAborted {
+				printStatus("Aborted.")
+				return nil
+			}
+			return err
+		}
+
+		cp, err := openCheckpoint(cmd)
+		if err != nil {
+			return err
+		}
+		defer cp.Close()
+
+		results := applyCustomerUpdates(updates, concurrency, cp, deadline)
+
+		counts := map[string]int{}
+		for _, result := range results {
+			counts[result.Status]++
+		}
+		printStatus("%d updated, %d skipped, %d failed, %d not started", counts["updated"], counts["skipped"], counts["failed"], counts["not_started"])
+
+		if err := outputResult(results); err != nil {
+			return err
+		}
+		if counts["failed"] > 0 {
+			return i18n.Errorf("%d customer(s) failed to update", counts["failed"])
+		}
+		return deadlineError(counts["not_started"], cp)
+	},
+}
+
+// customerUpdate is a row of a bulk update file
+type customerUpdate struct {
+	Row        int
+	CustomerID string
+	Customer   payjp.Customer
+	Changes    []string
+}
+
+// result returns the row result with the given status
+func (u customerUpdate) result(status, detail string) customerUpdateResult {
+	return customerUpdateResult{
+		Row:        u.Row,
+		CustomerID: u.CustomerID,
+		Status:     status,
+		Changes:    strings.Join(u.Changes, ", "),
+		Detail:     detail,
+	}
+}
+
+// customerUpdateResult is the outcome of a row of a bulk update
+type customerUpdateResult struct {
+	Row        int    `json:"row"`
+	CustomerID string `json:"customer_id"`
+	Status     string `json:"status"`
+	Changes    string `json:"changes"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (customerUpdateResult) TableColumns() []string {
+	return []string{"Row", "CustomerID", "Status", "Changes", "Detail"}
+}
+
+// readCustomerUpdatesFile reads a bulk update file; "-" reads from stdin
+func readCustomerUpdatesFile(path string) ([]customerUpdate, error) {
+	if path == "-" {
+		return readCustomerUpdates(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read update file: %w", err)
+	}
+	defer f.Close()
+	return readCustomerUpdates(f)
+}
+
+// readCustomerUpdates parses and validates a bulk update CSV
+// Row numbers count the header as row 1, matching spreadsheet row numbers
+func readCustomerUpdates(r io.Reader) ([]customerUpdate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, i18n.Errorf("update file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read update file: %w", err)
+	}
+
+	idColumn := -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		header[i] = name
+		switch {
+		case name == "customer_id" || name == "id":
+			idColumn = i
+		case name == "email", name == "description":
+		case strings.HasPrefix(name, "metadata.") && len(name) > len("metadata."):
+		default:
+			return nil, i18n.Errorf("unknown column: %s (supported: customer_id, email, description, metadata.<key>)", name)
+		}
+	}
+	if idColumn < 0 {
+		return nil, i18n.Errorf("the update file has no customer_id column")
+	}
+
+	updates := []customerUpdate{}
+	seen := map[string]int{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read update file: %w", err)
+		}
+
+		update := customerUpdate{Row: row, CustomerID: strings.TrimSpace(record[idColumn])}
+		if update.CustomerID == "" {
+			return nil, i18n.Errorf("row %d: customer_id is empty", row)
+		}
+		if first, ok := seen[update.CustomerID]; ok {
+			return nil, i18n.Errorf("row %d: customer %s is already updated on row %d", row, update.CustomerID, first)
+		}
+		seen[update.CustomerID] = row
+
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if i == idColumn || value == "" {
+				continue
+			}
+			switch name := header[i]; name {
+			case "email":
+				if !strings.Contains(value, "@") {
+					return nil, i18n.Errorf("row %d: invalid email: %s", row, value)
+				}
+				update.Customer.Email = value
+				update.Changes = append(update.Changes, name)
+			case "description":
+				update.Customer.Description = value
+				update.Changes = append(update.Changes, name)
+			default:
+				if update.Customer.Metadata == nil {
+					update.Customer.Metadata = map[string]string{}
+				}
+				update.Customer.Metadata[strings.TrimPrefix(name, "metadata.")] = value
+				update.Changes = append(update.Changes, name)
+			}
+		}
+		sort.Strings(update.Changes)
+		updates = append(updates, update)
+	}
+
+	if len(updates) == 0 {
+		return nil, i18n.Errorf("update file is empty")
+	}
+	return updates, nil
+}
+
+// applyCustomerUpdates updates customers concurrently, keeping the file order in the results
+// Customers completed in the checkpoint are skipped and each successful update is marked
+// Rows not started before the deadline are reported as not_started
+func applyCustomerUpdates(updates []customerUpdate, concurrency int, cp *checkpoint.Checkpoint, deadline time.Time) []customerUpdateResult {
+	results := make([]customerUpdateResult, len(updates))
+	started := runParallel("Updating", len(updates), concurrency, deadline, func(index int) {
+		results[index] = applyCustomerUpdate(updates[index], cp)
+	})
+	for i := started; i < len(updates); i++ {
+		results[i] = updates[i].result("not_started", "deadline reached")
+	}
+	return results
+}
+
+// applyCustomerUpdate updates one customer
+func applyCustomerUpdate(update customerUpdate, cp *checkpoint.Checkpoint) customerUpdateResult {
+	if cp.Done(update.CustomerID) {
+		return update.result("skipped", "completed in checkpoint")
+	}
+	if len(update.Changes) == 0 {
+		return update.result("skipped", "no changes")
+	}
+
+	printVerbose("Updating customer %s (row %d)", update.CustomerID, update.Row)
+	if _, err := client.GetCustomer().Update(update.CustomerID, update.Customer); err != nil {
+		return update.result("failed", errorDetail(err))
+	}
+	forgetCustomer(update.CustomerID)
+	if err := cp.Mark(update.CustomerID); err != nil {
+		return update.result("failed", err.Error())
+	}
+	return update.result("updated", "")
+}
+
+var customersBulkDeleteCmd = &cobra.Command{
+	Use:   "bulk-delete",
+	Short: "Delete customers listed in a file",
+	Long: `Delete many customers, e.g. for data deletion requests.
+
+The file has one customer ID per line. Blank lines and lines starting with #
+are ignored, and when a line has several comma or tab separated columns (a
+spreadsheet export) the first column is used; a customer_id or id header is
+skipped. Duplicate IDs are deleted once.
+
+Every customer is looked up first and the IDs and emails of the customers to
+delete are shown before asking for confirmation. Customers that no longer
+exist are reported as not_found and count as done, so a run can simply be
+repeated. Completed customers are recorded in a checkpoint, and --failures
+writes the IDs that failed to a file that can be passed to --file again.
+
+Example:
+  payjp customers bulk-delete --file ids.txt --dry-run
+  payjp customers bulk-delete --file ids.txt --concurrency 8 --failures failed.txt
+  payjp customers bulk-delete --file ids.txt --resume ~/.payjp/checkpoints/customers-bulk-delete-20240601-120000.jsonl`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		failuresFile, _ := cmd.Flags().GetString("failures")
+
+		if concurrency < 1 {
+			return i18n.Errorf("--concurrency must be at least 1")
+		}
+		deadline, err := commandDeadline(cmd)
+		if err != nil {
+			return err
+		}
+
+		targets, err := readCustomerIDsFile(file)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, len(targets))
+		for i, target := range targets {
+			ids[i] = target.CustomerID
+		}
+		if err := checkProtected(cmd, ids...); err != nil {
+			return err
+		}
+
+		var cp *checkpoint.Checkpoint
+		if !dryRun {
+			if cp, err = openCheckpoint(cmd); err != nil {
+				return err
+			}
+			defer cp.Close()
+		}
+
+		results := lookupCustomerDeletes(targets, concurrency, cp, deadline)
+
+		pending := []int{}
+		for i, result := range results {
+			if result.Status == "pending" {
+				pending = append(pending, i)
+			}
+		}
+
+		if dryRun {
+			for _, i := range pending {
+				results[i].Status = "dry-run"
+			}
+			return outputResult(results)
+		}
+
+		if len(pending) > 0 {
+			// The list is part of the prompt, so it is shown with --silent as well; with --file -
+			// the answer is read from the terminal
+			if willPrompt() {
+				fmt.Fprintln(os.Stderr, "Customers to delete:")
+				for _, i := range pending {
+					fmt.Fprintf(os.Stderr, "  %s  %s\n", results[i].CustomerID, results[i].Email)
+				}
+			}
+			if err := confirmAction(cmd, fmt.Sprintf("Delete %d customer(s) from %s?", len(pending), file)); err != nil {
+				if err == errAborted {
+					printStatus("Aborted.")
+					return nil
+				}
+				return err
+			}
+
+			started := runParallel("Deleting", len(pending), concurrency, deadline, func(index int) {
+				i := pending[index]
+				results[i] = deleteCustomer(results[i], cp)
+			})
+			for _, i := range pending[started:] {
+				results[i].Status = "not_started"
+				results[i].Detail = "deadline reached"
+			}
+		}
+
+		counts := map[string]int{}
+		for _, result := range results {
+			counts[result.Status]++
+		}
+		printStatus("%d deleted, %d not found, %d skipped, %d failed, %d not started",
+			counts["deleted"], counts["not_found"], counts["skipped"], counts["failed"], counts["not_started"])
+
+		if failuresFile != "" {
+			if err := writeFailedCustomerIDs(failuresFile, results); err != nil {
+				return err
+			}
+			if counts["failed"] > 0 {
+				printStatus("Failed customer IDs written to %s", failuresFile)
+			}
+		}
+
+		if err := outputResult(results); err != nil {
+			return err
+		}
+		if counts["failed"] > 0 {
+			return i18n.Errorf("%d customer(s) failed to delete", counts["failed"])
+		}
+		return deadlineError(counts["not_started"], cp)
+	},
+}
+
+// customerDeleteResult is the outcome of a line of a bulk delete file
+type customerDeleteResult struct {
+	Line       int    `json:"line"`
+	CustomerID string `json:"customer_id"`
+	Email      string `json:"email"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (customerDeleteResult) TableColumns() []string {
+	return []string{"Line", "CustomerID", "Email", "Status", "Detail"}
+}
+
+// readCustomerIDsFile reads a bulk delete file; "-" reads from stdin
+func readCustomerIDsFile(path string) ([]customerDeleteResult, error) {
+	if path == "-" {
+		return readCustomerIDs(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ID file: %w", err)
+	}
+	defer f.Close()
+	return readCustomerIDs(f)
+}
+
+// readCustomerIDs parses customer IDs, one per line, keeping the first line of duplicates
+func readCustomerIDs(r io.Reader) ([]customerDeleteResult, error) {
+	targets := []customerDeleteResult{}
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if i := strings.IndexAny(text, ",\t"); i >= 0 {
+			text = text[:i]
+		}
+		id := strings.TrimSpace(strings.Trim(strings.TrimSpace(text), `"`))
+		if id == "" || strings.HasPrefix(id, "#") {
+			continue
+		}
+		if line == 1 && (strings.EqualFold(id, "customer_id") || strings.EqualFold(id, "id")) {
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		targets = append(targets, customerDeleteResult{Line: line, CustomerID: id})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ID file: %w", err)
+	}
+
+	if len(targets) == 0 {
+		return nil, i18n.Errorf("the ID file has no customer IDs")
+	}
+	return targets, nil
+}
+
+// lookupCustomerDeletes retrieves the customers concurrently to show their emails before deleting
+// Customers completed in the checkpoint are not looked up again, and those not looked up before
+// the deadline are reported as not_started
+func lookupCustomerDeletes(targets []customerDeleteResult, concurrency int, cp *checkpoint.Checkpoint, deadline time.Time) []customerDeleteResult {
+	results := make([]customerDeleteResult, len(targets))
+	started := runParallel("Looking up", len(targets), concurrency, deadline, func(index int) {
+		result := targets[index]
+		if cp != nil && cp.Done(result.CustomerID) {
+			result.Status = "skipped"
+			result.Detail = "completed in checkpoint"
+			results[index] = result
+			return
+		}
+
+		printVerbose("Retrieving customer %s (line %d)", result.CustomerID, result.Line)
+		customer, err := lookupCustomer(result.CustomerID)
+		switch {
+		case isNotFound(err):
+			result.Status = "not_found"
+		case err != nil:
+			result.Status = "failed"
+			result.Detail = errorDetail(err)
+		default:
+			result.Email = customer.Email
+			result.Status = "pending"
+		}
+		results[index] = result
+	})
+	for i := started; i < len(targets); i++ {
+		results[i] = targets[i]
+		results[i].Status = "not_started"
+		results[i].Detail = "deadline reached"
+	}
+	return results
+}
+
+// deleteCustomer deletes one customer; a customer deleted in the meantime counts as not_found
+func deleteCustomer(result customerDeleteResult, cp *checkpoint.Checkpoint) customerDeleteResult {
+	printVerbose("Deleting customer %s (line %d)", result.CustomerID, result.Line)
+	err := client.GetCustomer().Delete(result.CustomerID)
+	forgetCustomer(result.CustomerID)
+	switch {
+	case isNotFound(err):
+		result.Status = "not_found"
+	case err != nil:
+		result.Status = "failed"
+		result.Detail = errorDetail(err)
+		return result
+	default:
+		result.Status = "deleted"
+	}
+	if err := cp.Mark(result.CustomerID); err != nil {
+		result.Status = "failed"
+		result.Detail = err.Error()
+	}
+	return result
+}
+
+// writeFailedCustomerIDs writes the IDs of failed customers, one per line
+func writeFailedCustomerIDs(path string, results []customerDeleteResult) error {
+	var b strings.Builder
+	for _, result := range results {
+		if result.Status == "failed" {
+			b.WriteString(result.CustomerID + "\n")
+		}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write failures file: %w", err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 response from the API
+func isNotFound(err error) bool {
+	var payjpErr *payjp.Error
+	return errors.As(err, &payjpErr) && payjpErr.Status == http.StatusNotFound
+}
+
+// errorDetail returns the API error message, or the error text for other errors
+func errorDetail(err error) string {
+	var payjpErr *payjp.Error
+	if errors.As(err, &payjpErr) {
+		return payjpErr.Message
+	}
+	return err.Error()
+}
+
 func init() {
 	rootCmd.AddCommand(customersCmd)
 
@@ -204,6 +882,9 @@ func init() {
 	customersCmd.AddCommand(customersListCmd)
 	customersCmd.AddCommand(customersUpdateCmd)
 	customersCmd.AddCommand(customersDeleteCmd)
+	customersCmd.AddCommand(customersLTVCmd)
+	customersCmd.AddCommand(customersBulkUpdateCmd)
+	customersCmd.AddCommand(customersBulkDeleteCmd)
 
 	// Create flags
 	customersCreateCmd.Flags().String("id", "", "Custom customer ID")
@@ -215,12 +896,36 @@ func init() {
 	// List flags
 	customersListCmd.Flags().Int("limit", 10, "Number of items to return")
 	customersListCmd.Flags().Int("offset", 0, "Offset for pagination")
-	customersListCmd.Flags().String("since", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
-	customersListCmd.Flags().String("until", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
+	customersListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	addTimeRangeFlags(customersListCmd)
 
 	// Update flags
 	customersUpdateCmd.Flags().String("email", "", "New email")
 	customersUpdateCmd.Flags().String("description", "", "New description")
 	customersUpdateCmd.Flags().String("default-card", "", "Card ID to set as default")
 	customersUpdateCmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
+
+	// LTV flags
+	addTimeRangeFlags(customersLTVCmd)
+
+	// Delete flags
+	addForceFlag(customersDeleteCmd)
+
+	// Bulk update flags
+	customersBulkUpdateCmd.Flags().String("file", "", "CSV file with customer_id and the columns to update (- for stdin) (required)")
+	customersBulkUpdateCmd.Flags().Int("concurrency", 4, "Number of concurrent updates")
+	customersBulkUpdateCmd.Flags().Bool("dry-run", false, "Validate the file and show the changes without applying them")
+	customersBulkUpdateCmd.MarkFlagRequired("file")
+	addCheckpointFlags(customersBulkUpdateCmd)
+	addDeadlineFlag(customersBulkUpdateCmd)
+
+	// Bulk delete flags
+	customersBulkDeleteCmd.Flags().String("file", "", "File with one customer ID per line (- for stdin) (required)")
+	customersBulkDeleteCmd.Flags().Int("concurrency", 4, "Number of concurrent requests")
+	customersBulkDeleteCmd.Flags().Bool("dry-run", false, "Look up the customers and show what would be deleted without deleting")
+	customersBulkDeleteCmd.Flags().String("failures", "", "Write the IDs of customers that failed to delete to this file")
+	customersBulkDeleteCmd.MarkFlagRequired("file")
+	addForceFlag(customersBulkDeleteCmd)
+	addCheckpointFlags(customersBulkDeleteCmd)
+	addDeadlineFlag(customersBulkDeleteCmd)
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// addTimeRangeFlags adds the --since, --until and --range flags to a command
+func addTimeRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", "Filter by created timestamp (Unix timestamp, RFC3339, YYYY-MM-DD or a duration ago such as 7d)")
+	cmd.Flags().String("until", "", "Filter by created timestamp (Unix timestamp, RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().String("range", "", "Named date range from config (ranges) or start..end")
+}
+
+// timeRange returns the created timestamp range from --since/--until or --range
+// A zero time means the bound is not set
+func timeRange(cmd *cobra.Command) (time.Time, time.Time, error) {
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	rangeName, _ := cmd.Flags().GetString("range")
+
+	var sinceTS, untilTS int64
+	if rangeName != "" {
+		if since != "" || until != "" {
+			return time.Time{}, time.Time{}, i18n.Errorf("--range cannot be used with --since or --until")
+		}
+
+		spec, ok := config.GetRange(rangeName)
+		if !ok {
+			if !strings.Contains(rangeName, "..") {
+				return time.Time{}, time.Time{}, i18n.Errorf("unknown range: %s (define it under ranges in the config file)", rangeName)
+			}
+			spec = rangeName
+		}
+
+		var err error
+		sinceTS, untilTS, err = util.ParseDateRange(spec)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	} else {
+		var err error
+		if sinceTS, err = util.ParseTimestamp(since); err != nil {
+			// A duration such as 7d or 12h looks back from now
+			lookback, durationErr := util.ParseDuration(since)
+			if durationErr != nil || lookback <= 0 {
+				return time.Time{}, time.Time{}, err
+			}
+			sinceTS = time.Now().Add(-lookback).Unix()
+		}
+		if untilTS, err = util.ParseTimestamp(until); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	var sinceTime, untilTime time.Time
+	if sinceTS != 0 {
+		sinceTime = time.Unix(sinceTS, 0)
+	}
+	if untilTS != 0 {
+		untilTime = time.Unix(untilTS, 0)
+	}
+	return sinceTime, untilTime, nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/checkpoint"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// addDeadlineFlag adds the --deadline flag to a bulk command
+func addDeadlineFlag(cmd *cobra.Command) {
+	cmd.Flags().String("deadline", "", "Stop starting new work this long after the command starts (e.g. 10m, 2h); in-flight work is finished")
+}
+
+// commandDeadline returns the time at which a bulk command stops starting new work
+// The zero time is returned when --deadline is not given
+func commandDeadline(cmd *cobra.Command) (time.Time, error) {
+	arg, _ := cmd.Flags().GetString("deadline")
+	if arg == "" {
+		return time.Time{}, nil
+	}
+	d, err := util.ParseDuration(arg)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d <= 0 {
+		return time.Time{}, i18n.Errorf("--deadline must be positive")
+	}
+	deadline := time.Now().Add(d)
+	printVerbose("Deadline: %s", deadline.Local().Format("15:04:05"))
+	return deadline, nil
+}
+
+// deadlinePassed reports whether a deadline is set and has passed
+func deadlinePassed(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// deadlineError reports the items that were not started before the deadline and how to resume
+// An error is returned so that a run cut short exits with a non-zero status
+func deadlineError(remaining int, cp *checkpoint.Checkpoint) error {
+	if remaining == 0 {
+		return nil
+	}
+	if cp != nil {
+		printStatus("Resume the remaining items with --resume %s", cp.Path)
+	}
+	return i18n.Errorf("deadline reached with %d item(s) remaining", remaining)
+}
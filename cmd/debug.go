@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/capability"
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Diagnostic tools",
+	Long:  `Diagnostic tools for troubleshooting CLI configuration and API access.`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+// authReport describes how the API key was resolved
+type authReport struct {
+	Source        string   `json:"source"`
+	Profile       string   `json:"profile"`
+	ConfigFile    string   `json:"config_file"`
+	ProjectFile   string   `json:"project_file,omitempty"`
+	APIKey        string   `json:"api_key"`
+	KeyMode       string   `json:"key_mode"`
+	RequestedMode string   `json:"requested_mode"`
+	Authorization string   `json:"authorization"`
+	Authenticated bool     `json:"authenticated"`
+	AccountID     string   `json:"account_id,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+var debugAuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Show which API key is used and verify it",
+	Long: `Show which API key source won (flag or stdin > env or key file > profile), its mode, the masked
+key and Authorization header, then perform a harmless authenticated call
+(account retrieval) to verify it.
+
+Example:
+  payjp debug auth
+  payjp debug auth --api-key sk_test_xxxxx -o json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resolved := config.ResolveAPIKey()
+		report := authReport{
+			Source:     resolved.Source,
+			Profile:    resolved.Profile,
+			ConfigFile: config.ConfigFilePath(),
+		}
+		if project := config.Project(); project != nil {
+			report.ProjectFile = project.Path
+		}
+
+		key := resolved.Key
+		override, source, err := apiKeyOverride()
+		if err != nil {
+			return err
+		}
+		if override != "" {
+			key = override
+			report.Source = source
+			report.Profile = ""
+		}
+
+		if key == "" {
+			report.Source = "none"
+			report.Error = "no API key found in --api-key or --api-key-stdin flag, PAYJP_API_KEY or PAYJP_API_KEY_FILE environment variable, or profile"
+			return outputResult(report)
+		}
+
+		report.APIKey = util.MaskAPIKey(key)
+		report.KeyMode = apiKeyMode(key)
+		report.RequestedMode = "test"
+		if config.IsLiveMode() {
+			report.RequestedMode = "live"
+		}
+		encoded := base64.StdEncoding.EncodeToString([]byte(key + ":"))
+		report.Authorization = "Basic " + util.MaskAPIKey(encoded)
+
+		if report.KeyMode != "unknown" && report.KeyMode != report.RequestedMode {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s mode requested but the API key is a %s key", report.RequestedMode, report.KeyMode))
+		}
+		if strings.TrimSpace(key) != key {
+			report.Warnings = append(report.Warnings, "API key contains leading or trailing whitespace")
+		}
+
+		opts, err := clientOptions()
+		if err != nil {
+			return err
+		}
+		if err := client.Init(opts...); err != nil {
+			report.Error = err.Error()
+			return outputResult(report)
+		}
+
+		printVerbose("Retrieving account to verify authentication")
+		account, err := client.GetAccount().Retrieve()
+		if err != nil {
+			if payjpErr, ok := err.(*payjp.Error); ok {
+				report.Error = fmt.Sprintf("%d %s: %s", payjpErr.Status, payjpErr.Type, payjpErr.Message)
+			} else {
+				report.Error = err.Error()
+			}
+		} else {
+			report.Authenticated = true
+			report.AccountID = account.ID
+		}
+
+		return outputResult(report)
+	},
+}
+
+// apiKeyMode returns the mode implied by an API key prefix
+func apiKeyMode(key string) string {
+	switch {
+	case strings.HasPrefix(key, "sk_live_"), strings.HasPrefix(key, "pk_live_"):
+		return "live"
+	case strings.HasPrefix(key, "sk_test_"), strings.HasPrefix(key, "pk_test_"):
+		return "test"
+	default:
+		return "unknown"
+	}
+}
+
+// rateLimitProbePath is the request debug ratelimit sends: the cheapest authenticated read
+const rateLimitProbePath = "/charges?limit=1"
+
+// rateLimitReport is the outcome of a debug ratelimit burst
+type rateLimitReport struct {
+	Endpoint             string  `json:"endpoint"`
+	Requests             int     `json:"requests"`
+	Concurrency          int     `json:"concurrency"`
+	DurationSeconds      float64 `json:"duration_seconds"`
+	Succeeded            int     `json:"succeeded"`
+	RateLimited          int     `json:"rate_limited"`
+	Errors               int     `json:"errors"`
+	FirstLimitedAt       int     `json:"first_limited_at,omitempty"`
+	AvgLatencyMS         int64   `json:"avg_latency_ms"`
+	ThroughputRPS        float64 `json:"throughput_rps"`
+	BudgetRPS            float64 `json:"budget_rps"`
+	BudgetReached        bool    `json:"budget_reached"`
+	MaxRetryAfter        string  `json:"max_retry_after,omitempty"`
+	RecoverySeconds      float64 `json:"recovery_seconds,omitempty"`
+	SuggestedConcurrency int     `json:"suggested_concurrency"`
+}
+
+// probeOutcome is one request of the burst
+type probeOutcome struct {
+	result *client.ProbeResult
+	err    error
+	// done is when the response arrived, relative to the start of the burst
+	done time.Duration
+}
+
+var debugRateLimitCmd = &cobra.Command{
+	Use:   "ratelimit",
+	Short: "Measure the API rate limit of the account with a burst of reads",
+	Long: `Send a burst of cheap read requests (GET /v1/charges?limit=1) without
+retrying, observe when the API starts answering 429 and how long it takes to
+accept requests again, and report the effective request budget.
+
+budget_rps is the rate of requests accepted before the first 429. When no
+request was rate limited, the budget was not reached and the measured
+throughput is only a lower bound; try again with a larger --concurrency.
+suggested_concurrency is the number of parallel requests that keeps within
+the budget at the measured latency; use it for --concurrency of bulk commands.
+
+The burst counts against the same limit as every other client of the
+account, so in live mode it asks for confirmation.
+
+Example:
+  payjp debug ratelimit
+  payjp debug ratelimit --requests 200 --concurrency 20 -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		requests, _ := cmd.Flags().GetInt("requests")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		maxRecovery, _ := cmd.Flags().GetString("max-recovery")
+
+		if requests < 1 || concurrency < 1 {
+			return i18n.Errorf("--requests and --concurrency must be at least 1")
+		}
+		recoveryLimit, err := util.ParseDuration(maxRecovery)
+		if err != nil {
+			return err
+		}
+
+		opts, err := clientOptions()
+		if err != nil {
+			return err
+		}
+		if err := client.Init(opts...); err != nil {
+			return err
+		}
+		if config.IsLiveMode() || client.IsLiveKey() {
+			message := fmt.Sprintf("Send %d requests to the live API? Other clients of the account may be rate limited meanwhile", requests)
+			if err := confirmAction(cmd, message); err != nil {
+				return err
+			}
+		}
+
+		outcomes := make([]probeOutcome, requests)
+		start := time.Now()
+		runParallel("Probing", requests, min(concurrency, requests), time.Time{}, func(i int) {
+			result, err := client.Probe(rateLimitProbePath)
+			outcomes[i] = probeOutcome{result: result, err: err, done: time.Since(start)}
+		})
+		elapsed := time.Since(start)
+
+		report := summarizeRateLimit(outcomes, elapsed, concurrency)
+		if report.Succeeded == 0 && report.RateLimited == 0 {
+			return i18n.Errorf("every request failed: %v", firstProbeError(outcomes))
+		}
+
+		if report.RateLimited > 0 {
+			wait := time.Second
+			for _, o := range outcomes {
+				if o.result != nil && o.result.HasRetryAfter && o.result.RetryAfter > wait {
+					wait = o.result.RetryAfter
+				}
+			}
+			printStatus("Rate limited after %d requests; waiting for the API to accept requests again", report.FirstLimitedAt)
+			report.RecoverySeconds = measureRecovery(wait, recoveryLimit)
+		}
+
+		switch {
+		case report.RateLimited == 0:
+			printStatus("No request was rate limited; the budget is above %.1f requests/s", report.BudgetRPS)
+		case report.RecoverySeconds == 0:
+			printStatus("Warning: the API still answered 429 after %s", recoveryLimit)
+		}
+		return outputResult(report)
+	},
+}
+
+// summarizeRateLimit computes the report of a burst from its requests in any order
+func summarizeRateLimit(outcomes []probeOutcome, elapsed time.Duration, concurrency int) rateLimitReport {
+	report := rateLimitReport{
+		Endpoint:        "GET /v1" + rateLimitProbePath,
+		Requests:        len(outcomes),
+		Concurrency:     concurrency,
+		DurationSeconds: roundTo(elapsed.Seconds(), 2),
+	}
+
+	sorted := append([]probeOutcome(nil), outcomes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].done < sorted[j].done
+	})
+
+	var latency, maxRetryAfter, firstLimited time.Duration
+	acceptedBefore := 0
+	for i, o := range sorted {
+		switch {
+		case o.err != nil:
+			report.Errors++
+			continue
+		case o.result.Status == http.StatusTooManyRequests:
+			report.RateLimited++
+			if report.FirstLimitedAt == 0 {
+				report.FirstLimitedAt = i + 1
+				firstLimited = o.done
+			}
+			if o.result.HasRetryAfter && o.result.RetryAfter > maxRetryAfter {
+				maxRetryAfter = o.result.RetryAfter
+			}
+		case o.result.Status < 300:
+			report.Succeeded++
+			latency += o.result.Latency
+			if report.FirstLimitedAt == 0 {
+				acceptedBefore++
+			}
+		default:
+			report.Errors++
+		}
+	}
+
+	if report.Succeeded > 0 {
+		average := latency / time.Duration(report.Succeeded)
+		report.AvgLatencyMS = average.Milliseconds()
+		report.ThroughputRPS = roundTo(float64(report.Succeeded)/elapsed.Seconds(), 1)
+
+		report.BudgetRPS = report.ThroughputRPS
+		if report.FirstLimitedAt > 0 && firstLimited > 0 {
+			report.BudgetReached = true
+			report.BudgetRPS = roundTo(float64(acceptedBefore)/firstLimited.Seconds(), 1)
+		}
+		// Little's law: requests in flight = arrival rate × time in the system
+		report.SuggestedConcurrency = max(int(report.BudgetRPS*average.Seconds()), 1)
+		if !report.BudgetReached {
+			report.SuggestedConcurrency = max(report.SuggestedConcurrency, concurrency)
+		}
+	}
+	if maxRetryAfter > 0 {
+		report.MaxRetryAfter = maxRetryAfter.String()
+	}
+	return report
+}
+
+// measureRecovery probes with a backoff starting at wait until the API accepts a request again
+// It returns the seconds until then, or 0 if the API still rate limits after limit
+func measureRecovery(wait, limit time.Duration) float64 {
+	start := time.Now()
+	for time.Since(start)+wait <= limit {
+		time.Sleep(wait)
+		result, err := client.Probe(rateLimitProbePath)
+		if err == nil && result.Status != http.StatusTooManyRequests {
+			return roundTo(time.Since(start).Seconds(), 1)
+		}
+		printVerbose("Still rate limited after %s", time.Since(start).Round(time.Second))
+		if err == nil && result.HasRetryAfter && result.RetryAfter > 0 {
+			wait = result.RetryAfter
+		} else {
+			wait *= 2
+		}
+	}
+	return 0
+}
+
+// firstProbeError returns the first failure of a burst
+func firstProbeError(outcomes []probeOutcome) error {
+	for _, o := range outcomes {
+		if o.err != nil {
+			return o.err
+		}
+		if o.result != nil && o.result.Status >= 300 {
+			return fmt.Errorf("HTTP %d", o.result.Status)
+		}
+	}
+	return nil
+}
+
+// roundTo rounds v to the given number of decimal places
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+// capabilityRow is the detected support of a capability
+type capabilityRow struct {
+	Capability string    `json:"capability"`
+	Status     string    `json:"status"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	SDK        string    `json:"sdk"`
+	Commands   string    `json:"commands"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// TableColumns returns the fields shown in list view
+func (capabilityRow) TableColumns() []string {
+	return []string{"Capability", "Status", "HTTPStatus", "SDK", "Commands", "CheckedAt"}
+}
+
+var debugCapabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Show which API features the account and the embedded SDK support",
+	Long: `Probe the endpoints of features that not every account has, with one list
+request each, and show whether the account of the current API key can use them.
+
+A 403 or 404 for the endpoint means the feature is unsupported; other errors
+leave it unknown. The sdk column shows whether the embedded payjp-go has a
+service for the feature or the CLI calls the endpoint directly.
+
+Results are cached per profile and mode for 24 hours in capabilities.json in
+the config directory. Commands that get a 403 or 404 from an unsupported
+feature use the cache to report it clearly; --refresh probes again.
+
+Example:
+  payjp debug capabilities
+  payjp debug capabilities --refresh -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		refresh, _ := cmd.Flags().GetBool("refresh")
+
+		opts, err := clientOptions()
+		if err != nil {
+			return err
+		}
+		if err := client.Init(opts...); err != nil {
+			return err
+		}
+
+		rows := []capabilityRow{}
+		for _, c := range capability.All {
+			result := detectCapability(c, refresh)
+			sdk := "payjp-go"
+			if !c.SDK {
+				sdk = "direct"
+			}
+			rows = append(rows, capabilityRow{
+				Capability: c.Name,
+				Status:     result.Status,
+				HTTPStatus: result.HTTPStatus,
+				SDK:        sdk,
+				Commands:   c.Commands,
+				CheckedAt:  result.CheckedAt,
+			})
+		}
+		return outputResult(rows)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+
+	debugCmd.AddCommand(debugAuthCmd)
+	debugCmd.AddCommand(debugRateLimitCmd)
+	debugCmd.AddCommand(debugCapabilitiesCmd)
+
+	debugRateLimitCmd.Flags().Int("requests", 50, "Number of requests in the burst")
+	debugRateLimitCmd.Flags().Int("concurrency", 10, "Number of concurrent requests")
+	debugRateLimitCmd.Flags().String("max-recovery", "1m", "How long to wait for the API to accept requests again after a 429")
+
+	debugCapabilitiesCmd.Flags().Bool("refresh", false, "Probe every feature again instead of using cached results")
+}
@@ -0,0 +1,483 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// disputeEventPageSize is the page size used to collect the events of a resource
+const disputeEventPageSize = 100
+
+// disputeBundle is the result of disputes prepare
+type disputeBundle struct {
+	File     string   `json:"file"`
+	Charge   string   `json:"charge"`
+	Customer string   `json:"customer,omitempty"`
+	Events   int      `json:"events"`
+	Files    []string `json:"files"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// disputeEvidence is what is collected for a disputed charge
+type disputeEvidence struct {
+	charge       *payjp.ChargeResponse
+	customer     *payjp.CustomerResponse
+	subscription *payjp.SubscriptionResponse
+	events       []*payjp.EventResponse
+	note         string
+	attachments  []string
+	// files maps the name in the bundle to the raw content
+	files map[string][]byte
+}
+
+var disputesCmd = &cobra.Command{
+	Use:     "disputes",
+	Aliases: []string{"dispute"},
+	Short:   "Prepare chargeback evidence",
+}
+
+var disputesPrepareCmd = &cobra.Command{
+	Use:   "prepare <charge_id>",
+	Short: "Collect the evidence for a disputed charge into a zip bundle",
+	Long: `Collect everything PAY.JP support asks for when a charge is disputed
+(chargeback) into a single zip file, step by step:
+
+  1. the charge, as returned by the API (charge.json)
+  2. its customer and subscription, if any (customer.json, subscription.json)
+  3. the events of the charge and the customer (events.json)
+  4. a receipt of the payment (receipt.txt)
+  5. your explanation and supporting files such as delivery records or
+     correspondence with the customer (attachments/)
+
+summary.txt describes the payment, the card checks, the timeline of events
+and what is missing from the bundle in plain language, and is meant to be
+read first. PAY.JP does not issue receipts through the API, so receipt.txt
+is built from the charge.
+
+On an interactive terminal the command asks for the explanation and the
+files to attach unless --note or --attach is given. With --non-interactive
+(or when stdin is not a terminal) nothing is asked.
+
+Example:
+  payjp disputes prepare ch_xxxxx
+  payjp disputes prepare ch_xxxxx --note "Shipped on 2024-06-03, signed for on 2024-06-05" \
+    --attach delivery.pdf --attach mail.eml --out evidence.zip`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		chargeID := args[0]
+		out, _ := cmd.Flags().GetString("out")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		note, _ := cmd.Flags().GetString("note")
+		attachments, _ := cmd.Flags().GetStringArray("attach")
+
+		if out == "" {
+			out = "dispute_" + chargeID + ".zip"
+		}
+		if _, err := os.Stat(out); err == nil && !overwrite {
+			return i18n.Errorf("%s already exists (use --overwrite to replace it)", out)
+		}
+
+		if promptDisputeDetails(cmd) {
+			reader := bufio.NewReader(os.Stdin)
+			note = promptLine(reader, "Describe what was sold and how it was delivered (empty to skip): ")
+			for {
+				path := promptLine(reader, "File to attach, e.g. a delivery record (empty to finish): ")
+				if path == "" {
+					break
+				}
+				if _, err := os.Stat(path); err != nil {
+					printStatus("Warning: %v", err)
+					continue
+				}
+				attachments = append(attachments, path)
+			}
+		}
+		for _, path := range attachments {
+			if _, err := os.Stat(path); err != nil {
+				return err
+			}
+		}
+
+		evidence := &disputeEvidence{note: note, attachments: attachments, files: map[string][]byte{}}
+
+		printStatus("[1/5] Retrieving charge %s", chargeID)
+		body, err := client.Request(http.MethodGet, "/charges/"+url.PathEscape(chargeID), nil)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		evidence.files["charge.json"] = body
+		evidence.charge = &payjp.ChargeResponse{}
+		if err := json.Unmarshal(body, evidence.charge); err != nil {
+			return err
+		}
+
+		printStatus("[2/5] Retrieving customer and subscription")
+		if evidence.charge.CustomerID != "" {
+			body, err := client.Request(http.MethodGet, "/customers/"+url.PathEscape(evidence.charge.CustomerID), nil)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			evidence.files["customer.json"] = body
+			evidence.customer = &payjp.CustomerResponse{}
+			if err := json.Unmarshal(body, evidence.customer); err != nil {
+				return err
+			}
+		}
+		if evidence.charge.SubscriptionID != "" {
+			body, err := client.Request(http.MethodGet, "/subscriptions/"+url.PathEscape(evidence.charge.SubscriptionID), nil)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			evidence.files["subscription.json"] = body
+			evidence.subscription = &payjp.SubscriptionResponse{}
+			if err := json.Unmarshal(body, evidence.subscription); err != nil {
+				return err
+			}
+		}
+
+		printStatus("[3/5] Collecting related events")
+		resources := []string{chargeID}
+		if evidence.charge.CustomerID != "" {
+			resources = append(resources, evidence.charge.CustomerID)
+		}
+		var rawEvents []json.RawMessage
+		for _, resourceID := range resources {
+			events, err := listResourceEvents(resourceID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			rawEvents = append(rawEvents, events...)
+		}
+		for _, raw := range rawEvents {
+			event := &payjp.EventResponse{}
+			if err := json.Unmarshal(raw, event); err != nil {
+				return err
+			}
+			evidence.events = append(evidence.events, event)
+		}
+		sort.SliceStable(evidence.events, func(i, j int) bool {
+			return evidence.events[i].CreatedAt.Before(evidence.events[j].CreatedAt)
+		})
+		evidence.files["events.json"], err = json.MarshalIndent(rawEvents, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		printStatus("[4/5] Writing receipt and summary")
+		evidence.files["receipt.txt"] = []byte(evidence.receipt())
+		evidence.files["summary.txt"] = []byte(evidence.summary())
+
+		printStatus("[5/5] Writing %s", out)
+		if err := writeDisputeBundle(out, evidence); err != nil {
+			return err
+		}
+
+		result := disputeBundle{
+			File:    out,
+			Charge:  chargeID,
+			Events:  len(evidence.events),
+			Missing: evidence.missing(),
+		}
+		if evidence.customer != nil {
+			result.Customer = evidence.customer.ID
+		}
+		for name := range evidence.files {
+			result.Files = append(result.Files, name)
+		}
+		for _, path := range attachments {
+			result.Files = append(result.Files, "attachments/"+filepath.Base(path))
+		}
+		sort.Strings(result.Files)
+
+		printSuccess("Evidence bundle for %s written to %s", chargeID, out)
+		for _, item := range result.Missing {
+			printStatus("Warning: %s", item)
+		}
+		return outputResult(result)
+	},
+}
+
+// promptDisputeDetails reports whether disputes prepare should ask for the explanation and attachments
+func promptDisputeDetails(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("note") || cmd.Flags().Changed("attach") {
+		return false
+	}
+	return !nonInteractive && !config.IsNonInteractive() && stdinIsTerminal()
+}
+
+// promptLine asks a question on stderr and returns the trimmed answer
+func promptLine(reader *bufio.Reader, message string) string {
+	fmt.Fprint(os.Stderr, message)
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// listResourceEvents returns every event of a resource as the API returned them
+func listResourceEvents(resourceID string) ([]json.RawMessage, error) {
+	var events []json.RawMessage
+	for offset := 0; ; offset += disputeEventPageSize {
+		params := url.Values{}
+		params.Set("resource_id", resourceID)
+		params.Set("limit", strconv.Itoa(disputeEventPageSize))
+		params.Set("offset", strconv.Itoa(offset))
+		body, err := client.Request(http.MethodGet, "/events", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Data    []json.RawMessage `json:"data"`
+			HasMore bool              `json:"has_more"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page.Data...)
+		if !page.HasMore || len(page.Data) == 0 {
+			return events, nil
+		}
+	}
+}
+
+// receipt describes the payment like a receipt handed to the customer
+func (e *disputeEvidence) receipt() string {
+	c := e.charge
+	var b strings.Builder
+	fmt.Fprintln(&b, "RECEIPT")
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "Charge:       %s\n", c.ID)
+	fmt.Fprintf(&b, "Date:         %s\n", util.FormatTimestamp(c.CreatedAt.Unix()))
+	fmt.Fprintf(&b, "Amount:       %s\n", util.FormatAmount(c.Amount, c.Currency))
+	if c.AmountRefunded > 0 {
+		fmt.Fprintf(&b, "Refunded:     %s\n", util.FormatAmount(c.AmountRefunded, c.Currency))
+	}
+	if c.Description != "" {
+		fmt.Fprintf(&b, "Description:  %s\n", c.Description)
+	}
+	fmt.Fprintf(&b, "Card:         %s **** %s\n", c.Card.Brand, c.Card.Last4)
+	if c.Card.Name != "" {
+		fmt.Fprintf(&b, "Cardholder:   %s\n", c.Card.Name)
+	}
+	if e.customer != nil && e.customer.Email != "" {
+		fmt.Fprintf(&b, "Email:        %s\n", e.customer.Email)
+	}
+	fmt.Fprintf(&b, "Status:       %s\n", chargeStatus(c))
+	return b.String()
+}
+
+// summary describes the evidence in plain language for whoever reviews the dispute
+func (e *disputeEvidence) summary() string {
+	c := e.charge
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dispute evidence for charge %s\n", c.ID)
+	fmt.Fprintf(&b, "Prepared at %s\n\n", time.Now().Format(time.RFC3339))
+
+	fmt.Fprintln(&b, "Payment")
+	fmt.Fprintf(&b, "  %s on %s (%s)\n", util.FormatAmount(c.Amount, c.Currency), util.FormatTimestamp(c.CreatedAt.Unix()), chargeStatus(c))
+	if c.Description != "" {
+		fmt.Fprintf(&b, "  Description: %s\n", c.Description)
+	}
+	if c.AmountRefunded > 0 {
+		fmt.Fprintf(&b, "  Refunded: %s (%s)\n", util.FormatAmount(c.AmountRefunded, c.Currency), c.RefundReason)
+	}
+	if c.Captured {
+		fmt.Fprintf(&b, "  Captured: %s\n", util.FormatTimestamp(c.CapturedAt.Unix()))
+	}
+
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Card")
+	fmt.Fprintf(&b, "  %s **** %s, expires %02d/%d, issued in %s\n", c.Card.Brand, c.Card.Last4, c.Card.ExpMonth, c.Card.ExpYear, c.Card.Country)
+	if c.Card.Name != "" {
+		fmt.Fprintf(&b, "  Cardholder: %s\n", c.Card.Name)
+	}
+	fmt.Fprintf(&b, "  CVC check: %s\n", valueOrNone(c.Card.CvcCheck))
+	fmt.Fprintf(&b, "  3-D Secure: %s\n", valueOrNone(stringValue(c.ThreeDSecureStatus)))
+
+	if e.customer != nil {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Customer")
+		fmt.Fprintf(&b, "  %s, customer since %s\n", e.customer.ID, util.FormatTimestamp(e.customer.CreatedAt.Unix()))
+		if e.customer.Email != "" {
+			fmt.Fprintf(&b, "  Email: %s\n", e.customer.Email)
+		}
+		if e.customer.Description != "" {
+			fmt.Fprintf(&b, "  Description: %s\n", e.customer.Description)
+		}
+	}
+	if e.subscription != nil {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Subscription")
+		fmt.Fprintf(&b, "  %s (%s), started %s\n", e.subscription.ID, e.subscription.Status, util.FormatTimestamp(e.subscription.StartAt.Unix()))
+		fmt.Fprintf(&b, "  Plan: %s, %s per %s\n", e.subscription.Plan.ID,
+			util.FormatAmount(e.subscription.Plan.Amount, e.subscription.Plan.Currency), e.subscription.Plan.Interval)
+	}
+
+	if len(c.Metadata) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Metadata")
+		keys := make([]string, 0, len(c.Metadata))
+		for key := range c.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "  %s: %s\n", key, c.Metadata[key])
+		}
+	}
+
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Timeline")
+	if len(e.events) == 0 {
+		fmt.Fprintln(&b, "  (no events)")
+	}
+	for _, event := range e.events {
+		fmt.Fprintf(&b, "  %s  %-32s %s\n", util.FormatTimestamp(event.CreatedAt.Unix()), event.Type, event.ID)
+	}
+
+	if e.note != "" {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Merchant explanation")
+		for _, line := range strings.Split(e.note, "\n") {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	if len(e.attachments) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Attachments")
+		for _, path := range e.attachments {
+			fmt.Fprintf(&b, "  attachments/%s\n", filepath.Base(path))
+		}
+	}
+
+	if missing := e.missing(); len(missing) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Missing")
+		for _, item := range missing {
+			fmt.Fprintf(&b, "  - %s\n", item)
+		}
+	}
+	return b.String()
+}
+
+// missing lists evidence that strengthens a dispute response but is not in the bundle
+func (e *disputeEvidence) missing() []string {
+	var missing []string
+	if e.customer == nil || e.customer.Email == "" {
+		missing = append(missing, "no customer email to show who made the purchase")
+	}
+	if status := stringValue(e.charge.ThreeDSecureStatus); status != "verified" && status != "attempted" {
+		missing = append(missing, "the payment was not authenticated with 3-D Secure")
+	}
+	if e.note == "" {
+		missing = append(missing, "no explanation of what was sold and how it was delivered (--note)")
+	}
+	if len(e.attachments) == 0 {
+		missing = append(missing, "no supporting files such as delivery records or correspondence (--attach)")
+	}
+	return missing
+}
+
+// writeDisputeBundle writes the evidence and attachments to a zip file
+// The zip is written to a temp file first so that a failure leaves no partial bundle
+func writeDisputeBundle(path string, e *disputeEvidence) error {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	names := make([]string, 0, len(e.files))
+	for name := range e.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(e.files[name]); err != nil {
+			return err
+		}
+	}
+	for _, attachment := range e.attachments {
+		if err := addZipFile(zw, attachment, "attachments/"+filepath.Base(attachment)); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	tempFile := path + ".part"
+	if err := os.WriteFile(tempFile, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	return nil
+}
+
+// addZipFile copies a file into the zip under name
+func addZipFile(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// valueOrNone returns s, or "none" when it is empty
+func valueOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func init() {
+	rootCmd.AddCommand(disputesCmd)
+	disputesCmd.AddCommand(disputesPrepareCmd)
+
+	disputesPrepareCmd.Flags().String("out", "", "Zip file to write (default is dispute_<charge_id>.zip)")
+	disputesPrepareCmd.Flags().Bool("overwrite", false, "Replace the zip file if it exists")
+	disputesPrepareCmd.Flags().String("note", "", "Explanation of what was sold and how it was delivered")
+	disputesPrepareCmd.Flags().StringArray("attach", nil, "File to include in the bundle, e.g. a delivery record (can be repeated)")
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/keychain"
+	"github.com/payjp/payjp-cli/internal/security"
+	"github.com/spf13/cobra"
+)
+
+// doctorCheck is the result of a security check
+type doctorCheck struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Target  string `json:"target,omitempty"`
+	Message string `json:"message,omitempty"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (doctorCheck) TableColumns() []string {
+	return []string{"Check", "Status", "Target", "Message", "Fix"}
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check how API keys are stored and passed",
+	Long: `Check for risky ways of storing and passing API keys:
+
+  config_permissions        the config file or directory can be read by other users
+  key_in_shell_file         a secret key is in a shell history or startup file
+  key_on_command_line       the API key was given with --api-key
+  live_key_in_test_profile  a profile in test mode has a live key
+
+Every command runs these checks and warns about each issue once; doctor
+shows all of them every time.
+
+With --fix, the permissions of the config file and directory are corrected
+and, when the OS keychain is available (macOS, or Linux with secret-tool),
+it offers to move the API key of each profile from the config file to the
+keychain. Keys in shell files have to be removed by hand and rolled.
+
+Example:
+  payjp doctor
+  payjp doctor --fix`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fix, _ := cmd.Flags().GetBool("fix")
+
+		issues := security.Check(apiKey != "")
+		fixed := map[string]bool{}
+		if fix {
+			for _, issue := range issues {
+				if issue.Check != security.CheckConfigPermissions {
+					continue
+				}
+				if err := security.Fix(issue); err != nil {
+					return err
+				}
+				fixed[issue.ID()] = true
+				printSuccess("Changed the permissions of %s to %04o", issue.Target, issue.Mode)
+			}
+			if err := offerKeychainMigration(cmd); err != nil {
+				return err
+			}
+		}
+
+		checks := []doctorCheck{}
+		for _, check := range security.Checks {
+			found := false
+			for _, issue := range issues {
+				if issue.Check != check {
+					continue
+				}
+				found = true
+				status := "warn"
+				if fixed[issue.ID()] {
+					status = "fixed"
+				}
+				checks = append(checks, doctorCheck{Check: check, Status: status, Target: issue.Target, Message: issue.Message, Fix: issue.Fix})
+			}
+			if !found {
+				checks = append(checks, doctorCheck{Check: check, Status: "ok"})
+			}
+		}
+		return outputResult(checks)
+	},
+}
+
+// offerKeychainMigration asks whether to move the API key of each profile to the OS keychain
+// --yes moves every key; in non-interactive mode doctor must be in automation.allowlist
+func offerKeychainMigration(cmd *cobra.Command) error {
+	profiles := config.Get().Profiles
+	names := []string{}
+	for name, profile := range profiles {
+		if profile.APIKey != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	if !keychain.Available() {
+		printStatus("No OS keychain is available; API keys stay in the config file")
+		return nil
+	}
+	for _, name := range names {
+		err := confirmAction(cmd, fmt.Sprintf("Move the API key of profile '%s' to the OS keychain?", name))
+		if err == errAborted {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := config.MoveKeyToKeychain(name); err != nil {
+			return err
+		}
+		printSuccess("Moved the API key of profile '%s' to the OS keychain", name)
+	}
+	return nil
+}
+
+// warnSecurityIssues warns about each security issue the first time it is found
+// The files are checked at most once per security.CheckInterval; the issues already warned
+// about and the time of the last check are recorded in the config directory
+func warnSecurityIssues(cmd *cobra.Command) {
+	if silent || cmd == doctorCmd {
+		return
+	}
+	path := security.WarnedPath(config.ConfigDir())
+	warned := security.LoadWarned(path)
+
+	now := time.Now()
+	issues := security.CommandLineIssues(apiKey != "")
+	due := warned.CheckDue(now)
+	if due {
+		issues = security.Check(apiKey != "")
+		warned.MarkChecked(now)
+	}
+
+	found := false
+	for _, issue := range issues {
+		if _, ok := warned[issue.ID()]; ok {
+			continue
+		}
+		printStatus("Warning: %s; %s", issue.Message, issue.Fix)
+		warned[issue.ID()] = now
+		found = true
+	}
+	if found {
+		printStatus("Run 'payjp doctor' to check again; each issue is only warned about once")
+	}
+	if found || due {
+		// Failing to record the check only means it runs again
+		warned.Save(path)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().Bool("fix", false, "Fix file permissions and offer to move API keys to the OS keychain")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/payjp/payjp-go/v1"
+)
+
+// enrichOutput adds derived columns to table and CSV output
+// JSON, NDJSON and YAML stay the API objects as they are, so only tabular output is enriched
+func enrichOutput(data interface{}) interface{} {
+	switch v := data.(type) {
+	case []*payjp.ChargeResponse:
+		rows := make([]chargeRow, len(v))
+		for i, charge := range v {
+			rows[i] = newChargeRow(charge)
+		}
+		return rows
+	case *payjp.ChargeResponse:
+		return newChargeRow(v)
+	}
+	return data
+}
+
+// chargeRow is a charge with its net amount for table and CSV output
+type chargeRow struct {
+	*payjp.ChargeResponse
+	Net int `json:"net"`
+}
+
+// newChargeRow computes the net amount of a charge: the amount less refunds and the
+// processing fee when the charge has a fee rate
+// A charge that was not paid and captured has no proceeds, so its net amount is 0
+func newChargeRow(charge *payjp.ChargeResponse) chargeRow {
+	row := chargeRow{ChargeResponse: charge}
+	if !charge.Paid || !charge.Captured {
+		return row
+	}
+	row.Net = charge.Amount - charge.AmountRefunded
+	if charge.FeeRate != "" {
+		// An unparsable rate leaves the fee out rather than failing the listing
+		if fee, err := feeAmount(charge.Amount, charge.FeeRate); err == nil {
+			row.Net -= fee
+		}
+	}
+	return row
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+)
+
+// envelopeOutput is the standard wrapper of the result of a command with --envelope
+type envelopeOutput struct {
+	OK    bool           `json:"ok"`
+	Data  interface{}    `json:"data"`
+	Error *envelopeError `json:"error"`
+	Meta  envelopeMeta   `json:"meta"`
+}
+
+// envelopeError describes why a command failed; the API fields are set for API errors
+type envelopeError struct {
+	Message  string `json:"message"`
+	ExitCode int    `json:"exit_code"`
+	Status   int    `json:"status,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Param    string `json:"param,omitempty"`
+}
+
+// envelopeMeta describes the run of a command
+type envelopeMeta struct {
+	Count     int    `json:"count"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	Profile   string `json:"profile"`
+	LiveMode  bool   `json:"livemode"`
+}
+
+// commandStart is when the command started, for the elapsed time of the envelope
+var commandStart = time.Now()
+
+// envelopeWritten is set once the envelope is written, so that it is written only once
+var envelopeWritten bool
+
+// applyEnvelopeMode turns on --envelope from PAYJP_ENVELOPE and checks that the output is JSON
+// The envelope is applied before the config is loaded, so that config commands are wrapped too
+func applyEnvelopeMode() error {
+	if os.Getenv("PAYJP_ENVELOPE") == "true" {
+		envelope = true
+	}
+	if !envelope {
+		return nil
+	}
+	// A .path argument is only parsed when the get command runs, so enableValuePaths checks it
+	if quiet || countOnly || rawOutput {
+		return i18n.Errorf("--envelope cannot be used with --quiet, --count, --raw or a .path argument")
+	}
+	if outputFmtChanged && outputFmt != string(output.FormatJSON) {
+		return i18n.Errorf("--envelope requires JSON output; remove -o %s", outputFmt)
+	}
+	output.SetEnvelope(true)
+	return nil
+}
+
+// writeEnvelope writes the envelope of the command's results, or of err when the command failed
+// It does nothing without --envelope
+func writeEnvelope(code util.ExitCode, err error) {
+	if !envelope || envelopeWritten {
+		return
+	}
+	envelopeWritten = true
+
+	profile, _ := config.GetCurrentProfile()
+	result := envelopeOutput{
+		OK: err == nil,
+		Meta: envelopeMeta{
+			ElapsedMs: time.Since(commandStart).Milliseconds(),
+			Profile:   profile,
+			LiveMode:  config.IsLiveMode() || client.IsLiveKey(),
+		},
+	}
+	if err != nil {
+		result.Error = &envelopeError{Message: err.Error(), ExitCode: int(code)}
+		var payjpErr *payjp.Error
+		if errors.As(err, &payjpErr) {
+			result.Error.Message = payjpErr.Message
+			result.Error.Status = payjpErr.Status
+			result.Error.Type = payjpErr.Type
+			result.Error.Code = payjpErr.Code
+			result.Error.Param = payjpErr.Param
+		}
+	} else {
+		result.Data = output.EnvelopeData()
+		result.Meta.Count = output.EnvelopeCount(result.Data)
+	}
+
+	if err := output.WriteEnvelope(result); err != nil {
+		printStatus("Warning: failed to write the envelope: %v", err)
+	}
+}
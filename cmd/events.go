@@ -2,10 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/payjp/payjp-cli/internal/client"
-	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-cli/internal/eventschema"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
 
@@ -42,17 +49,30 @@ var eventsListCmd = &cobra.Command{
 	Short: "List events",
 	Long: `List all events with optional filters.
 
+--type can be repeated (or comma separated) to list events of any of the
+types, and --exclude-type leaves out types. A type ending with * matches by
+prefix (e.g. charge.*). A single --type without * is filtered by the API;
+other type filters are applied to the fetched events, so --limit still
+returns up to that many matching events.
+
 Example:
   payjp events list --limit 10
+  payjp events list --all --output csv > events.csv
   payjp events list --type charge.succeeded
+  payjp events list --type charge.succeeded --type charge.failed
+  payjp events list --type 'charge.*' --exclude-type charge.updated
+  payjp events list --all --exclude-type token.created
   payjp events list --resource-id ch_xxxxx`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
-		eventType, _ := cmd.Flags().GetString("type")
+		all, _ := cmd.Flags().GetBool("all")
+		types := newEventTypeFilter(cmd)
 		resourceID, _ := cmd.Flags().GetString("resource-id")
-		since, _ := cmd.Flags().GetString("since")
-		until, _ := cmd.Flags().GetString("until")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
 
 		caller := client.GetEvent().List()
 
@@ -62,25 +82,50 @@ Example:
 		if offset > 0 {
 			caller.Offset(offset)
 		}
-		if eventType != "" {
+		if eventType := types.queryType(); eventType != "" {
 			caller.Type(eventType)
 		}
 		if resourceID != "" {
 			caller.ResourceID(resourceID)
 		}
-		if since != "" {
-			ts, err := util.ParseTimestamp(since)
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+
+		// Several types, prefixes and exclusions cannot be queried, so they are matched client-side,
+		// which --raw cannot do since it prints the API responses as they are
+		if types.clientSide() && rawOutput {
+			return i18n.Errorf("--raw cannot be used with %s, which filter the fetched results", "--exclude-type, several --type or --type with *")
+		}
+		var keep func(*payjp.EventResponse) bool
+		if types.clientSide() {
+			keep = func(event *payjp.EventResponse) bool {
+				return types.match(event.Type)
+			}
+		}
+
+		if all {
+			err := streamAll("events", func(limit, offset int) ([]*payjp.EventResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, keep)
 			if err != nil {
-				return err
+				handleError(err)
 			}
-			caller.Since(time.Unix(ts, 0))
+			return nil
 		}
-		if until != "" {
-			ts, err := util.ParseTimestamp(until)
+
+		if keep != nil {
+			result, err := listFiltered("events", func(limit, offset int) ([]*payjp.EventResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, keep, limit, offset)
 			if err != nil {
-				return err
+				handleError(err)
+				return nil
 			}
-			caller.Until(time.Unix(ts, 0))
+			return outputResult(result)
 		}
 
 		result, _, err := caller.Do()
@@ -93,56 +138,648 @@ Example:
 	},
 }
 
+var eventsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export events as CSV with flattened payload columns",
+	Long: `Export events with the common fields of their payload flattened into columns,
+so that webhook activity can be pivoted in a spreadsheet.
+
+Every page is fetched and written as CSV (or in the format given by --output).
+The columns are id, type, created and livemode of the event, followed by the
+object type, resource_id, amount, currency, status and customer taken from
+the payload. Where these fields are found depends on the object type and is
+defined by a schema registry (see --schemas); for example subscription events
+take the amount from the plan, and charge events get a status of succeeded,
+authorized, partially_refunded, refunded or failed. Columns that do not apply
+to an object type are left empty.
+
+With --upload the output is uploaded to S3 (s3://) or Google Cloud Storage
+(gs://) instead of being printed. A URL ending with / gets a file name with
+the current time. --type and --exclude-type work as in events list.
+
+S3 credentials are read from AWS_ACCESS_KEY_ID,
+AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN or the AWS_PROFILE of the shared
+credentials file, with the region from AWS_REGION; Cloud Storage uses the
+service account key in GOOGLE_APPLICATION_CREDENTIALS or
+GOOGLE_OAUTH_ACCESS_TOKEN.
+
+Example:
+  payjp events export > events.csv
+  payjp events export --type charge.succeeded --range last-month > charges.csv
+  payjp events export --type 'subscription.*' --exclude-type subscription.updated
+  payjp events export --since 2024-06-01 -o ndjson
+  payjp events export --since 2024-06-01 --upload s3://datalake/payjp/events/
+  payjp events export --schemas`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schemas, _ := cmd.Flags().GetBool("schemas")
+		types := newEventTypeFilter(cmd)
+		resourceID, _ := cmd.Flags().GetString("resource-id")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		if schemas {
+			return outputResult(eventSchemaRows())
+		}
+
+		// CSV is the point of the command, so it is the default regardless of the configured format
+		if !outputFmtChanged {
+			outputFmt = "csv"
+			outputFmtChanged = true
+		}
+
+		caller := client.GetEvent().List()
+		if eventType := types.queryType(); eventType != "" {
+			caller.Type(eventType)
+		}
+		if resourceID != "" {
+			caller.ResourceID(resourceID)
+		}
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+
+		contentType, ext := formatContentType(outputFmt)
+		target, err := uploadTarget(cmd, uploadName("events", ext))
+		if err != nil {
+			return err
+		}
+
+		export := func() error {
+			return streamAll("events", func(limit, offset int) ([]eventExportRow, bool, error) {
+				events, hasMore, err := caller.Limit(limit).Offset(offset).Do()
+				if err != nil {
+					return nil, false, err
+				}
+				rows := make([]eventExportRow, 0, len(events))
+				for _, e := range events {
+					rows = append(rows, newEventExportRow(e))
+				}
+				return rows, hasMore, nil
+			}, func(row eventExportRow) bool {
+				return types.match(row.Type)
+			})
+		}
+
+		if target == nil {
+			if err := export(); err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
+		path, err := captureStdout(export)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		defer os.Remove(path)
+		return uploadFile(target, path, contentType)
+	},
+}
+
+// eventExportRow is an event with the common fields of its payload flattened
+type eventExportRow struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Created    time.Time `json:"created"`
+	LiveMode   bool      `json:"livemode"`
+	Object     string    `json:"object"`
+	ResourceID string    `json:"resource_id"`
+	Amount     *int64    `json:"amount"`
+	Currency   string    `json:"currency"`
+	Status     string    `json:"status"`
+	Customer   string    `json:"customer"`
+}
+
+// TableColumns returns the fields shown in list view
+func (eventExportRow) TableColumns() []string {
+	return []string{"ID", "Type", "Created", "LiveMode", "Object", "ResourceID", "Amount", "Currency", "Status", "Customer"}
+}
+
+// newEventExportRow flattens an event through the schema of its payload
+func newEventExportRow(e *payjp.EventResponse) eventExportRow {
+	fields := eventschema.Flatten(e.Type, e.DataMap)
+	return eventExportRow{
+		ID:         e.ID,
+		Type:       e.Type,
+		Created:    e.CreatedAt,
+		LiveMode:   e.LiveMode,
+		Object:     fields.Object,
+		ResourceID: fields.ResourceID,
+		Amount:     fields.Amount,
+		Currency:   fields.Currency,
+		Status:     fields.Status,
+		Customer:   fields.Customer,
+	}
+}
+
+// eventSchemaRow shows where the payload columns of an object type come from
+type eventSchemaRow struct {
+	Object   string `json:"object"`
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+	Status   string `json:"status"`
+	Customer string `json:"customer"`
+}
+
+// TableColumns returns the fields shown in list view
+func (eventSchemaRow) TableColumns() []string {
+	return []string{"Object", "Amount", "Currency", "Status", "Customer"}
+}
+
+// eventSchemaRows lists the schema registry used by events export
+func eventSchemaRows() []eventSchemaRow {
+	rows := []eventSchemaRow{}
+	for _, schema := range eventschema.Schemas() {
+		status := schema.StatusPath
+		if schema.Status != nil {
+			status = "(derived)"
+		}
+		rows = append(rows, eventSchemaRow{
+			Object:   schema.Object,
+			Amount:   schema.AmountPath,
+			Currency: schema.CurrencyPath,
+			Status:   status,
+			Customer: schema.CustomerPath,
+		})
+	}
+	return rows
+}
+
+// knownEventTypes are the event types sent by PAY.JP, used by events types and shell completion
+var knownEventTypes = []string{
+	"charge.succeeded",
+	"charge.failed",
+	"charge.updated",
+	"charge.refunded",
+	"charge.captured",
+	"customer.created",
+	"customer.updated",
+	"customer.deleted",
+	"customer.card.created",
+	"customer.card.updated",
+	"customer.card.deleted",
+	"plan.created",
+	"plan.updated",
+	"plan.deleted",
+	"subscription.created",
+	"subscription.updated",
+	"subscription.deleted",
+	"subscription.paused",
+	"subscription.resumed",
+	"subscription.canceled",
+	"subscription.renewed",
+	"transfer.succeeded",
+	"token.created",
+}
+
 var eventsTypesCmd = &cobra.Command{
 	Use:   "types",
 	Short: "List available event types",
 	Long:  `Display a list of all available event types.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		eventTypes := []string{
-			"charge.succeeded",
-			"charge.failed",
-			"charge.updated",
-			"charge.refunded",
-			"charge.captured",
-			"customer.created",
-			"customer.updated",
-			"customer.deleted",
-			"customer.card.created",
-			"customer.card.updated",
-			"customer.card.deleted",
-			"plan.created",
-			"plan.updated",
-			"plan.deleted",
-			"subscription.created",
-			"subscription.updated",
-			"subscription.deleted",
-			"subscription.paused",
-			"subscription.resumed",
-			"subscription.canceled",
-			"subscription.renewed",
-			"transfer.succeeded",
-			"token.created",
-		}
-
 		fmt.Println("Available event types:")
-		for _, t := range eventTypes {
+		for _, t := range knownEventTypes {
 			fmt.Printf("  %s\n", t)
 		}
 	},
 }
 
+var eventsDiffConfigCmd = &cobra.Command{
+	Use:   "diff-config",
+	Short: "Compare handled event types with events in the account",
+	Long: `Compare the event types your webhook endpoint handles with the event types
+that actually occurred in the account over a period.
+
+Reports event types that occurred but are not handled (unhandled) and
+handled event types that never occurred (unseen). Handled types are read from
+--handled and/or --handled-file (one type per line, # starts a comment).
+A trailing "*" matches by prefix (e.g. charge.*).
+
+The period defaults to the last 30 days.
+
+Example:
+  payjp events diff-config --handled-file webhooks.txt
+  payjp events diff-config --handled charge.succeeded,charge.failed --range last-quarter
+  payjp events diff-config --handled 'subscription.*' --since 2024-04-01`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		handledFlag, _ := cmd.Flags().GetStringSlice("handled")
+		handledFile, _ := cmd.Flags().GetString("handled-file")
+		showAll, _ := cmd.Flags().GetBool("show-all")
+
+		handled, err := loadHandledEventTypes(handledFlag, handledFile)
+		if err != nil {
+			return err
+		}
+
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+		if since.IsZero() && until.IsZero() {
+			since = time.Now().AddDate(0, 0, -30)
+		}
+
+		caller := client.GetEvent().List()
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+
+		events, err := fetchAll("events", func(limit, offset int) ([]*payjp.EventResponse, bool, error) {
+			return caller.Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		seen := map[string]int{}
+		for _, e := range events {
+			seen[e.Type]++
+		}
+
+		rows := diffEventTypes(handled, seen)
+		unhandled, unseen := 0, 0
+		for _, r := range rows {
+			switch r.Status {
+			case eventStatusUnhandled:
+				unhandled++
+			case eventStatusUnseen:
+				unseen++
+			}
+		}
+		printStatus("%d events checked: %d unhandled, %d unseen event types", len(events), unhandled, unseen)
+
+		if !showAll {
+			filtered := []eventCoverageRow{}
+			for _, r := range rows {
+				if r.Status != eventStatusHandled {
+					filtered = append(filtered, r)
+				}
+			}
+			rows = filtered
+		}
+
+		return outputResult(rows)
+	},
+}
+
+// Coverage statuses reported by events diff-config
+const (
+	eventStatusHandled   = "handled"
+	eventStatusUnhandled = "unhandled"
+	eventStatusUnseen    = "unseen"
+)
+
+// eventCoverageRow is one event type in the diff-config report
+type eventCoverageRow struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Events int    `json:"events"`
+}
+
+// TableColumns returns the fields shown in list view
+func (eventCoverageRow) TableColumns() []string {
+	return []string{"Type", "Status", "Events"}
+}
+
+// loadHandledEventTypes merges handled event types from flags and a file
+func loadHandledEventTypes(types []string, path string) ([]string, error) {
+	handled := []string{}
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			handled = append(handled, t)
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read handled event types: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if i := strings.Index(line, "#"); i >= 0 {
+				line = line[:i]
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				handled = append(handled, line)
+			}
+		}
+	}
+
+	if len(handled) == 0 {
+		return nil, i18n.Errorf("no handled event types given (use --handled or --handled-file)")
+	}
+	return handled, nil
+}
+
+// eventTypeFilter selects events by type from the --type and --exclude-type flags
+// Each entry may end with "*" to match by prefix; no --type matches every type
+type eventTypeFilter struct {
+	types   []string
+	exclude []string
+}
+
+// newEventTypeFilter reads the type flags of a command
+func newEventTypeFilter(cmd *cobra.Command) *eventTypeFilter {
+	f := &eventTypeFilter{}
+	f.types, _ = cmd.Flags().GetStringSlice("type")
+	f.exclude, _ = cmd.Flags().GetStringSlice("exclude-type")
+	return f
+}
+
+// queryType returns the type to filter by in the API request, or "" when it cannot express the filter
+func (f *eventTypeFilter) queryType() string {
+	if len(f.types) == 1 && !strings.HasSuffix(f.types[0], "*") {
+		return f.types[0]
+	}
+	return ""
+}
+
+// clientSide reports whether fetched events have to be matched with match
+func (f *eventTypeFilter) clientSide() bool {
+	return len(f.exclude) > 0 || (len(f.types) > 0 && f.queryType() == "")
+}
+
+// match reports whether an event type is selected by the filter
+func (f *eventTypeFilter) match(eventType string) bool {
+	for _, pattern := range f.exclude {
+		if matchEventType(pattern, eventType) {
+			return false
+		}
+	}
+	if len(f.types) == 0 {
+		return true
+	}
+	for _, pattern := range f.types {
+		if matchEventType(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchEventType reports whether an event type matches a handled pattern
+// A pattern ending with "*" matches by prefix
+func matchEventType(pattern, eventType string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == eventType
+}
+
+// diffEventTypes compares handled patterns with the counts of seen event types
+// Rows are sorted by status (unhandled, unseen, handled) and then by type
+func diffEventTypes(handled []string, seen map[string]int) []eventCoverageRow {
+	rows := []eventCoverageRow{}
+	matched := map[string]bool{}
+
+	for eventType, count := range seen {
+		status := eventStatusUnhandled
+		for _, pattern := range handled {
+			if matchEventType(pattern, eventType) {
+				status = eventStatusHandled
+				matched[pattern] = true
+			}
+		}
+		rows = append(rows, eventCoverageRow{Type: eventType, Status: status, Events: count})
+	}
+
+	reported := map[string]bool{}
+	for _, pattern := range handled {
+		if matched[pattern] || reported[pattern] {
+			continue
+		}
+		reported[pattern] = true
+		rows = append(rows, eventCoverageRow{Type: pattern, Status: eventStatusUnseen})
+	}
+
+	order := map[string]int{eventStatusUnhandled: 0, eventStatusUnseen: 1, eventStatusHandled: 2}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Status != rows[j].Status {
+			return order[rows[i].Status] < order[rows[j].Status]
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	return rows
+}
+
+var eventsStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Count events per type per day and highlight spikes",
+	Long: `Count the events of each type (or object, with --by object) per day, so that
+a sudden change such as a surge in charge.failed stands out.
+
+A day is marked as a spike when it has at least 5 events of a type and at
+least --spike-factor times the average per day of the other days in the
+period. Spikes are also reported as warnings on stderr.
+
+Every page of events in the period is fetched. The period defaults to the
+last 7 days; --since also accepts a duration such as 7d or 12h. Days are
+in the local time zone. --type and --exclude-type work as in events list.
+
+Example:
+  payjp events stats --by type --since 7d
+  payjp events stats --type 'charge.*' --since 30d -o csv
+  payjp events stats --by object --range last-month -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, _ := cmd.Flags().GetString("by")
+		factor, _ := cmd.Flags().GetFloat64("spike-factor")
+		types := newEventTypeFilter(cmd)
+
+		if !slices.Contains(eventStatsKeys, by) {
+			return i18n.Errorf("invalid --by: %s (supported: %s)", by, strings.Join(eventStatsKeys, ", "))
+		}
+		if factor <= 0 {
+			return i18n.Errorf("--spike-factor must be greater than 0")
+		}
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+		if since.IsZero() && until.IsZero() {
+			since = time.Now().AddDate(0, 0, -7)
+		}
+
+		caller := client.GetEvent().List()
+		if eventType := types.queryType(); eventType != "" {
+			caller.Type(eventType)
+		}
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+
+		stats := newEventStats(by)
+		err = forEach("events", func(limit, offset int) ([]*payjp.EventResponse, bool, error) {
+			return caller.Limit(limit).Offset(offset).Do()
+		}, func(e *payjp.EventResponse) {
+			if types.match(e.Type) {
+				stats.add(e)
+			}
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		end := until
+		if end.IsZero() {
+			end = time.Now()
+		}
+		rows := stats.rows(since, end, factor)
+		spikes := 0
+		for _, r := range rows {
+			if r.Spike {
+				spikes++
+				printStatus("Warning: spike of %s on %s: %d events (%.1f per day otherwise)", r.Group, r.Day, r.Events, r.Baseline)
+			}
+		}
+		printStatus("%d events, %d spike(s)", stats.total, spikes)
+
+		return outputResult(rows)
+	},
+}
+
+// eventStatsKeys are the supported --by values of events stats
+var eventStatsKeys = []string{"type", "object"}
+
+// eventSpikeMinEvents is the number of events below which a day is never a spike,
+// so that a type that usually has no events is not flagged for one or two
+const eventSpikeMinEvents = 5
+
+// eventStatRow is the number of events of a type or object on a day
+type eventStatRow struct {
+	Day      string  `json:"day"`
+	Group    string  `json:"group"`
+	Events   int     `json:"events"`
+	Baseline float64 `json:"baseline"`
+	Spike    bool    `json:"spike"`
+}
+
+// TableColumns returns the fields shown in list view
+func (eventStatRow) TableColumns() []string {
+	return []string{"Day", "Group", "Events", "Baseline", "Spike"}
+}
+
+// eventStats counts events per group and day as they are paged through
+type eventStats struct {
+	by     string
+	counts map[string]map[string]int
+	first  time.Time
+	total  int
+}
+
+// newEventStats creates a counter for a --by value
+func newEventStats(by string) *eventStats {
+	return &eventStats{by: by, counts: map[string]map[string]int{}}
+}
+
+// add counts an event
+func (s *eventStats) add(e *payjp.EventResponse) {
+	group := e.Type
+	if s.by == "object" {
+		group, _, _ = strings.Cut(e.Type, ".")
+	}
+	if s.counts[group] == nil {
+		s.counts[group] = map[string]int{}
+	}
+	s.counts[group][e.CreatedAt.Local().Format("2006-01-02")]++
+	if s.first.IsZero() || e.CreatedAt.Before(s.first) {
+		s.first = e.CreatedAt
+	}
+	s.total++
+}
+
+// rows returns the counts sorted by day and group, with the baseline of each day being the
+// average per day of the group over the other days from start (or the first event) to end
+func (s *eventStats) rows(start, end time.Time, factor float64) []eventStatRow {
+	rows := []eventStatRow{}
+	if start.IsZero() {
+		start = s.first
+	}
+	if start.IsZero() {
+		return rows
+	}
+	y, m, d := start.Local().Date()
+	days := 0
+	for day := time.Date(y, m, d, 0, 0, 0, 0, time.Local); !day.After(end); day = day.AddDate(0, 0, 1) {
+		days++
+	}
+
+	for group, perDay := range s.counts {
+		total := 0
+		for _, count := range perDay {
+			total += count
+		}
+		for day, count := range perDay {
+			row := eventStatRow{Day: day, Group: group, Events: count}
+			if days > 1 {
+				baseline := float64(total-count) / float64(days-1)
+				row.Baseline = math.Round(baseline*10) / 10
+				row.Spike = count >= eventSpikeMinEvents && float64(count) >= factor*baseline
+			}
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Day != rows[j].Day {
+			return rows[i].Day < rows[j].Day
+		}
+		return rows[i].Group < rows[j].Group
+	})
+	return rows
+}
+
 func init() {
 	rootCmd.AddCommand(eventsCmd)
 
 	eventsCmd.AddCommand(eventsGetCmd)
 	eventsCmd.AddCommand(eventsListCmd)
+	eventsCmd.AddCommand(eventsExportCmd)
 	eventsCmd.AddCommand(eventsTypesCmd)
+	eventsCmd.AddCommand(eventsDiffConfigCmd)
+	eventsCmd.AddCommand(eventsStatsCmd)
 
 	// List flags
 	eventsListCmd.Flags().Int("limit", 10, "Number of items to return")
 	eventsListCmd.Flags().Int("offset", 0, "Offset for pagination")
-	eventsListCmd.Flags().String("type", "", "Filter by event type")
+	eventsListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	eventsListCmd.Flags().StringSlice("type", nil, "Filter by event type (repeatable; * at the end matches by prefix)")
+	eventsListCmd.Flags().StringSlice("exclude-type", nil, "Leave out events of this type (repeatable; * at the end matches by prefix)")
 	eventsListCmd.Flags().String("resource-id", "", "Filter by resource ID")
-	eventsListCmd.Flags().String("since", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
-	eventsListCmd.Flags().String("until", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
+	addTimeRangeFlags(eventsListCmd)
+
+	// Export flags
+	eventsExportCmd.Flags().StringSlice("type", nil, "Filter by event type (repeatable; * at the end matches by prefix)")
+	eventsExportCmd.Flags().StringSlice("exclude-type", nil, "Leave out events of this type (repeatable; * at the end matches by prefix)")
+	eventsExportCmd.Flags().String("resource-id", "", "Filter by resource ID")
+	eventsExportCmd.Flags().Bool("schemas", false, "Show which payload fields fill the columns of each object type instead of exporting")
+	addTimeRangeFlags(eventsExportCmd)
+	addUploadFlag(eventsExportCmd)
+
+	// Diff-config flags
+	eventsDiffConfigCmd.Flags().StringSlice("handled", nil, "Handled event types (comma separated)")
+	eventsDiffConfigCmd.Flags().String("handled-file", "", "File listing handled event types, one per line")
+	eventsDiffConfigCmd.Flags().Bool("show-all", false, "Also show handled event types that occurred")
+	addTimeRangeFlags(eventsDiffConfigCmd)
+
+	// Stats flags
+	eventsStatsCmd.Flags().String("by", "type", "Count per "+strings.Join(eventStatsKeys, " or "))
+	eventsStatsCmd.Flags().Float64("spike-factor", 3, "Mark a day as a spike at this many times the average of the other days")
+	eventsStatsCmd.Flags().StringSlice("type", nil, "Filter by event type (repeatable; * at the end matches by prefix)")
+	eventsStatsCmd.Flags().StringSlice("exclude-type", nil, "Leave out events of this type (repeatable; * at the end matches by prefix)")
+	addTimeRangeFlags(eventsStatsCmd)
 }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// eventPrinter prints events one at a time as a long-running command receives them
+// Table output prints a line per event; json is written as NDJSON, since the output never ends
+type eventPrinter struct {
+	format string
+	writer output.StreamWriter
+}
+
+// newEventPrinter creates a printer for the output format
+func newEventPrinter() (*eventPrinter, error) {
+	format := getOutputFormat()
+	switch format {
+	case "table", "quiet":
+		return &eventPrinter{format: format}, nil
+	case "json", "ndjson":
+		format = "ndjson"
+	case "csv":
+	default:
+		return nil, i18n.Errorf("-o %s cannot be used with commands that run until interrupted; use table, json, ndjson or csv", format)
+	}
+	return &eventPrinter{format: format, writer: output.NewStreamWriter(output.Format(format))}, nil
+}
+
+// print prints an event with the common fields of its payload, as in events export
+func (p *eventPrinter) print(event *payjp.EventResponse) error {
+	row := newEventExportRow(event)
+	switch p.format {
+	case "quiet":
+		fmt.Println(event.ID)
+		return nil
+	case "table":
+		fmt.Printf("%s  %-24s %s  %s\n", util.FormatTimestamp(event.CreatedAt.Unix()), event.Type, event.ID, row.ResourceID)
+		return nil
+	}
+	return writePage(p.writer, p.format, []eventExportRow{row})
+}
+
+var eventsTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print new events as they are created",
+	Long: `Poll for events created after the command starts and print each one as it
+arrives, until interrupted.
+
+Table output prints a line per event; -o json (or ndjson) and -o csv print the
+columns of events export, one event at a time. --type and --exclude-type work
+as in events list.
+
+With --metrics-addr the events processed and the count, failures and latency
+of the API requests are served on /metrics in the Prometheus text format.
+
+Example:
+  payjp events tail
+  payjp events tail --type 'charge.*' -o json
+  payjp events tail --interval 10s --metrics-addr :9464`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		types := newEventTypeFilter(cmd)
+
+		if interval <= 0 {
+			return i18n.Errorf("--interval must be greater than 0")
+		}
+		if err := checkStreamFlags(); err != nil {
+			return err
+		}
+		printer, err := newEventPrinter()
+		if err != nil {
+			return err
+		}
+
+		registry, err := startMetrics(cmd)
+		if err != nil {
+			return err
+		}
+		if registry != nil {
+			// Reinitialize the client so that its requests are recorded
+			opts, err := clientOptions()
+			if err != nil {
+				return err
+			}
+			if err := client.Init(append(opts, client.WithMetrics(registry))...); err != nil {
+				return err
+			}
+		}
+		processed := eventsProcessed(registry)
+
+		tail, err := newEventTail(types.queryType())
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		printStatus("Waiting for events (press Ctrl+C to stop)")
+		for {
+			time.Sleep(interval)
+			events, err := tail.poll()
+			if err != nil {
+				// A long-running tail outlives transient API failures; they are counted in the metrics
+				printStatus("Warning: failed to fetch events: %v", err)
+				continue
+			}
+			for _, event := range events {
+				if !types.match(event.Type) {
+					continue
+				}
+				processed.Inc(event.Type)
+				if err := printer.print(event); err != nil {
+					return err
+				}
+			}
+		}
+	},
+}
+
+// eventTail finds the events created since the last poll
+// Events are listed by creation time in seconds, so the events of the newest second that were
+// already seen are remembered and skipped by the next poll
+type eventTail struct {
+	eventType string
+	since     time.Time
+	seen      map[string]bool
+}
+
+// newEventTail starts after the newest existing event, so that only new events are returned
+func newEventTail(eventType string) (*eventTail, error) {
+	t := &eventTail{eventType: eventType, since: time.Now(), seen: map[string]bool{}}
+	caller := client.GetEvent().List().Limit(1)
+	if eventType != "" {
+		caller.Type(eventType)
+	}
+	events, _, err := caller.Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > 0 {
+		t.since = events[0].CreatedAt
+		t.seen[events[0].ID] = true
+	}
+	return t, nil
+}
+
+// poll returns the events created since the last poll, oldest first
+func (t *eventTail) poll() ([]*payjp.EventResponse, error) {
+	caller := client.GetEvent().List().Since(t.since)
+	if t.eventType != "" {
+		caller.Type(t.eventType)
+	}
+	events, err := fetchAll("events", func(limit, offset int) ([]*payjp.EventResponse, bool, error) {
+		return caller.Limit(limit).Offset(offset).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The API lists the newest events first
+	fresh := []*payjp.EventResponse{}
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		if t.seen[event.ID] {
+			continue
+		}
+		if event.CreatedAt.After(t.since) {
+			t.since = event.CreatedAt
+			t.seen = map[string]bool{}
+		}
+		if event.CreatedAt.Equal(t.since) {
+			t.seen[event.ID] = true
+		}
+		fresh = append(fresh, event)
+	}
+	return fresh, nil
+}
+
+func init() {
+	eventsCmd.AddCommand(eventsTailCmd)
+
+	eventsTailCmd.Flags().Duration("interval", 5*time.Second, "Time between polls")
+	eventsTailCmd.Flags().StringSlice("type", nil, "Only print events of this type (repeatable; * at the end matches by prefix)")
+	eventsTailCmd.Flags().StringSlice("exclude-type", nil, "Leave out events of this type (repeatable; * at the end matches by prefix)")
+	addMetricsFlag(eventsTailCmd)
+}
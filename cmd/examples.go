@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/examples"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// exampleRow is one example in structured output
+type exampleRow struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	Run         string `json:"run"`
+}
+
+// TableColumns returns the fields shown in list view
+func (exampleRow) TableColumns() []string {
+	return []string{"Command", "Description", "Run"}
+}
+
+// exampleCountRow is a command with examples in the list of payjp examples without arguments
+type exampleCountRow struct {
+	Command  string `json:"command"`
+	Examples int    `json:"examples"`
+}
+
+// TableColumns returns the fields shown in list view
+func (exampleCountRow) TableColumns() []string {
+	return []string{"Command", "Examples"}
+}
+
+var examplesCmd = &cobra.Command{
+	Use:   "examples [command]",
+	Short: "Show runnable examples of a command",
+	Long: `Show curated, runnable examples of a command, covering more than the
+basic cases in its help. Descriptions are in Japanese for ja locales (see
+--locale) and in English otherwise.
+
+Without a command, the commands that have examples are listed. Aliases can
+be used in the command (e.g. payjp examples charge create).
+
+Example:
+  payjp examples
+  payjp examples charges list
+  payjp examples events stats --locale en
+  payjp examples charges create -o json`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		catalog, err := examples.Embedded()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			rows := make([]exampleCountRow, 0, len(catalog))
+			for _, e := range catalog {
+				rows = append(rows, exampleCountRow{Command: e.Command, Examples: len(e.Examples)})
+			}
+			return outputResult(rows)
+		}
+
+		// A quoted path such as "charges create" is split like separate arguments
+		target, rest, err := cmd.Root().Find(strings.Fields(strings.Join(args, " ")))
+		if err != nil || target == cmd.Root() || len(rest) > 0 {
+			return i18n.Errorf("unknown command: %s", strings.Join(args, " "))
+		}
+		name := commandName(target)
+		list := examples.For(catalog, name)
+		if len(list) == 0 {
+			return i18n.Errorf("no examples for %s; see payjp %s --help", name, name)
+		}
+
+		japanese := isJapaneseLocale()
+		if getOutputFormat() == "table" {
+			for i, e := range list {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("# %s\n%s\n", e.Description(japanese), e.Run)
+			}
+			return nil
+		}
+
+		rows := make([]exampleRow, 0, len(list))
+		for _, e := range list {
+			rows = append(rows, exampleRow{Command: name, Description: e.Description(japanese), Run: e.Run})
+		}
+		return outputResult(rows)
+	},
+}
+
+// isJapaneseLocale reports whether messages are shown in Japanese
+func isJapaneseLocale() bool {
+	base, _ := output.Locale().Base()
+	return base.String() == "ja"
+}
+
+func init() {
+	rootCmd.AddCommand(examplesCmd)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/history"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show and re-run previously executed commands",
+	Long: `Show and re-run commands executed with this CLI.
+
+History is stored in history.jsonl in the config directory. API key flags
+are not recorded and secret values are masked.`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List command history",
+	Long: `List previously executed commands.
+
+Example:
+  payjp history list
+  payjp history list --limit 50`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		entries, err := history.Load(history.Path(config.ConfigDir()))
+		if err != nil {
+			return err
+		}
+
+		start := 0
+		if limit > 0 && len(entries) > limit {
+			start = len(entries) - limit
+		}
+
+		rows := make([]map[string]interface{}, 0, len(entries)-start)
+		for i := start; i < len(entries); i++ {
+			entry := entries[i]
+			rows = append(rows, map[string]interface{}{
+				"n":         i + 1,
+				"time":      entry.Time.Format(time.RFC3339),
+				"profile":   entry.Profile,
+				"exit_code": entry.ExitCode,
+				"command":   strings.Join(entry.Args, " "),
+			})
+		}
+
+		if getOutputFormat() == "table" || getOutputFormat() == "quiet" {
+			if len(rows) == 0 {
+				fmt.Println("No history.")
+				return nil
+			}
+			for _, row := range rows {
+				fmt.Printf("%5d  %s  [%s] (exit %d)  payjp %s\n", row["n"], row["time"], row["profile"], row["exit_code"], row["command"])
+			}
+			return nil
+		}
+
+		return outputResult(rows)
+	},
+}
+
+var historyRerunCmd = &cobra.Command{
+	Use:   "rerun <n>",
+	Short: "Re-run a command from history",
+	Long: `Re-run the command with the given history number.
+
+Example:
+  payjp history rerun 42`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return i18n.Errorf("invalid history number: %s", args[0])
+		}
+
+		entries, err := history.Load(history.Path(config.ConfigDir()))
+		if err != nil {
+			return err
+		}
+		if n < 1 || n > len(entries) {
+			return i18n.Errorf("history entry %d not found", n)
+		}
+
+		entry := entries[n-1]
+		if history.HasMaskedSecret(entry.Args) {
+			return i18n.Errorf("history entry %d contains a masked secret and cannot be re-run", n)
+		}
+		if entry.APIKeyRemoved {
+			return i18n.Errorf("history entry %d was run with --api-key, which is not recorded, and cannot be re-run", n)
+		}
+
+		printStatus("payjp %s", strings.Join(entry.Args, " "))
+
+		executable, err := os.Executable()
+		if err != nil {
+			return err
+		}
+
+		c := exec.Command(executable, entry.Args...)
+		// Run against the profile the command was recorded with, not the current one
+		if entry.Profile != "" {
+			c.Env = append(os.Environ(), "PAYJP_PROFILE="+entry.Profile)
+		}
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				os.Exit(exitErr.ExitCode())
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+// recordHistory appends the current invocation to the history file
+// Errors are ignored so that history never affects the command result
+func recordHistory(code util.ExitCode) {
+	args := os.Args[1:]
+	if len(args) == 0 || args[0] == historyCmd.Name() {
+		return
+	}
+	if os.Getenv("PAYJP_NO_HISTORY") == "true" {
+		return
+	}
+
+	profileName, _ := config.GetCurrentProfile()
+
+	history.Append(history.Path(config.ConfigDir()), history.Entry{
+		Time:          time.Now(),
+		Args:          history.MaskArgs(args),
+		Profile:       profileName,
+		ExitCode:      int(code),
+		APIKeyRemoved: history.HasAPIKeyFlag(args),
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyRerunCmd)
+
+	// List flags
+	historyListCmd.Flags().Int("limit", 20, "Number of entries to show")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/ledger"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// ledgerCharge returns the charge already created with an idempotency key of the profile
+// A key that created a charge with another amount or currency is an error, since reusing it
+// for a different charge is almost certainly a bug in the calling script
+func ledgerCharge(profile, key string, amount int, currency string) (string, bool, error) {
+	l, err := ledger.Load(ledger.Path(config.ConfigDir()))
+	if err != nil {
+		return "", false, err
+	}
+	entry, ok := l.Get(profile, key)
+	if !ok {
+		return "", false, nil
+	}
+	if !entry.Matches(amount, currency) {
+		return "", false, i18n.Errorf("idempotency key %s already created %s for %s; use a new key for a different charge",
+			key, entry.ChargeID, util.FormatAmount(entry.Amount, entry.Currency))
+	}
+	return entry.ChargeID, true, nil
+}
+
+// recordLedgerCharge records a charge created with an idempotency key of the profile
+func recordLedgerCharge(profile, key string, charge *payjp.ChargeResponse) error {
+	return ledger.Update(ledger.Path(config.ConfigDir()), func(l *ledger.Ledger) {
+		l.Add(ledger.Entry{
+			Profile:   profile,
+			Key:       key,
+			ChargeID:  charge.ID,
+			Amount:    charge.Amount,
+			Currency:  charge.Currency,
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
+var ledgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Manage the idempotency ledger",
+	Long: `Manage the local ledger of charges created with --idempotency-key.
+
+charges create --idempotency-key records the key and the created charge per
+profile in ledger.json in the config directory. Running the same command
+again prints the recorded charge instead of creating another one, also after
+the API has forgotten the key.`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+var ledgerListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List idempotency keys and their charges",
+	Long: `List the idempotency keys of the current profile and the charges they created.
+
+Example:
+  payjp ledger list
+  payjp ledger list --all-profiles -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		allProfiles, _ := cmd.Flags().GetBool("all-profiles")
+
+		l, err := ledger.Load(ledger.Path(config.ConfigDir()))
+		if err != nil {
+			return err
+		}
+		profile := ""
+		if !allProfiles {
+			profile, _ = config.GetCurrentProfile()
+		}
+		return outputResult(l.List(profile))
+	},
+}
+
+var ledgerPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old idempotency keys",
+	Long: `Remove the idempotency keys of the current profile recorded before --older-than.
+A pruned key creates a new charge when it is used again.
+
+Example:
+  payjp ledger prune
+  payjp ledger prune --older-than 7d
+  payjp ledger prune --older-than 0s --all-profiles`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		allProfiles, _ := cmd.Flags().GetBool("all-profiles")
+
+		age, err := util.ParseDuration(olderThan)
+		if err != nil {
+			return err
+		}
+		profile := ""
+		if !allProfiles {
+			profile, _ = config.GetCurrentProfile()
+		}
+
+		var removed []ledger.Entry
+		err = ledger.Update(ledger.Path(config.ConfigDir()), func(l *ledger.Ledger) {
+			removed = l.Prune(profile, time.Now().Add(-age))
+		})
+		if err != nil {
+			return err
+		}
+
+		printSuccess("Removed %d idempotency keys", len(removed))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ledgerCmd)
+	ledgerCmd.AddCommand(ledgerListCmd)
+	ledgerCmd.AddCommand(ledgerPruneCmd)
+
+	ledgerListCmd.Flags().Bool("all-profiles", false, "List the keys of every profile")
+
+	ledgerPruneCmd.Flags().String("older-than", "30d", "Remove keys recorded longer ago than this (e.g. 7d, 12h)")
+	ledgerPruneCmd.Flags().Bool("all-profiles", false, "Prune the keys of every profile")
+}
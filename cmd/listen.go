@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/metrics"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// webhookTokenHeader carries the webhook token of the account in webhook requests
+const webhookTokenHeader = "X-Payjp-Webhook-Token"
+
+// maxWebhookBody is the largest webhook request body accepted by listen
+const maxWebhookBody = 1 << 20
+
+var listenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Receive webhook events locally",
+	Long: `Run a local HTTP server that receives PAY.JP webhook events, prints each
+event and optionally forwards it to a local application, until interrupted.
+
+Point the webhook URL of the account at the server (through a tunnel when
+developing locally). With --webhook-token (or PAYJP_WEBHOOK_TOKEN) requests
+without the matching X-Payjp-Webhook-Token header are rejected. With
+--forward-to the event is posted to the URL with the same body and token,
+and the response status of the application is returned to PAY.JP so that
+failed deliveries are retried.
+
+Output works as in events tail. With --metrics-addr the events processed,
+rejected requests and the count, failures and latency of forwarded requests
+are served on /metrics in the Prometheus text format.
+
+Example:
+  payjp listen
+  payjp listen --addr :4242 --webhook-token whook_xxxxx
+  payjp listen --forward-to http://localhost:3000/webhooks --metrics-addr :9464`,
+	Args: cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		path, _ := cmd.Flags().GetString("path")
+		token, _ := cmd.Flags().GetString("webhook-token")
+		forwardTo, _ := cmd.Flags().GetString("forward-to")
+
+		if token == "" {
+			token = os.Getenv("PAYJP_WEBHOOK_TOKEN")
+		}
+		if err := checkStreamFlags(); err != nil {
+			return err
+		}
+		printer, err := newEventPrinter()
+		if err != nil {
+			return err
+		}
+		registry, err := startMetrics(cmd)
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return i18n.Errorf("failed to listen on %s: %v", addr, err)
+		}
+		if token == "" {
+			printStatus("Warning: no --webhook-token; requests are accepted without checking %s", webhookTokenHeader)
+		}
+		printStatus("Listening for webhook events on http://%s%s (press Ctrl+C to stop)", listener.Addr(), path)
+
+		receiver := newWebhookReceiver(printer, token, forwardTo, registry)
+		mux := http.NewServeMux()
+		mux.Handle(path, receiver)
+		return newServer(mux).Serve(listener)
+	},
+}
+
+// webhookReceiver handles the webhook requests of listen
+type webhookReceiver struct {
+	printer   *eventPrinter
+	token     string
+	forwardTo string
+	http      *http.Client
+	// mu serializes the output of concurrent requests
+	mu sync.Mutex
+
+	processed       *metrics.Counter
+	rejected        *metrics.Counter
+	forwarded       *metrics.Counter
+	forwardErrors   *metrics.Counter
+	forwardDuration *metrics.Histogram
+}
+
+// newWebhookReceiver creates a receiver recording its activity in registry, which may be nil
+func newWebhookReceiver(printer *eventPrinter, token, forwardTo string, registry *metrics.Registry) *webhookReceiver {
+	return &webhookReceiver{
+		printer:         printer,
+		token:           token,
+		forwardTo:       forwardTo,
+		http:            &http.Client{Timeout: 30 * time.Second},
+		processed:       eventsProcessed(registry),
+		rejected:        registry.Counter("payjp_webhooks_rejected_total", "Webhook requests rejected by reason", "reason"),
+		forwarded:       registry.Counter("payjp_forward_requests_total", "Events forwarded by HTTP status of the application (0 when no response was received)", "status"),
+		forwardErrors:   registry.Counter("payjp_forward_errors_total", "Forwarded events that failed or returned an error status"),
+		forwardDuration: registry.Histogram("payjp_forward_duration_seconds", "Latency of forwarded events", metrics.DefaultBuckets),
+	}
+}
+
+// ServeHTTP checks, prints and forwards a webhook request
+func (h *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.rejected.Inc("method")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(webhookTokenHeader)), []byte(h.token)) != 1 {
+		h.rejected.Inc("token")
+		printStatus("Warning: rejected a request from %s with an invalid webhook token", r.RemoteAddr)
+		http.Error(w, "invalid webhook token", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	var event payjp.EventResponse
+	if err == nil {
+		err = json.Unmarshal(body, &event)
+	}
+	if err != nil || event.ID == "" {
+		h.rejected.Inc("body")
+		printStatus("Warning: rejected a request from %s that is not an event", r.RemoteAddr)
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	h.processed.Inc(event.Type)
+	h.mu.Lock()
+	err = h.printer.print(&event)
+	h.mu.Unlock()
+	if err != nil {
+		printStatus("Warning: %v", err)
+	}
+
+	status := http.StatusOK
+	if h.forwardTo != "" {
+		status = h.forward(r, body, event.ID)
+	}
+	w.WriteHeader(status)
+}
+
+// forward posts the event to the application and returns the status to answer PAY.JP with
+func (h *webhookReceiver) forward(r *http.Request, body []byte, eventID string) int {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, h.forwardTo, bytes.NewReader(body))
+	if err != nil {
+		h.forwardErrors.Inc()
+		printStatus("Warning: failed to forward %s: %v", eventID, err)
+		return http.StatusBadGateway
+	}
+	req.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+	if token := r.Header.Get(webhookTokenHeader); token != "" {
+		req.Header.Set(webhookTokenHeader, token)
+	}
+	req.Header.Set("User-Agent", userAgent())
+
+	start := time.Now()
+	resp, err := h.http.Do(req)
+	h.forwardDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		h.forwarded.Inc("0")
+		h.forwardErrors.Inc()
+		printStatus("Warning: failed to forward %s: %v", eventID, err)
+		return http.StatusBadGateway
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	h.forwarded.Inc(strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		h.forwardErrors.Inc()
+		printStatus("Warning: %s returned %d for %s", h.forwardTo, resp.StatusCode, eventID)
+	}
+	return resp.StatusCode
+}
+
+func init() {
+	rootCmd.AddCommand(listenCmd)
+
+	listenCmd.Flags().String("addr", "localhost:4242", "Address to listen on")
+	listenCmd.Flags().String("path", "/", "URL path that receives the webhook events")
+	listenCmd.Flags().String("webhook-token", "", "Webhook token of the account; requests with another X-Payjp-Webhook-Token are rejected")
+	listenCmd.Flags().String("forward-to", "", "URL of the application to post each event to")
+	addMetricsFlag(listenCmd)
+}
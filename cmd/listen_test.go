@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/payjp/payjp-cli/internal/metrics"
+)
+
+const testEvent = `{"id":"evnt_0001","object":"event","type":"charge.succeeded","livemode":false,"created":1717200000,"data":{"id":"ch_0001","object":"charge"}}`
+
+// serveWebhook sends a webhook request to a receiver and returns the response status and metrics
+func serveWebhook(t *testing.T, receiver *webhookReceiver, registry *metrics.Registry, req *http.Request) (int, string) {
+	t.Helper()
+	defer func(saved bool) { silent = saved }(silent)
+	silent = true
+
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+	var b strings.Builder
+	if err := registry.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, b.String()
+}
+
+func newTestReceiver(token, forwardTo string) (*webhookReceiver, *metrics.Registry) {
+	registry := metrics.New()
+	return newWebhookReceiver(&eventPrinter{format: "quiet"}, token, forwardTo, registry), registry
+}
+
+func webhookRequest(method, token, body string) *http.Request {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set(webhookTokenHeader, token)
+	}
+	return req
+}
+
+func TestWebhookReceiverRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+		reason string
+	}{
+		{"method", webhookRequest(http.MethodGet, "whook_test", ""), http.StatusMethodNotAllowed, "method"},
+		{"missing token", webhookRequest(http.MethodPost, "", testEvent), http.StatusUnauthorized, "token"},
+		{"wrong token", webhookRequest(http.MethodPost, "whook_other", testEvent), http.StatusUnauthorized, "token"},
+		{"not json", webhookRequest(http.MethodPost, "whook_test", "hello"), http.StatusBadRequest, "body"},
+		{"not an event", webhookRequest(http.MethodPost, "whook_test", `{"object":"event"}`), http.StatusBadRequest, "body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver, registry := newTestReceiver("whook_test", "")
+			status, exposition := serveWebhook(t, receiver, registry, tt.req)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if want := `payjp_webhooks_rejected_total{reason="` + tt.reason + `"} 1`; !strings.Contains(exposition, want) {
+				t.Errorf("metrics =\n%s\nwant %s", exposition, want)
+			}
+			if strings.Contains(exposition, "payjp_events_processed_total{") {
+				t.Errorf("metrics =\n%s\nwant no processed events", exposition)
+			}
+		})
+	}
+}
+
+func TestWebhookReceiverAccepts(t *testing.T) {
+	receiver, registry := newTestReceiver("whook_test", "")
+	status, exposition := serveWebhook(t, receiver, registry, webhookRequest(http.MethodPost, "whook_test", testEvent))
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want 200", status)
+	}
+	if want := `payjp_events_processed_total{type="charge.succeeded"} 1`; !strings.Contains(exposition, want) {
+		t.Errorf("metrics =\n%s\nwant %s", exposition, want)
+	}
+}
+
+func TestWebhookReceiverForwards(t *testing.T) {
+	for _, appStatus := range []int{http.StatusOK, http.StatusInternalServerError} {
+		var got *http.Request
+		var gotBody string
+		app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			gotBody = string(b)
+			w.WriteHeader(appStatus)
+		}))
+
+		receiver, registry := newTestReceiver("whook_test", app.URL+"/webhooks")
+		status, exposition := serveWebhook(t, receiver, registry, webhookRequest(http.MethodPost, "whook_test", testEvent))
+		app.Close()
+
+		// The status of the application is returned so that PAY.JP retries failed deliveries
+		if status != appStatus {
+			t.Errorf("status = %d, want %d", status, appStatus)
+		}
+		if got == nil {
+			t.Fatal("the event was not forwarded")
+		}
+		if got.URL.Path != "/webhooks" || got.Header.Get(webhookTokenHeader) != "whook_test" || gotBody != testEvent {
+			t.Errorf("forwarded %s with token %q and body %q", got.URL.Path, got.Header.Get(webhookTokenHeader), gotBody)
+		}
+		if want := `payjp_forward_requests_total{status="` + strconv.Itoa(appStatus) + `"} 1`; !strings.Contains(exposition, want) {
+			t.Errorf("metrics =\n%s\nwant %s", exposition, want)
+		}
+		wantErrors := "payjp_forward_errors_total 0"
+		if appStatus >= 400 {
+			wantErrors = "payjp_forward_errors_total 1"
+		}
+		if !strings.Contains(exposition, wantErrors) {
+			t.Errorf("metrics =\n%s\nwant %s", exposition, wantErrors)
+		}
+	}
+}
+
+func TestWebhookReceiverForwardFails(t *testing.T) {
+	app := httptest.NewServer(http.NotFoundHandler())
+	url := app.URL
+	app.Close()
+
+	receiver, registry := newTestReceiver("", url)
+	status, exposition := serveWebhook(t, receiver, registry, webhookRequest(http.MethodPost, "", testEvent))
+	if status != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", status)
+	}
+	for _, want := range []string{`payjp_forward_requests_total{status="0"} 1`, "payjp_forward_errors_total 1"} {
+		if !strings.Contains(exposition, want) {
+			t.Errorf("metrics =\n%s\nwant %s", exposition, want)
+		}
+	}
+}
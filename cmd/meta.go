@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/examples"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// commandSchemaURL is the JSON Schema dialect of the flag schemas in meta commands --json
+const commandSchemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// commandSurface is the machine-readable description of the CLI printed by meta commands --json
+type commandSurface struct {
+	Schema      string               `json:"$schema"`
+	Name        string               `json:"name"`
+	Version     string               `json:"version"`
+	GlobalFlags flagSchema           `json:"global_flags"`
+	Commands    []commandDescription `json:"commands"`
+}
+
+// commandDescription describes one runnable command
+type commandDescription struct {
+	Path           string     `json:"path"`
+	Usage          string     `json:"usage"`
+	Summary        string     `json:"summary"`
+	Description    string     `json:"description,omitempty"`
+	Aliases        []string   `json:"aliases,omitempty"`
+	ValidArgs      []string   `json:"valid_args,omitempty"`
+	RequiresAPIKey bool       `json:"requires_api_key"`
+	Tenant         bool       `json:"tenant"`
+	Deprecated     string     `json:"deprecated,omitempty"`
+	Flags          flagSchema `json:"flags"`
+	// Examples are the curated examples of payjp examples
+	Examples []examples.Example `json:"examples,omitempty"`
+}
+
+// flagSchema is a JSON Schema of an object whose properties are the flags of a command
+type flagSchema struct {
+	Type       string                  `json:"type"`
+	Properties map[string]flagProperty `json:"properties"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+// flagProperty is the JSON Schema of one flag
+type flagProperty struct {
+	Type        string        `json:"type"`
+	Items       *flagProperty `json:"items,omitempty"`
+	Description string        `json:"description"`
+	Default     interface{}   `json:"default,omitempty"`
+	Shorthand   string        `json:"x-shorthand,omitempty"`
+	FlagType    string        `json:"x-flag-type"`
+}
+
+// commandRow is a command in the table output of meta commands
+type commandRow struct {
+	Command string `json:"command"`
+	Summary string `json:"summary"`
+}
+
+// TableColumns returns the fields shown in list view
+func (commandRow) TableColumns() []string {
+	return []string{"Command", "Summary"}
+}
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Describe the CLI itself for tools",
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+var metaCommandsCmd = &cobra.Command{
+	Use:   "commands",
+	Short: "List every command, or describe them with their flags as JSON",
+	Long: `List every runnable command with its summary.
+
+With --json, a machine-readable description of the command surface is
+printed instead, for tools such as ChatOps bots and form generators that
+build on the CLI without parsing help text. Each command has its path,
+usage, description, aliases, whether it needs an API key, whether it
+supports --tenant, and its flags as a JSON Schema object: the property type
+is the JSON type of the value (string, integer, number, boolean, array or
+object), default is the built-in default and required lists the required
+flags. x-flag-type is the flag type of the CLI (e.g. stringSlice, duration)
+and x-shorthand the one-letter form. Commands with curated examples (see
+payjp examples) list them in examples. Flags shared by every command are in
+global_flags. Hidden commands and help are not included.
+
+Example:
+  payjp meta commands
+  payjp meta commands --json
+  payjp meta commands --json | jq '.commands[] | select(.path == "charges create") | .flags'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		commands := describeCommands(cmd.Root())
+		if !asJSON {
+			rows := make([]commandRow, 0, len(commands))
+			for _, c := range commands {
+				rows = append(rows, commandRow{Command: c.Path, Summary: c.Summary})
+			}
+			return outputResult(rows)
+		}
+
+		surface := commandSurface{
+			Schema:      commandSchemaURL,
+			Name:        cmd.Root().Name(),
+			Version:     Version,
+			GlobalFlags: describeFlags(cmd.Root().PersistentFlags()),
+			Commands:    commands,
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(surface)
+	},
+}
+
+// describeCommands returns the runnable commands under root sorted by path
+func describeCommands(root *cobra.Command) []commandDescription {
+	// The catalog is built in, so it only fails to parse in a broken build
+	catalog, _ := examples.Embedded()
+
+	var commands []commandDescription
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, child := range c.Commands() {
+			if child.Hidden || child.Name() == "help" {
+				continue
+			}
+			walk(child)
+		}
+		if !c.Runnable() || c == root {
+			return
+		}
+		_, tenant := c.Annotations[annotationTenant]
+		commands = append(commands, commandDescription{
+			Path:           commandName(c),
+			Usage:          c.UseLine(),
+			Summary:        c.Short,
+			Description:    c.Long,
+			Aliases:        c.Aliases,
+			ValidArgs:      c.ValidArgs,
+			RequiresAPIKey: requiresClient(c),
+			Tenant:         tenant,
+			Deprecated:     c.Deprecated,
+			Flags:          describeFlags(c.LocalNonPersistentFlags()),
+			Examples:       examples.For(catalog, commandName(c)),
+		})
+	}
+	walk(root)
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Path < commands[j].Path
+	})
+	return commands
+}
+
+// describeFlags returns the JSON Schema of a flag set
+func describeFlags(flags *pflag.FlagSet) flagSchema {
+	schema := flagSchema{Type: "object", Properties: map[string]flagProperty{}}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Name == "help" {
+			return
+		}
+		schema.Properties[f.Name] = describeFlag(f)
+		if required, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && len(required) > 0 && required[0] == "true" {
+			schema.Required = append(schema.Required, f.Name)
+		}
+	})
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// describeFlag returns the JSON Schema of a flag
+// Empty and zero defaults other than false are left out, as they mean "not set"
+func describeFlag(f *pflag.Flag) flagProperty {
+	flagType := f.Value.Type()
+	property := flagProperty{
+		Type:        jsonType(flagType),
+		Description: f.Usage,
+		Shorthand:   f.Shorthand,
+		FlagType:    flagType,
+	}
+
+	switch property.Type {
+	case "boolean":
+		property.Default, _ = strconv.ParseBool(f.DefValue)
+	case "integer":
+		if n, err := strconv.ParseInt(f.DefValue, 10, 64); err == nil && n != 0 {
+			property.Default = n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(f.DefValue, 64); err == nil && n != 0 {
+			property.Default = n
+		}
+	case "array":
+		property.Items = &flagProperty{Type: jsonType(strings.TrimSuffix(strings.TrimSuffix(flagType, "Slice"), "Array"))}
+		if values := strings.Trim(f.DefValue, "[]"); values != "" {
+			property.Default = strings.Split(values, ",")
+		}
+	case "object":
+		// Key=value maps have no meaningful default to report
+	default:
+		if f.DefValue != "" && f.DefValue != "0s" {
+			property.Default = f.DefValue
+		}
+	}
+	return property
+}
+
+// jsonType maps a pflag value type to a JSON Schema type
+func jsonType(flagType string) string {
+	switch {
+	case flagType == "bool":
+		return "boolean"
+	case flagType == "count" || strings.HasPrefix(flagType, "int") || strings.HasPrefix(flagType, "uint"):
+		if strings.HasSuffix(flagType, "Slice") {
+			return "array"
+		}
+		return "integer"
+	case strings.HasPrefix(flagType, "float"):
+		if strings.HasSuffix(flagType, "Slice") {
+			return "array"
+		}
+		return "number"
+	case strings.HasSuffix(flagType, "Slice") || strings.HasSuffix(flagType, "Array"):
+		return "array"
+	case strings.HasPrefix(flagType, "stringTo"):
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(metaCmd)
+	metaCmd.AddCommand(metaCommandsCmd)
+
+	metaCommandsCmd.Flags().Bool("json", false, "Print every command with its flags as JSON (flags as JSON Schema)")
+}
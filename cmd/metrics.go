@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+// addMetricsFlag adds --metrics-addr to a long-running command
+func addMetricsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("metrics-addr", "", "Serve Prometheus metrics on /metrics at this address (e.g. :9464)")
+}
+
+// startMetrics serves the metrics of a long-running command when --metrics-addr is given
+// Without the flag it returns a nil registry, which records nothing
+func startMetrics(cmd *cobra.Command) (*metrics.Registry, error) {
+	addr, _ := cmd.Flags().GetString("metrics-addr")
+	if addr == "" {
+		return nil, nil
+	}
+	registry := metrics.New()
+	bound, err := metrics.Serve(addr, registry)
+	if err != nil {
+		return nil, err
+	}
+	printStatus("Serving metrics on http://%s/metrics", bound)
+	return registry, nil
+}
+
+// newServer returns the HTTP server of a long-running command, with timeouts so that slow or
+// idle clients cannot hold connections open
+// The write timeout leaves room for listen to wait for the application it forwards to
+func newServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// eventsProcessed returns the counter of the events handled by a long-running command
+func eventsProcessed(registry *metrics.Registry) *metrics.Counter {
+	return registry.Counter("payjp_events_processed_total", "Events processed by type", "type")
+}
+
+// checkStreamFlags rejects --envelope, which needs the whole result that a command running until
+// it is interrupted never has
+func checkStreamFlags() error {
+	if envelope {
+		return i18n.Errorf("--envelope cannot be used with commands that run until interrupted")
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/metrics"
+	"github.com/payjp/payjp-cli/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Run a mock of the PAY.JP API",
+	Long:  `Run a local server answering API requests from fixture files, for testing applications without the API.`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+var mockServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve API responses from fixture files",
+	Long: `Run a local HTTP server that answers API requests with JSON fixture files,
+until interrupted. Point the API base of the application under test at
+http://<addr>/v1.
+
+A request is answered with the first file found in --dir:
+
+  <path>.<method>.json   e.g. v1/charges.post.json for POST /v1/charges
+  <path>.json            GET only, e.g. v1/charges/ch_xxxxx.json
+
+followed by the same files with the IDs in the path replaced by {id}, e.g.
+v1/charges/{id}.json for any charge. Other requests get a 404 error in the
+format of the API. The requests are logged on stderr.
+
+With --metrics-addr the count and latency of the requests per endpoint are
+served on /metrics in the Prometheus text format.
+
+Example:
+  payjp mock serve --dir fixtures
+  payjp mock serve --dir fixtures --addr :12111 --metrics-addr :9464`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		dir, _ := cmd.Flags().GetString("dir")
+
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return i18n.Errorf("fixture directory not found: %s", dir)
+		}
+		registry, err := startMetrics(cmd)
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return i18n.Errorf("failed to listen on %s: %v", addr, err)
+		}
+		printStatus("Serving fixtures from %s on http://%s/v1 (press Ctrl+C to stop)", dir, listener.Addr())
+		return newServer(newMockServer(dir, registry)).Serve(listener)
+	},
+}
+
+// mockServer answers API requests with fixture files
+type mockServer struct {
+	dir      string
+	requests *metrics.Counter
+	duration *metrics.Histogram
+}
+
+// newMockServer creates a server recording its requests in registry, which may be nil
+func newMockServer(dir string, registry *metrics.Registry) *mockServer {
+	return &mockServer{
+		dir:      dir,
+		requests: registry.Counter("payjp_api_requests_total", "API requests by endpoint and HTTP status", "endpoint", "status"),
+		duration: registry.Histogram("payjp_api_request_duration_seconds", "Latency of API requests", metrics.DefaultBuckets, "endpoint"),
+	}
+}
+
+// ServeHTTP answers a request with its fixture, or a 404 error of the API
+func (s *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	endpoint := usage.Endpoint(r.Method, r.URL.Path)
+
+	status := http.StatusOK
+	body, err := s.fixture(r.Method, r.URL.Path)
+	if err != nil {
+		status = http.StatusNotFound
+		body, _ = json.Marshal(map[string]interface{}{
+			"error": map[string]interface{}{
+				"status":  status,
+				"type":    "client_error",
+				"code":    "invalid_id",
+				"message": fmt.Sprintf("No fixture for %s %s", r.Method, r.URL.Path),
+			},
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+
+	s.requests.Inc(endpoint, strconv.Itoa(status))
+	s.duration.Observe(time.Since(start).Seconds(), endpoint)
+	printStatus("%s %s %d", r.Method, r.URL.Path, status)
+}
+
+// fixture reads the fixture file of a request
+func (s *mockServer) fixture(method, path string) ([]byte, error) {
+	clean := filepath.Clean("/" + strings.Trim(path, "/"))
+	template := strings.TrimPrefix(usage.Endpoint(method, clean), method+" ")
+	for _, p := range []string{clean, template} {
+		candidates := []string{p + "." + strings.ToLower(method) + ".json"}
+		if method == http.MethodGet {
+			candidates = append(candidates, p+".json")
+		}
+		for _, candidate := range candidates {
+			if body, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(candidate))); err == nil {
+				return body, nil
+			}
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func init() {
+	rootCmd.AddCommand(mockCmd)
+
+	mockCmd.AddCommand(mockServeCmd)
+
+	mockServeCmd.Flags().String("addr", "localhost:12111", "Address to listen on")
+	mockServeCmd.Flags().String("dir", "", "Directory of fixture files")
+	mockServeCmd.MarkFlagRequired("dir")
+	addMetricsFlag(mockServeCmd)
+}
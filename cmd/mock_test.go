@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/payjp/payjp-cli/internal/metrics"
+)
+
+// writeFixtures writes fixture files, keyed by their path relative to dir
+func writeFixtures(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMockServerFixtures(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"v1/charges.json":                  `{"list":true}`,
+		"v1/charges.post.json":             `{"created":true}`,
+		"v1/charges/ch_0001.json":          `{"id":"ch_0001"}`,
+		"v1/charges/{id}.json":             `{"id":"any"}`,
+		"v1/charges/{id}/refund.post.json": `{"refunded":true}`,
+	})
+	defer func(saved bool) { silent = saved }(silent)
+	silent = true
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/v1/charges", http.StatusOK, `{"list":true}`},
+		{http.MethodPost, "/v1/charges", http.StatusOK, `{"created":true}`},
+		{http.MethodGet, "/v1/charges/ch_0001", http.StatusOK, `{"id":"ch_0001"}`},
+		// The IDs in the path fall back to {id}
+		{http.MethodGet, "/v1/charges/ch_0002", http.StatusOK, `{"id":"any"}`},
+		{http.MethodPost, "/v1/charges/ch_0002/refund", http.StatusOK, `{"refunded":true}`},
+		// <path>.json only answers GET
+		{http.MethodDelete, "/v1/charges/ch_0001", http.StatusNotFound, ""},
+		{http.MethodGet, "/v1/customers", http.StatusNotFound, ""},
+		// Paths cannot leave the fixture directory
+		{http.MethodGet, "/../v1/charges", http.StatusOK, `{"list":true}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		newMockServer(dir, nil).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s Content-Type = %q", tt.method, tt.path, ct)
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+			t.Errorf("%s %s body = %s, want %s", tt.method, tt.path, rec.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestMockServerNotFoundError(t *testing.T) {
+	defer func(saved bool) { silent = saved }(silent)
+	silent = true
+
+	rec := httptest.NewRecorder()
+	newMockServer(t.TempDir(), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/charges/ch_0001", nil))
+
+	// The error has the format of the API so that clients handle it as a missing resource
+	var body struct {
+		Error struct {
+			Status int    `json:"status"`
+			Type   string `json:"type"`
+			Code   string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s: %v", rec.Body.String(), err)
+	}
+	if body.Error.Status != http.StatusNotFound || body.Error.Type != "client_error" || body.Error.Code != "invalid_id" {
+		t.Errorf("error = %+v", body.Error)
+	}
+}
+
+func TestMockServerMetrics(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{"v1/charges/{id}.json": `{}`})
+	defer func(saved bool) { silent = saved }(silent)
+	silent = true
+
+	registry := metrics.New()
+	server := newMockServer(dir, registry)
+	for _, path := range []string{"/v1/charges/ch_0001", "/v1/charges/ch_0002", "/v1/customers/cus_0001"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var b strings.Builder
+	if err := registry.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`payjp_api_requests_total{endpoint="GET /v1/charges/{id}",status="200"} 2`,
+		`payjp_api_requests_total{endpoint="GET /v1/customers/{id}",status="404"} 1`,
+		`payjp_api_request_duration_seconds_count{endpoint="GET /v1/charges/{id}"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics =\n%s\nwant %s", b.String(), want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/notify"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// notifySummaryLines is the number of trailing status lines included in a notification
+const notifySummaryLines = 3
+
+var (
+	// notifyCommand is the command to report on; it is empty until --notify has been validated
+	notifyCommand string
+	notifyStarted time.Time
+	statusLines   []string
+)
+
+// setupNotify validates --notify and starts timing the command
+func setupNotify(cmd *cobra.Command) error {
+	if !notifyOnExit {
+		return nil
+	}
+	if !notify.Configured(config.GetNotifyConfig()) {
+		return i18n.Errorf("--notify requires notify.slack_webhook_url or notify.smtp in the config file")
+	}
+	notifyCommand = commandName(cmd)
+	notifyStarted = time.Now()
+	return nil
+}
+
+// recordStatus keeps the last status lines as the notification summary
+func recordStatus(line string) {
+	if notifyCommand == "" {
+		return
+	}
+	statusLines = append(statusLines, line)
+	if len(statusLines) > notifySummaryLines {
+		statusLines = statusLines[len(statusLines)-notifySummaryLines:]
+	}
+}
+
+// notifyCompletion sends the --notify notification for the finished command
+// Delivery errors are reported on stderr but never change the exit code
+func notifyCompletion(code util.ExitCode, err error) {
+	if notifyCommand == "" {
+		return
+	}
+
+	profileName, _ := config.GetCurrentProfile()
+	message := notify.Message{
+		Command:  notifyCommand,
+		Profile:  profileName,
+		ExitCode: int(code),
+		Duration: time.Since(notifyStarted),
+		Summary:  statusLines,
+	}
+	if err != nil {
+		message.Error = err.Error()
+	}
+
+	printVerbose("Sending notification for %s", notifyCommand)
+	if err := notify.Send(config.GetNotifyConfig(), message); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send notification: %v\n", err)
+	}
+}
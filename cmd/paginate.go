@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// pageSize is the page size used when paginating through resources client-side
+const pageSize = 100
+
+// fetchAll pages through a list endpoint until there are no more items
+func fetchAll[T any](resource string, fetch func(limit, offset int) ([]T, bool, error)) ([]T, error) {
+	result := []T{}
+	offset := 0
+	for {
+		printVerbose("Fetching %s (offset: %d)", resource, offset)
+		items, hasMore, err := fetch(pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+		if !hasMore || len(items) == 0 {
+			return result, nil
+		}
+		offset += len(items)
+	}
+}
+
+// forEach pages through a list endpoint and calls visit for each item without keeping the items
+func forEach[T any](resource string, fetch func(limit, offset int) ([]T, bool, error), visit func(T)) error {
+	offset := 0
+	for {
+		printVerbose("Fetching %s (offset: %d)", resource, offset)
+		items, hasMore, err := fetch(pageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			visit(item)
+		}
+		if !hasMore || len(items) == 0 {
+			return nil
+		}
+		offset += len(items)
+	}
+}
+
+// listFiltered pages through a list endpoint from offset and returns up to limit items for which keep returns true
+// A limit of 0 returns every kept item
+func listFiltered[T any](resource string, fetch func(limit, offset int) ([]T, bool, error), keep func(T) bool, limit, offset int) ([]T, error) {
+	result := []T{}
+	for {
+		printVerbose("Fetching %s (offset: %d)", resource, offset)
+		items, hasMore, err := fetch(pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if keep(item) {
+				result = append(result, item)
+				if limit > 0 && len(result) >= limit {
+					return result, nil
+				}
+			}
+		}
+
+		if !hasMore || len(items) == 0 {
+			return result, nil
+		}
+		offset += len(items)
+	}
+}
+
+// streamAll pages through a list endpoint and writes each page as it arrives
+// CSV and NDJSON output is flushed per page so memory use stays bounded
+// Items for which keep returns false are skipped; a nil keep writes every item
+// With --count only the number of kept items is printed once all pages are fetched
+func streamAll[T any](resource string, fetch func(limit, offset int) ([]T, bool, error), keep func(T) bool) error {
+	format := getOutputFormat()
+
+	var writer output.StreamWriter
+	if format != "quiet" && format != "count" && !rawOutput {
+		writer = output.NewStreamWriter(output.Format(format))
+	}
+
+	count := 0
+	offset := 0
+	for {
+		printVerbose("Fetching %s (offset: %d)", resource, offset)
+		items, hasMore, err := fetch(pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		kept := items
+		if keep != nil {
+			kept = make([]T, 0, len(items))
+			for _, item := range items {
+				if keep(item) {
+					kept = append(kept, item)
+				}
+			}
+		}
+
+		if format == "count" {
+			count += len(kept)
+		} else if rawOutput {
+			if err := writeRaw(); err != nil {
+				return err
+			}
+		} else if writer == nil {
+			for _, item := range kept {
+				if err := outputResultQuiet(item); err != nil {
+					return err
+				}
+			}
+		} else if err := writePage(writer, format, kept); err != nil {
+			return err
+		}
+
+		if !hasMore || len(items) == 0 {
+			if format == "count" {
+				return printCount(count)
+			}
+			if writer == nil {
+				return nil
+			}
+			return writer.Close()
+		}
+		offset += len(items)
+	}
+}
+
+// writePage applies the field projection and writes one page to the stream
+func writePage[T any](writer output.StreamWriter, format string, items []T) error {
+	page, err := projectOutput(format, items)
+	if err != nil {
+		return err
+	}
+	return writer.WritePage(page)
+}
+
+// checkAllFlags rejects --limit and --offset given on the command line together with --all,
+// which fetches every page and would otherwise ignore them
+func checkAllFlags(cmd *cobra.Command) error {
+	if all, err := cmd.Flags().GetBool("all"); err != nil || !all {
+		return nil
+	}
+	for _, name := range []string{"limit", "offset"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return i18n.Errorf("--%s cannot be used with --all", name)
+		}
+	}
+	return nil
+}
+
+// annotationClientFilter marks the flags of list commands that filter the fetched items
+// client-side, which --raw cannot do since it prints the API responses as they are
+const annotationClientFilter = "payjp:client-filter"
+
+// markClientFilters marks flags of a list command as client-side filters
+func markClientFilters(cmd *cobra.Command, names ...string) {
+	for _, name := range names {
+		cmd.Flags().SetAnnotation(name, annotationClientFilter, []string{"true"})
+	}
+}
+
+// checkRawFilters rejects --raw together with client-side filters given on the command line
+func checkRawFilters(cmd *cobra.Command) error {
+	var names []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if _, ok := flag.Annotations[annotationClientFilter]; ok {
+			names = append(names, "--"+flag.Name)
+		}
+	})
+	if len(names) > 0 {
+		return i18n.Errorf("--raw cannot be used with %s, which filter the fetched results", strings.Join(names, ", "))
+	}
+	return nil
+}
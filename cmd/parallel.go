@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/output"
+)
+
+// runParallel calls work for every index below n on up to concurrency goroutines
+// A progress bar with the label is shown on stderr unless --silent is given
+// No new work is started once the deadline has passed (a zero deadline means none); work in
+// progress is finished. It returns the number of indices started, which are always 0 to started-1
+func runParallel(label string, n, concurrency int, deadline time.Time, work func(index int)) int {
+	progress := output.NewProgress(label, n, !silent)
+
+	var wg sync.WaitGroup
+	queue := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				work(index)
+				progress.Increment()
+			}
+		}()
+	}
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	started := 0
+dispatch:
+	for ; started < n; started++ {
+		if deadlinePassed(deadline) {
+			break
+		}
+		select {
+		case queue <- started:
+		case <-expired:
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+	progress.Finish()
+	return started
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/payjp/payjp-cli/internal/picker"
+	"github.com/spf13/cobra"
+)
+
+// pickTimeLayout is the layout of creation times in picker labels
+const pickTimeLayout = "2006-01-02 15:04"
+
+// pickSources fetch the most recent resources of each kind as picker items
+var pickSources = map[string]func(limit int) ([]picker.Item, error){
+	"charges": func(limit int) ([]picker.Item, error) {
+		charges, _, err := client.GetCharge().List().Limit(limit).Do()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]picker.Item, 0, len(charges))
+		for _, c := range charges {
+			label := fmt.Sprintf("%-27s %12s  %-18s %s  %s %s", c.ID, output.FormatCurrency(int64(c.Amount), c.Currency),
+				chargeStatus(c), c.CreatedAt.Format(pickTimeLayout), c.CustomerID, c.Description)
+			items = append(items, pickItem(c.ID, label, c))
+		}
+		return items, nil
+	},
+	"customers": func(limit int) ([]picker.Item, error) {
+		customers, _, err := client.GetCustomer().List().Limit(limit).Do()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]picker.Item, 0, len(customers))
+		for _, c := range customers {
+			label := fmt.Sprintf("%-28s %s  %s %s", c.ID, c.CreatedAt.Format(pickTimeLayout), c.Email, c.Description)
+			items = append(items, pickItem(c.ID, label, c))
+		}
+		return items, nil
+	},
+	"plans": func(limit int) ([]picker.Item, error) {
+		plans, _, err := client.GetPlan().List().Limit(limit).Do()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]picker.Item, 0, len(plans))
+		for _, p := range plans {
+			label := fmt.Sprintf("%-28s %12s/%-5s %s", p.ID, output.FormatCurrency(int64(p.Amount), p.Currency), p.Interval, p.Name)
+			items = append(items, pickItem(p.ID, label, p))
+		}
+		return items, nil
+	},
+	"subscriptions": func(limit int) ([]picker.Item, error) {
+		subscriptions, _, err := client.GetSubscription().List().Limit(limit).Do()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]picker.Item, 0, len(subscriptions))
+		for _, s := range subscriptions {
+			label := fmt.Sprintf("%-28s %-8s %s  %s %s", s.ID, s.Status, s.CreatedAt.Format(pickTimeLayout), s.Customer, s.Plan.ID)
+			items = append(items, pickItem(s.ID, label, s))
+		}
+		return items, nil
+	},
+	"transfers": func(limit int) ([]picker.Item, error) {
+		transfers, _, err := client.GetTransfer().List().Limit(limit).Do()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]picker.Item, 0, len(transfers))
+		for _, t := range transfers {
+			label := fmt.Sprintf("%-28s %12s  %-8s %s", t.ID, output.FormatCurrency(int64(t.Amount), t.Currency), t.Status, t.ScheduledDate)
+			items = append(items, pickItem(t.ID, label, t))
+		}
+		return items, nil
+	},
+	"events": func(limit int) ([]picker.Item, error) {
+		events, _, err := client.GetEvent().List().Limit(limit).Do()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]picker.Item, 0, len(events))
+		for _, e := range events {
+			resourceID, _ := e.DataMap["id"].(string)
+			label := fmt.Sprintf("%-28s %-28s %s  %s", e.ID, e.Type, e.CreatedAt.Format(pickTimeLayout), resourceID)
+			items = append(items, pickItem(e.ID, label, e))
+		}
+		return items, nil
+	},
+}
+
+// pickResources returns the sorted names of the resources that can be picked
+func pickResources() []string {
+	names := make([]string, 0, len(pickSources))
+	for name := range pickSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pickItem builds a picker item whose preview is the resource as it is printed by -o json
+func pickItem(id, label string, resource interface{}) picker.Item {
+	preview, err := json.MarshalIndent(resource, "", "  ")
+	if err != nil {
+		preview = []byte(err.Error())
+	}
+	return picker.Item{ID: id, Label: strings.TrimRight(label, " "), Preview: string(preview)}
+}
+
+var pickCmd = &cobra.Command{
+	Use:   "pick <resource>",
+	Short: "Interactively pick a resource and print its ID",
+	Long: `List recent resources in a fuzzy finder and print the ID of the selected one.
+
+The picker is drawn on the terminal (/dev/tty) and only the selected ID is
+written to stdout, so the command can be used inside $(...) to pass an ID to
+another command. Type to filter (space separated terms must all match), move
+with Up/Down or Ctrl-P/Ctrl-N, select with Enter and cancel with Esc or
+Ctrl-C. The JSON of the resource under the cursor is shown in a preview pane
+when the terminal is at least 80 columns wide.
+
+The command fails without printing anything when the picker is canceled, so
+a following command is not run with an empty ID.
+
+Resources: charges, customers, events, plans, subscriptions, transfers
+
+Example:
+  payjp pick charges
+  payjp charges refund $(payjp pick charges)
+  payjp pick customers --query "@example.com"
+  payjp pick events --limit 100`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: pickResources(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		query, _ := cmd.Flags().GetString("query")
+
+		source, ok := pickSources[args[0]]
+		if !ok {
+			return i18n.Errorf("unsupported resource type: %s (supported: %s)", args[0], strings.Join(pickResources(), ", "))
+		}
+		if limit < 1 || limit > 100 {
+			return i18n.Errorf("--limit must be between 1 and 100")
+		}
+		if nonInteractive || config.IsNonInteractive() {
+			return i18n.Errorf("pick cannot be used in non-interactive mode")
+		}
+
+		printVerbose("Fetching %d most recent %s", limit, args[0])
+		items, err := source(limit)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		if len(items) == 0 {
+			return i18n.Errorf("no %s to pick from", args[0])
+		}
+
+		item, err := picker.Pick(items, picker.Options{
+			Query: query,
+			Highlight: func(s string) string {
+				return output.Colorize(os.Stderr, output.RoleID, s)
+			},
+		})
+		switch {
+		case errors.Is(err, picker.ErrNoTerminal):
+			return i18n.Errorf("pick requires a terminal")
+		case errors.Is(err, picker.ErrCanceled):
+			return i18n.Errorf("nothing was picked")
+		case err != nil:
+			return err
+		}
+
+		_, err = fmt.Println(item.ID)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pickCmd)
+
+	pickCmd.Flags().Int("limit", 50, "Number of recent resources to list (max 100)")
+	pickCmd.Flags().String("query", "", "Initial filter query")
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// pingResult is the outcome of one ping
+// Code is the HTTP status code, or 0 when no response was received
+type pingResult struct {
+	Seq       int    `json:"seq"`
+	OK        bool   `json:"ok"`
+	Code      int    `json:"code"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	Mode      string `json:"mode"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (pingResult) TableColumns() []string {
+	return []string{"Seq", "OK", "Code", "ElapsedMs", "Mode", "Error"}
+}
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check that the API is reachable and the API key works",
+	Long: `Perform a cheap authenticated API call (account retrieval) and report the latency.
+
+The exit code is 0 when every ping succeeds. Otherwise it is the exit code of
+the last error (see Exit codes in the README), so the command can be used for
+synthetic monitoring. A summary is printed on stderr.
+
+Example:
+  payjp ping
+  payjp ping -o json --timeout 5s
+  payjp ping --count 5 --interval 2s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		count, _ := cmd.Flags().GetInt("count")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		if count < 1 {
+			return i18n.Errorf("--count must be at least 1")
+		}
+
+		// Reinitialize the client with a time limit per request
+		opts, err := clientOptions()
+		if err != nil {
+			return err
+		}
+		if err := client.Init(append(opts, client.WithTimeout(timeout))...); err != nil {
+			return err
+		}
+
+		mode := "test"
+		if client.IsLiveKey() {
+			mode = "live"
+		}
+
+		results := make([]pingResult, 0, count)
+		var lastErr error
+		for seq := 1; seq <= count; seq++ {
+			if seq > 1 {
+				time.Sleep(interval)
+			}
+			result, err := ping(seq, mode)
+			if err != nil {
+				lastErr = err
+			}
+			results = append(results, result)
+		}
+
+		printPingSummary(results)
+		if err := outputResult(results); err != nil {
+			return err
+		}
+		if lastErr != nil {
+			handleError(lastErr)
+		}
+		return nil
+	},
+}
+
+// ping retrieves the account once and measures the latency
+func ping(seq int, mode string) (pingResult, error) {
+	result := pingResult{Seq: seq, Mode: mode}
+
+	printVerbose("Ping %d: retrieving account", seq)
+	start := time.Now()
+	_, err := client.Request(http.MethodGet, "/accounts", nil)
+	result.ElapsedMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		var payjpErr *payjp.Error
+		if errors.As(err, &payjpErr) {
+			result.Code = payjpErr.Status
+			result.Error = payjpErr.Message
+		} else {
+			result.Error = err.Error()
+		}
+		return result, err
+	}
+	result.OK = true
+	result.Code = http.StatusOK
+	return result, nil
+}
+
+// printPingSummary prints the success count and latency of successful pings on stderr
+func printPingSummary(results []pingResult) {
+	var succeeded int
+	var min, max, total int64
+	for _, result := range results {
+		if !result.OK {
+			continue
+		}
+		if succeeded == 0 || result.ElapsedMs < min {
+			min = result.ElapsedMs
+		}
+		if result.ElapsedMs > max {
+			max = result.ElapsedMs
+		}
+		total += result.ElapsedMs
+		succeeded++
+	}
+
+	if succeeded == 0 {
+		printStatus("%d/%d succeeded", succeeded, len(results))
+		return
+	}
+	printStatus("%d/%d succeeded, latency min/avg/max = %d/%d/%d ms", succeeded, len(results), min, total/int64(succeeded), max)
+}
+
+func init() {
+	rootCmd.AddCommand(pingCmd)
+
+	pingCmd.Flags().Int("count", 1, "Number of pings")
+	pingCmd.Flags().Duration("interval", time.Second, "Time between pings")
+	pingCmd.Flags().Duration("timeout", 10*time.Second, "Time limit for each ping")
+}
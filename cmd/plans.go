@@ -2,8 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/manifest"
 	"github.com/payjp/payjp-cli/internal/util"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
@@ -62,7 +69,7 @@ Example:
 		}
 		if billingDay > 0 {
 			if billingDay > 31 {
-				return fmt.Errorf("billing-day must be between 1 and 31")
+				return i18n.Errorf("billing-day must be between 1 and 31")
 			}
 			plan.BillingDay = billingDay
 		}
@@ -106,11 +113,23 @@ var plansListCmd = &cobra.Command{
 	Short: "List plans",
 	Long: `List all subscription plans.
 
+Plans can be filtered by interval, amount range and name. The filters are
+applied client-side, so plans are paged through until enough matches are found.
+
 Example:
-  payjp plans list --limit 10`,
+  payjp plans list --limit 10
+  payjp plans list --all --output csv > plans.csv
+  payjp plans list --interval year --amount-range 1000..5000
+  payjp plans list --all --name premium`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
+		all, _ := cmd.Flags().GetBool("all")
+
+		keep, err := planFilter(cmd)
+		if err != nil {
+			return err
+		}
 
 		caller := client.GetPlan().List()
 
@@ -121,6 +140,27 @@ Example:
 			caller.Offset(offset)
 		}
 
+		if all {
+			err := streamAll("plans", func(limit, offset int) ([]*payjp.PlanResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, keep)
+			if err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
+		if keep != nil {
+			result, err := listFiltered("plans", func(limit, offset int) ([]*payjp.PlanResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, keep, limit, offset)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			return outputResult(result)
+		}
+
 		result, _, err := caller.Do()
 		if err != nil {
 			handleError(err)
@@ -131,6 +171,39 @@ Example:
 	},
 }
 
+// planFilter builds a filter from the --interval, --amount-range and --name flags
+// It returns nil when no filter is given
+func planFilter(cmd *cobra.Command) (func(*payjp.PlanResponse) bool, error) {
+	interval, _ := cmd.Flags().GetString("interval")
+	amountRange, _ := cmd.Flags().GetString("amount-range")
+	name, _ := cmd.Flags().GetString("name")
+
+	if interval != "" && interval != "month" && interval != "year" {
+		return nil, i18n.Errorf("invalid interval: %s (use month or year)", interval)
+	}
+	min, max := 0, -1
+	if amountRange != "" {
+		var err error
+		if min, max, err = util.ParseAmountRange(amountRange); err != nil {
+			return nil, err
+		}
+	}
+	if interval == "" && amountRange == "" && name == "" {
+		return nil, nil
+	}
+
+	name = strings.ToLower(name)
+	return func(plan *payjp.PlanResponse) bool {
+		if interval != "" && plan.Interval != interval {
+			return false
+		}
+		if plan.Amount < min || max >= 0 && plan.Amount > max {
+			return false
+		}
+		return name == "" || strings.Contains(strings.ToLower(plan.Name), name)
+	}, nil
+}
+
 var plansUpdateCmd = &cobra.Command{
 	Use:   "update <plan_id>",
 	Short: "Update plan information",
@@ -174,6 +247,13 @@ Example:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		planID := args[0]
 
+		if err := checkProtected(cmd, planID); err != nil {
+			return err
+		}
+		if err := confirmDelete(cmd, "plan", planID, previewPlan); err != nil {
+			return err
+		}
+
 		err := client.GetPlan().Delete(planID)
 		if err != nil {
 			handleError(err)
@@ -192,6 +272,189 @@ Example:
 	},
 }
 
+// dumpedFile is a resource file written by plans dump
+type dumpedFile struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Status string `json:"status"`
+}
+
+// TableColumns returns the fields shown in list view
+func (dumpedFile) TableColumns() []string {
+	return []string{"Kind", "ID", "File", "Status"}
+}
+
+// dumpKinds are the resources plans dump can write besides plans, with the subdirectory they go to
+var dumpKinds = []string{"customers", "subscriptions"}
+
+var plansDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write each plan to its own YAML manifest file",
+	Long: `Write every plan in the account to <dir>/<plan_id>.yaml in the manifest
+format read by apply, so that the plans can be kept in a Git repository and
+changed through review:
+
+  payjp plans dump --dir plans/
+  git diff plans/
+  payjp apply -f plans/ --dry-run
+
+Files whose content did not change are not rewritten, so running dump again
+only touches the plans that changed. With --prune, .yaml files for plans
+that no longer exist are removed.
+
+--include customers,subscriptions also writes customers and subscriptions
+to the customers/ and subscriptions/ subdirectories. These files record the
+state of the account for review; apply reads only the files directly in the
+directory and does not manage customers or subscriptions.
+
+Example:
+  payjp plans dump --dir plans/
+  payjp plans dump --dir account/ --include customers,subscriptions --prune`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		include, _ := cmd.Flags().GetStringSlice("include")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		kinds := map[string]bool{}
+		for _, kind := range include {
+			if !slices.Contains(dumpKinds, kind) {
+				return i18n.Errorf("invalid include: %s (supported: %s)", kind, strings.Join(dumpKinds, ", "))
+			}
+			kinds[kind] = true
+		}
+
+		plans, err := fetchAll("plans", func(limit, offset int) ([]*payjp.PlanResponse, bool, error) {
+			return client.GetPlan().List().Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		docs := map[string]interface{}{}
+		for _, plan := range plans {
+			docs[plan.ID] = manifest.PlanManifest(plan)
+		}
+		files, err := dumpResources(dir, "plan", docs, prune)
+		if err != nil {
+			return err
+		}
+
+		if kinds["customers"] {
+			customers, err := listAllCustomers()
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			docs := map[string]interface{}{}
+			for _, customer := range customers {
+				docs[customer.ID] = manifest.CustomerDocument(customer)
+			}
+			written, err := dumpResources(filepath.Join(dir, "customers"), "customer", docs, prune)
+			if err != nil {
+				return err
+			}
+			files = append(files, written...)
+		}
+
+		if kinds["subscriptions"] {
+			subscriptions, err := fetchAll("subscriptions", func(limit, offset int) ([]*payjp.SubscriptionResponse, bool, error) {
+				return client.GetSubscription().List().Limit(limit).Offset(offset).Do()
+			})
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			docs := map[string]interface{}{}
+			for _, sub := range subscriptions {
+				docs[sub.ID] = manifest.SubscriptionDocument(sub)
+			}
+			written, err := dumpResources(filepath.Join(dir, "subscriptions"), "subscription", docs, prune)
+			if err != nil {
+				return err
+			}
+			files = append(files, written...)
+		}
+
+		changed := 0
+		for _, f := range files {
+			if f.Status != manifest.WriteUnchanged {
+				changed++
+			}
+		}
+		printStatus("Wrote %d files to %s (%d changed)", len(files)-countRemoved(files), dir, changed)
+
+		if quiet {
+			return nil
+		}
+		return outputResult(files)
+	},
+}
+
+// dumpResources writes each document to <dir>/<id>.yaml in ID order
+// With prune, other .yaml files in dir are removed
+func dumpResources(dir, kind string, docs map[string]interface{}, prune bool) ([]dumpedFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	files := make([]dumpedFile, 0, len(ids))
+	names := map[string]bool{}
+	for _, id := range ids {
+		name, err := manifest.FileName(id)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, name)
+		status, err := manifest.WriteFile(path, docs[id])
+		if err != nil {
+			return nil, err
+		}
+		names[name] = true
+		files = append(files, dumpedFile{Kind: kind, ID: id, File: path, Status: status})
+		printVerbose("%s %s", status, path)
+	}
+
+	if !prune {
+		return files, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" || names[entry.Name()] {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(entry.Name(), ".yaml")
+		files = append(files, dumpedFile{Kind: kind, ID: id, File: path, Status: manifest.WriteRemoved})
+		printVerbose("removed %s", path)
+	}
+	return files, nil
+}
+
+// countRemoved returns the number of files removed by --prune
+func countRemoved(files []dumpedFile) int {
+	n := 0
+	for _, f := range files {
+		if f.Status == manifest.WriteRemoved {
+			n++
+		}
+	}
+	return n
+}
+
 func init() {
 	rootCmd.AddCommand(plansCmd)
 
@@ -200,6 +463,7 @@ func init() {
 	plansCmd.AddCommand(plansListCmd)
 	plansCmd.AddCommand(plansUpdateCmd)
 	plansCmd.AddCommand(plansDeleteCmd)
+	plansCmd.AddCommand(plansDumpCmd)
 
 	// Create flags
 	plansCreateCmd.Flags().Int("amount", 0, "Amount in smallest currency unit (required)")
@@ -215,8 +479,21 @@ func init() {
 	// List flags
 	plansListCmd.Flags().Int("limit", 10, "Number of items to return")
 	plansListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	plansListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	plansListCmd.Flags().String("interval", "", "Filter by billing interval (month or year)")
+	plansListCmd.Flags().String("amount-range", "", "Filter by amount range (min..max, e.g. 1000..5000)")
+	plansListCmd.Flags().String("name", "", "Filter by plan name (case-insensitive substring)")
+	markClientFilters(plansListCmd, "interval", "amount-range", "name")
 
 	// Update flags
 	plansUpdateCmd.Flags().String("name", "", "New plan name")
 	plansUpdateCmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
+
+	// Delete flags
+	addForceFlag(plansDeleteCmd)
+
+	// Dump flags
+	plansDumpCmd.Flags().String("dir", "plans", "Directory to write the files to")
+	plansDumpCmd.Flags().StringSlice("include", nil, "Also write these resources (customers, subscriptions)")
+	plansDumpCmd.Flags().Bool("prune", false, "Remove .yaml files of resources that no longer exist")
 }
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// deletePreview fetches a concise summary of a resource, one line per fact, to show before it is deleted
+type deletePreview func(id string) ([]string, error)
+
+// confirmDelete shows a summary of a resource and asks to confirm deleting it, so that the
+// operator confirms against the actual data rather than an ID
+// The summary is only fetched when there is a prompt; failing to fetch it is only a warning,
+// since the delete itself reports a missing resource
+func confirmDelete(cmd *cobra.Command, kind, id string, preview deletePreview) error {
+	message := fmt.Sprintf("Delete %s %s?", kind, id)
+	if !willPrompt() {
+		return confirmAction(cmd, message)
+	}
+
+	lines, err := preview(id)
+	if err != nil {
+		printStatus("Warning: could not fetch %s %s to summarize it: %v", kind, id, err)
+	}
+	// The summary is part of the prompt, so it is shown with --silent as well
+	for _, line := range lines {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	return confirmAction(cmd, message)
+}
+
+// previewCustomer summarizes a customer with its cards and subscriptions
+func previewCustomer(id string) ([]string, error) {
+	customer, err := lookupCustomer(id)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := fetchSubscriptions(func(params *payjp.SubscriptionListParams) {
+		params.Customer = payjp.String(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{fmt.Sprintf("Customer %s", customer.ID)}
+	lines = append(lines, fmt.Sprintf("  Email:          %s", valueOrNone(customer.Email)))
+	if customer.Description != "" {
+		lines = append(lines, fmt.Sprintf("  Description:    %s", customer.Description))
+	}
+	lines = append(lines, fmt.Sprintf("  Created:        %s", util.FormatTimestamp(customer.CreatedAt.Unix())))
+	lines = append(lines, fmt.Sprintf("  Cards:          %d", customer.RawCards.Count))
+	lines = append(lines, fmt.Sprintf("  Subscriptions:  %s", describeSubscriptions(subscriptions)))
+	return lines, nil
+}
+
+// previewPlan summarizes a plan with the subscriptions to it
+func previewPlan(id string) ([]string, error) {
+	plan, err := client.GetPlan().Retrieve(id)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := fetchSubscriptions(func(params *payjp.SubscriptionListParams) {
+		params.Plan = payjp.String(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{fmt.Sprintf("Plan %s", plan.ID)}
+	if plan.Name != "" {
+		lines = append(lines, fmt.Sprintf("  Name:           %s", plan.Name))
+	}
+	lines = append(lines, fmt.Sprintf("  Amount:         %s / %s", util.FormatAmount(plan.Amount, plan.Currency), plan.Interval))
+	lines = append(lines, fmt.Sprintf("  Subscriptions:  %s", describeSubscriptions(subscriptions)))
+	return lines, nil
+}
+
+// fetchSubscriptions lists every subscription matching the filter set by the function
+func fetchSubscriptions(filter func(*payjp.SubscriptionListParams)) ([]*payjp.SubscriptionResponse, error) {
+	caller := client.GetSubscription().List()
+	filter(&caller.SubscriptionListParams)
+	return fetchAll("subscriptions", func(limit, offset int) ([]*payjp.SubscriptionResponse, bool, error) {
+		return caller.Limit(limit).Offset(offset).Do()
+	})
+}
+
+// describeSubscriptions counts the subscriptions that are still billed, e.g. "2 active or trial (5 total)"
+func describeSubscriptions(subscriptions []*payjp.SubscriptionResponse) string {
+	billed := 0
+	for _, sub := range subscriptions {
+		if sub.Status == payjp.SubscriptionActive || sub.Status == payjp.SubscriptionTrial {
+			billed++
+		}
+	}
+	return fmt.Sprintf("%d active or trial (%d total)", billed, len(subscriptions))
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/protect"
+	"github.com/spf13/cobra"
+)
+
+var protectCmd = &cobra.Command{
+	Use:   "protect",
+	Short: "Manage protected resources",
+	Long: `Manage the local registry of protected resources.
+
+Delete and cancel commands refuse to act on a protected ID unless --force is
+given. Deleting a protected customer's cards is refused as well. The registry
+is stored in protected.json in the config directory and only guards this CLI;
+the resources can still be deleted through the API or dashboard.`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+var protectAddCmd = &cobra.Command{
+	Use:   "add <id>...",
+	Short: "Protect resources",
+	Long: `Add resource IDs to the protected registry.
+
+Example:
+  payjp protect add pln_core
+  payjp protect add cus_xxxxx sub_xxxxx --note "key account"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		note, _ := cmd.Flags().GetString("note")
+
+		path := protect.Path(config.ConfigDir())
+		registry, err := protect.Load(path)
+		if err != nil {
+			return err
+		}
+		for _, id := range args {
+			registry.Add(id, note)
+		}
+		if err := registry.Save(path); err != nil {
+			return err
+		}
+
+		printSuccess("Protected %s", strings.Join(args, ", "))
+		return nil
+	},
+}
+
+var protectRemoveCmd = &cobra.Command{
+	Use:     "remove <id>...",
+	Aliases: []string{"rm"},
+	Short:   "Unprotect resources",
+	Long: `Remove resource IDs from the protected registry.
+
+Example:
+  payjp protect remove pln_core`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := protect.Path(config.ConfigDir())
+		registry, err := protect.Load(path)
+		if err != nil {
+			return err
+		}
+		for _, id := range args {
+			if !registry.Remove(id) {
+				return i18n.Errorf("%s is not protected", id)
+			}
+		}
+		if err := registry.Save(path); err != nil {
+			return err
+		}
+
+		printSuccess("Unprotected %s", strings.Join(args, ", "))
+		return nil
+	},
+}
+
+var protectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List protected resources",
+	Long: `List the resource IDs in the protected registry.
+
+Example:
+  payjp protect list`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := protect.Load(protect.Path(config.ConfigDir()))
+		if err != nil {
+			return err
+		}
+		return outputResult(registry.Resources)
+	},
+}
+
+// addForceFlag adds the --force flag that overrides resource protection
+func addForceFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("force", false, "Act on protected resources (see payjp protect)")
+}
+
+// checkProtected returns an error if any of the IDs is protected and --force is not given
+func checkProtected(cmd *cobra.Command, ids ...string) error {
+	registry, err := protect.Load(protect.Path(config.ConfigDir()))
+	if err != nil {
+		return err
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	for _, id := range ids {
+		entry, ok := registry.Get(id)
+		if !ok {
+			continue
+		}
+		if force {
+			printStatus("Warning: %s is protected, continuing because of --force", id)
+			continue
+		}
+		reason := ""
+		if entry.Note != "" {
+			reason = fmt.Sprintf(" (%s)", entry.Note)
+		}
+		return i18n.Errorf("%s is protected%s (use --force to override, or 'payjp protect remove %s')", id, reason, id)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(protectCmd)
+
+	protectCmd.AddCommand(protectAddCmd)
+	protectCmd.AddCommand(protectRemoveCmd)
+	protectCmd.AddCommand(protectListCmd)
+
+	// Add flags
+	protectAddCmd.Flags().String("note", "", "Why the resource is protected")
+}
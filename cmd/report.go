@@ -0,0 +1,458 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate reports",
+	Long:  `Generate aggregate reports from PAY.JP resources.`,
+}
+
+// mrrRow is a row of the MRR report
+type mrrRow struct {
+	PlanID   string `json:"plan_id,omitempty"`
+	PlanName string `json:"plan_name,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	Currency string `json:"currency"`
+	Active   int    `json:"active"`
+	Trial    int    `json:"trial"`
+	Paused   int    `json:"paused"`
+	MRR      int    `json:"mrr"`
+	ARR      int    `json:"arr"`
+}
+
+// TableColumns returns the fields shown in list view
+func (mrrRow) TableColumns() []string {
+	return []string{"PlanID", "PlanName", "Interval", "Currency", "Active", "Trial", "Paused", "MRR", "ARR"}
+}
+
+var reportMRRCmd = &cobra.Command{
+	Use:   "mrr",
+	Short: "Calculate monthly recurring revenue",
+	Long: `Calculate MRR and ARR from active subscriptions.
+
+Yearly plans contribute 1/12 of their amount per month. Subscriptions in
+trial and paused subscriptions are counted but do not contribute to MRR.
+Canceled subscriptions are ignored.
+
+Example:
+  payjp report mrr
+  payjp report mrr --by-plan
+  payjp report mrr --by-plan -o csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		byPlan, _ := cmd.Flags().GetBool("by-plan")
+
+		subscriptions, err := fetchAll("subscriptions", func(limit, offset int) ([]*payjp.SubscriptionResponse, bool, error) {
+			return client.GetSubscription().List().Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		plans, err := newPlanCache()
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		rows := map[string]*mrrRow{}
+		for _, sub := range subscriptions {
+			if sub.Status == payjp.SubscriptionCanceled {
+				continue
+			}
+
+			plan, err := plans.get(sub.Plan.ID, sub.Plan)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+
+			key := plan.Currency
+			if byPlan {
+				key = plan.ID
+			}
+			row, ok := rows[key]
+			if !ok {
+				row = &mrrRow{Currency: plan.Currency}
+				if byPlan {
+					row.PlanID = plan.ID
+					row.PlanName = plan.Name
+					row.Interval = plan.Interval
+				}
+				rows[key] = row
+			}
+
+			switch sub.Status {
+			case payjp.SubscriptionActive:
+				row.Active++
+				row.MRR += monthlyAmount(plan.Amount, plan.Interval)
+			case payjp.SubscriptionTrial:
+				row.Trial++
+			case payjp.SubscriptionPaused:
+				row.Paused++
+			}
+		}
+
+		keys := make([]string, 0, len(rows))
+		for key := range rows {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result := make([]mrrRow, 0, len(rows))
+		for _, key := range keys {
+			row := rows[key]
+			row.ARR = row.MRR * 12
+			result = append(result, *row)
+		}
+
+		return outputResult(result)
+	},
+}
+
+// monthlyAmount normalizes a plan amount to a monthly amount
+func monthlyAmount(amount int, interval string) int {
+	if interval == "year" {
+		return amount / 12
+	}
+	return amount
+}
+
+// planCache caches plans by ID to avoid retrieving the same plan repeatedly
+type planCache map[string]*payjp.PlanResponse
+
+// newPlanCache creates a plan cache populated with all plans
+func newPlanCache() (planCache, error) {
+	plans, err := fetchAll("plans", func(limit, offset int) ([]*payjp.PlanResponse, bool, error) {
+		return client.GetPlan().List().Limit(limit).Offset(offset).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache := planCache{}
+	for _, plan := range plans {
+		cache[plan.ID] = plan
+	}
+	return cache, nil
+}
+
+// get returns a cached plan, falling back to the plan embedded in the subscription
+// and finally retrieving it from the API (e.g. for deleted plans)
+func (c planCache) get(id string, embedded payjp.Plan) (*payjp.PlanResponse, error) {
+	if plan, ok := c[id]; ok {
+		return plan, nil
+	}
+
+	if embedded.Interval != "" {
+		plan := &payjp.PlanResponse{
+			ID:         embedded.ID,
+			Name:       embedded.Name,
+			Amount:     embedded.Amount,
+			Currency:   embedded.Currency,
+			Interval:   embedded.Interval,
+			BillingDay: embedded.BillingDay,
+		}
+		c[id] = plan
+		return plan, nil
+	}
+
+	printVerbose("Retrieving plan %s", id)
+	plan, err := client.GetPlan().Retrieve(id)
+	if err != nil {
+		return nil, err
+	}
+	c[id] = plan
+	return plan, nil
+}
+
+var reportPayoutForecastCmd = &cobra.Command{
+	Use:   "payout-forecast",
+	Short: "Estimate upcoming payouts",
+	Long: `Estimate upcoming payout dates and amounts from open balances and
+pending transfers.
+
+Each open balance is shown with its due date and the period of the terms it
+covers. Balances whose term has not closed yet are marked as estimates since
+their amount still changes as charges and refunds are made.
+
+Example:
+  payjp report payout-forecast
+  payjp report payout-forecast -o json
+  payjp report payout-forecast --owner tenant -o csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owner, _ := cmd.Flags().GetString("owner")
+
+		closed := false
+		balances, err := fetchAll("balances", func(limit, offset int) ([]*payjp.BalanceResponse, bool, error) {
+			params := &payjp.BalanceListParams{Closed: &closed}
+			params.Limit = payjp.Int(limit)
+			params.Offset = payjp.Int(offset)
+			if owner != "" {
+				params.Owner = payjp.String(owner)
+			}
+			return client.GetBalance().All(params)
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		transfers, err := fetchAll("transfers", func(limit, offset int) ([]*payjp.TransferResponse, bool, error) {
+			return client.GetTransfer().List().Status(payjp.TransferPending).Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		result := []payoutRow{}
+		for _, balance := range balances {
+			result = append(result, balancePayoutRow(balance))
+		}
+		for _, transfer := range transfers {
+			result = append(result, payoutRow{
+				Date:      transfer.ScheduledDate,
+				Source:    "transfer",
+				ID:        transfer.ID,
+				Status:    string(transfer.Status),
+				TermStart: formatDate(transfer.TermStartAt),
+				TermEnd:   formatDate(transfer.TermEndAt),
+				Amount:    int64(transfer.Amount),
+				Currency:  transfer.Currency,
+			})
+		}
+
+		// Undated rows (e.g. balances still collecting) go last
+		sort.SliceStable(result, func(i, j int) bool {
+			if result[i].Date == "" || result[j].Date == "" {
+				return result[j].Date == "" && result[i].Date != ""
+			}
+			return result[i].Date < result[j].Date
+		})
+
+		return outputResult(result)
+	},
+}
+
+// payoutRow is a row of the payout forecast report
+type payoutRow struct {
+	Date      string `json:"date"`
+	Source    string `json:"source"`
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	TermStart string `json:"term_start"`
+	TermEnd   string `json:"term_end"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Estimate  bool   `json:"estimate"`
+}
+
+// TableColumns returns the fields shown in list view
+func (payoutRow) TableColumns() []string {
+	return []string{"Date", "Source", "ID", "Status", "TermStart", "TermEnd", "Amount", "Currency", "Estimate"}
+}
+
+// balancePayoutRow builds a forecast row from an open balance and the terms of its statements
+func balancePayoutRow(balance *payjp.BalanceResponse) payoutRow {
+	row := payoutRow{
+		Source:   "balance",
+		ID:       balance.ID,
+		Status:   balance.Type,
+		Amount:   balance.Net,
+		Currency: "jpy",
+	}
+	if !balance.DueDate.IsZero() {
+		row.Date = formatDate(balance.DueDate)
+	}
+
+	var start, end int
+	for _, statement := range balance.Statements {
+		term := statement.Term
+		if term == nil {
+			continue
+		}
+		if term.StartAt != nil && (start == 0 || *term.StartAt < start) {
+			start = *term.StartAt
+		}
+		if term.EndAt == nil {
+			// The term is still open so the amount is not final
+			row.Estimate = true
+		} else if *term.EndAt > end {
+			end = *term.EndAt
+		}
+	}
+	if start > 0 {
+		row.TermStart = formatDate(time.Unix(int64(start), 0))
+	}
+	if end > 0 && !row.Estimate {
+		row.TermEnd = formatDate(time.Unix(int64(end), 0))
+	}
+	return row
+}
+
+// formatDate formats a time as a date, returning an empty string for the zero time
+func formatDate(t time.Time) string {
+	if t.IsZero() || t.Unix() <= 0 {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// tdsReport is the 3D Secure usage of charges
+type tdsReport struct {
+	Charges        int             `json:"charges"`
+	With3DS        int             `json:"with_3ds"`
+	Percent3DS     float64         `json:"percent_3ds"`
+	Statuses       []tdsStatusRow  `json:"statuses"`
+	AwaitingFinish []tdsPendingRow `json:"awaiting_tds_finish"`
+}
+
+// tdsStatusRow is the number of charges with a 3D Secure status
+type tdsStatusRow struct {
+	Status  string  `json:"status"`
+	Charges int     `json:"charges"`
+	Percent float64 `json:"percent"`
+}
+
+// TableColumns returns the fields shown in list view
+func (tdsStatusRow) TableColumns() []string {
+	return []string{"Status", "Charges", "Percent"}
+}
+
+// tdsPendingRow is a charge whose 3D Secure authentication finished but that awaits tds-finish
+type tdsPendingRow struct {
+	ID        string    `json:"id"`
+	Created   time.Time `json:"created"`
+	Amount    int       `json:"amount"`
+	Currency  string    `json:"currency"`
+	Customer  string    `json:"customer"`
+	TdsStatus string    `json:"three_d_secure_status"`
+}
+
+// TableColumns returns the fields shown in list view
+func (tdsPendingRow) TableColumns() []string {
+	return []string{"ID", "Created", "Amount", "Currency", "Customer", "TdsStatus"}
+}
+
+var reportTdsCmd = &cobra.Command{
+	Use:   "3ds",
+	Short: "Summarize 3D Secure usage of charges",
+	Long: `Summarize how charges used 3D Secure: how many went through it, the
+number of charges per three_d_secure_status (none for charges without 3D
+Secure), and the charges whose authentication finished (verified or
+attempted) but that are not paid because tds-finish has not been called.
+
+All charges in the period are paged through; narrow it with --since/--until
+or --range.
+
+Example:
+  payjp report 3ds --since 2024-06-01
+  payjp report 3ds --range fiscal_q1 -o json`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		caller := client.GetCharge().List()
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+		caller.Tenant = client.TenantParam()
+
+		report := tdsReport{Statuses: []tdsStatusRow{}, AwaitingFinish: []tdsPendingRow{}}
+		counts := map[string]int{}
+		err = forEach("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+			return caller.Limit(limit).Offset(offset).Do()
+		}, func(charge *payjp.ChargeResponse) {
+			report.Charges++
+			status := chargeTdsStatus(charge)
+			counts[status]++
+			if status != "none" {
+				report.With3DS++
+			}
+			if awaitingTdsFinish(charge) {
+				report.AwaitingFinish = append(report.AwaitingFinish, tdsPendingRow{
+					ID:        charge.ID,
+					Created:   charge.CreatedAt,
+					Amount:    charge.Amount,
+					Currency:  charge.Currency,
+					Customer:  charge.CustomerID,
+					TdsStatus: status,
+				})
+			}
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		report.Percent3DS = percentOf(report.With3DS, report.Charges)
+		for _, status := range tdsStatuses {
+			if counts[status] > 0 {
+				report.Statuses = append(report.Statuses, tdsStatusRow{
+					Status:  status,
+					Charges: counts[status],
+					Percent: percentOf(counts[status], report.Charges),
+				})
+			}
+		}
+
+		if getOutputFormat() != "table" {
+			return outputResult(report)
+		}
+
+		printStatus("%d charges, %d with 3D Secure (%.1f%%)", report.Charges, report.With3DS, report.Percent3DS)
+		if err := outputResult(report.Statuses); err != nil {
+			return err
+		}
+		if len(report.AwaitingFinish) > 0 {
+			fmt.Println("Awaiting tds-finish:")
+			return outputResult(report.AwaitingFinish)
+		}
+		return nil
+	},
+}
+
+// percentOf returns n as a percentage of total rounded to one decimal place
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(total)) / 10
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.AddCommand(reportMRRCmd)
+	reportCmd.AddCommand(reportPayoutForecastCmd)
+	reportCmd.AddCommand(reportTdsCmd)
+
+	// MRR flags
+	reportMRRCmd.Flags().Bool("by-plan", false, "Group results by plan")
+
+	// Payout forecast flags
+	reportPayoutForecastCmd.Flags().String("owner", "", "Filter balances by owner type (merchant, tenant)")
+
+	// 3D Secure flags
+	addTimeRangeFlags(reportTdsCmd)
+}
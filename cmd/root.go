@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/payjp/payjp-cli/internal/client"
 	"github.com/payjp/payjp-cli/internal/config"
+	apierrors "github.com/payjp/payjp-cli/internal/errors"
+	"github.com/payjp/payjp-cli/internal/i18n"
 	"github.com/payjp/payjp-cli/internal/output"
 	"github.com/payjp/payjp-cli/internal/util"
 	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
 )
 
 var (
@@ -16,12 +23,33 @@ var (
 	Version = "dev"
 
 	// Global flags
-	cfgFile   string
-	apiKey    string
-	outputFmt string
-	liveMode  bool
-	verbose   bool
-	quiet     bool
+	cfgFile    string
+	apiKey     string
+	keyStdin   bool
+	outputFmt  string
+	liveMode   bool
+	verbose    bool
+	quiet      bool
+	silent     bool
+	fieldsArg  string
+	maxWait    int
+	sortKeys   bool
+	expandMaps bool
+	locale     string
+	timeFmt    string
+	noHeaders  bool
+	delimiter  string
+	rawOutput  bool
+	tenantID   string
+	themeName  string
+	noColor    bool
+	noPager    bool
+	machine    bool
+	envelope   bool
+
+	nonInteractive bool
+	assumeYes      bool
+	notifyOnExit   bool
 )
 
 // rootCmd represents the base command
@@ -46,99 +74,683 @@ Example:
 For more information, visit: https://pay.jp/docs/api/`,
 	Version: Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Checked before the defaults are applied, so that a default --limit does not conflict
+		if err := checkAllFlags(cmd); err != nil {
+			return err
+		}
+
+		// Apply flag defaults from the project file before any flag is used
+		if err := applyProjectDefaults(cmd); err != nil {
+			return err
+		}
+
 		// Track if --output flag was explicitly set
 		outputFmtChanged = cmd.Flags().Changed("output")
 
-		// Skip client initialization for config commands
-		if cmd.Parent() != nil && cmd.Parent().Name() == "config" {
-			return nil
+		if err := applyEnvelopeMode(); err != nil {
+			return err
 		}
-		if cmd.Name() == "config" {
-			return nil
+
+		// Parse field projection early so that syntax errors are reported before any API call
+		fields, err := output.ParseFields(fieldsArg)
+		if err != nil {
+			return err
+		}
+		outputFields = fields
+
+		if silent && verbose {
+			return i18n.Errorf("--silent and --verbose cannot be used together")
+		}
+
+		if rawOutput {
+			if name := cmd.Name(); name != "get" && name != "list" || !requiresClient(cmd) || cmd.Parent().Name() == "config" {
+				return i18n.Errorf("--raw is only supported by get and list commands")
+			}
+			if quiet || fieldsArg != "" {
+				return i18n.Errorf("--raw cannot be used with --quiet or --fields")
+			}
+			if err := checkRawFilters(cmd); err != nil {
+				return err
+			}
+		}
+
+		if themeName != "" {
+			if _, ok := output.LookupTheme(themeName); !ok {
+				return i18n.Errorf("invalid theme: %s (use %s)", themeName, strings.Join(output.ThemeNames, ", "))
+			}
+		}
+
+		if tenantID != "" {
+			if _, ok := cmd.Annotations[annotationTenant]; !ok {
+				return i18n.Errorf("--tenant is not supported by '%s'", commandName(cmd))
+			}
+		}
+
+		if locale != "" {
+			if _, err := language.Parse(locale); err != nil {
+				return i18n.Errorf("invalid locale: %s (use a language tag such as ja-JP or en-US)", locale)
+			}
+		}
+		if err := output.ValidateTimeFormat(timeFmt); err != nil {
+			return err
+		}
+
+		// Skip client initialization for config commands
+		if cmd.Parent() != nil && cmd.Parent().Name() == "config" || cmd.Name() == "config" {
+			if notifyOnExit {
+				return i18n.Errorf("--notify cannot be used with config commands")
+			}
+			return validateRequiredFlags(cmd)
 		}
 
 		// Initialize configuration
 		if err := config.Init(cfgFile); err != nil {
 			return fmt.Errorf("failed to initialize config: %w", err)
 		}
+		warnCorruptConfig()
+		warnSecurityIssues(cmd)
+		if err := setupNotify(cmd); err != nil {
+			return err
+		}
+		if err := applyConfigDefaults(cmd); err != nil {
+			return err
+		}
+
+		if err := applyMachineMode(); err != nil {
+			return err
+		}
+		outputCfg := config.GetOutputConfig()
+		theme, err := outputTheme(config.GetThemeConfig())
+		if err != nil {
+			return err
+		}
+		output.SetOptions(output.Options{
+			Machine:    machine,
+			Color:      outputCfg.Color && !noColor && !machine,
+			SortKeys:   sortKeys || outputCfg.SortKeys,
+			ExpandMaps: expandMaps || outputCfg.ExpandMaps,
+			Theme:      theme,
+			Locale:     outputLocale(outputCfg.Locale),
+			TimeFormat: outputTimeFormat(outputCfg.TimeFormat),
+			NoHeaders:  noHeaders,
+			Delimiter:  delimiter,
+			Pager:      !noPager && !machine && !nonInteractive && !config.IsNonInteractive(),
+		})
+		i18n.SetLocale(output.Locale())
+		cmd.Root().SetErrPrefix(output.Colorize(os.Stderr, output.RoleError, "Error:"))
+		if err := validateRequiredFlags(cmd); err != nil {
+			return err
+		}
 
 		// Set live mode environment variable if --live flag is used
 		if liveMode {
 			os.Setenv("PAYJP_LIVE", "true")
 		}
 
-		// Initialize client with API key override if provided
-		opts := []client.Option{}
-		if apiKey != "" {
-			opts = append(opts, client.WithAPIKey(apiKey))
+		// Skip client initialization for commands that do not call the API
+		if !requiresClient(cmd) {
+			return nil
 		}
 
+		// Initialize client with API key override if provided
+		opts, err := clientOptions()
+		if err != nil {
+			return err
+		}
 		if err := client.Init(opts...); err != nil {
 			return err
 		}
+		setCommandCapability(cmd)
 
 		return nil
 	},
 }
 
+// clientOptions returns client options derived from global flags
+func clientOptions() ([]client.Option, error) {
+	opts := []client.Option{
+		client.WithLogf(printVerbose),
+		client.WithLanguage(output.Locale().String()),
+		client.WithUserAgent(userAgent()),
+	}
+	if config.UsageStatsEnabled() {
+		opts = append(opts, client.WithUsage(usageCounter))
+	}
+	key, _, err := apiKeyOverride()
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		opts = append(opts, client.WithAPIKey(key))
+	}
+	if maxWait > 0 {
+		opts = append(opts, client.WithMaxWait(maxWait))
+	}
+	if rawOutput {
+		opts = append(opts, client.WithCapture(true))
+	}
+	if tenantID != "" {
+		opts = append(opts, client.WithTenant(tenantID))
+	}
+	return opts, nil
+}
+
+// overrideKey caches the API key read by apiKeyOverride, since stdin can only be read once
+var overrideKey *config.APIKeySource
+
+// apiKeyOverride returns the API key that takes precedence over the profile and its source
+// Priority: --api-key or --api-key-stdin > PAYJP_API_KEY_FILE
+// An empty key means the key is resolved from PAYJP_API_KEY or the profile
+func apiKeyOverride() (string, string, error) {
+	if overrideKey != nil {
+		return overrideKey.Key, overrideKey.Source, nil
+	}
+
+	var key, source string
+	switch {
+	case keyStdin:
+		if apiKey != "" {
+			return "", "", i18n.Errorf("--api-key and --api-key-stdin cannot be used together")
+		}
+		k, err := client.ReadAPIKey(os.Stdin)
+		if err != nil {
+			return "", "", err
+		}
+		key, source = k, "stdin (--api-key-stdin)"
+	case apiKey != "":
+		key, source = apiKey, "flag (--api-key)"
+	case os.Getenv("PAYJP_API_KEY_FILE") != "":
+		if os.Getenv("PAYJP_API_KEY") != "" {
+			return "", "", i18n.Errorf("PAYJP_API_KEY and PAYJP_API_KEY_FILE cannot both be set")
+		}
+		k, err := client.ReadAPIKeyFile(os.Getenv("PAYJP_API_KEY_FILE"))
+		if err != nil {
+			return "", "", err
+		}
+		key, source = k, "file (PAYJP_API_KEY_FILE)"
+	}
+
+	overrideKey = &config.APIKeySource{Key: key, Source: source}
+	return key, source, nil
+}
+
 // Execute runs the root command
 func Execute() {
+	enableValuePaths(rootCmd)
+	enableCountFlags(rootCmd)
+	enableCompletion(rootCmd)
+	localizeArgErrors(rootCmd)
+	i18n.SetLocale(output.Locale())
 	if err := rootCmd.Execute(); err != nil {
+		recordHistory(util.ExitGeneralError)
+		recordUsage()
+		notifyCompletion(util.ExitGeneralError, err)
+		writeEnvelope(util.ExitGeneralError, err)
 		os.Exit(int(util.ExitGeneralError))
 	}
+	recordHistory(util.ExitSuccess)
+	recordUsage()
+	notifyCompletion(util.ExitSuccess, nil)
+	writeEnvelope(util.ExitSuccess, nil)
+}
+
+// localizeArgErrors translates the argument count errors of every command through the i18n layer
+func localizeArgErrors(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		localizeArgErrors(c)
+	}
+	if validate := root.Args; validate != nil {
+		root.Args = func(cmd *cobra.Command, args []string) error {
+			return i18n.Localize(validate(cmd, args))
+		}
+	}
+}
+
+// validateRequiredFlags checks required flags before cobra does, so that the error is localized
+func validateRequiredFlags(cmd *cobra.Command) error {
+	return i18n.Localize(cmd.ValidateRequiredFlags())
+}
+
+// annotationNoClient marks commands that do not need an API client
+const annotationNoClient = "payjp:no-client"
+
+// annotationTenant marks commands whose queries can be scoped to a tenant with --tenant
+const annotationTenant = "payjp:tenant"
+
+// requiresClient returns false if the command or one of its parents is marked with annotationNoClient
+func requiresClient(cmd *cobra.Command) bool {
+	if isCompletionRequest(cmd) {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[annotationNoClient]; ok {
+			return false
+		}
+	}
+	return true
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	// Flag parse errors are translated through the i18n layer
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		applyLocaleFlag()
+		return i18n.Localize(err)
+	})
+
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is ~/.payjp/config.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&apiKey, "api-key", "k", "", "API key (overrides config file and environment variable)")
-	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "table", "output format (json, table, yaml)")
+	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "table", "output format (json, table, yaml, csv, ndjson)")
+	rootCmd.PersistentFlags().BoolVar(&keyStdin, "api-key-stdin", false, "read the API key from the first line of stdin")
 	rootCmd.PersistentFlags().BoolVar(&liveMode, "live", false, "use live mode (default is test mode)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "quiet output (only output IDs)")
+	rootCmd.PersistentFlags().BoolVar(&silent, "silent", false, "suppress all non-essential output (only results and errors are printed)")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "never prompt; fail unless the command is in automation.allowlist")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to confirmation prompts")
+	rootCmd.PersistentFlags().BoolVar(&notifyOnExit, "notify", false, "send a Slack/email notification with the result when the command finishes (see notify in the config file)")
+	rootCmd.PersistentFlags().IntVar(&maxWait, "max-wait", 0, "maximum seconds to wait before retrying a rate limited request")
+	rootCmd.PersistentFlags().BoolVar(&sortKeys, "sort-keys", false, "sort object keys in json output")
+	rootCmd.PersistentFlags().BoolVar(&noPager, "no-pager", false, "print long tables at once instead of one screen at a time on a terminal")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also disabled by the NO_COLOR environment variable)")
+	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "", "color theme for terminal output (dark, light, none)")
+	rootCmd.PersistentFlags().BoolVar(&expandMaps, "expand-maps", false, "show maps such as metadata as key=value lines in table output")
+	rootCmd.PersistentFlags().StringVar(&locale, "locale", "", "locale used for amounts in table output and for messages (e.g. ja, ja-JP, en-US)")
+	rootCmd.PersistentFlags().StringVar(&timeFmt, "time-format", "", "timestamp format in table/csv output (unix, rfc3339, relative, or a Go layout)")
+	rootCmd.PersistentFlags().BoolVar(&noHeaders, "no-headers", false, "print table output as plain rows without borders and headers")
+	rootCmd.PersistentFlags().StringVar(&delimiter, "delimiter", "", "print table output as plain rows separated by this string (default is tab with --no-headers)")
+	rootCmd.PersistentFlags().BoolVar(&machine, "machine", false, "stable output for scripts: UTC RFC 3339 timestamps, raw amounts, no localization (json by default; also PAYJP_MACHINE=true)")
+	rootCmd.PersistentFlags().BoolVar(&envelope, "envelope", false, "wrap the result in a JSON envelope {ok, data, error, meta} for scripts, also on failure (also PAYJP_ENVELOPE=true)")
+	rootCmd.PersistentFlags().StringVar(&tenantID, "tenant", "", "scope charges, transfers, statements and balances queries to a PAY.JP Platform tenant")
+	rootCmd.PersistentFlags().BoolVar(&rawOutput, "raw", false, "print the untouched API JSON response (get and list commands only)")
+	rootCmd.PersistentFlags().StringVar(&fieldsArg, "fields", "", "fields to include in json/yaml/ndjson output (e.g. id,amount,card{brand,last4})")
 }
 
 func initConfig() {
 	// Configuration is initialized in PersistentPreRunE
+	applyLocaleFlag()
+}
+
+// applyLocaleFlag switches messages to the --locale language as soon as flags are parsed
+// Argument and flag errors are raised by cobra before PersistentPreRunE runs
+func applyLocaleFlag() {
+	if locale == "" {
+		return
+	}
+	if tag, err := language.Parse(locale); err == nil {
+		i18n.SetLocale(tag)
+	}
 }
 
 // outputFmtChanged tracks if --output flag was explicitly set
 var outputFmtChanged bool
 
+// outputFields holds the parsed --fields projection
+var outputFields output.FieldSet
+
 // getOutputFormat returns the output format to use
 func getOutputFormat() string {
 	if quiet {
 		return "quiet"
 	}
+	if valuePath != nil {
+		return "value"
+	}
+	if countOnly {
+		return "count"
+	}
 	if outputFmtChanged {
 		return outputFmt
 	}
+	if machine || envelope {
+		return "json"
+	}
 	return config.GetOutputFormat()
 }
 
 // outputResult outputs the result in the appropriate format
 func outputResult(data interface{}) error {
+	if rawOutput {
+		return writeRaw()
+	}
+
 	format := getOutputFormat()
+	if format == "value" {
+		return outputValue(data)
+	}
+	if format == "count" {
+		return outputCount(data)
+	}
+
+	data, err := projectOutput(format, data)
+	if err != nil {
+		return err
+	}
+
 	return output.Output(format, data)
 }
 
+// projectFlags are the flags set by the project file rather than on the command line
+var projectFlags = map[string]bool{}
+
+// configDefaultFlags are the flags set by defaults in the config file rather than on the command line
+var configDefaultFlags = map[string]bool{}
+
+// flagOnCommandLine reports whether a flag was given on the command line rather than by the
+// project file or the config file defaults
+func flagOnCommandLine(cmd *cobra.Command, name string) bool {
+	return cmd.Flags().Changed(name) && !projectFlags[name] && !configDefaultFlags[name]
+}
+
+// projectDeniedFlags are the flags a project file cannot set: a .payjp.yaml comes with a cloned
+// repository, so it must not switch to live mode, skip confirmations and safeguards, read another
+// config file or send data to a place of its choosing
+var projectDeniedFlags = map[string]bool{
+	"config":          true,
+	"api-key-stdin":   true,
+	"live":            true,
+	"non-interactive": true,
+	"yes":             true,
+	"force":           true,
+	"override-limit":  true,
+	"no-verify":       true,
+	"no-rollback":     true,
+	"prune":           true,
+	"upload":          true,
+	"webhook-token":   true,
+	"forward-to":      true,
+}
+
+// applyProjectDefaults loads the project file and sets flags that were not given on the command line
+// Global defaults come from "flags", command specific defaults from "commands" keyed by command path
+// A global flag whose environment variable is set (e.g. output and PAYJP_OUTPUT) is left to the
+// environment, which takes precedence over the project file
+func applyProjectDefaults(cmd *cobra.Command) error {
+	project, err := config.LoadProject()
+	if err != nil || project == nil {
+		return err
+	}
+	printVerbose("Using project file %s", project.Path)
+
+	defaults := map[string]string{}
+	for name, value := range project.Flags {
+		defaults[name] = value
+	}
+	for name, value := range project.Commands[commandName(cmd)] {
+		defaults[name] = value
+	}
+
+	for name, value := range defaults {
+		if name == "api-key" {
+			return i18n.Errorf("%s: api-key cannot be set in a project file (use profile instead)", project.Path)
+		}
+		if projectDeniedFlags[name] {
+			return i18n.Errorf("%s: %s cannot be set in a project file (give it on the command line)", project.Path, name)
+		}
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return i18n.Errorf("%s: unknown flag '%s' for '%s'", project.Path, name, commandName(cmd))
+		}
+		if flag.Changed {
+			continue
+		}
+		if env := config.FlagEnv(name); env != "" && os.Getenv(env) != "" {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return i18n.Errorf("%s: invalid value for '%s': %w", project.Path, name, err)
+		}
+		projectFlags[name] = true
+	}
+	return nil
+}
+
+// applyConfigDefaults sets flags from defaults.<command path> in the config file
+// Flags given on the command line or by the project file take precedence
+func applyConfigDefaults(cmd *cobra.Command) error {
+	name := commandName(cmd)
+	for flagName, value := range config.CommandDefaults(name) {
+		key := "defaults." + strings.ReplaceAll(name, " ", ".") + "." + flagName
+		flag := cmd.LocalNonPersistentFlags().Lookup(flagName)
+		if flag == nil {
+			return i18n.Errorf("%s: unknown flag '%s' for '%s'", key, flagName, name)
+		}
+		if flag.Changed {
+			continue
+		}
+		printVerbose("Using %s=%s from the config file", key, value)
+		if err := cmd.Flags().Set(flagName, value); err != nil {
+			return i18n.Errorf("%s: invalid value for '%s': %w", key, flagName, err)
+		}
+		configDefaultFlags[flagName] = true
+	}
+	return nil
+}
+
+// outputTheme returns the theme from --theme or theme.name with the colors configured in the theme section
+func outputTheme(cfg config.ThemeConfig) (output.Theme, error) {
+	name := themeName
+	if name == "" {
+		name = cfg.Name
+	}
+	theme, ok := output.LookupTheme(name)
+	if !ok {
+		return theme, i18n.Errorf("invalid theme: %s (use %s)", name, strings.Join(output.ThemeNames, ", "))
+	}
+	if strings.EqualFold(name, "none") {
+		return theme, nil
+	}
+
+	colors := []struct {
+		key  string
+		role output.Role
+		spec string
+	}{
+		{"success", output.RoleSuccess, cfg.Success},
+		{"warn", output.RoleWarn, cfg.Warn},
+		{"error", output.RoleError, cfg.Error},
+		{"ids", output.RoleID, cfg.IDs},
+		{"amounts", output.RoleAmount, cfg.Amounts},
+	}
+	for _, c := range colors {
+		if c.spec == "" {
+			continue
+		}
+		color, ok := output.ParseColor(c.spec)
+		if !ok {
+			return theme, i18n.Errorf("invalid color for theme.%s: %s (use a name such as red or bold blue, or SGR parameters such as 38;5;208)", c.key, c.spec)
+		}
+		theme = theme.With(c.role, color)
+	}
+	return theme, nil
+}
+
+// outputLocale returns the locale from the --locale flag or the configured default
+func outputLocale(configured string) string {
+	if locale != "" {
+		return locale
+	}
+	return configured
+}
+
+// applyMachineMode turns on --machine from PAYJP_MACHINE and checks that it is used with a machine format
+// Timestamps are made UTC by switching the local time zone, so that JSON and YAML, which format
+// time values themselves, do not depend on the time zone of the host either
+func applyMachineMode() error {
+	if os.Getenv("PAYJP_MACHINE") == "true" {
+		machine = true
+	}
+	if !machine {
+		return nil
+	}
+	if getOutputFormat() == string(output.FormatTable) {
+		return i18n.Errorf("--machine cannot be used with table output; use -o json, ndjson, csv or yaml")
+	}
+	time.Local = time.UTC
+	return nil
+}
+
+// outputTimeFormat returns the time format from the --time-format flag or the configured default
+func outputTimeFormat(configured string) string {
+	if timeFmt != "" {
+		return timeFmt
+	}
+	return configured
+}
+
+// projectOutput applies the --fields projection to structured formats and adds derived
+// columns to tabular ones
+func projectOutput(format string, data interface{}) (interface{}, error) {
+	switch output.Format(format) {
+	case output.FormatJSON, output.FormatYAML, output.FormatNDJSON:
+		if len(outputFields) > 0 {
+			return output.Project(data, outputFields)
+		}
+	case output.FormatTable, output.FormatCSV:
+		return enrichOutput(data), nil
+	}
+	return data, nil
+}
+
 // outputResultQuiet outputs only the ID
 func outputResultQuiet(data interface{}) error {
 	return output.OutputQuiet(data)
 }
 
+// writeRaw prints the API responses captured since the last call exactly as they were received
+// Each response is written on its own line, so paginated results form a JSON sequence
+func writeRaw() error {
+	for _, body := range client.Captured() {
+		body = bytes.TrimRight(body, "\n")
+		if _, err := fmt.Fprintf(os.Stdout, "%s\n", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // handleError handles errors and exits with appropriate code
 func handleError(err error) {
+	err = unsupportedError(commandCapability, err)
 	code := util.HandleError(err)
-	fmt.Fprintf(os.Stderr, "\nExit code: %d\n", code)
+	if hint, ok := apierrors.HintFor(err); ok && !silent {
+		fmt.Fprintf(os.Stderr, "  Hint: %s\n", hint.Message(output.Locale()))
+	}
+	printVerbose("Exit code: %d", code)
+	recordHistory(code)
+	recordUsage()
+	notifyCompletion(code, err)
+	writeEnvelope(code, err)
 	os.Exit(int(code))
 }
 
-// printVerbose prints verbose output if enabled
+// commandName returns the command path without the root command name (e.g. "customers delete")
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// errAborted is returned by confirmAction when the user declines
+var errAborted = errors.New("aborted")
+
+// confirmAction asks the user to confirm a destructive action
+// In non-interactive mode the command must be allowlisted in the config, otherwise an error is returned
+// With --yes the action is confirmed without a prompt; without a terminal to ask on, scripts
+// must pass --yes, so that a destructive action is never confirmed by accident
+// When stdin is a pipe (e.g. --api-key-stdin or --file -) the answer is read from the terminal
+func confirmAction(cmd *cobra.Command, message string) error {
+	if nonInteractive || config.IsNonInteractive() {
+		name := commandName(cmd)
+		if config.IsAllowedNonInteractive(name) {
+			return nil
+		}
+		return i18n.Errorf("'%s' requires confirmation and is not in automation.allowlist (non-interactive mode)", name)
+	}
+	if assumeYes {
+		return nil
+	}
+	if !willPrompt() {
+		return i18n.Errorf("'%s' requires confirmation and there is no terminal to ask on; pass --yes to confirm", commandName(cmd))
+	}
+
+	confirmed := false
+	if stdinIsTerminal() {
+		confirmed = util.ConfirmAction(message)
+	} else {
+		term, err := openTerminal()
+		if err != nil {
+			return err
+		}
+		confirmed = util.ConfirmFrom(term, message)
+		term.Close()
+	}
+	if !confirmed {
+		return errAborted
+	}
+	return nil
+}
+
+// willPrompt reports whether confirmAction asks the user, which needs a terminal and no --yes
+func willPrompt() bool {
+	return !assumeYes && !nonInteractive && !config.IsNonInteractive() && (stdinIsTerminal() || hasTerminal())
+}
+
+// openTerminal opens the controlling terminal, which is there even when stdin is a pipe
+func openTerminal() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
+
+// hasTerminal reports whether the process has a controlling terminal
+func hasTerminal() bool {
+	term, err := openTerminal()
+	if err != nil {
+		return false
+	}
+	term.Close()
+	return true
+}
+
+// stdinIsTerminal reports whether stdin is a terminal rather than a pipe or a file
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// warnCorruptConfig warns that the config file could not be loaded and the defaults are in use
+func warnCorruptConfig() {
+	if err := config.Corrupt(); err != nil {
+		printStatus("Warning: config file %s is corrupt (%v); using the defaults and environment variables. Run 'payjp config repair' to fix it", config.ConfigFilePath(), err)
+	}
+}
+
+// printVerbose prints verbose output to stderr if enabled
 func printVerbose(format string, args ...interface{}) {
 	if verbose {
-		fmt.Printf(format+"\n", args...)
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// printStatus prints a diagnostic message to stderr unless --silent is set
+// Stdout is reserved for command results so that pipelines never ingest diagnostics
+// The message is also kept as the --notify summary
+func printStatus(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	recordStatus(message)
+	if silent {
+		return
+	}
+	if rest, ok := strings.CutPrefix(message, "Warning:"); ok {
+		message = output.Colorize(os.Stderr, output.RoleWarn, "Warning:") + rest
+	}
+	fmt.Fprintln(os.Stderr, message)
+}
+
+// printSuccess prints a status message reporting a completed change in the success color
+func printSuccess(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	recordStatus(message)
+	if !silent {
+		fmt.Fprintln(os.Stderr, output.Colorize(os.Stderr, output.RoleSuccess, message))
 	}
 }
@@ -2,8 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/payjp/payjp-cli/internal/capability"
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
@@ -13,6 +22,9 @@ var statementsCmd = &cobra.Command{
 	Aliases: []string{"statement"},
 	Short:   "Manage statements",
 	Long:    `Retrieve and list transaction statements.`,
+	Annotations: map[string]string{
+		annotationCapability: capability.Statements,
+	},
 }
 
 var statementsGetCmd = &cobra.Command{
@@ -44,6 +56,9 @@ var statementsListCmd = &cobra.Command{
 Example:
   payjp statements list --limit 10
   payjp statements list --owner merchant`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
@@ -64,6 +79,7 @@ Example:
 		if sourceTransfer != "" {
 			params.SourceTransfer = payjp.String(sourceTransfer)
 		}
+		params.Tenant = client.TenantParam()
 
 		result, _, err := client.GetStatement().All(&params)
 		if err != nil {
@@ -111,16 +127,267 @@ Example:
 	},
 }
 
+var statementsDownloadCmd = &cobra.Command{
+	Use:   "download [statement_id...]",
+	Short: "Download statements into a directory",
+	Long: `Download statement files into a directory.
+
+Give statement IDs, or --all to download every statement matching the filters
+(--term, --owner, --type, --tenant and the created date range). Files are named
+<owner>_<period>_<type>_<statement_id> so that repeated downloads line up;
+existing files are skipped unless --overwrite is given. Downloads run
+concurrently with a progress bar on stderr.
+
+Example:
+  payjp statements download st_xxxxx
+  payjp statements download --all --term tm_xxxxx --dir ./statements
+  payjp statements download --all --range 2024-04-01..2024-06-30 --owner merchant`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		dir, _ := cmd.Flags().GetString("dir")
+		term, _ := cmd.Flags().GetString("term")
+		owner, _ := cmd.Flags().GetString("owner")
+		statementType, _ := cmd.Flags().GetString("type")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+
+		deadline, err := commandDeadline(cmd)
+		if err != nil {
+			return err
+		}
+		if all == (len(args) > 0) {
+			return i18n.Errorf("specify statement IDs or --all")
+		}
+		if concurrency < 1 {
+			return i18n.Errorf("--concurrency must be at least 1")
+		}
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		var statements []*payjp.StatementResponse
+		if all {
+			params := payjp.StatementListParams{}
+			if term != "" {
+				params.Term = payjp.String(term)
+			}
+			if owner != "" {
+				params.Owner = payjp.String(owner)
+			}
+			if statementType != "" {
+				params.Type = payjp.String(statementType)
+			}
+			params.Tenant = client.TenantParam()
+			if !since.IsZero() {
+				params.Since = payjp.Int(int(since.Unix()))
+			}
+			if !until.IsZero() {
+				params.Until = payjp.Int(int(until.Unix()))
+			}
+			statements, err = fetchAll("statements", func(limit, offset int) ([]*payjp.StatementResponse, bool, error) {
+				params.Limit = payjp.Int(limit)
+				params.Offset = payjp.Int(offset)
+				return client.GetStatement().All(&params)
+			})
+		} else {
+			for _, id := range args {
+				var statement *payjp.StatementResponse
+				if statement, err = client.GetStatement().Retrieve(id); err != nil {
+					break
+				}
+				statements = append(statements, statement)
+			}
+		}
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		results := downloadStatements(statements, dir, concurrency, overwrite, deadline)
+
+		counts := map[string]int{}
+		for _, result := range results {
+			counts[result.Status]++
+		}
+		printStatus("%d statement(s) downloaded to %s, %d skipped, %d failed, %d not started",
+			counts["downloaded"], dir, counts["skipped"], counts["failed"], counts["not_started"])
+
+		if err := outputResult(results); err != nil {
+			return err
+		}
+		if counts["failed"] > 0 {
+			return i18n.Errorf("%d statement(s) failed to download", counts["failed"])
+		}
+		return deadlineError(counts["not_started"], nil)
+	},
+}
+
+// statementDownload is the result of downloading one statement
+type statementDownload struct {
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (statementDownload) TableColumns() []string {
+	return []string{"ID", "File", "Status", "Error"}
+}
+
+// downloadStatements downloads statements concurrently, keeping the input order in the results
+// Statements not started before the deadline are reported as not_started
+func downloadStatements(statements []*payjp.StatementResponse, dir string, concurrency int, overwrite bool, deadline time.Time) []statementDownload {
+	results := make([]statementDownload, len(statements))
+	started := runParallel("Downloading", len(statements), concurrency, deadline, func(index int) {
+		results[index] = downloadStatement(statements[index], dir, overwrite)
+	})
+	for i := started; i < len(statements); i++ {
+		results[i] = statementDownload{ID: statements[i].ID, Status: "not_started"}
+	}
+	return results
+}
+
+// downloadStatement downloads one statement into dir
+func downloadStatement(statement *payjp.StatementResponse, dir string, overwrite bool) statementDownload {
+	result := statementDownload{ID: statement.ID}
+	base := filepath.Join(dir, statementFileName(statement))
+
+	if !overwrite {
+		// A .part file left by an interrupted download is not a downloaded statement
+		for _, ext := range statementExtensions {
+			if _, err := os.Stat(base + ext); err == nil {
+				result.File = base + ext
+				result.Status = "skipped"
+				return result
+			}
+		}
+	}
+
+	printVerbose("Creating download URL for %s", statement.ID)
+	urls, err := statement.StatementUrls()
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	file, err := downloadFile(urls.URL, base)
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	result.File = file
+	result.Status = "downloaded"
+	return result
+}
+
+// statementFileName returns the file name of a statement without extension
+// e.g. merchant_20240601-20240615_sales_st_xxxxx
+func statementFileName(statement *payjp.StatementResponse) string {
+	owner := "merchant"
+	if statement.TenantId != "" {
+		owner = statement.TenantId
+	}
+
+	period := statement.CreatedAt.Local().Format("20060102")
+	if statement.Term != nil && statement.Term.StartAt != nil {
+		period = time.Unix(int64(*statement.Term.StartAt), 0).Local().Format("20060102")
+		if statement.Term.EndAt != nil {
+			period += "-" + time.Unix(int64(*statement.Term.EndAt), 0).Local().Format("20060102")
+		}
+	}
+
+	statementType := statement.Type
+	if statementType == "" {
+		statementType = "statement"
+	}
+	return strings.Join([]string{owner, period, statementType, statement.ID}, "_")
+}
+
+// statementExtensions are the extensions downloadFile gives statement files
+var statementExtensions = []string{".pdf", ".csv", ".zip"}
+
+// statementDownloadTimeout bounds one download so that a stalled connection does not hang the command
+const statementDownloadTimeout = 5 * time.Minute
+
+// downloadFile downloads a URL to base plus an extension from the content type
+// The file is written to a temp file first so that interrupted downloads leave no partial file
+func downloadFile(url, base string) (string, error) {
+	httpClient := client.HTTPClient()
+	httpClient.Timeout = statementDownloadTimeout
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	ext := ".pdf"
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		switch mediaType {
+		case "text/csv":
+			ext = ".csv"
+		case "application/zip":
+			ext = ".zip"
+		}
+	}
+
+	file := base + ext
+	tempFile := file + ".part"
+	f, err := os.Create(tempFile)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tempFile)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tempFile)
+		return "", err
+	}
+	if err := os.Rename(tempFile, file); err != nil {
+		os.Remove(tempFile)
+		return "", err
+	}
+	return file, nil
+}
+
 func init() {
 	rootCmd.AddCommand(statementsCmd)
 
 	statementsCmd.AddCommand(statementsGetCmd)
 	statementsCmd.AddCommand(statementsListCmd)
 	statementsCmd.AddCommand(statementsDownloadUrlCmd)
+	statementsCmd.AddCommand(statementsDownloadCmd)
 
 	// List flags
 	statementsListCmd.Flags().Int("limit", 10, "Number of items to return")
 	statementsListCmd.Flags().Int("offset", 0, "Offset for pagination")
 	statementsListCmd.Flags().String("owner", "", "Filter by owner type (merchant, tenant)")
 	statementsListCmd.Flags().String("source-transfer", "", "Filter by source transfer ID")
+
+	// Download flags
+	statementsDownloadCmd.Flags().Bool("all", false, "Download all statements matching the filters")
+	statementsDownloadCmd.Flags().String("dir", ".", "Directory to save the files in")
+	statementsDownloadCmd.Flags().String("term", "", "Filter by term ID")
+	statementsDownloadCmd.Flags().String("owner", "", "Filter by owner type (merchant, tenant)")
+	statementsDownloadCmd.Flags().String("type", "", "Filter by statement type (e.g. sales, service_fee)")
+	statementsDownloadCmd.Flags().Int("concurrency", 4, "Number of concurrent downloads")
+	statementsDownloadCmd.Flags().Bool("overwrite", false, "Download again even if the file exists")
+	addDeadlineFlag(statementsDownloadCmd)
+	addTimeRangeFlags(statementsDownloadCmd)
 }
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/usage"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// usageCounter counts the API requests of this run until recordUsage saves them
+var usageCounter = usage.NewCounter()
+
+// userAgent returns the User-Agent sent with API requests, e.g.
+// payjp-cli/1.2.0 (linux; amd64) payjp-go/0.0.1 go1.22.1
+func userAgent() string {
+	return fmt.Sprintf("payjp-cli/%s (%s; %s) payjp-go/%s %s",
+		Version, runtime.GOOS, runtime.GOARCH, payjp.Version, runtime.Version())
+}
+
+// recordUsage adds the API requests of this run to the local usage counters
+// Failures are ignored: the counters must never affect the result of a command
+func recordUsage() {
+	counts := usageCounter.Take()
+	if len(counts) == 0 || !config.UsageStatsEnabled() {
+		return
+	}
+	usage.Record(usage.Path(config.ConfigDir()), counts)
+}
+
+// usageRow is the request count of one endpoint in a month
+type usageRow struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	Calls    int    `json:"calls"`
+}
+
+// TableColumns returns the fields shown in list view
+func (usageRow) TableColumns() []string {
+	return []string{"Method", "Endpoint", "Calls"}
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show local statistics of CLI use",
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+}
+
+var statsUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show how many API calls per endpoint the CLI made in a month",
+	Long: `Show how many API requests the CLI made per endpoint in a month, most
+called first. Resource IDs in the paths are shown as {id}.
+
+The counters are kept only in usage.json in the config directory for the
+last 12 months and are never sent anywhere. Retried requests are counted
+once. Turn counting off with stats.usage: false in the config file,
+PAYJP_NO_USAGE_STATS=true or DO_NOT_TRACK=1.
+
+Every API request also carries a User-Agent with the CLI version and the
+OS, which helps PAY.JP support investigate issues.
+
+Example:
+  payjp stats usage
+  payjp stats usage --month 2024-06 -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		month, _ := cmd.Flags().GetString("month")
+		if month == "" {
+			month = usage.Month(time.Now())
+		} else if _, err := time.Parse("2006-01", month); err != nil {
+			return i18n.Errorf("invalid month: %s (use YYYY-MM)", month)
+		}
+
+		stats, err := usage.Load(usage.Path(config.ConfigDir()))
+		if err != nil {
+			return err
+		}
+		if !config.UsageStatsEnabled() {
+			printStatus("Warning: usage counting is turned off; only earlier counts are shown")
+		}
+
+		rows := []usageRow{}
+		total := 0
+		for endpoint, calls := range stats[month] {
+			method, path, _ := strings.Cut(endpoint, " ")
+			rows = append(rows, usageRow{Method: method, Endpoint: path, Calls: calls})
+			total += calls
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Calls != rows[j].Calls {
+				return rows[i].Calls > rows[j].Calls
+			}
+			if rows[i].Endpoint != rows[j].Endpoint {
+				return rows[i].Endpoint < rows[j].Endpoint
+			}
+			return rows[i].Method < rows[j].Method
+		})
+
+		printStatus("%d API calls in %s", total, month)
+		return outputResult(rows)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.AddCommand(statsUsageCmd)
+
+	statsUsageCmd.Flags().String("month", "", "Month to show (YYYY-MM, default is the current month)")
+}
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/payjp/payjp-cli/internal/checkpoint"
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
 	"github.com/payjp/payjp-cli/internal/util"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
@@ -22,9 +26,13 @@ var subscriptionsCreateCmd = &cobra.Command{
 	Short: "Create a new subscription",
 	Long: `Create a new recurring subscription.
 
+--trial-end takes a time relative to now (+14d, +2w, +3h), end-of-month (the
+last second of the current month), a Unix timestamp, RFC3339 or a date
+(YYYY-MM-DD). On update and resume, now ends the trial immediately.
+
 Example:
   payjp subscriptions create --customer cus_xxxxx --plan pln_xxxxx
-  payjp subscriptions create --customer cus_xxxxx --plan pln_xxxxx --trial-end 1640000000`,
+  payjp subscriptions create --customer cus_xxxxx --plan pln_xxxxx --trial-end +14d`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		customer, _ := cmd.Flags().GetString("customer")
 		plan, _ := cmd.Flags().GetString("plan")
@@ -41,11 +49,11 @@ Example:
 			subscription.Prorate = prorate
 		}
 		if trialEnd != "" {
-			ts, err := util.ParseTimestamp(trialEnd)
+			value, err := parseTrialEnd(trialEnd)
 			if err != nil {
 				return err
 			}
-			subscription.TrialEnd = time.Unix(ts, 0)
+			subscription.TrialEnd = value
 		}
 		if metadata != "" {
 			subscription.Metadata = util.ParseMetadata(metadata)
@@ -62,18 +70,25 @@ Example:
 }
 
 var subscriptionsGetCmd = &cobra.Command{
-	Use:   "get <customer_id> <subscription_id>",
+	Use:   "get [customer_id] <subscription_id>",
 	Short: "Get subscription information",
 	Long: `Retrieve information about a specific subscription.
 
+The customer ID is optional.
+
 Example:
+  payjp subscriptions get sub_xxxxx
   payjp subscriptions get cus_xxxxx sub_xxxxx`,
-	Args: cobra.ExactArgs(2),
+	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		customerID := args[0]
-		subscriptionID := args[1]
+		var result *payjp.SubscriptionResponse
+		var err error
 
-		result, err := client.GetSubscription().Retrieve(customerID, subscriptionID)
+		if len(args) == 1 {
+			result, err = client.RetrieveSubscription(args[0])
+		} else {
+			result, err = client.GetSubscription().Retrieve(args[0], args[1])
+		}
 		if err != nil {
 			handleError(err)
 			return nil
@@ -89,10 +104,12 @@ var subscriptionsListCmd = &cobra.Command{
 	Long: `List all subscriptions with optional filters.
 
 Example:
-  payjp subscriptions list --limit 10`,
+  payjp subscriptions list --limit 10
+  payjp subscriptions list --all --output csv > subscriptions.csv`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
+		all, _ := cmd.Flags().GetBool("all")
 
 		caller := client.GetSubscription().List()
 
@@ -103,6 +120,16 @@ Example:
 			caller.Offset(offset)
 		}
 
+		if all {
+			err := streamAll("subscriptions", func(limit, offset int) ([]*payjp.SubscriptionResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, nil)
+			if err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
 		result, _, err := caller.Do()
 		if err != nil {
 			handleError(err)
@@ -118,9 +145,13 @@ var subscriptionsUpdateCmd = &cobra.Command{
 	Short: "Update subscription information",
 	Long: `Update information for a specific subscription.
 
+--trial-end takes the same values as in create; now ends the trial
+immediately.
+
 Example:
   payjp subscriptions update sub_xxxxx --plan pln_new_xxxxx
-  payjp subscriptions update sub_xxxxx --trial-end 1640000000`,
+  payjp subscriptions update sub_xxxxx --trial-end end-of-month
+  payjp subscriptions update sub_xxxxx --trial-end now`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		subscriptionID := args[0]
@@ -139,11 +170,11 @@ Example:
 			subscription.PlanID = plan
 		}
 		if trialEnd != "" {
-			ts, err := util.ParseTimestamp(trialEnd)
+			value, err := parseTrialEnd(trialEnd)
 			if err != nil {
 				return err
 			}
-			subscription.TrialEnd = time.Unix(ts, 0)
+			subscription.TrialEnd = value
 		}
 		if metadata != "" {
 			subscription.Metadata = util.ParseMetadata(metadata)
@@ -187,7 +218,7 @@ var subscriptionsResumeCmd = &cobra.Command{
 
 Example:
   payjp subscriptions resume sub_xxxxx
-  payjp subscriptions resume sub_xxxxx --trial-end 1640000000`,
+  payjp subscriptions resume sub_xxxxx --trial-end +3h`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		subscriptionID := args[0]
@@ -201,11 +232,11 @@ Example:
 			subscription.Prorate = prorate
 		}
 		if trialEnd != "" {
-			ts, err := util.ParseTimestamp(trialEnd)
+			value, err := parseTrialEnd(trialEnd)
 			if err != nil {
 				return err
 			}
-			subscription.TrialEnd = time.Unix(ts, 0)
+			subscription.TrialEnd = value
 		}
 
 		result, err := client.GetSubscription().Resume(subscriptionID, subscription)
@@ -229,6 +260,10 @@ Example:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		subscriptionID := args[0]
 
+		if err := checkProtected(cmd, subscriptionID); err != nil {
+			return err
+		}
+
 		result, err := client.GetSubscription().Cancel(subscriptionID)
 		if err != nil {
 			handleError(err)
@@ -250,6 +285,13 @@ Example:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		subscriptionID := args[0]
 
+		if err := checkProtected(cmd, subscriptionID); err != nil {
+			return err
+		}
+		if err := confirmAction(cmd, fmt.Sprintf("Delete subscription %s?", subscriptionID)); err != nil {
+			return err
+		}
+
 		err := client.GetSubscription().Delete(subscriptionID, payjp.SubscriptionDelete{})
 		if err != nil {
 			handleError(err)
@@ -268,6 +310,464 @@ Example:
 	},
 }
 
+var subscriptionsTrialExtendCmd = &cobra.Command{
+	Use:   "trial-extend <subscription_id> <duration>",
+	Short: "Extend the trial or the current period of a subscription",
+	Long: `Extend a subscription by a duration (e.g. +7d, 2w, 12h) from its current
+trial_end, or from current_period_end when it is not in trial, so that the
+next charge is deferred by that long.
+
+The current and new dates are shown before the change is made. Use
+--dry-run to only show them. The new end is sent as trial_end, so an active
+subscription is in trial until then.
+
+Example:
+  payjp subscriptions trial-extend sub_xxxxx +7d
+  payjp subscriptions trial-extend sub_xxxxx 2w --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subscriptionID := args[0]
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		extension, err := util.ParseDuration(strings.TrimPrefix(args[1], "+"))
+		if err != nil {
+			return err
+		}
+		if extension <= 0 {
+			return i18n.Errorf("duration must be greater than 0")
+		}
+
+		sub, err := client.RetrieveSubscription(subscriptionID)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		preview := trialExtension{
+			Subscription: sub.ID,
+			Status:       string(sub.Status),
+			Extension:    args[1],
+		}
+		switch {
+		case sub.Status == payjp.SubscriptionTrial && sub.TrialEnd != nil:
+			preview.From = "trial_end"
+			preview.CurrentEnd = sub.TrialEndAt
+		case sub.Status == payjp.SubscriptionActive && sub.CurrentPeriodEnd != nil:
+			preview.From = "current_period_end"
+			preview.CurrentEnd = sub.CurrentPeriodEndAt
+		default:
+			return i18n.Errorf("subscription %s is %s; only trial and active subscriptions can be extended", sub.ID, sub.Status)
+		}
+		preview.NewEnd = preview.CurrentEnd.Add(extension)
+
+		printStatus("%s of %s: %s -> %s (the next charge of %s moves to the new date)", preview.From, sub.ID,
+			util.FormatTimestamp(preview.CurrentEnd.Unix()), util.FormatTimestamp(preview.NewEnd.Unix()),
+			util.FormatAmount(sub.Plan.Amount, sub.Plan.Currency))
+		if dryRun {
+			return outputResult(preview)
+		}
+
+		if err := confirmAction(cmd, fmt.Sprintf("Extend %s until %s?", sub.ID, util.FormatTimestamp(preview.NewEnd.Unix()))); err != nil {
+			if err == errAborted {
+				printStatus("Aborted.")
+				return nil
+			}
+			return err
+		}
+
+		result, err := client.GetSubscription().Update(subscriptionID, payjp.Subscription{TrialEnd: preview.NewEnd})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		printSuccess("Extended %s until %s", sub.ID, util.FormatTimestamp(preview.NewEnd.Unix()))
+		return outputResult(result)
+	},
+}
+
+// trialExtension is the preview of subscriptions trial-extend
+type trialExtension struct {
+	Subscription string    `json:"subscription"`
+	Status       string    `json:"status"`
+	From         string    `json:"from"`
+	CurrentEnd   time.Time `json:"current_end"`
+	Extension    string    `json:"extension"`
+	NewEnd       time.Time `json:"new_end"`
+}
+
+var subscriptionsShiftTrialsCmd = &cobra.Command{
+	Use:   "shift-trials",
+	Short: "Move the trial end of every trialing subscription on a plan",
+	Long: `Move trial_end of every subscription in trial on a plan by a duration, e.g.
+when a launch is delayed. A negative duration (-3d) moves the trials earlier;
+a trial that would then end in the past is skipped.
+
+The subscriptions and their current and new trial ends are shown before
+asking for confirmation; --dry-run only shows them. Completed subscriptions
+are recorded in a checkpoint. Running the command again shifts the trials
+again, so resume an interrupted run with --resume instead.
+
+Example:
+  payjp subscriptions shift-trials --plan pln_xxxxx --by 7d --dry-run
+  payjp subscriptions shift-trials --plan pln_xxxxx --by 7d --concurrency 8
+  payjp subscriptions shift-trials --plan pln_xxxxx --by 7d --resume ~/.payjp/checkpoints/subscriptions-shift-trials-20240601-120000.jsonl`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		planID, _ := cmd.Flags().GetString("plan")
+		by, _ := cmd.Flags().GetString("by")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		shift, err := util.ParseDuration(strings.TrimPrefix(by, "+"))
+		if err != nil {
+			return err
+		}
+		if shift == 0 {
+			return i18n.Errorf("--by must not be 0")
+		}
+		if concurrency < 1 {
+			return i18n.Errorf("--concurrency must be at least 1")
+		}
+		deadline, err := commandDeadline(cmd)
+		if err != nil {
+			return err
+		}
+
+		trial := payjp.SubscriptionTrial
+		subscriptions, err := fetchSubscriptions(func(params *payjp.SubscriptionListParams) {
+			params.Plan = payjp.String(planID)
+			params.Status = &trial
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		var cp *checkpoint.Checkpoint
+		if !dryRun {
+			if cp, err = openCheckpoint(cmd); err != nil {
+				return err
+			}
+			defer cp.Close()
+		}
+
+		now := time.Now()
+		results := make([]trialShift, 0, len(subscriptions))
+		pending := []int{}
+		for _, sub := range subscriptions {
+			result := trialShift{Subscription: sub.ID, Customer: sub.Customer}
+			switch {
+			case sub.Status != payjp.SubscriptionTrial || sub.TrialEnd == nil:
+				result.Status = "skipped"
+				result.Detail = "not in trial"
+			case cp != nil && cp.Done(sub.ID):
+				result.Status = "skipped"
+				result.Detail = "completed in checkpoint"
+			default:
+				result.CurrentTrialEnd = sub.TrialEndAt
+				result.NewTrialEnd = sub.TrialEndAt.Add(shift)
+				result.Status = "pending"
+				if !result.NewTrialEnd.After(now) {
+					result.Status = "skipped"
+					result.Detail = "new trial end is in the past"
+				}
+			}
+			if result.Status == "pending" {
+				pending = append(pending, len(results))
+			}
+			results = append(results, result)
+		}
+
+		if dryRun {
+			for _, i := range pending {
+				results[i].Status = "dry-run"
+			}
+			return outputResult(results)
+		}
+
+		if len(pending) > 0 {
+			printStatus("Trials to shift by %s:", by)
+			for _, i := range pending {
+				printStatus("  %s  %s -> %s", results[i].Subscription,
+					util.FormatTimestamp(results[i].CurrentTrialEnd.Unix()), util.FormatTimestamp(results[i].NewTrialEnd.Unix()))
+			}
+			if err := confirmAction(cmd, fmt.Sprintf("Shift the trial end of %d subscription(s) on %s by %s?", len(pending), planID, by)); err != nil {
+				if err == errAborted {
+					printStatus("Aborted.")
+					return nil
+				}
+				return err
+			}
+
+			started := runParallel("Shifting", len(pending), concurrency, deadline, func(index int) {
+				i := pending[index]
+				results[i] = shiftTrial(results[i], cp)
+			})
+			for _, i := range pending[started:] {
+				results[i].Status = "not_started"
+				results[i].Detail = "deadline reached"
+			}
+		} else {
+			printStatus("No trialing subscriptions on %s to shift", planID)
+		}
+
+		counts := map[string]int{}
+		for _, result := range results {
+			counts[result.Status]++
+		}
+		printStatus("%d shifted, %d skipped, %d failed, %d not started",
+			counts["shifted"], counts["skipped"], counts["failed"], counts["not_started"])
+
+		if err := outputResult(results); err != nil {
+			return err
+		}
+		if counts["failed"] > 0 {
+			return i18n.Errorf("%d subscription(s) failed to shift", counts["failed"])
+		}
+		return deadlineError(counts["not_started"], cp)
+	},
+}
+
+// trialShift is the outcome of subscriptions shift-trials for a subscription
+type trialShift struct {
+	Subscription    string    `json:"subscription"`
+	Customer        string    `json:"customer"`
+	CurrentTrialEnd time.Time `json:"current_trial_end"`
+	NewTrialEnd     time.Time `json:"new_trial_end"`
+	Status          string    `json:"status"`
+	Detail          string    `json:"detail,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (trialShift) TableColumns() []string {
+	return []string{"Subscription", "Customer", "CurrentTrialEnd", "NewTrialEnd", "Status", "Detail"}
+}
+
+// shiftTrial sets the new trial end of one subscription
+func shiftTrial(result trialShift, cp *checkpoint.Checkpoint) trialShift {
+	printVerbose("Moving the trial end of %s to %s", result.Subscription, result.NewTrialEnd.Format(time.RFC3339))
+	_, err := client.GetSubscription().Update(result.Subscription, payjp.Subscription{TrialEnd: result.NewTrialEnd})
+	if err != nil {
+		result.Status = "failed"
+		result.Detail = errorDetail(err)
+		return result
+	}
+	result.Status = "shifted"
+	if err := cp.Mark(result.Subscription); err != nil {
+		result.Status = "failed"
+		result.Detail = err.Error()
+	}
+	return result
+}
+
+var subscriptionsCalendarCmd = &cobra.Command{
+	Use:   "calendar",
+	Short: "List expected billing dates in a month",
+	Long: `List the expected billing dates and amounts of active and trialing
+subscriptions in a month.
+
+Renewals are simulated from the end of the current period: subscriptions in
+trial are first billed when the trial ends, plans with a billing_day renew on
+that day, and other plans renew on the same day of each interval (the last day
+of the month if the day does not exist). A scheduled plan change
+(next_cycle_plan) applies from the next renewal. Paused and canceled
+subscriptions are not billed.
+
+Example:
+  payjp subscriptions calendar
+  payjp subscriptions calendar --month 2024-08
+  payjp subscriptions calendar --month 2024-08 --by-day -o csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monthArg, _ := cmd.Flags().GetString("month")
+		byDay, _ := cmd.Flags().GetBool("by-day")
+
+		start, end, err := calendarMonth(monthArg, time.Now())
+		if err != nil {
+			return err
+		}
+
+		subscriptions, err := fetchAll("subscriptions", func(limit, offset int) ([]*payjp.SubscriptionResponse, bool, error) {
+			return client.GetSubscription().List().Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		plans, err := newPlanCache()
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		rows := []billingRow{}
+		for _, sub := range subscriptions {
+			if sub.Status != payjp.SubscriptionActive && sub.Status != payjp.SubscriptionTrial {
+				continue
+			}
+
+			plan, err := plans.get(sub.Plan.ID, sub.Plan)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			next := plan
+			if sub.NextCyclePlan != nil && sub.NextCyclePlan.ID != "" {
+				if next, err = plans.get(sub.NextCyclePlan.ID, *sub.NextCyclePlan); err != nil {
+					handleError(err)
+					return nil
+				}
+			}
+
+			rows = append(rows, simulateBilling(sub, next, start, end)...)
+		}
+
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i].Date != rows[j].Date {
+				return rows[i].Date < rows[j].Date
+			}
+			return rows[i].SubscriptionID < rows[j].SubscriptionID
+		})
+
+		if byDay {
+			return outputResult(billingByDay(rows))
+		}
+		return outputResult(rows)
+	},
+}
+
+// billingRow is an expected billing of a subscription
+type billingRow struct {
+	Date           string `json:"date"`
+	SubscriptionID string `json:"subscription_id"`
+	Customer       string `json:"customer"`
+	PlanID         string `json:"plan_id"`
+	Currency       string `json:"currency"`
+	Amount         int    `json:"amount"`
+	TrialEnd       bool   `json:"trial_end"`
+}
+
+// TableColumns returns the fields shown in list view
+func (billingRow) TableColumns() []string {
+	return []string{"Date", "SubscriptionID", "Customer", "PlanID", "Currency", "Amount", "TrialEnd"}
+}
+
+// billingDayRow is the total of expected billings on a day
+type billingDayRow struct {
+	Date          string `json:"date"`
+	Currency      string `json:"currency"`
+	Subscriptions int    `json:"subscriptions"`
+	Amount        int    `json:"amount"`
+}
+
+// TableColumns returns the fields shown in list view
+func (billingDayRow) TableColumns() []string {
+	return []string{"Date", "Currency", "Subscriptions", "Amount"}
+}
+
+// calendarMonth returns the start and end of a month given as YYYY-MM in local time
+// An empty month is the current month
+func calendarMonth(month string, now time.Time) (time.Time, time.Time, error) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if month != "" {
+		t, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, i18n.Errorf("invalid month: %s (use YYYY-MM)", month)
+		}
+		start = t
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// simulateBilling returns the expected billings of a subscription in [start, end)
+// The first billing is at the end of the current period, billed with the next cycle plan
+func simulateBilling(sub *payjp.SubscriptionResponse, plan *payjp.PlanResponse, start, end time.Time) []billingRow {
+	rows := []billingRow{}
+	first := sub.CurrentPeriodEndAt
+	if sub.CurrentPeriodEnd == nil || first.Unix() <= 0 {
+		return rows
+	}
+	first = first.Local()
+
+	months := 1
+	if plan.Interval == "year" {
+		months = 12
+	}
+	day := first.Day()
+	if plan.BillingDay > 0 {
+		day = plan.BillingDay
+	}
+
+	for n := 0; ; n++ {
+		date := first
+		if n > 0 {
+			date = addMonthsClamped(first, n*months, day)
+		}
+		if !date.Before(end) {
+			break
+		}
+		if date.Before(start) {
+			continue
+		}
+		rows = append(rows, billingRow{
+			Date:           formatDate(date),
+			SubscriptionID: sub.ID,
+			Customer:       sub.Customer,
+			PlanID:         plan.ID,
+			Currency:       plan.Currency,
+			Amount:         plan.Amount,
+			TrialEnd:       n == 0 && sub.Status == payjp.SubscriptionTrial,
+		})
+	}
+	return rows
+}
+
+// addMonthsClamped adds months to t and sets the day, clamped to the last day of the month
+func addMonthsClamped(t time.Time, months, day int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	last := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// billingByDay totals billings per date and currency
+func billingByDay(rows []billingRow) []billingDayRow {
+	result := []billingDayRow{}
+	index := map[string]int{}
+	for _, row := range rows {
+		key := row.Date + "/" + row.Currency
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, billingDayRow{Date: row.Date, Currency: row.Currency})
+		}
+		result[i].Subscriptions++
+		result[i].Amount += row.Amount
+	}
+	return result
+}
+
+// trialEndUsage is the help of the --trial-end flags
+const trialEndUsage = "Trial end (Unix timestamp, RFC3339, YYYY-MM-DD, +14d, +3h, now or end-of-month)"
+
+// parseTrialEnd converts a --trial-end value to the trial_end parameter
+// now is sent as the API keyword, which ends the trial immediately
+func parseTrialEnd(s string) (interface{}, error) {
+	if strings.TrimSpace(s) == "now" {
+		return "now", nil
+	}
+	t, err := util.ParseRelativeTime(s, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Trial ends at %s", t.Format(time.RFC3339))
+	return t, nil
+}
+
 func init() {
 	rootCmd.AddCommand(subscriptionsCmd)
 
@@ -279,11 +779,14 @@ func init() {
 	subscriptionsCmd.AddCommand(subscriptionsResumeCmd)
 	subscriptionsCmd.AddCommand(subscriptionsCancelCmd)
 	subscriptionsCmd.AddCommand(subscriptionsDeleteCmd)
+	subscriptionsCmd.AddCommand(subscriptionsCalendarCmd)
+	subscriptionsCmd.AddCommand(subscriptionsTrialExtendCmd)
+	subscriptionsCmd.AddCommand(subscriptionsShiftTrialsCmd)
 
 	// Create flags
 	subscriptionsCreateCmd.Flags().String("customer", "", "Customer ID (required)")
 	subscriptionsCreateCmd.Flags().String("plan", "", "Plan ID (required)")
-	subscriptionsCreateCmd.Flags().String("trial-end", "", "Trial end timestamp (Unix timestamp or RFC3339)")
+	subscriptionsCreateCmd.Flags().String("trial-end", "", trialEndUsage)
 	subscriptionsCreateCmd.Flags().Bool("prorate", false, "Prorate charges")
 	subscriptionsCreateCmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
 	subscriptionsCreateCmd.MarkFlagRequired("customer")
@@ -292,14 +795,38 @@ func init() {
 	// List flags
 	subscriptionsListCmd.Flags().Int("limit", 10, "Number of items to return")
 	subscriptionsListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	subscriptionsListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
 
 	// Update flags
 	subscriptionsUpdateCmd.Flags().String("plan", "", "New plan ID")
-	subscriptionsUpdateCmd.Flags().String("trial-end", "", "Trial end timestamp (Unix timestamp or RFC3339)")
+	subscriptionsUpdateCmd.Flags().String("trial-end", "", trialEndUsage)
 	subscriptionsUpdateCmd.Flags().Bool("prorate", false, "Prorate charges")
 	subscriptionsUpdateCmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
 
+	// Trial extend flags
+	subscriptionsTrialExtendCmd.Flags().Bool("dry-run", false, "Show the current and new dates without changing the subscription")
+
+	// Shift trials flags
+	subscriptionsShiftTrialsCmd.Flags().String("plan", "", "Plan whose trialing subscriptions are shifted (required)")
+	subscriptionsShiftTrialsCmd.Flags().String("by", "", "Duration to move the trial ends by, e.g. 7d, 2w or -3d (required)")
+	subscriptionsShiftTrialsCmd.Flags().Int("concurrency", 4, "Number of concurrent requests")
+	subscriptionsShiftTrialsCmd.Flags().Bool("dry-run", false, "Show the current and new trial ends without changing the subscriptions")
+	subscriptionsShiftTrialsCmd.MarkFlagRequired("plan")
+	subscriptionsShiftTrialsCmd.MarkFlagRequired("by")
+	addCheckpointFlags(subscriptionsShiftTrialsCmd)
+	addDeadlineFlag(subscriptionsShiftTrialsCmd)
+
+	// Calendar flags
+	subscriptionsCalendarCmd.Flags().String("month", "", "Month to simulate (YYYY-MM, default is the current month)")
+	subscriptionsCalendarCmd.Flags().Bool("by-day", false, "Show totals per day instead of each subscription")
+
 	// Resume flags
-	subscriptionsResumeCmd.Flags().String("trial-end", "", "Trial end timestamp (Unix timestamp or RFC3339)")
+	subscriptionsResumeCmd.Flags().String("trial-end", "", trialEndUsage)
 	subscriptionsResumeCmd.Flags().Bool("prorate", false, "Prorate charges")
+
+	// Cancel flags
+	addForceFlag(subscriptionsCancelCmd)
+
+	// Delete flags
+	addForceFlag(subscriptionsDeleteCmd)
 }
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/payjp/payjp-cli/internal/syncstate"
+	"github.com/payjp/payjp-cli/internal/upload"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Incrementally export resources",
+	Long:  `Append resources created since the last run to a file, e.g. to feed a data warehouse.`,
+}
+
+var syncChargesCmd = &cobra.Command{
+	Use:   "charges",
+	Short: "Append new charges to an NDJSON file",
+	Long: `Fetch the charges created since the last run and append them to an NDJSON file.
+
+The state file remembers the creation time and IDs of the last synced charges,
+so each run only fetches new charges. On the first run every charge is
+fetched, or only those created since --since. Charges are appended in
+ascending creation order; --fields selects the fields that are written.
+
+The output file is written before the state file, so an interrupted run may
+append a charge again on the next run but never skips one; deduplicate on id
+when loading.
+
+With --upload the new charges of each run are also uploaded as one object to
+S3 (s3://) or Google Cloud Storage (gs://), with or without --out. End the
+URL with / so that every run gets its own object named with the current
+time. Credentials are read from the standard AWS and Google Cloud
+environment (see payjp events export --help). The state is only saved after
+a successful upload.
+
+Example:
+  payjp sync charges --state .payjp-sync.json --out charges.ndjson
+  payjp sync charges --out charges.ndjson --since 2024-01-01
+  payjp sync charges --state charges.state.json --out - | gzip >> charges.ndjson.gz
+  payjp sync charges --upload gs://datalake/payjp/charges/`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statePath, _ := cmd.Flags().GetString("state")
+		out, _ := cmd.Flags().GetString("out")
+		sinceArg, _ := cmd.Flags().GetString("since")
+
+		target, err := uploadTarget(cmd, uploadName("charges", "ndjson"))
+		if err != nil {
+			return err
+		}
+		if out == "" && target == nil {
+			return i18n.Errorf("specify --out or --upload")
+		}
+
+		state, err := syncstate.Load(statePath, "charges")
+		if err != nil {
+			return err
+		}
+
+		var since int64
+		if !state.IsNew() {
+			// Go back a second so that charges created in the same second as the last one are seen
+			since = state.LastCreated - 1
+		} else if sinceArg != "" {
+			if since, err = util.ParseTimestamp(sinceArg); err != nil {
+				return err
+			}
+		}
+		// Fix the end of the window so that paging is not shifted by charges created meanwhile
+		until := time.Now()
+
+		caller := client.GetCharge().List()
+		if since > 0 {
+			caller.Since(time.Unix(since, 0))
+		}
+		caller.Until(until)
+
+		charges, err := fetchAll("charges", func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+			return caller.Limit(limit).Offset(offset).Do()
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		// Charges are listed newest first
+		slices.Reverse(charges)
+		pending := make([]*payjp.ChargeResponse, 0, len(charges))
+		for _, charge := range charges {
+			if !state.Synced(chargeCreated(charge), charge.ID) {
+				pending = append(pending, charge)
+			}
+		}
+		slices.SortStableFunc(pending, func(a, b *payjp.ChargeResponse) int {
+			return int(chargeCreated(a) - chargeCreated(b))
+		})
+
+		if len(pending) == 0 {
+			printStatus("No new charges since %s", state.SyncedAt.Local().Format(time.RFC3339))
+		} else {
+			if out != "" {
+				if err := appendNDJSON(out, pending); err != nil {
+					return err
+				}
+			}
+			// The state is only saved after the upload, so a failed upload is retried on the next run
+			if target != nil {
+				if err := uploadNDJSON(target, pending); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, charge := range pending {
+			state.Advance(chargeCreated(charge), charge.ID)
+		}
+		state.SyncedAt = until
+		if err := state.Save(statePath); err != nil {
+			return err
+		}
+
+		if len(pending) > 0 && out != "" {
+			printSuccess("%d new charge(s) appended to %s (%d synced in total)", len(pending), out, state.Total)
+		}
+		return nil
+	},
+}
+
+// uploadNDJSON uploads the items as one NDJSON object
+func uploadNDJSON(target *upload.Target, items interface{}) error {
+	f, err := os.CreateTemp("", "payjp-upload-*.ndjson")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	if err := appendNDJSON(path, items); err != nil {
+		return err
+	}
+	return uploadFile(target, path, "application/x-ndjson")
+}
+
+// chargeCreated returns the creation timestamp of a charge
+func chargeCreated(charge *payjp.ChargeResponse) int64 {
+	if charge.Created != nil {
+		return int64(*charge.Created)
+	}
+	return charge.CreatedAt.Unix()
+}
+
+// appendNDJSON appends the items as JSON lines to path, or writes them to stdout for "-"
+// The file is synced before returning so that the state is only saved after the data is on disk
+func appendNDJSON(path string, items interface{}) error {
+	data, err := projectOutput(string(output.FormatNDJSON), items)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return output.NewNDJSONWriter(os.Stdout).WritePage(data)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+	if err := output.NewNDJSONWriter(f).WritePage(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return f.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.AddCommand(syncChargesCmd)
+
+	// Charges flags
+	syncChargesCmd.Flags().String("state", ".payjp-sync.json", "State file recording the last synced charge")
+	syncChargesCmd.Flags().String("out", "", "NDJSON file to append to (- for stdout)")
+	syncChargesCmd.Flags().String("since", "", "On the first run, only sync charges created since this time (Unix timestamp, RFC3339 or YYYY-MM-DD)")
+	addUploadFlag(syncChargesCmd)
+}
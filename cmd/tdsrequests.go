@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"slices"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// tdsRequestPendingStates are the states of a 3D Secure request whose authentication has not finished
+var tdsRequestPendingStates = []string{"created", "in_progress"}
+
+var tdsRequestsCmd = &cobra.Command{
+	Use:     "three-d-secure-requests",
+	Aliases: []string{"three-d-secure-request", "tds-requests", "tds-request"},
+	Short:   "Manage 3D Secure requests",
+	Long: `Create, retrieve and list 3D Secure requests, which authenticate a
+customer's card with 3D Secure without a charge.`,
+}
+
+var tdsRequestsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a 3D Secure request",
+	Long: `Create a 3D Secure request for a customer's card. Open the authentication
+page of the request in the customer's browser to start the authentication.
+
+Example:
+  payjp tds-requests create --card car_xxxxx
+  payjp tds-requests create --card car_xxxxx --tenant ten_xxxxx`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		card, _ := cmd.Flags().GetString("card")
+
+		result, err := client.GetThreeDSecureRequest().Create(payjp.ThreeDSecureRequest{
+			ResourceID: card,
+			TenantID:   client.TenantParam(),
+		})
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tdsRequestsGetCmd = &cobra.Command{
+	Use:   "get <three_d_secure_request_id>",
+	Short: "Get 3D Secure request information",
+	Long: `Retrieve information about a specific 3D Secure request.
+
+With --wait the request is polled until its authentication has finished
+(the state is no longer created or in_progress) or --timeout has passed.
+
+Example:
+  payjp tds-requests get tdsr_xxxxx
+  payjp tds-requests get tdsr_xxxxx --wait --timeout 5m`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		requestID := args[0]
+		wait, _ := cmd.Flags().GetBool("wait")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		if wait && interval <= 0 {
+			return i18n.Errorf("--interval must be greater than 0")
+		}
+
+		result, err := client.GetThreeDSecureRequest().Retrieve(requestID)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		deadline := time.Now().Add(timeout)
+		state := result.State
+		for wait && slices.Contains(tdsRequestPendingStates, result.State) {
+			if time.Now().Add(interval).After(deadline) {
+				return i18n.Errorf("timed out waiting for %s (state: %s)", requestID, result.State)
+			}
+			time.Sleep(interval)
+
+			result, err = client.GetThreeDSecureRequest().Retrieve(requestID)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			if result.State != state {
+				printStatus("%s: %s -> %s", requestID, state, result.State)
+				state = result.State
+			}
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tdsRequestsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List 3D Secure requests",
+	Long: `List 3D Secure requests with optional filters.
+
+Example:
+  payjp tds-requests list --limit 10
+  payjp tds-requests list --card car_xxxxx
+  payjp tds-requests list --all --since 2024-06-01 --output csv > tds_requests.csv`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		offset, _ := cmd.Flags().GetInt("offset")
+		all, _ := cmd.Flags().GetBool("all")
+		card, _ := cmd.Flags().GetString("card")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		params := payjp.ThreeDSecureRequestListParams{}
+		// Note: int conversion is safe for Unix timestamps (valid until year 2038 on 32-bit)
+		// The PAY.JP API expects int values for timestamps
+		if !since.IsZero() {
+			params.Since = payjp.Int(int(since.Unix()))
+		}
+		if !until.IsZero() {
+			params.Until = payjp.Int(int(until.Unix()))
+		}
+		if card != "" {
+			params.ResourceID = payjp.String(card)
+		}
+		params.TenantID = client.TenantParam()
+		fetch := func(limit, offset int) ([]*payjp.ThreeDSecureRequestResponse, bool, error) {
+			if limit > 0 {
+				params.Limit = payjp.Int(limit)
+			}
+			if offset > 0 {
+				params.Offset = payjp.Int(offset)
+			}
+			return client.GetThreeDSecureRequest().All(&params)
+		}
+
+		if all {
+			if err := streamAll("3D Secure requests", fetch, nil); err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
+		result, _, err := fetch(limit, offset)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tdsRequestsCmd)
+
+	tdsRequestsCmd.AddCommand(tdsRequestsCreateCmd)
+	tdsRequestsCmd.AddCommand(tdsRequestsGetCmd)
+	tdsRequestsCmd.AddCommand(tdsRequestsListCmd)
+
+	// Create flags
+	tdsRequestsCreateCmd.Flags().String("card", "", "ID of the customer's card to authenticate")
+	tdsRequestsCreateCmd.MarkFlagRequired("card")
+
+	// Get flags
+	tdsRequestsGetCmd.Flags().Bool("wait", false, "Poll until the authentication has finished")
+	tdsRequestsGetCmd.Flags().Duration("timeout", 10*time.Minute, "Time limit for --wait")
+	tdsRequestsGetCmd.Flags().Duration("interval", 5*time.Second, "Time between polls with --wait")
+
+	// List flags
+	tdsRequestsListCmd.Flags().Int("limit", 10, "Number of items to return")
+	tdsRequestsListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	tdsRequestsListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	tdsRequestsListCmd.Flags().String("card", "", "Filter by the ID of the customer's card")
+	addTimeRangeFlags(tdsRequestsListCmd)
+}
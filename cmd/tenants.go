@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/capability"
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var tenantsCmd = &cobra.Command{
+	Use:     "tenants",
+	Aliases: []string{"tenant"},
+	Short:   "Manage PAY.JP Platform tenants",
+	Long: `Create, retrieve, list, update and delete the tenants (merchants) of a
+PAY.JP Platform account, and issue the URLs where tenants apply for live mode.`,
+	Annotations: map[string]string{
+		annotationCapability: capability.Tenants,
+	},
+}
+
+var tenantsCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a tenant",
+	Long: `Create a new tenant.
+
+Example:
+  payjp tenants create --name "Shop A" --platform-fee-rate 10.15
+  payjp tenants create --id shop_a --name "Shop A" --platform-fee-rate 10 --payjp-fee-included
+  payjp tenants create --name "Shop A" --platform-fee-rate 10 --minimum-transfer-amount 5000 \
+    --bank-code 0001 --bank-branch-code 001 --bank-account-type 普通 \
+    --bank-account-number 0001000 --bank-account-holder-name "ｼﾖﾂﾌﾟ ｴｰ"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		params := tenantParams(cmd)
+		if id, _ := cmd.Flags().GetString("id"); id != "" {
+			params.Set("id", id)
+		}
+		if cmd.Flags().Changed("payjp-fee-included") {
+			included, _ := cmd.Flags().GetBool("payjp-fee-included")
+			params.Set("payjp_fee_included", strconv.FormatBool(included))
+		}
+
+		result, err := client.CreateTenant(params)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tenantsGetCmd = &cobra.Command{
+	Use:   "get <tenant_id>",
+	Short: "Get tenant information",
+	Long: `Retrieve information about a specific tenant.
+
+Example:
+  payjp tenants get ten_xxxxx`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tenantID := args[0]
+
+		result, err := client.RetrieveTenant(tenantID)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tenantsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tenants",
+	Long: `List all tenants with optional filters.
+
+Example:
+  payjp tenants list --limit 10
+  payjp tenants list --all --output csv > tenants.csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		offset, _ := cmd.Flags().GetInt("offset")
+		all, _ := cmd.Flags().GetBool("all")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		params := url.Values{}
+		if !since.IsZero() {
+			params.Set("since", strconv.FormatInt(since.Unix(), 10))
+		}
+		if !until.IsZero() {
+			params.Set("until", strconv.FormatInt(until.Unix(), 10))
+		}
+		fetch := func(limit, offset int) ([]*client.TenantResponse, bool, error) {
+			if limit > 0 {
+				params.Set("limit", strconv.Itoa(limit))
+			}
+			if offset > 0 {
+				params.Set("offset", strconv.Itoa(offset))
+			}
+			return client.ListTenants(params)
+		}
+
+		if all {
+			if err := streamAll("tenants", fetch, nil); err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
+		result, _, err := fetch(limit, offset)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tenantsUpdateCmd = &cobra.Command{
+	Use:   "update <tenant_id>",
+	Short: "Update a tenant",
+	Long: `Update a tenant. Only the given fields are changed.
+
+Example:
+  payjp tenants update ten_xxxxx --name "Shop A (new)"
+  payjp tenants update ten_xxxxx --platform-fee-rate 12 --metadata contract=2024`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tenantID := args[0]
+
+		result, err := client.UpdateTenant(tenantID, tenantParams(cmd))
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tenantsDeleteCmd = &cobra.Command{
+	Use:   "delete <tenant_id>",
+	Short: "Delete a tenant",
+	Long: `Delete a specific tenant.
+
+Example:
+  payjp tenants delete ten_xxxxx`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tenantID := args[0]
+
+		if err := checkProtected(cmd, tenantID); err != nil {
+			return err
+		}
+		if err := confirmDelete(cmd, "tenant", tenantID, previewTenant); err != nil {
+			return err
+		}
+
+		if err := client.DeleteTenant(tenantID); err != nil {
+			handleError(err)
+			return nil
+		}
+
+		if quiet {
+			return nil
+		}
+
+		return outputResult(map[string]interface{}{
+			"id":      tenantID,
+			"deleted": true,
+		})
+	},
+}
+
+var tenantsApplicationURLsCmd = &cobra.Command{
+	Use:   "application-urls <tenant_id>",
+	Short: "Issue a URL where a tenant applies for live mode",
+	Long: `Issue a URL where the tenant submits its application for live mode
+(the review of the merchant). Send the URL to the tenant before it expires.
+
+Example:
+  payjp tenants application-urls ten_xxxxx`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tenantID := args[0]
+
+		result, err := client.CreateTenantApplicationURL(tenantID)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+// tenantParams returns the request parameters of the tenant fields given on the command line
+func tenantParams(cmd *cobra.Command) url.Values {
+	params := url.Values{}
+	for _, name := range []string{"name", "platform-fee-rate", "bank-code", "bank-branch-code",
+		"bank-account-type", "bank-account-number", "bank-account-holder-name"} {
+		if cmd.Flags().Changed(name) {
+			value, _ := cmd.Flags().GetString(name)
+			params.Set(strings.ReplaceAll(name, "-", "_"), value)
+		}
+	}
+	if cmd.Flags().Changed("minimum-transfer-amount") {
+		amount, _ := cmd.Flags().GetInt("minimum-transfer-amount")
+		params.Set("minimum_transfer_amount", strconv.Itoa(amount))
+	}
+	if metadata, _ := cmd.Flags().GetString("metadata"); metadata != "" {
+		for key, value := range util.ParseMetadata(metadata) {
+			params.Set("metadata["+key+"]", value)
+		}
+	}
+	return params
+}
+
+// previewTenant summarizes a tenant
+func previewTenant(id string) ([]string, error) {
+	tenant, err := client.RetrieveTenant(id)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{fmt.Sprintf("Tenant %s", tenant.ID)}
+	lines = append(lines, fmt.Sprintf("  Name:           %s", valueOrNone(tenant.Name)))
+	lines = append(lines, fmt.Sprintf("  Platform fee:   %s%%", tenant.PlatformFeeRate))
+	lines = append(lines, fmt.Sprintf("  Created:        %s", util.FormatTimestamp(int64(tenant.Created))))
+	return lines, nil
+}
+
+// addTenantFlags adds the flags of the tenant fields that can be created and updated
+func addTenantFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name", "", "Tenant name")
+	cmd.Flags().String("platform-fee-rate", "", "Platform fee rate in percent (e.g. 10.15)")
+	cmd.Flags().Int("minimum-transfer-amount", 0, "Minimum amount transferred to the tenant")
+	cmd.Flags().String("bank-code", "", "Bank code (4 digits)")
+	cmd.Flags().String("bank-branch-code", "", "Bank branch code (3 digits)")
+	cmd.Flags().String("bank-account-type", "", "Bank account type (普通 or 当座)")
+	cmd.Flags().String("bank-account-number", "", "Bank account number (7 digits)")
+	cmd.Flags().String("bank-account-holder-name", "", "Bank account holder name (half-width katakana)")
+	cmd.Flags().String("metadata", "", "Metadata (key1=value1,key2=value2)")
+}
+
+func init() {
+	rootCmd.AddCommand(tenantsCmd)
+
+	tenantsCmd.AddCommand(tenantsCreateCmd)
+	tenantsCmd.AddCommand(tenantsGetCmd)
+	tenantsCmd.AddCommand(tenantsListCmd)
+	tenantsCmd.AddCommand(tenantsUpdateCmd)
+	tenantsCmd.AddCommand(tenantsDeleteCmd)
+	tenantsCmd.AddCommand(tenantsApplicationURLsCmd)
+
+	// Create flags
+	tenantsCreateCmd.Flags().String("id", "", "Tenant ID (generated if omitted)")
+	tenantsCreateCmd.Flags().Bool("payjp-fee-included", false, "Include the PAY.JP fee in the platform fee")
+	addTenantFlags(tenantsCreateCmd)
+	tenantsCreateCmd.MarkFlagRequired("name")
+	tenantsCreateCmd.MarkFlagRequired("platform-fee-rate")
+
+	// List flags
+	tenantsListCmd.Flags().Int("limit", 10, "Number of items to return")
+	tenantsListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	tenantsListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	addTimeRangeFlags(tenantsListCmd)
+
+	// Update flags
+	addTenantFlags(tenantsUpdateCmd)
+
+	// Delete flags
+	addForceFlag(tenantsDeleteCmd)
+}
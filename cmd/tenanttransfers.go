@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/capability"
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// tenantTransferStatuses are the statuses of a transfer to a tenant
+var tenantTransferStatuses = []string{"pending", "paid", "failed", "stop", "carried_over", "recombination"}
+
+var tenantTransfersCmd = &cobra.Command{
+	Use:     "tenant-transfers",
+	Aliases: []string{"tenant-transfer"},
+	Short:   "Inspect transfers to PAY.JP Platform tenants",
+	Long:    `Retrieve and list the transfers (payouts) of a PAY.JP Platform account to its tenants.`,
+	Annotations: map[string]string{
+		annotationCapability: capability.TenantTransfers,
+	},
+}
+
+var tenantTransfersGetCmd = &cobra.Command{
+	Use:   "get <tenant_transfer_id>",
+	Short: "Get tenant transfer information",
+	Long: `Retrieve information about a specific transfer to a tenant.
+
+Example:
+  payjp tenant-transfers get ten_tr_xxxxx`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		transferID := args[0]
+
+		result, err := client.RetrieveTenantTransfer(transferID)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+var tenantTransfersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tenant transfers",
+	Long: `List the transfers to all tenants, or with --tenant to one tenant.
+
+Example:
+  payjp tenant-transfers list --limit 10
+  payjp tenant-transfers list --tenant ten_xxxxx --status paid
+  payjp tenant-transfers list --all --since 2024-06-01 --output csv > tenant_transfers.csv`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		offset, _ := cmd.Flags().GetInt("offset")
+		all, _ := cmd.Flags().GetBool("all")
+		status, _ := cmd.Flags().GetString("status")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		params := tenantTransferParams(since, until)
+		if status != "" {
+			params.Set("status", status)
+		}
+		return listTenantTransfers(client.Tenant(), params, limit, offset, all)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tenantTransfersCmd)
+
+	tenantTransfersCmd.AddCommand(tenantTransfersGetCmd)
+	tenantTransfersCmd.AddCommand(tenantTransfersListCmd)
+
+	// List flags
+	tenantTransfersListCmd.Flags().Int("limit", 10, "Number of items to return")
+	tenantTransfersListCmd.Flags().Int("offset", 0, "Offset for pagination")
+	tenantTransfersListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	tenantTransfersListCmd.Flags().String("status", "", "Filter by status ("+strings.Join(tenantTransferStatuses, ", ")+")")
+	addTimeRangeFlags(tenantTransfersListCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/payjp/payjp-cli/internal/capability"
 	"github.com/payjp/payjp-cli/internal/client"
 	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
@@ -11,6 +12,9 @@ var termsCmd = &cobra.Command{
 	Aliases: []string{"term"},
 	Short:   "Manage terms",
 	Long:    `Retrieve and list aggregation terms (billing periods).`,
+	Annotations: map[string]string{
+		annotationCapability: capability.Terms,
+	},
 }
 
 var termsGetCmd = &cobra.Command{
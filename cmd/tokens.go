@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
 
@@ -35,8 +38,95 @@ Example:
 	},
 }
 
+var tokensInspectCmd = &cobra.Command{
+	Use:   "inspect <token_id>",
+	Short: "Inspect a token's card",
+	Long: `Show the card of a token and, with --match-customer, compare its
+fingerprint against the customer's saved cards to report whether the card is
+already attached. Use this before adding a card to avoid saving the same card
+twice.
+
+Example:
+  payjp tokens inspect tok_xxxxx
+  payjp tokens inspect tok_xxxxx --match-customer cus_xxxxx`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenID := args[0]
+		customerID, _ := cmd.Flags().GetString("match-customer")
+
+		token, err := client.GetToken().Retrieve(tokenID)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		result := tokenInspection{
+			ID:          token.ID,
+			Used:        token.Used,
+			LiveMode:    token.LiveMode,
+			Brand:       token.Card.Brand,
+			Last4:       token.Card.Last4,
+			ExpMonth:    token.Card.ExpMonth,
+			ExpYear:     token.Card.ExpYear,
+			Fingerprint: token.Card.Fingerprint,
+		}
+
+		if customerID != "" {
+			cards, err := fetchAll("cards", func(limit, offset int) ([]*payjp.CardResponse, bool, error) {
+				return client.GetCustomer().ListCard(customerID).Limit(limit).Offset(offset).Do()
+			})
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+
+			result.Customer = customerID
+			result.MatchingCards = matchingCards(token.Card.Fingerprint, cards)
+			result.Duplicate = len(result.MatchingCards) > 0
+			if result.Duplicate {
+				printStatus("Card is already saved on %s as %s", customerID, strings.Join(result.MatchingCards, ", "))
+			}
+		}
+
+		return outputResult(result)
+	},
+}
+
+// tokenInspection describes a token's card and whether the card is already saved on a customer
+type tokenInspection struct {
+	ID            string   `json:"id"`
+	Used          bool     `json:"used"`
+	LiveMode      bool     `json:"livemode"`
+	Brand         string   `json:"brand"`
+	Last4         string   `json:"last4"`
+	ExpMonth      int      `json:"exp_month"`
+	ExpYear       int      `json:"exp_year"`
+	Fingerprint   string   `json:"fingerprint"`
+	Customer      string   `json:"customer,omitempty"`
+	Duplicate     bool     `json:"duplicate"`
+	MatchingCards []string `json:"matching_cards,omitempty"`
+}
+
+// matchingCards returns the IDs of cards with the given fingerprint
+func matchingCards(fingerprint string, cards []*payjp.CardResponse) []string {
+	ids := []string{}
+	if fingerprint == "" {
+		return ids
+	}
+	for _, card := range cards {
+		if card.Fingerprint == fingerprint {
+			ids = append(ids, card.ID)
+		}
+	}
+	return ids
+}
+
 func init() {
 	rootCmd.AddCommand(tokensCmd)
 
 	tokensCmd.AddCommand(tokensGetCmd)
+	tokensCmd.AddCommand(tokensInspectCmd)
+
+	// Inspect flags
+	tokensInspectCmd.Flags().String("match-customer", "", "Customer ID whose saved cards are compared with the token's card")
 }
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/payjp/payjp-cli/internal/client"
-	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/payjp/payjp-go/v1"
 	"github.com/spf13/cobra"
 )
 
@@ -41,13 +48,27 @@ var transfersListCmd = &cobra.Command{
 	Short: "List transfers",
 	Long: `List all transfers with optional filters.
 
+With --tenant, the transfers to that PAY.JP Platform tenant are listed instead.
+
 Example:
-  payjp transfers list --limit 10`,
+  payjp transfers list --limit 10
+  payjp transfers list --all --output csv > transfers.csv
+  payjp transfers list --tenant ten_xxxxx`,
+	Annotations: map[string]string{
+		annotationTenant: "true",
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		offset, _ := cmd.Flags().GetInt("offset")
-		since, _ := cmd.Flags().GetString("since")
-		until, _ := cmd.Flags().GetString("until")
+		all, _ := cmd.Flags().GetBool("all")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		if tenant := client.Tenant(); tenant != "" {
+			return listTenantTransfers(tenant, tenantTransferParams(since, until), limit, offset, all)
+		}
 
 		caller := client.GetTransfer().List()
 
@@ -57,19 +78,21 @@ Example:
 		if offset > 0 {
 			caller.Offset(offset)
 		}
-		if since != "" {
-			ts, err := util.ParseTimestamp(since)
-			if err != nil {
-				return err
-			}
-			caller.Since(time.Unix(ts, 0))
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
 		}
-		if until != "" {
-			ts, err := util.ParseTimestamp(until)
+
+		if all {
+			err := streamAll("transfers", func(limit, offset int) ([]*payjp.TransferResponse, bool, error) {
+				return caller.Limit(limit).Offset(offset).Do()
+			}, nil)
 			if err != nil {
-				return err
+				handleError(err)
 			}
-			caller.Until(time.Unix(ts, 0))
+			return nil
 		}
 
 		result, _, err := caller.Do()
@@ -82,15 +105,383 @@ Example:
 	},
 }
 
+// tenantTransferParams returns the time range filter of a tenant transfers listing
+func tenantTransferParams(since, until time.Time) url.Values {
+	params := url.Values{}
+	if !since.IsZero() {
+		params.Set("since", strconv.FormatInt(since.Unix(), 10))
+	}
+	if !until.IsZero() {
+		params.Set("until", strconv.FormatInt(until.Unix(), 10))
+	}
+	return params
+}
+
+// listTenantTransfers lists the transfers to a tenant, or to every tenant when tenant is empty,
+// with the same paging as transfers list
+func listTenantTransfers(tenant string, params url.Values, limit, offset int, all bool) error {
+	fetch := func(limit, offset int) ([]*client.TenantTransfer, bool, error) {
+		if limit > 0 {
+			params.Set("limit", strconv.Itoa(limit))
+		}
+		if offset > 0 {
+			params.Set("offset", strconv.Itoa(offset))
+		}
+		return client.ListTenantTransfers(tenant, params)
+	}
+
+	if all {
+		if err := streamAll("tenant transfers", fetch, nil); err != nil {
+			handleError(err)
+		}
+		return nil
+	}
+
+	result, _, err := fetch(limit, offset)
+	if err != nil {
+		handleError(err)
+		return nil
+	}
+
+	return outputResult(result)
+}
+
+var transfersExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export transfers, optionally with the charges paid out in each",
+	Long: `Export every transfer in the time range as CSV (or in the format given by
+--output).
+
+With --with-charges, the charges paid out in each transfer are fetched as
+well, paging through both the transfers and their charges:
+
+  - JSON, YAML and NDJSON nest the charges in a charges array of each
+    transfer; NDJSON writes one transfer per line as soon as its charges
+    are fetched
+  - CSV writes two linked files to --dir: transfers.csv and charges.csv,
+    whose transfer_id column refers to the id column of transfers.csv.
+    Existing files are only replaced with --overwrite
+  - table prints the transfers followed by their charges
+
+Example:
+  payjp transfers export --range last-month > transfers.csv
+  payjp transfers export --with-charges --range last-month -o ndjson > payouts.ndjson
+  payjp transfers export --with-charges --since 2024-06-01 --dir ./payouts`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		withCharges, _ := cmd.Flags().GetBool("with-charges")
+		dir, _ := cmd.Flags().GetString("dir")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		since, until, err := timeRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		// CSV is what accounting tools import, so it is the default regardless of the configured format
+		if !outputFmtChanged {
+			outputFmt = "csv"
+			outputFmtChanged = true
+		}
+		format := getOutputFormat()
+		if dir != "" && (!withCharges || format != "csv") {
+			return i18n.Errorf("--dir is only used for CSV output with --with-charges")
+		}
+		if withCharges && format == "csv" && dir == "" {
+			return i18n.Errorf("CSV output with --with-charges is written to two files; give the directory with --dir (or use -o json or ndjson)")
+		}
+
+		caller := client.GetTransfer().List()
+		if !since.IsZero() {
+			caller.Since(since)
+		}
+		if !until.IsZero() {
+			caller.Until(until)
+		}
+		fetch := func(limit, offset int) ([]transferExportRow, bool, error) {
+			transfers, hasMore, err := caller.Limit(limit).Offset(offset).Do()
+			if err != nil {
+				return nil, false, err
+			}
+			rows := make([]transferExportRow, 0, len(transfers))
+			for _, t := range transfers {
+				rows = append(rows, newTransferExportRow(t))
+			}
+			return rows, hasMore, nil
+		}
+
+		if !withCharges {
+			if err := streamAll("transfers", fetch, nil); err != nil {
+				handleError(err)
+			}
+			return nil
+		}
+
+		transfers, err := fetchAll("transfers", fetch)
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		var exported int
+		switch format {
+		case "csv":
+			exported, err = exportTransferCSVs(dir, overwrite, transfers)
+		case "table":
+			exported, err = printTransfersWithCharges(transfers)
+		default:
+			exported, err = streamTransfersWithCharges(format, transfers)
+		}
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+		printStatus("Exported %d transfers with %d charges", len(transfers), exported)
+		return nil
+	},
+}
+
+// transferExportRow is a transfer with its summary flattened into columns
+type transferExportRow struct {
+	ID             string    `json:"id" yaml:"id"`
+	Status         string    `json:"status" yaml:"status"`
+	Amount         int       `json:"amount" yaml:"amount"`
+	Currency       string    `json:"currency" yaml:"currency"`
+	ChargeCount    int       `json:"charge_count" yaml:"charge_count"`
+	ChargeGross    int       `json:"charge_gross" yaml:"charge_gross"`
+	ChargeFee      int       `json:"charge_fee" yaml:"charge_fee"`
+	RefundAmount   int       `json:"refund_amount" yaml:"refund_amount"`
+	DisputeAmount  int       `json:"dispute_amount" yaml:"dispute_amount"`
+	Net            int       `json:"net" yaml:"net"`
+	CarriedBalance int       `json:"carried_balance" yaml:"carried_balance"`
+	TermStart      time.Time `json:"term_start" yaml:"term_start"`
+	TermEnd        time.Time `json:"term_end" yaml:"term_end"`
+	ScheduledDate  string    `json:"scheduled_date" yaml:"scheduled_date"`
+	TransferDate   string    `json:"transfer_date" yaml:"transfer_date"`
+	Description    string    `json:"description" yaml:"description"`
+	Created        time.Time `json:"created" yaml:"created"`
+}
+
+// transferChargeRow is a charge paid out in a transfer
+// TransferID links the row to its transfer in the CSV export
+type transferChargeRow struct {
+	TransferID     string    `json:"transfer_id" yaml:"transfer_id"`
+	ID             string    `json:"id" yaml:"id"`
+	Amount         int       `json:"amount" yaml:"amount"`
+	AmountRefunded int       `json:"amount_refunded" yaml:"amount_refunded"`
+	Currency       string    `json:"currency" yaml:"currency"`
+	FeeRate        string    `json:"fee_rate" yaml:"fee_rate"`
+	Captured       bool      `json:"captured" yaml:"captured"`
+	Refunded       bool      `json:"refunded" yaml:"refunded"`
+	Customer       string    `json:"customer" yaml:"customer"`
+	Subscription   string    `json:"subscription" yaml:"subscription"`
+	Description    string    `json:"description" yaml:"description"`
+	Created        time.Time `json:"created" yaml:"created"`
+}
+
+// transferWithCharges is a transfer with its charges nested for JSON, YAML and NDJSON
+type transferWithCharges struct {
+	transferExportRow `yaml:",inline"`
+	Charges           []transferChargeRow `json:"charges" yaml:"charges"`
+}
+
+func newTransferExportRow(t *payjp.TransferResponse) transferExportRow {
+	return transferExportRow{
+		ID:             t.ID,
+		Status:         string(t.Status),
+		Amount:         t.Amount,
+		Currency:       t.Currency,
+		ChargeCount:    t.Summary.ChargeCount,
+		ChargeGross:    t.Summary.ChargeGross,
+		ChargeFee:      t.Summary.ChargeFee,
+		RefundAmount:   t.Summary.RefundAmount,
+		DisputeAmount:  t.Summary.DisputeAmount,
+		Net:            t.Summary.Net,
+		CarriedBalance: t.CarriedBalance,
+		TermStart:      t.TermStartAt,
+		TermEnd:        t.TermEndAt,
+		ScheduledDate:  t.ScheduledDate,
+		TransferDate:   t.TransferDate,
+		Description:    t.Description,
+		Created:        t.CreatedAt,
+	}
+}
+
+// transferCharges fetches every charge paid out in a transfer
+func transferCharges(transferID string) ([]transferChargeRow, error) {
+	charges, err := fetchAll("charges of "+transferID, func(limit, offset int) ([]*payjp.ChargeResponse, bool, error) {
+		return client.GetTransfer().ChargeList(transferID).Limit(limit).Offset(offset).Do()
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]transferChargeRow, 0, len(charges))
+	for _, c := range charges {
+		rows = append(rows, transferChargeRow{
+			TransferID:     transferID,
+			ID:             c.ID,
+			Amount:         c.Amount,
+			AmountRefunded: c.AmountRefunded,
+			Currency:       c.Currency,
+			FeeRate:        c.FeeRate,
+			Captured:       c.Captured,
+			Refunded:       c.Refunded,
+			Customer:       c.CustomerID,
+			Subscription:   c.SubscriptionID,
+			Description:    c.Description,
+			Created:        c.CreatedAt,
+		})
+	}
+	return rows, nil
+}
+
+// streamTransfersWithCharges writes each transfer with its charges as soon as they are fetched
+// It returns the number of charges
+func streamTransfersWithCharges(format string, transfers []transferExportRow) (int, error) {
+	var writer output.StreamWriter
+	if format != "quiet" && format != "count" {
+		writer = output.NewStreamWriter(output.Format(format))
+	}
+
+	count := 0
+	for _, t := range transfers {
+		charges, err := transferCharges(t.ID)
+		if err != nil {
+			return count, err
+		}
+		count += len(charges)
+
+		switch {
+		case format == "quiet":
+			fmt.Println(t.ID)
+		case writer != nil:
+			if err := writePage(writer, format, []transferWithCharges{{transferExportRow: t, Charges: charges}}); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if format == "count" {
+		fmt.Println(len(transfers))
+		return count, nil
+	}
+	if writer != nil {
+		return count, writer.Close()
+	}
+	return count, nil
+}
+
+// printTransfersWithCharges prints the transfers and then their charges as tables
+// It returns the number of charges
+func printTransfersWithCharges(transfers []transferExportRow) (int, error) {
+	charges := []transferChargeRow{}
+	for _, t := range transfers {
+		rows, err := transferCharges(t.ID)
+		if err != nil {
+			return 0, err
+		}
+		charges = append(charges, rows...)
+	}
+
+	if err := outputResult(transfers); err != nil {
+		return 0, err
+	}
+	fmt.Println()
+	fmt.Println("Charges:")
+	return len(charges), outputResult(charges)
+}
+
+// exportTransferCSVs writes transfers.csv and charges.csv to dir
+// The files are written under temporary names and renamed once complete, so that an
+// interrupted export does not leave files that look finished
+// It returns the number of charges
+func exportTransferCSVs(dir string, overwrite bool, transfers []transferExportRow) (int, error) {
+	transfersPath := filepath.Join(dir, "transfers.csv")
+	chargesPath := filepath.Join(dir, "charges.csv")
+	if !overwrite {
+		for _, path := range []string{transfersPath, chargesPath} {
+			if _, err := os.Stat(path); err == nil {
+				return 0, i18n.Errorf("%s already exists (use --overwrite to replace it)", path)
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	chargesFile, err := os.Create(chargesPath + ".part")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(chargesPath + ".part")
+	defer chargesFile.Close()
+
+	// An empty first page writes the header, also when no transfer has charges
+	writer := output.NewCSVWriter(chargesFile)
+	if err := writer.WritePage([]transferChargeRow{}); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, t := range transfers {
+		charges, err := transferCharges(t.ID)
+		if err != nil {
+			return count, err
+		}
+		if err := writer.WritePage(charges); err != nil {
+			return count, err
+		}
+		count += len(charges)
+	}
+	if err := writer.Close(); err != nil {
+		return count, err
+	}
+	if err := chargesFile.Close(); err != nil {
+		return count, err
+	}
+
+	transfersFile, err := os.Create(transfersPath + ".part")
+	if err != nil {
+		return count, err
+	}
+	defer os.Remove(transfersPath + ".part")
+	defer transfersFile.Close()
+
+	writer = output.NewCSVWriter(transfersFile)
+	if err := writer.WritePage(transfers); err != nil {
+		return count, err
+	}
+	if err := writer.Close(); err != nil {
+		return count, err
+	}
+	if err := transfersFile.Close(); err != nil {
+		return count, err
+	}
+
+	if err := os.Rename(chargesPath+".part", chargesPath); err != nil {
+		return count, err
+	}
+	if err := os.Rename(transfersPath+".part", transfersPath); err != nil {
+		return count, err
+	}
+	printSuccess("Wrote %s and %s", transfersPath, chargesPath)
+	return count, nil
+}
+
 func init() {
 	rootCmd.AddCommand(transfersCmd)
 
 	transfersCmd.AddCommand(transfersGetCmd)
 	transfersCmd.AddCommand(transfersListCmd)
+	transfersCmd.AddCommand(transfersExportCmd)
 
 	// List flags
 	transfersListCmd.Flags().Int("limit", 10, "Number of items to return")
 	transfersListCmd.Flags().Int("offset", 0, "Offset for pagination")
-	transfersListCmd.Flags().String("since", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
-	transfersListCmd.Flags().String("until", "", "Filter by created timestamp (Unix timestamp or RFC3339)")
+	transfersListCmd.Flags().Bool("all", false, "Fetch all pages and stream them to the output")
+	addTimeRangeFlags(transfersListCmd)
+
+	// Export flags
+	transfersExportCmd.Flags().Bool("with-charges", false, "Include the charges paid out in each transfer")
+	transfersExportCmd.Flags().String("dir", "", "Directory for transfers.csv and charges.csv (CSV output with --with-charges)")
+	transfersExportCmd.Flags().Bool("overwrite", false, "Replace existing CSV files in --dir")
+	addTimeRangeFlags(transfersExportCmd)
 }
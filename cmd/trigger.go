@@ -0,0 +1,404 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// Test card numbers used to generate events in test mode
+const (
+	testCardSucceeded = "4242424242424242"
+	testCardDeclined  = "4000000000000002"
+)
+
+// triggerFunc performs the API actions needed to generate an event
+type triggerFunc func() (interface{}, error)
+
+// triggers maps event types to the actions that generate them
+var triggers = map[string]triggerFunc{
+	"charge.succeeded":      triggerChargeSucceeded,
+	"charge.failed":         triggerChargeFailed,
+	"charge.updated":        triggerChargeUpdated,
+	"charge.refunded":       triggerChargeRefunded,
+	"charge.captured":       triggerChargeCaptured,
+	"customer.created":      triggerCustomerCreated,
+	"customer.updated":      triggerCustomerUpdated,
+	"customer.deleted":      triggerCustomerDeleted,
+	"customer.card.created": triggerCardCreated,
+	"customer.card.updated": triggerCardUpdated,
+	"customer.card.deleted": triggerCardDeleted,
+	"plan.created":          triggerPlanCreated,
+	"plan.updated":          triggerPlanUpdated,
+	"plan.deleted":          triggerPlanDeleted,
+	"subscription.created":  triggerSubscriptionCreated,
+	"subscription.updated":  triggerSubscriptionUpdated,
+	"subscription.deleted":  triggerSubscriptionDeleted,
+	"subscription.paused":   triggerSubscriptionPaused,
+	"subscription.resumed":  triggerSubscriptionResumed,
+	"subscription.canceled": triggerSubscriptionCanceled,
+	"token.created":         triggerTokenCreated,
+}
+
+var triggerCmd = &cobra.Command{
+	Use:   "trigger <event_type>",
+	Short: "Trigger a webhook event in test mode",
+	Long: `Perform the API actions needed to generate the given event type.
+
+This command only works in test mode. It creates real test-mode resources
+(tokens, customers, charges, etc.) so that webhook handlers can be exercised
+end-to-end.
+
+Example:
+  payjp trigger charge.succeeded
+  payjp trigger customer.deleted
+  payjp trigger --list`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		if list {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	ValidArgs: triggerEventTypes(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		if list {
+			fmt.Println("Supported event types:")
+			for _, t := range triggerEventTypes() {
+				fmt.Printf("  %s\n", t)
+			}
+			return nil
+		}
+
+		eventType := args[0]
+		trigger, ok := triggers[eventType]
+		if !ok {
+			return i18n.Errorf("unsupported event type: %s (use --list to see supported types)", eventType)
+		}
+
+		if config.IsLiveMode() || client.IsLiveKey() {
+			return i18n.Errorf("trigger is only available in test mode")
+		}
+
+		printVerbose("Triggering %s", eventType)
+
+		result, err := trigger()
+		if err != nil {
+			handleError(err)
+			return nil
+		}
+
+		return outputResult(result)
+	},
+}
+
+// triggerEventTypes returns the sorted list of supported event types
+func triggerEventTypes() []string {
+	types := make([]string, 0, len(triggers))
+	for t := range triggers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// createTestToken creates a token for the given test card number
+func createTestToken(number string) (*payjp.TokenResponse, error) {
+	printVerbose("Creating token with test card %s", number)
+	return client.GetToken().Create(payjp.Token{
+		Number:   number,
+		ExpMonth: "12",
+		ExpYear:  fmt.Sprintf("%d", time.Now().Year()+1),
+		CVC:      "123",
+	})
+}
+
+// createTestCharge creates a test charge with a newly created token
+func createTestCharge(capture bool) (*payjp.ChargeResponse, error) {
+	token, err := createTestToken(testCardSucceeded)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Creating charge (capture: %v)", capture)
+	return client.GetCharge().Create(1000, payjp.Charge{
+		Currency:    "jpy",
+		CardToken:   token.ID,
+		Capture:     capture,
+		Description: "payjp trigger",
+	})
+}
+
+// createTestCustomer creates a test customer, optionally with a card
+func createTestCustomer(withCard bool) (*payjp.CustomerResponse, error) {
+	customer := payjp.Customer{
+		Description: "payjp trigger",
+	}
+	if withCard {
+		token, err := createTestToken(testCardSucceeded)
+		if err != nil {
+			return nil, err
+		}
+		customer.CardToken = token.ID
+	}
+	printVerbose("Creating customer")
+	return client.GetCustomer().Create(customer)
+}
+
+// createTestPlan creates a monthly test plan
+func createTestPlan() (*payjp.PlanResponse, error) {
+	printVerbose("Creating plan")
+	return client.GetPlan().Create(payjp.Plan{
+		Amount:   500,
+		Currency: "jpy",
+		Interval: "month",
+		Name:     "payjp trigger",
+	})
+}
+
+// createTestSubscription creates a customer with a card and subscribes it to a new plan
+func createTestSubscription() (*payjp.SubscriptionResponse, error) {
+	customer, err := createTestCustomer(true)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := createTestPlan()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Creating subscription")
+	return client.GetSubscription().Subscribe(customer.ID, payjp.Subscription{
+		PlanID: plan.ID,
+	})
+}
+
+func triggerChargeSucceeded() (interface{}, error) {
+	return createTestCharge(true)
+}
+
+func triggerChargeFailed() (interface{}, error) {
+	token, err := createTestToken(testCardDeclined)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Creating charge with declined card")
+	result, err := client.GetCharge().Create(1000, payjp.Charge{
+		Currency:  "jpy",
+		CardToken: token.ID,
+		Capture:   true,
+	})
+	if err != nil {
+		// A card error is the expected outcome and generates the event
+		if payjpErr, ok := err.(*payjp.Error); ok && payjpErr.Type == "card_error" {
+			return map[string]interface{}{
+				"event": "charge.failed",
+				"code":  payjpErr.Code,
+			}, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func triggerChargeUpdated() (interface{}, error) {
+	charge, err := createTestCharge(true)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Updating charge %s", charge.ID)
+	return client.GetCharge().Update(charge.ID, "payjp trigger (updated)")
+}
+
+func triggerChargeRefunded() (interface{}, error) {
+	charge, err := createTestCharge(true)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Refunding charge %s", charge.ID)
+	return client.GetCharge().Refund(charge.ID, "payjp trigger")
+}
+
+func triggerChargeCaptured() (interface{}, error) {
+	charge, err := createTestCharge(false)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Capturing charge %s", charge.ID)
+	return client.GetCharge().Capture(charge.ID)
+}
+
+func triggerCustomerCreated() (interface{}, error) {
+	return createTestCustomer(false)
+}
+
+func triggerCustomerUpdated() (interface{}, error) {
+	customer, err := createTestCustomer(false)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Updating customer %s", customer.ID)
+	return client.GetCustomer().Update(customer.ID, payjp.Customer{
+		Description: "payjp trigger (updated)",
+	})
+}
+
+func triggerCustomerDeleted() (interface{}, error) {
+	customer, err := createTestCustomer(false)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Deleting customer %s", customer.ID)
+	if err := client.GetCustomer().Delete(customer.ID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":      customer.ID,
+		"deleted": true,
+	}, nil
+}
+
+func triggerCardCreated() (interface{}, error) {
+	customer, err := createTestCustomer(false)
+	if err != nil {
+		return nil, err
+	}
+	token, err := createTestToken(testCardSucceeded)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Adding card to customer %s", customer.ID)
+	return client.GetCustomer().AddCardToken(customer.ID, token.ID)
+}
+
+func triggerCardUpdated() (interface{}, error) {
+	customer, err := createTestCustomer(true)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Updating card %s", customer.DefaultCard)
+	return client.GetCustomer().UpdateCard(customer.ID, customer.DefaultCard, payjp.Card{
+		Name: "PAY TRIGGER",
+	})
+}
+
+func triggerCardDeleted() (interface{}, error) {
+	customer, err := createTestCustomer(true)
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Deleting card %s", customer.DefaultCard)
+	if err := client.GetCustomer().DeleteCard(customer.ID, customer.DefaultCard); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":      customer.DefaultCard,
+		"deleted": true,
+	}, nil
+}
+
+func triggerPlanCreated() (interface{}, error) {
+	return createTestPlan()
+}
+
+func triggerPlanUpdated() (interface{}, error) {
+	plan, err := createTestPlan()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Updating plan %s", plan.ID)
+	return client.GetPlan().Update(plan.ID, payjp.Plan{
+		Name: "payjp trigger (updated)",
+	})
+}
+
+func triggerPlanDeleted() (interface{}, error) {
+	plan, err := createTestPlan()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Deleting plan %s", plan.ID)
+	if err := client.GetPlan().Delete(plan.ID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":      plan.ID,
+		"deleted": true,
+	}, nil
+}
+
+func triggerSubscriptionCreated() (interface{}, error) {
+	return createTestSubscription()
+}
+
+func triggerSubscriptionUpdated() (interface{}, error) {
+	subscription, err := createTestSubscription()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Updating subscription %s", subscription.ID)
+	return client.GetSubscription().Update(subscription.ID, payjp.Subscription{
+		Metadata: map[string]string{"trigger": "updated"},
+	})
+}
+
+func triggerSubscriptionDeleted() (interface{}, error) {
+	subscription, err := createTestSubscription()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Deleting subscription %s", subscription.ID)
+	if err := client.GetSubscription().Delete(subscription.ID, payjp.SubscriptionDelete{}); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":      subscription.ID,
+		"deleted": true,
+	}, nil
+}
+
+func triggerSubscriptionPaused() (interface{}, error) {
+	subscription, err := createTestSubscription()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Pausing subscription %s", subscription.ID)
+	return client.GetSubscription().Pause(subscription.ID)
+}
+
+func triggerSubscriptionResumed() (interface{}, error) {
+	subscription, err := createTestSubscription()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Pausing subscription %s", subscription.ID)
+	if _, err := client.GetSubscription().Pause(subscription.ID); err != nil {
+		return nil, err
+	}
+	printVerbose("Resuming subscription %s", subscription.ID)
+	return client.GetSubscription().Resume(subscription.ID, payjp.Subscription{})
+}
+
+func triggerSubscriptionCanceled() (interface{}, error) {
+	subscription, err := createTestSubscription()
+	if err != nil {
+		return nil, err
+	}
+	printVerbose("Canceling subscription %s", subscription.ID)
+	return client.GetSubscription().Cancel(subscription.ID)
+}
+
+func triggerTokenCreated() (interface{}, error) {
+	return createTestToken(testCardSucceeded)
+}
+
+func init() {
+	rootCmd.AddCommand(triggerCmd)
+
+	triggerCmd.Flags().Bool("list", false, "List supported event types")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/client"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/payjp/payjp-cli/internal/upload"
+	"github.com/spf13/cobra"
+)
+
+// addUploadFlag adds the --upload flag to an export command
+func addUploadFlag(cmd *cobra.Command) {
+	cmd.Flags().String("upload", "", "Upload the output to object storage (s3://bucket/key or gs://bucket/key; a key ending with / gets a generated file name)")
+}
+
+// uploadTarget returns the --upload target with name used for prefixes, or nil when the flag is not given
+func uploadTarget(cmd *cobra.Command, name string) (*upload.Target, error) {
+	raw, _ := cmd.Flags().GetString("upload")
+	if raw == "" {
+		return nil, nil
+	}
+	target, err := upload.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	target = target.WithName(name)
+	return &target, nil
+}
+
+// uploadName returns a generated object name such as events-20240601T120000Z.csv
+func uploadName(resource, ext string) string {
+	return resource + "-" + time.Now().UTC().Format("20060102T150405Z") + "." + ext
+}
+
+// uploadFile uploads a local file to the target and reports the destination
+func uploadFile(target *upload.Target, path, contentType string) error {
+	printVerbose("Uploading %s to %s", path, target)
+	if err := upload.File(client.HTTPClient(), *target, path, contentType); err != nil {
+		return err
+	}
+	printSuccess("Uploaded to %s", target)
+	return nil
+}
+
+// captureStdout runs write with stdout redirected to a new temp file and returns its path
+// The caller removes the file
+func captureStdout(write func() error) (string, error) {
+	f, err := os.CreateTemp("", "payjp-upload-*")
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = f
+	err = write()
+	os.Stdout = stdout
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// formatContentType returns the MIME type and file extension of an output format
+func formatContentType(format string) (string, string) {
+	switch output.Format(format) {
+	case output.FormatCSV:
+		return "text/csv", "csv"
+	case output.FormatNDJSON:
+		return "application/x-ndjson", "ndjson"
+	case output.FormatJSON:
+		return "application/json", "json"
+	case output.FormatYAML:
+		return "application/yaml", "yaml"
+	}
+	return "text/plain", "txt"
+}
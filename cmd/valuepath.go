@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// valuePath is the trailing .path argument of a get command; nil when not given
+var valuePath output.ValuePath
+
+// enableValuePaths lets every get command take a trailing .path argument
+// that prints a single value of the result, e.g. payjp charges get ch_xxxxx .card.last4
+func enableValuePaths(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		enableValuePaths(c)
+	}
+	if root.Name() != "get" || root.RunE == nil {
+		return
+	}
+
+	validate := root.Args
+	run := root.RunE
+	root.Use += " [.path]"
+	root.Args = func(cmd *cobra.Command, args []string) error {
+		args, _ = splitValuePath(args)
+		if validate == nil {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+	root.RunE = func(cmd *cobra.Command, args []string) error {
+		args, path := splitValuePath(args)
+		if path != "" {
+			if quiet || rawOutput || fieldsArg != "" {
+				return i18n.Errorf("a .path argument cannot be used with --quiet, --raw or --fields")
+			}
+			if envelope {
+				return i18n.Errorf("--envelope cannot be used with --quiet, --count, --raw or a .path argument")
+			}
+			parsed, err := output.ParseValuePath(path)
+			if err != nil {
+				return err
+			}
+			valuePath = parsed
+		}
+		return run(cmd, args)
+	}
+}
+
+// splitValuePath separates a trailing argument starting with "." from the other arguments
+func splitValuePath(args []string) ([]string, string) {
+	if n := len(args); n > 0 && strings.HasPrefix(args[n-1], ".") {
+		return args[:n-1], args[n-1]
+	}
+	return args, ""
+}
+
+// outputValue prints the value at valuePath
+func outputValue(data interface{}) error {
+	value, err := valuePath.Extract(data)
+	if err != nil {
+		return err
+	}
+	return output.OutputValue(value)
+}
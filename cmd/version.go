@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-go/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Commit is the git commit set at build time
+	Commit = "unknown"
+
+	// BuildDate is the build timestamp set at build time
+	BuildDate = "unknown"
+)
+
+// latestReleaseURL is the GitHub API endpoint for the latest release
+const latestReleaseURL = "https://api.github.com/repos/payjp/payjp-cli/releases/latest"
+
+// versionInfo describes the installed CLI build
+type versionInfo struct {
+	Version         string `json:"version"`
+	Commit          string `json:"commit"`
+	BuildDate       string `json:"build_date"`
+	GoVersion       string `json:"go_version"`
+	SDKVersion      string `json:"sdk_version"`
+	Platform        string `json:"platform"`
+	LatestVersion   string `json:"latest_version,omitempty"`
+	UpdateAvailable *bool  `json:"update_available,omitempty"`
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version and build information",
+	Long: `Show the CLI version with build metadata.
+
+With --check the latest release is compared with this build by semantic
+version precedence; update_available is left out for development builds.
+
+Example:
+  payjp version
+  payjp version --check
+  payjp version -o json`,
+	Annotations: map[string]string{
+		annotationNoClient: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+
+		info := versionInfo{
+			Version:    Version,
+			Commit:     Commit,
+			BuildDate:  BuildDate,
+			GoVersion:  runtime.Version(),
+			SDKVersion: payjp.Version,
+			Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+		}
+
+		if check {
+			latest, err := fetchLatestVersion()
+			if err != nil {
+				return fmt.Errorf("failed to check for updates: %w", err)
+			}
+			info.LatestVersion = latest
+			// Development and other non-release builds have no place in the release order
+			if cmp, ok := compareVersions(Version, latest); ok {
+				available := cmp < 0
+				info.UpdateAvailable = &available
+			} else {
+				printStatus("Cannot tell whether %s is newer than this build (%s)", latest, Version)
+			}
+		}
+
+		if getOutputFormat() == "quiet" {
+			fmt.Println(info.Version)
+			return nil
+		}
+
+		return outputResult(info)
+	},
+}
+
+// fetchLatestVersion returns the tag name of the latest published release
+func fetchLatestVersion() (string, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Get(latestReleaseURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+// semanticVersion is a parsed MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] version
+type semanticVersion struct {
+	core       [3]int
+	prerelease []string
+}
+
+// parseVersion parses a semantic version with an optional leading "v"
+func parseVersion(v string) (semanticVersion, bool) {
+	var parsed semanticVersion
+	v = strings.TrimPrefix(v, "v")
+	// Build metadata does not affect precedence
+	v, build, hasBuild := strings.Cut(v, "+")
+	if hasBuild && !validIdentifiers(build, false) {
+		return parsed, false
+	}
+	v, prerelease, hasPrerelease := strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		n, ok := numericIdentifier(part)
+		if !ok {
+			return parsed, false
+		}
+		parsed.core[i] = n
+	}
+	if hasPrerelease {
+		if !validIdentifiers(prerelease, true) {
+			return parsed, false
+		}
+		parsed.prerelease = strings.Split(prerelease, ".")
+	}
+	return parsed, true
+}
+
+// validIdentifiers reports whether s is dot-separated non-empty identifiers of ASCII letters,
+// digits and hyphens; numeric pre-release identifiers must not have leading zeros
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return false
+			}
+		}
+		if prerelease && numeric && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// numericIdentifier parses a version number, which has no sign or leading zeros
+func numericIdentifier(s string) (int, bool) {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return 0, false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// compareVersions compares two semantic versions by precedence, returning -1, 0 or 1
+// ok is false when either is not a semantic version, such as a development build
+func compareVersions(a, b string) (cmp int, ok bool) {
+	va, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	for i := range va.core {
+		if c := compareInts(va.core[i], vb.core[i]); c != 0 {
+			return c, true
+		}
+	}
+
+	// A pre-release precedes the release of the same version
+	switch {
+	case len(va.prerelease) == 0 && len(vb.prerelease) == 0:
+		return 0, true
+	case len(va.prerelease) == 0:
+		return 1, true
+	case len(vb.prerelease) == 0:
+		return -1, true
+	}
+	for i := 0; i < len(va.prerelease) && i < len(vb.prerelease); i++ {
+		x, y := va.prerelease[i], vb.prerelease[i]
+		nx, xNumeric := numericIdentifier(x)
+		ny, yNumeric := numericIdentifier(y)
+		switch {
+		case xNumeric && yNumeric:
+			if c := compareInts(nx, ny); c != 0 {
+				return c, true
+			}
+		case xNumeric:
+			return -1, true
+		case yNumeric:
+			return 1, true
+		case x != y:
+			return strings.Compare(x, y), true
+		}
+	}
+	return compareInts(len(va.prerelease), len(vb.prerelease)), true
+}
+
+// compareInts returns -1, 0 or 1 as a is less than, equal to or greater than b
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().Bool("check", false, "Check for a newer release")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		ok      bool
+	}{
+		{"1.2.3", true},
+		{"v1.2.3", true},
+		{"0.0.0", true},
+		{"1.2.3-alpha", true},
+		{"1.2.3-alpha.1", true},
+		{"1.2.3-rc-1", true},
+		{"1.2.3-0.3.7", true},
+		{"1.2.3+build.5", true},
+		{"1.2.3-beta+exp.sha.5114f85", true},
+		{"1.2.3+001", true},
+		{"", false},
+		{"dev", false},
+		{"1.2", false},
+		{"1.2.3.4", false},
+		{"01.2.3", false},
+		{"1.02.3", false},
+		{"-1.2.3", false},
+		{"1.2.x", false},
+		{"1.2.3-", false},
+		{"1.2.3-alpha..1", false},
+		{"1.2.3-01", false},
+		{"1.2.3-alpha_1", false},
+		{"1.2.3+", false},
+		{"1.2.3+build..1", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseVersion(tt.version); ok != tt.ok {
+			t.Errorf("parseVersion(%q) ok = %v, want %v", tt.version, ok, tt.ok)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "1.99.99", 1},
+		// A pre-release precedes the release
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-rc.1", "0.9.9", 1},
+		// Numeric identifiers compare numerically
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		// Numeric identifiers precede alphanumeric ones
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-alpha", "1.0.0-1", 1},
+		// Alphanumeric identifiers compare in ASCII order
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-Beta", "1.0.0-alpha", -1},
+		// More identifiers follow fewer when the rest are equal
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.beta", "1.0.0-alpha.1", 1},
+		// Build metadata does not affect precedence
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"1.0.0-rc.1+build.1", "1.0.0-rc.1", 0},
+		{"1.0.0+build.1", "1.0.1", -1},
+	}
+	for _, tt := range tests {
+		got, ok := compareVersions(tt.a, tt.b)
+		if !ok || got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, %v, want %d, true", tt.a, tt.b, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsInvalid(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"dev", "1.0.0"},
+		{"1.0.0", "dev"},
+		{"1.0", "1.0.0"},
+		{"1.0.0", "1.0.0-"},
+	} {
+		if got, ok := compareVersions(pair[0], pair[1]); ok {
+			t.Errorf("compareVersions(%q, %q) = %d, true, want not ok", pair[0], pair[1], got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/checkpoint"
+	"github.com/payjp/payjp-cli/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+// addWorkflowFlags adds the flags of a command that runs as a workflow of several API requests
+func addWorkflowFlags(cmd *cobra.Command) {
+	addCheckpointFlags(cmd)
+	cmd.Flags().Bool("dry-run", false, "Show the plan without running it")
+	cmd.Flags().Bool("no-rollback", false, "Keep the completed steps when a step fails so that the run can be resumed with --resume")
+}
+
+// newWorkflow returns a workflow reporting progress as status messages
+// The checkpoint is used as the journal of completed steps when it is not nil
+func newWorkflow(id string, cp *checkpoint.Checkpoint, steps ...workflow.Step) *workflow.Workflow {
+	w := workflow.New(id, steps...)
+	w.Logf = printStatus
+	if cp != nil {
+		w.Journal = cp
+	}
+	return w
+}
+
+// openWorkflowCheckpoint opens the checkpoint of a workflow command
+// A dry run does not create a checkpoint file, but reads the one given with --resume
+func openWorkflowCheckpoint(cmd *cobra.Command) (*checkpoint.Checkpoint, error) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	resume, _ := cmd.Flags().GetString("resume")
+	if dryRun && resume == "" {
+		return nil, nil
+	}
+	return openCheckpoint(cmd)
+}
+
+// printPlan prints the steps of a workflow
+func printPlan(w *workflow.Workflow) {
+	printStatus("Plan:")
+	for _, line := range w.Plan() {
+		printStatus("  %s", line)
+	}
+}
+
+// runWorkflow runs a workflow, rolling back unless --no-rollback is given
+// It returns the error of the failed step after reporting the steps that were kept
+func runWorkflow(cmd *cobra.Command, w *workflow.Workflow, cp *checkpoint.Checkpoint) error {
+	noRollback, _ := cmd.Flags().GetBool("no-rollback")
+
+	err := w.Run(!noRollback)
+	var failed *workflow.Error
+	if !errors.As(err, &failed) {
+		return err
+	}
+	if len(failed.RolledBack) > 0 {
+		printStatus("Rolled back: %s", strings.Join(failed.RolledBack, ", "))
+	}
+	if len(failed.Kept) > 0 {
+		printStatus("Warning: completed steps were kept: %s", strings.Join(failed.Kept, ", "))
+		if cp != nil {
+			printStatus("Resume with --resume %s", cp.Path)
+		}
+	}
+	return failed.Err
+}
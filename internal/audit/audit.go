@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the audit log in the config directory
+const FileName = "audit.jsonl"
+
+// Actions recorded in the audit log
+const (
+	ActionRotateKey = "rotate-key"
+)
+
+// Entry is a change to credentials made through the CLI
+// Keys are stored masked; Revoked is nil when the old key was not checked
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Profile string    `json:"profile"`
+	Account string    `json:"account"`
+	OldKey  string    `json:"old_key"`
+	NewKey  string    `json:"new_key"`
+	Revoked *bool     `json:"old_key_revoked,omitempty"`
+}
+
+// Path returns the audit log path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Append appends an entry to the audit log
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("error writing audit log: %w", err)
+	}
+	return nil
+}
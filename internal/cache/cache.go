@@ -0,0 +1,88 @@
+// Package cache keeps API objects on disk between commands for a limited time
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// FileName is the name of the cache file in the config directory
+const FileName = "cache.json"
+
+// Entry is an API response body and when it was stored
+type Entry struct {
+	Body     json.RawMessage `json:"body"`
+	StoredAt time.Time       `json:"stored_at"`
+}
+
+// Cache maps keys (see Key) to response bodies
+type Cache map[string]Entry
+
+// Path returns the cache file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Key identifies an object of an account; objects of different profiles and modes never mix
+func Key(profile string, live bool, object, id string) string {
+	mode := "test"
+	if live {
+		mode = "live"
+	}
+	return profile + "/" + mode + "/" + object + "/" + id
+}
+
+// Load reads the cache, returning an empty cache if the file does not exist or is unreadable
+func Load(path string) Cache {
+	c := Cache{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	json.Unmarshal(data, &c)
+	return c
+}
+
+// Get returns the body stored for a key if it was stored less than ttl before now
+func (c Cache) Get(key string, ttl time.Duration, now time.Time) ([]byte, bool) {
+	entry, ok := c[key]
+	if !ok || now.Sub(entry.StoredAt) >= ttl {
+		return nil, false
+	}
+	return entry.Body, true
+}
+
+// Put stores a body for a key
+func (c Cache) Put(key string, body []byte, now time.Time) {
+	c[key] = Entry{Body: body, StoredAt: now}
+}
+
+// Prune removes the entries stored ttl or longer before now and reports whether any were removed
+func (c Cache) Prune(ttl time.Duration, now time.Time) bool {
+	pruned := false
+	for key, entry := range c {
+		if now.Sub(entry.StoredAt) >= ttl {
+			delete(c, key)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// Save writes the cache through a temp file and rename
+// The file can contain personal data such as email addresses, so it is only readable by the user
+func (c Cache) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := util.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
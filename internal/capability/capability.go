@@ -0,0 +1,148 @@
+// Package capability records which API features the account and the embedded SDK support
+// Commands that depend on features not every account has (PAY.JP Platform, statements)
+// probe them when a request fails, so that a missing feature is reported as such instead of
+// as an opaque 404
+package capability
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// Capabilities
+const (
+	Statements      = "statements"
+	Balances        = "balances"
+	Terms           = "terms"
+	Tenants         = "tenants"
+	TenantTransfers = "tenant_transfers"
+)
+
+// Capability is an API feature that is probed with a cheap list request
+type Capability struct {
+	Name string
+	// Path is the endpoint probed to detect the feature
+	Path string
+	// SDK is whether the embedded payjp-go has a service for the feature; the CLI sends
+	// its own requests for the others
+	SDK bool
+	// Commands are the commands that depend on the feature
+	Commands string
+}
+
+// All lists every capability in the order they are reported
+var All = []Capability{
+	{Name: Statements, Path: "/statements?limit=1", SDK: true, Commands: "statements"},
+	{Name: Balances, Path: "/balances?limit=1", SDK: true, Commands: "balances"},
+	{Name: Terms, Path: "/terms?limit=1", SDK: true, Commands: "terms"},
+	{Name: Tenants, Path: "/tenants?limit=1", SDK: false, Commands: "tenants, --tenant, charges get --with-fees"},
+	{Name: TenantTransfers, Path: "/tenant_transfers?limit=1", SDK: false, Commands: "tenant-transfers, transfers list --tenant"},
+}
+
+// Lookup returns the capability with the given name
+func Lookup(name string) (Capability, bool) {
+	for _, c := range All {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Capability{}, false
+}
+
+// Statuses
+const (
+	StatusSupported   = "supported"
+	StatusUnsupported = "unsupported"
+	StatusUnknown     = "unknown"
+)
+
+// StatusOf interprets the HTTP status of a probe
+// A 403 or 404 for a list endpoint means the account or the API does not have the feature;
+// anything other than success says nothing about it (e.g. an invalid key or a server error)
+func StatusOf(httpStatus int) string {
+	switch {
+	case httpStatus >= 200 && httpStatus < 300:
+		return StatusSupported
+	case httpStatus == http.StatusForbidden || httpStatus == http.StatusNotFound:
+		return StatusUnsupported
+	default:
+		return StatusUnknown
+	}
+}
+
+// TTL is how long a probe result is reused
+const TTL = 24 * time.Hour
+
+// Result is the detected status of a capability
+type Result struct {
+	Status     string    `json:"status"`
+	HTTPStatus int       `json:"http_status"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Fresh reports whether the result can still be used
+func (r Result) Fresh(now time.Time) bool {
+	return r.Status != StatusUnknown && now.Sub(r.CheckedAt) < TTL
+}
+
+// FileName is the name of the file in the config directory that caches probe results
+const FileName = "capabilities.json"
+
+// Cache maps an account key (profile and mode) and a capability name to the probe result
+type Cache map[string]map[string]Result
+
+// Path returns the cache file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// AccountKey identifies the account a result belongs to
+func AccountKey(profile string, live bool) string {
+	if live {
+		return profile + "/live"
+	}
+	return profile + "/test"
+}
+
+// Load reads the cache, returning an empty cache if the file does not exist or is unreadable
+func Load(path string) Cache {
+	cache := Cache{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	json.Unmarshal(data, &cache)
+	return cache
+}
+
+// Get returns the cached result of a capability for an account
+func (c Cache) Get(account, name string) (Result, bool) {
+	result, ok := c[account][name]
+	return result, ok
+}
+
+// Set records the result of a capability for an account
+func (c Cache) Set(account, name string, result Result) {
+	if c[account] == nil {
+		c[account] = map[string]Result{}
+	}
+	c[account][name] = result
+}
+
+// Save writes the cache
+func (c Cache) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := util.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package changelog
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Unreleased is the version of changes that are not in a release yet
+const Unreleased = "unreleased"
+
+// embedded holds the release notes of this build
+//
+//go:embed changelog.yaml
+var embedded []byte
+
+// Change is one user visible change
+type Change struct {
+	Type     string `yaml:"type"`
+	Scope    string `yaml:"scope"`
+	Summary  string `yaml:"summary"`
+	Breaking bool   `yaml:"breaking"`
+}
+
+// Release is a version with its changes
+type Release struct {
+	Version string   `yaml:"version"`
+	Date    string   `yaml:"date"`
+	Changes []Change `yaml:"changes"`
+}
+
+// Embedded returns the release notes built into the CLI, newest first
+func Embedded() ([]Release, error) {
+	return Parse(embedded)
+}
+
+// Parse parses release notes in the format of changelog.yaml
+func Parse(data []byte) ([]Release, error) {
+	var notes struct {
+		Releases []Release `yaml:"releases"`
+	}
+	if err := yaml.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("invalid release notes: %w", err)
+	}
+	return notes.Releases, nil
+}
+
+// Between returns the releases newer than since and not newer than until
+// An empty since or until leaves that end open
+func Between(releases []Release, since, until string) []Release {
+	result := []Release{}
+	for _, r := range releases {
+		if since != "" && Compare(r.Version, since) <= 0 {
+			continue
+		}
+		if until != "" && Compare(r.Version, until) > 0 {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// Compare compares two versions such as v1.2.0 and 1.10.0 numerically
+// Unreleased, dev builds and versions that cannot be parsed are newer than any release
+func Compare(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	}
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsRelease reports whether a version has the form v1.2.3 (the v and patch are optional)
+// Builds between releases, such as v1.2.3-4-gabcdef from git describe, are not releases
+func IsRelease(v string) bool {
+	_, ok := parseVersion(v)
+	return ok && !strings.ContainsAny(v, "-+")
+}
+
+// parseVersion parses major.minor.patch, ignoring a leading v and pre-release or build suffixes
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) < 2 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
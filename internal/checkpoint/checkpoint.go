@@ -0,0 +1,185 @@
+package checkpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirName is the name of the checkpoint directory in the config directory
+const DirName = "checkpoints"
+
+// Entry records a completed item of a bulk operation
+type Entry struct {
+	Command string    `json:"command"`
+	Key     string    `json:"key"`
+	Value   string    `json:"value,omitempty"`
+	Undone  bool      `json:"undone,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Checkpoint tracks completed items of a bulk operation in an append-only file
+// Each completed item is written and synced immediately, so an interrupted run can be resumed
+// A Checkpoint is safe for concurrent use
+type Checkpoint struct {
+	Path    string
+	command string
+	mu      sync.Mutex
+	done    map[string]string
+	file    *os.File
+}
+
+// NewPath returns a new checkpoint file path in the given config directory
+func NewPath(dir, command string, now time.Time) string {
+	name := strings.ReplaceAll(command, " ", "-") + "-" + now.Format("20060102-150405") + ".jsonl"
+	return filepath.Join(dir, DirName, name)
+}
+
+// Open opens a checkpoint file for the command, loading completed items if the file exists
+// A checkpoint written by another command is rejected
+func Open(path, command string) (*Checkpoint, error) {
+	c := &Checkpoint{Path: path, command: command, done: map[string]string{}}
+
+	entries, truncated, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Command != command {
+			return nil, fmt.Errorf("checkpoint %s was written by '%s', not '%s'", path, entry.Command, command)
+		}
+		if entry.Undone {
+			delete(c.done, entry.Key)
+		} else {
+			c.done[entry.Key] = entry.Value
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("error creating checkpoint directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint file: %w", err)
+	}
+	c.file = f
+
+	// Terminate a truncated line so that new entries start on their own line
+	if truncated {
+		if _, err := f.Write([]byte("\n")); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error writing checkpoint file: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Resume opens an existing checkpoint file
+func Resume(path, command string) (*Checkpoint, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("error opening checkpoint file: %w", err)
+	}
+	return Open(path, command)
+}
+
+// Done reports whether the item was completed in a previous run
+func (c *Checkpoint) Done(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.done[key]
+	return ok
+}
+
+// Value returns the value recorded with a completed item
+func (c *Checkpoint) Value(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.done[key]
+	return value, ok
+}
+
+// Len returns the number of completed items
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Mark records the item as completed and syncs the file
+func (c *Checkpoint) Mark(key string) error {
+	return c.MarkValue(key, "")
+}
+
+// MarkValue records the item as completed with a value, such as the ID of an object it created
+func (c *Checkpoint) MarkValue(key, value string) error {
+	if err := c.write(Entry{Command: c.command, Key: key, Value: value, Time: time.Now()}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.done[key] = value
+	c.mu.Unlock()
+	return nil
+}
+
+// Unmark records that a completed item was undone, so that a resumed run does it again
+func (c *Checkpoint) Unmark(key string) error {
+	if err := c.write(Entry{Command: c.command, Key: key, Undone: true, Time: time.Now()}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	delete(c.done, key)
+	c.mu.Unlock()
+	return nil
+}
+
+// write appends an entry to the file and syncs it
+func (c *Checkpoint) write(entry Entry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("error writing checkpoint file: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("error writing checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the checkpoint file
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// load reads all entries from a checkpoint file
+// A missing file has no entries; a truncated last line from an interrupted write is skipped
+func load(path string) ([]Entry, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("error reading checkpoint file: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	truncated := len(data) > 0 && data[len(data)-1] != '\n'
+	return entries, truncated, nil
+}
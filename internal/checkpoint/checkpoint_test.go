@@ -0,0 +1,109 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSkipsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	data := `{"command":"charges dedupe","key":"ch_1","time":"2024-06-01T00:00:00Z"}
+{"command":"charges dedupe","key":"ch_2","time":"2024-06-01T00:00:01Z"}
+{"command":"charges dedupe","key":"ch_3","ti`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, truncated, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("truncated = false, want true")
+	}
+	if len(entries) != 2 || entries[0].Key != "ch_1" || entries[1].Key != "ch_2" {
+		t.Errorf("entries = %+v, want ch_1 and ch_2", entries)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	entries, truncated, err := load(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || truncated || len(entries) != 0 {
+		t.Errorf("load() = %v, %v, %v, want no entries", entries, truncated, err)
+	}
+}
+
+func TestOpenAfterTruncatedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	data := `{"command":"charges reauthorize","key":"ch_1/create","value":"ch_2","time":"2024-06-01T00:00:00Z"}
+{"command":"charges reauthorize","key":"ch_1/ca`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Open(path, "charges reauthorize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkValue("ch_1/cancel", "ch_1"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	// The entry written after the truncated line is on its own line and read back
+	c, err = Resume(path, "charges reauthorize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if value, ok := c.Value("ch_1/create"); !ok || value != "ch_2" {
+		t.Errorf("Value(ch_1/create) = %q, %v, want ch_2", value, ok)
+	}
+	if !c.Done("ch_1/cancel") {
+		t.Error("Done(ch_1/cancel) = false, want true")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestUnmark(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	c, err := Open(path, "charges reauthorize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.MarkValue("ch_1/create", "ch_2")
+	c.Unmark("ch_1/create")
+	c.Close()
+
+	c, err = Resume(path, "charges reauthorize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Done("ch_1/create") {
+		t.Error("Done(ch_1/create) = true after Unmark, want false")
+	}
+}
+
+func TestOpenRejectsOtherCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	c, err := Open(path, "charges dedupe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Mark("ch_1")
+	c.Close()
+
+	if _, err := Open(path, "charges refund"); err == nil {
+		t.Error("Open() with another command succeeded")
+	}
+}
+
+func TestResumeMissingFile(t *testing.T) {
+	if _, err := Resume(filepath.Join(t.TempDir(), "missing.jsonl"), "charges dedupe"); err == nil {
+		t.Error("Resume() of a missing file succeeded")
+	}
+}
@@ -2,13 +2,26 @@ package client
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/metrics"
+	"github.com/payjp/payjp-cli/internal/usage"
 	"github.com/payjp/payjp-go/v1"
 )
 
 var (
-	client *payjp.Service
+	client  *payjp.Service
+	apiKey  string
+	tenant  string
+	capture *captureTransport
+
+	idempotency *idempotencyTransport
 )
 
 // Options represents client options
@@ -17,6 +30,15 @@ type Options struct {
 	MaxRetry     int
 	InitialDelay int
 	MaxDelay     int
+	MaxWait      int
+	Capture      bool
+	Language     string
+	Timeout      time.Duration
+	Tenant       string
+	UserAgent    string
+	Usage        *usage.Counter
+	Metrics      *metrics.Registry
+	Logf         func(format string, args ...interface{})
 }
 
 // Option is a function that configures Options
@@ -50,6 +72,108 @@ func WithMaxDelay(delay int) Option {
 	}
 }
 
+// WithMaxWait sets the upper bound in seconds for a single retry wait
+func WithMaxWait(seconds int) Option {
+	return func(o *Options) {
+		o.MaxWait = seconds
+	}
+}
+
+// WithCapture records the raw body of every successful GET response (see Captured)
+func WithCapture(enabled bool) Option {
+	return func(o *Options) {
+		o.Capture = enabled
+	}
+}
+
+// WithLanguage sets the language requested for API messages (e.g. ja)
+func WithLanguage(language string) Option {
+	return func(o *Options) {
+		o.Language = language
+	}
+}
+
+// WithTimeout sets the time limit for each HTTP request, including retries
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+// WithTenant scopes the queries that support it to a PAY.JP Platform tenant
+func WithTenant(tenant string) Option {
+	return func(o *Options) {
+		o.Tenant = tenant
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every API request
+func WithUserAgent(userAgent string) Option {
+	return func(o *Options) {
+		o.UserAgent = userAgent
+	}
+}
+
+// WithUsage counts every API request per endpoint in counter
+func WithUsage(counter *usage.Counter) Option {
+	return func(o *Options) {
+		o.Usage = counter
+	}
+}
+
+// WithMetrics records the count, failures and latency of every API request in registry
+func WithMetrics(registry *metrics.Registry) Option {
+	return func(o *Options) {
+		o.Metrics = registry
+	}
+}
+
+// WithLogf sets the function used to log retry activity
+func WithLogf(logf func(format string, args ...interface{})) Option {
+	return func(o *Options) {
+		o.Logf = logf
+	}
+}
+
+// ReadAPIKey reads an API key from the first line of r
+// Surrounding whitespace, including the trailing newline, is removed
+// r is read one byte at a time and not past the newline, so that the rest of stdin is left for
+// the command, e.g. the CSV of customers bulk-update --file -
+func ReadAPIKey(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key: %w", err)
+		}
+	}
+	key := strings.TrimSpace(string(line))
+	if key == "" {
+		return "", i18n.Errorf("failed to read API key: input is empty")
+	}
+	return key, nil
+}
+
+// ReadAPIKeyFile reads an API key from the first line of a file
+func ReadAPIKeyFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	defer f.Close()
+	return ReadAPIKey(f)
+}
+
 // Init initializes the PAY.JP client
 func Init(opts ...Option) error {
 	retryCfg := config.GetRetryConfig()
@@ -66,23 +190,101 @@ func Init(opts ...Option) error {
 	}
 
 	if options.APIKey == "" {
-		return fmt.Errorf("API key is required. Set it via --api-key flag, PAYJP_API_KEY environment variable, or config file")
+		return i18n.Errorf("API key is required. Set it via --api-key or --api-key-stdin flag, PAYJP_API_KEY or PAYJP_API_KEY_FILE environment variable, or config file")
+	}
+
+	if err := config.GetHTTPConfig().Validate(); err != nil {
+		return i18n.Errorf("invalid config file: %v", err)
+	}
+
+	// Retries are handled by the transport so that Retry-After can be honored
+	var transport http.RoundTripper = &retryTransport{
+		base:         sharedTransport(),
+		maxRetry:     options.MaxRetry,
+		initialDelay: time.Duration(options.InitialDelay) * time.Second,
+		maxDelay:     time.Duration(options.MaxDelay) * time.Second,
+		maxWait:      time.Duration(options.MaxWait) * time.Second,
+		logf:         options.Logf,
+	}
+	if options.Language != "" {
+		transport = &languageTransport{base: transport, language: options.Language}
+	}
+	if options.UserAgent != "" {
+		transport = &userAgentTransport{base: transport, userAgent: options.UserAgent}
+	}
+	if options.Usage != nil {
+		transport = &usageTransport{base: transport, counter: options.Usage}
+	}
+	if options.Metrics != nil {
+		transport = newMetricsTransport(transport, options.Metrics)
+	}
+	idempotency = &idempotencyTransport{base: transport}
+	transport = idempotency
+	capture = nil
+	if options.Capture {
+		capture = &captureTransport{base: transport}
+		transport = capture
 	}
+	httpClient := &http.Client{Transport: transport, Timeout: options.Timeout}
 
-	client = payjp.New(options.APIKey, nil,
-		payjp.WithMaxCount(options.MaxRetry),
-		payjp.WithInitialDelay(float64(options.InitialDelay)),
-		payjp.WithMaxDelay(float64(options.MaxDelay)),
+	apiKey = options.APIKey
+	tenant = options.Tenant
+	client = payjp.New(options.APIKey, httpClient,
+		payjp.WithMaxCount(0),
 	)
 
 	return nil
 }
 
+// HTTPClient returns a client without API authentication that shares the connection pool of
+// the API client, e.g. for downloading files from URLs returned by the API
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: sharedTransport()}
+}
+
+// Captured returns the response bodies recorded since the last call and clears them
+// It returns nil unless the client was initialized with WithCapture
+func Captured() [][]byte {
+	if capture == nil {
+		return nil
+	}
+	return capture.take()
+}
+
+// Idempotent runs fn with the Idempotency-Key header set to key on its POST requests
+// The API returns the response of the first request for a repeated key instead of acting again
+func Idempotent(key string, fn func() error) error {
+	if idempotency == nil {
+		return fmt.Errorf("client is not initialized")
+	}
+	idempotency.setKey(key)
+	defer idempotency.setKey("")
+	return fn()
+}
+
 // Get returns the PAY.JP client
 func Get() *payjp.Service {
 	return client
 }
 
+// Tenant returns the tenant ID that queries are scoped to, or "" for the whole platform
+func Tenant() string {
+	return tenant
+}
+
+// TenantParam returns the tenant ID as a request parameter, or nil when no tenant is set
+func TenantParam() *string {
+	if tenant == "" {
+		return nil
+	}
+	return payjp.String(tenant)
+}
+
+// IsLiveKey returns true if the client was initialized with a live secret key
+func IsLiveKey() bool {
+	return strings.HasPrefix(apiKey, "sk_live_")
+}
+
 // GetCharge returns the Charge service
 func GetCharge() *payjp.ChargeService {
 	return client.Charge
@@ -137,3 +339,8 @@ func GetBalance() *payjp.BalanceService {
 func GetAccount() *payjp.AccountService {
 	return client.Account
 }
+
+// GetThreeDSecureRequest returns the ThreeDSecureRequest service
+func GetThreeDSecureRequest() *payjp.ThreeDSecureRequestService {
+	return client.ThreeDSecureRequest
+}
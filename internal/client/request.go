@@ -0,0 +1,282 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-go/v1"
+)
+
+// Request sends an authenticated request to the PAY.JP API and returns the response body
+// It is used for endpoints that the SDK does not cover. API errors are returned as *payjp.Error
+func Request(method, path string, params url.Values) ([]byte, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client is not initialized")
+	}
+
+	endpoint := client.APIBase() + path
+	var body io.Reader
+	if len(params) > 0 {
+		if method == http.MethodGet || method == http.MethodDelete {
+			endpoint += "?" + params.Encode()
+		} else {
+			body = strings.NewReader(params.Encode())
+		}
+	}
+
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(apiKey, "")
+	req.Header.Set("User-Agent", "Go-http-client/payjp-"+payjp.Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, parseError(resp.StatusCode, b)
+	}
+	return b, nil
+}
+
+// parseError converts an error response body into a *payjp.Error
+func parseError(status int, body []byte) error {
+	var errResp struct {
+		Error *payjp.Error `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		if errResp.Error.Status == 0 {
+			errResp.Error.Status = status
+		}
+		return errResp.Error
+	}
+	return &payjp.Error{
+		Status:  status,
+		Message: strings.TrimSpace(string(body)),
+	}
+}
+
+// RetrieveSubscription retrieves a subscription by ID without its customer ID
+func RetrieveSubscription(id string) (*payjp.SubscriptionResponse, error) {
+	body, err := Request(http.MethodGet, "/subscriptions/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &payjp.SubscriptionResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateChargeMetadata sets metadata on a charge without touching its description
+func UpdateChargeMetadata(id string, metadata map[string]string) (*payjp.ChargeResponse, error) {
+	params := url.Values{}
+	for key, value := range metadata {
+		params.Set("metadata["+key+"]", value)
+	}
+
+	body, err := Request(http.MethodPost, "/charges/"+url.PathEscape(id), params)
+	if err != nil {
+		return nil, err
+	}
+	result := &payjp.ChargeResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListCards lists the cards of a customer with one request
+// The SDK retrieves the customer before listing its cards
+func ListCards(customerID string, params url.Values) ([]*payjp.CardResponse, bool, error) {
+	body, err := Request(http.MethodGet, "/customers/"+url.PathEscape(customerID)+"/cards", params)
+	if err != nil {
+		return nil, false, err
+	}
+	var list struct {
+		Data    []*payjp.CardResponse `json:"data"`
+		HasMore bool                  `json:"has_more"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, false, err
+	}
+	return list.Data, list.HasMore, nil
+}
+
+// PlatformFields holds PAY.JP Platform fields of a charge that the SDK does not decode
+type PlatformFields struct {
+	Tenant          string `json:"tenant"`
+	PlatformFee     *int   `json:"platform_fee"`
+	PlatformFeeRate string `json:"platform_fee_rate"`
+}
+
+// RetrieveChargeWithPlatform retrieves a charge along with its platform fields
+func RetrieveChargeWithPlatform(id string) (*payjp.ChargeResponse, *PlatformFields, error) {
+	body, err := Request(http.MethodGet, "/charges/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := &payjp.ChargeResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, nil, err
+	}
+	platform := &PlatformFields{}
+	if err := json.Unmarshal(body, platform); err != nil {
+		return nil, nil, err
+	}
+	return result, platform, nil
+}
+
+// ChargeWithPlatform is a charge along with its platform fields
+type ChargeWithPlatform struct {
+	Charge   *payjp.ChargeResponse
+	Platform *PlatformFields
+}
+
+// ListChargesWithPlatform lists charges along with their platform fields
+func ListChargesWithPlatform(params url.Values) ([]ChargeWithPlatform, bool, error) {
+	body, err := Request(http.MethodGet, "/charges", params)
+	if err != nil {
+		return nil, false, err
+	}
+	var list struct {
+		Data    []json.RawMessage `json:"data"`
+		HasMore bool              `json:"has_more"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, false, err
+	}
+	result := make([]ChargeWithPlatform, 0, len(list.Data))
+	for _, raw := range list.Data {
+		item := ChargeWithPlatform{Charge: &payjp.ChargeResponse{}, Platform: &PlatformFields{}}
+		if err := json.Unmarshal(raw, item.Charge); err != nil {
+			return nil, false, err
+		}
+		if err := json.Unmarshal(raw, item.Platform); err != nil {
+			return nil, false, err
+		}
+		result = append(result, item)
+	}
+	return result, list.HasMore, nil
+}
+
+// RetrieveTenantFeeRate retrieves the platform fee rate of a tenant
+func RetrieveTenantFeeRate(id string) (string, error) {
+	body, err := Request(http.MethodGet, "/tenants/"+url.PathEscape(id), nil)
+	if err != nil {
+		return "", err
+	}
+	var tenant struct {
+		PlatformFeeRate string `json:"platform_fee_rate"`
+	}
+	if err := json.Unmarshal(body, &tenant); err != nil {
+		return "", err
+	}
+	return tenant.PlatformFeeRate, nil
+}
+
+// TenantTransfer is a transfer to a PAY.JP Platform tenant
+type TenantTransfer struct {
+	ID             string `json:"id"`
+	Amount         int    `json:"amount"`
+	Currency       string `json:"currency"`
+	Status         string `json:"status"`
+	TenantID       string `json:"tenant_id"`
+	ScheduledDate  string `json:"scheduled_date"`
+	TransferDate   string `json:"transfer_date"`
+	TransferAmount int    `json:"transfer_amount"`
+	TermStart      int    `json:"term_start"`
+	TermEnd        int    `json:"term_end"`
+	Created        int    `json:"created"`
+}
+
+// ListTenantTransfers lists the transfers to a tenant, or to every tenant when tenant is empty
+func ListTenantTransfers(tenant string, params url.Values) ([]*TenantTransfer, bool, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if tenant != "" {
+		query.Set("tenant", tenant)
+	}
+
+	body, err := Request(http.MethodGet, "/tenant_transfers", query)
+	if err != nil {
+		return nil, false, err
+	}
+	var list struct {
+		Data    []*TenantTransfer `json:"data"`
+		HasMore bool              `json:"has_more"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, false, err
+	}
+	return list.Data, list.HasMore, nil
+}
+
+// RetrieveTenantTransfer retrieves a transfer to a tenant
+func RetrieveTenantTransfer(id string) (*TenantTransfer, error) {
+	body, err := Request(http.MethodGet, "/tenant_transfers/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &TenantTransfer{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ProbeResult is the outcome of a request sent by Probe
+type ProbeResult struct {
+	Status  int
+	Latency time.Duration
+	// RetryAfter is the wait requested by a rate limited response, if it had a Retry-After header
+	RetryAfter    time.Duration
+	HasRetryAfter bool
+}
+
+// Probe sends an authenticated GET request once, without retrying a rate limited response,
+// and returns its status and timing. It is used to observe the rate limit of the account
+func Probe(path string) (*ProbeResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client is not initialized")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, client.APIBase()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(apiKey, "")
+	req.Header.Set("User-Agent", "Go-http-client/payjp-"+payjp.Version)
+
+	start := time.Now()
+	resp, err := HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	result := &ProbeResult{Status: resp.StatusCode, Latency: time.Since(start)}
+	result.RetryAfter, result.HasRetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	return result, nil
+}
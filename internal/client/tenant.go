@@ -0,0 +1,99 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// The embedded payjp-go has no tenant service, so PAY.JP Platform tenants are managed with
+// requests sent by Request
+
+// TenantResponse is a PAY.JP Platform tenant (a merchant of a marketplace)
+type TenantResponse struct {
+	ID                    string            `json:"id"`
+	Name                  string            `json:"name"`
+	PlatformFeeRate       string            `json:"platform_fee_rate"`
+	PayjpFeeIncluded      bool              `json:"payjp_fee_included"`
+	MinimumTransferAmount int               `json:"minimum_transfer_amount"`
+	BankCode              string            `json:"bank_code"`
+	BankBranchCode        string            `json:"bank_branch_code"`
+	BankAccountType       string            `json:"bank_account_type"`
+	BankAccountNumber     string            `json:"bank_account_number"`
+	BankAccountHolderName string            `json:"bank_account_holder_name"`
+	BankAccountStatus     string            `json:"bank_account_status"`
+	CurrenciesSupported   []string          `json:"currencies_supported"`
+	DefaultCurrency       string            `json:"default_currency"`
+	Metadata              map[string]string `json:"metadata"`
+	LiveMode              bool              `json:"livemode"`
+	Created               int               `json:"created"`
+}
+
+// TenantApplicationURL is a URL where a tenant applies for live mode (review of the merchant)
+type TenantApplicationURL struct {
+	URL     string `json:"url"`
+	Expires int    `json:"expires"`
+}
+
+// CreateTenant creates a tenant
+func CreateTenant(params url.Values) (*TenantResponse, error) {
+	return tenantRequest(http.MethodPost, "/tenants", params)
+}
+
+// RetrieveTenant retrieves a tenant
+func RetrieveTenant(id string) (*TenantResponse, error) {
+	return tenantRequest(http.MethodGet, "/tenants/"+url.PathEscape(id), nil)
+}
+
+// UpdateTenant updates a tenant
+func UpdateTenant(id string, params url.Values) (*TenantResponse, error) {
+	return tenantRequest(http.MethodPost, "/tenants/"+url.PathEscape(id), params)
+}
+
+// DeleteTenant deletes a tenant
+func DeleteTenant(id string) error {
+	_, err := Request(http.MethodDelete, "/tenants/"+url.PathEscape(id), nil)
+	return err
+}
+
+// ListTenants lists tenants
+func ListTenants(params url.Values) ([]*TenantResponse, bool, error) {
+	body, err := Request(http.MethodGet, "/tenants", params)
+	if err != nil {
+		return nil, false, err
+	}
+	var list struct {
+		Data    []*TenantResponse `json:"data"`
+		HasMore bool              `json:"has_more"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, false, err
+	}
+	return list.Data, list.HasMore, nil
+}
+
+// CreateTenantApplicationURL issues a URL where a tenant applies for live mode
+func CreateTenantApplicationURL(id string) (*TenantApplicationURL, error) {
+	body, err := Request(http.MethodPost, "/tenants/"+url.PathEscape(id)+"/application_urls", nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &TenantApplicationURL{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// tenantRequest sends a request that returns a tenant
+func tenantRequest(method, path string, params url.Values) (*TenantResponse, error) {
+	body, err := Request(method, path, params)
+	if err != nil {
+		return nil, err
+	}
+	result := &TenantResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
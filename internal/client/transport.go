@@ -0,0 +1,294 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+	"github.com/payjp/payjp-cli/internal/metrics"
+	"github.com/payjp/payjp-cli/internal/usage"
+)
+
+var (
+	poolOnce sync.Once
+	pool     *http.Transport
+)
+
+// sharedTransport returns the connection pool used by every request of the process
+// It is created once, so reinitializing the client keeps the open connections. The default
+// transport keeps only two idle connections per host, which makes parallel bulk commands
+// open a new TLS connection for most requests (see BenchmarkPoolTransport)
+func sharedTransport() *http.Transport {
+	poolOnce.Do(func() {
+		pool = newPoolTransport(config.GetHTTPConfig())
+	})
+	return pool
+}
+
+// newPoolTransport creates a transport tuned for many requests to the same host
+func newPoolTransport(cfg config.HTTPConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(cfg.DialTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	t.DialContext = dialer.DialContext
+	t.MaxIdleConns = 0
+	t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	t.IdleConnTimeout = time.Duration(cfg.IdleConnTimeout) * time.Second
+	t.TLSHandshakeTimeout = time.Duration(cfg.TLSHandshakeTimeout) * time.Second
+	t.ResponseHeaderTimeout = time.Duration(cfg.ResponseHeaderTimeout) * time.Second
+	t.ForceAttemptHTTP2 = !cfg.DisableHTTP2
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map disables the HTTP/2 upgrade
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// retryTransport retries rate limited requests, honoring the Retry-After header
+type retryTransport struct {
+	base         http.RoundTripper
+	maxRetry     int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	maxWait      time.Duration
+	logf         func(format string, args ...interface{})
+}
+
+// RoundTrip executes a request and retries it while the API responds with 429
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	for retry := 0; retry < t.maxRetry; retry++ {
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+
+		delay, fromHeader := t.delay(resp, retry)
+		if fromHeader {
+			t.log("Rate limited (retry %d/%d). Retry-After: waiting %v", retry+1, t.maxRetry, delay)
+		} else {
+			t.log("Rate limited (retry %d/%d). Backing off for %v", retry+1, t.maxRetry, delay)
+		}
+
+		// Rewind the request body for the next attempt
+		if req.Body != nil {
+			if req.GetBody == nil {
+				break
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			req.Body = body
+		}
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		resp, err = t.base.RoundTrip(req)
+	}
+	return resp, err
+}
+
+// delay returns how long to wait before the next retry and whether it came from Retry-After
+func (t *retryTransport) delay(resp *http.Response, retry int) (time.Duration, bool) {
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		if t.maxWait > 0 && wait > t.maxWait {
+			wait = t.maxWait
+		}
+		return wait, true
+	}
+
+	// Equal jitter exponential backoff, matching payjp-go
+	delay := math.Min(float64(t.maxDelay), float64(t.initialDelay)*math.Pow(2, float64(retry)))
+	half := delay / 2
+	wait := time.Duration(half + rand.Float64()*half)
+	if t.maxWait > 0 && wait > t.maxWait {
+		wait = t.maxWait
+	}
+	return wait, false
+}
+
+// log writes a retry message if a logger is configured
+func (t *retryTransport) log(format string, args ...interface{}) {
+	if t.logf != nil {
+		t.logf(format, args...)
+	}
+}
+
+// parseRetryAfter parses a Retry-After header value in seconds or HTTP-date form
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// languageTransport sets Accept-Language so that the API returns error messages in the user's language
+type languageTransport struct {
+	base     http.RoundTripper
+	language string
+}
+
+// RoundTrip executes a request with the Accept-Language header set
+func (t *languageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Language", t.language)
+	return t.base.RoundTrip(req)
+}
+
+// userAgentTransport sets User-Agent so that PAY.JP can tell which CLI build made a request
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip executes a request with the User-Agent header set
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// usageTransport counts the requests per endpoint for stats usage
+// It wraps the retry transport, so a retried request is counted once
+type usageTransport struct {
+	base    http.RoundTripper
+	counter *usage.Counter
+}
+
+// RoundTrip counts and executes a request
+func (t *usageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.counter.Add(req.Method, req.URL.Path)
+	return t.base.RoundTrip(req)
+}
+
+// metricsTransport records the count, failures and latency of the requests per endpoint
+// It wraps the retry transport, so a retried request is recorded once with its final outcome
+type metricsTransport struct {
+	base     http.RoundTripper
+	requests *metrics.Counter
+	errors   *metrics.Counter
+	duration *metrics.Histogram
+}
+
+// newMetricsTransport creates the API request metrics in registry
+func newMetricsTransport(base http.RoundTripper, registry *metrics.Registry) *metricsTransport {
+	return &metricsTransport{
+		base:     base,
+		requests: registry.Counter("payjp_api_requests_total", "API requests by endpoint and HTTP status (0 when no response was received)", "endpoint", "status"),
+		errors:   registry.Counter("payjp_api_errors_total", "API requests that failed or returned an error status", "endpoint"),
+		duration: registry.Histogram("payjp_api_request_duration_seconds", "Latency of API requests including retries", metrics.DefaultBuckets, "endpoint"),
+	}
+}
+
+// RoundTrip executes a request and records it
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := usage.Endpoint(req.Method, req.URL.Path)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.duration.Observe(time.Since(start).Seconds(), endpoint)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	t.requests.Inc(endpoint, strconv.Itoa(status))
+	if err != nil || status >= 400 {
+		t.errors.Inc(endpoint)
+	}
+	return resp, err
+}
+
+// idempotencyTransport sets Idempotency-Key on POST requests while a key is set, so that the
+// API returns the original response instead of acting twice when a request is sent again
+type idempotencyTransport struct {
+	base http.RoundTripper
+	mu   sync.Mutex
+	key  string
+}
+
+// RoundTrip executes a request with the Idempotency-Key header set
+func (t *idempotencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	key := t.key
+	t.mu.Unlock()
+	if key == "" || req.Method != http.MethodPost {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Idempotency-Key", key)
+	return t.base.RoundTrip(req)
+}
+
+// setKey sets the key sent with the following POST requests ("" stops sending it)
+func (t *idempotencyTransport) setKey(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.key = key
+}
+
+// captureTransport keeps a copy of successful GET response bodies so they can be printed untouched
+type captureTransport struct {
+	base   http.RoundTripper
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+// RoundTrip executes a request and records the response body of a successful GET
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	t.bodies = append(t.bodies, body)
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// take returns the recorded bodies and clears them
+func (t *captureTransport) take() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bodies := t.bodies
+	t.bodies = nil
+	return bodies
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+)
+
+// burstSize is the number of requests sent at once, like a bulk command with --concurrency 16
+const burstSize = 16
+
+// benchmarkTransport sends bursts of parallel requests to a TLS server and reports the
+// connections opened per burst, which is what the pool size is meant to keep down
+func benchmarkTransport(b *testing.B, transport *http.Transport) {
+	var conns int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The API takes a while to respond, so the requests of a burst overlap
+		time.Sleep(time.Millisecond)
+		io.WriteString(w, `{"object":"charge"}`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	defer transport.CloseIdleConnections()
+	httpClient := &http.Client{Transport: transport}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < burstSize; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := httpClient.Get(server.URL)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}()
+		}
+		wg.Wait()
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&conns))/float64(b.N), "conns/op")
+}
+
+func BenchmarkDefaultTransport(b *testing.B) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = false
+	t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	benchmarkTransport(b, t)
+}
+
+func BenchmarkPoolTransport(b *testing.B) {
+	benchmarkTransport(b, newPoolTransport(config.HTTPConfig{
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90,
+		DialTimeout:         10,
+		TLSHandshakeTimeout: 10,
+		DisableHTTP2:        true,
+	}))
+}
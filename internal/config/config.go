@@ -4,41 +4,146 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/payjp/payjp-cli/internal/keychain"
 	"github.com/spf13/viper"
 )
 
 // Config represents the CLI configuration
 type Config struct {
-	DefaultProfile string            `mapstructure:"default_profile"`
-	Output         OutputConfig      `mapstructure:"output"`
-	Retry          RetryConfig       `mapstructure:"retry"`
-	Profiles       map[string]Profile `mapstructure:"profiles"`
-	Aliases        map[string]string  `mapstructure:"aliases"`
+	DefaultProfile string                 `mapstructure:"default_profile" yaml:"default_profile"`
+	Output         OutputConfig           `mapstructure:"output" yaml:"output"`
+	Retry          RetryConfig            `mapstructure:"retry" yaml:"retry"`
+	HTTP           HTTPConfig             `mapstructure:"http" yaml:"http"`
+	Profiles       map[string]Profile     `mapstructure:"profiles" yaml:"profiles"`
+	Aliases        map[string]string      `mapstructure:"aliases" yaml:"aliases"`
+	Automation     AutomationConfig       `mapstructure:"automation" yaml:"automation"`
+	Limits         LimitsConfig           `mapstructure:"limits" yaml:"limits"`
+	Ranges         map[string]string      `mapstructure:"ranges" yaml:"ranges"`
+	Notify         NotifyConfig           `mapstructure:"notify" yaml:"notify"`
+	Defaults       map[string]interface{} `mapstructure:"defaults" yaml:"defaults"`
+	Theme          ThemeConfig            `mapstructure:"theme" yaml:"theme"`
+	Stats          StatsConfig            `mapstructure:"stats" yaml:"stats"`
+	Cache          CacheConfig            `mapstructure:"cache" yaml:"cache"`
 }
 
 // OutputConfig represents output settings
 type OutputConfig struct {
-	Format string `mapstructure:"format"`
-	Color  bool   `mapstructure:"color"`
+	Format     string `mapstructure:"format" yaml:"format"`
+	Color      bool   `mapstructure:"color" yaml:"color"`
+	SortKeys   bool   `mapstructure:"sort_keys" yaml:"sort_keys"`
+	ExpandMaps bool   `mapstructure:"expand_maps" yaml:"expand_maps"`
+	Locale     string `mapstructure:"locale" yaml:"locale"`
+	TimeFormat string `mapstructure:"time_format" yaml:"time_format"`
+}
+
+// ThemeConfig represents output colors
+// Name selects a built-in theme (dark, light or none); the other fields override its colors
+type ThemeConfig struct {
+	Name    string `mapstructure:"name" yaml:"name"`
+	Success string `mapstructure:"success" yaml:"success"`
+	Warn    string `mapstructure:"warn" yaml:"warn"`
+	Error   string `mapstructure:"error" yaml:"error"`
+	IDs     string `mapstructure:"ids" yaml:"ids"`
+	Amounts string `mapstructure:"amounts" yaml:"amounts"`
+}
+
+// StatsConfig represents the local usage counters shown by stats usage
+type StatsConfig struct {
+	Usage bool `mapstructure:"usage" yaml:"usage"`
+}
+
+// CacheConfig represents the on-disk cache of API objects
+// Customers is how long a customer is reused (e.g. 10m); empty keeps customers only in memory
+type CacheConfig struct {
+	Customers string `mapstructure:"customers" yaml:"customers"`
 }
 
 // RetryConfig represents retry settings
 type RetryConfig struct {
-	MaxCount     int `mapstructure:"max_count"`
-	InitialDelay int `mapstructure:"initial_delay"`
-	MaxDelay     int `mapstructure:"max_delay"`
+	MaxCount     int `mapstructure:"max_count" yaml:"max_count"`
+	InitialDelay int `mapstructure:"initial_delay" yaml:"initial_delay"`
+	MaxDelay     int `mapstructure:"max_delay" yaml:"max_delay"`
+}
+
+// HTTPConfig represents the connection settings shared by every API request
+// Timeouts are in seconds; 0 disables the response header timeout
+type HTTPConfig struct {
+	MaxIdleConnsPerHost   int  `mapstructure:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	IdleConnTimeout       int  `mapstructure:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+	DialTimeout           int  `mapstructure:"dial_timeout" yaml:"dial_timeout"`
+	TLSHandshakeTimeout   int  `mapstructure:"tls_handshake_timeout" yaml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout int  `mapstructure:"response_header_timeout" yaml:"response_header_timeout"`
+	DisableHTTP2          bool `mapstructure:"disable_http2" yaml:"disable_http2"`
+}
+
+// Validate checks that the connection settings can be used
+// A zero or negative timeout would make every connection fail, and a pool without idle
+// connections opens a new TLS connection for each request
+func (c HTTPConfig) Validate() error {
+	positive := []struct {
+		key   string
+		value int
+	}{
+		{"http.max_idle_conns_per_host", c.MaxIdleConnsPerHost},
+		{"http.idle_conn_timeout", c.IdleConnTimeout},
+		{"http.dial_timeout", c.DialTimeout},
+		{"http.tls_handshake_timeout", c.TLSHandshakeTimeout},
+	}
+	for _, setting := range positive {
+		if setting.value <= 0 {
+			return fmt.Errorf("%s must be greater than 0, got %d", setting.key, setting.value)
+		}
+	}
+	if c.ResponseHeaderTimeout < 0 {
+		return fmt.Errorf("http.response_header_timeout must be 0 or greater, got %d", c.ResponseHeaderTimeout)
+	}
+	return nil
+}
+
+// AutomationConfig represents settings for non-interactive use
+type AutomationConfig struct {
+	Allowlist []string `mapstructure:"allowlist" yaml:"allowlist"`
+}
+
+// LimitsConfig represents local spending limits applied in live mode
+type LimitsConfig struct {
+	MaxChargeAmount  int `mapstructure:"max_charge_amount" yaml:"max_charge_amount"`
+	DailyChargeTotal int `mapstructure:"daily_charge_total" yaml:"daily_charge_total"`
+}
+
+// NotifyConfig represents destinations for --notify completion notifications
+type NotifyConfig struct {
+	SlackWebhookURL string     `mapstructure:"slack_webhook_url" yaml:"slack_webhook_url"`
+	SMTP            SMTPConfig `mapstructure:"smtp" yaml:"smtp"`
+}
+
+// SMTPConfig represents the mail server used for email notifications
+type SMTPConfig struct {
+	Host     string   `mapstructure:"host" yaml:"host"`
+	Port     int      `mapstructure:"port" yaml:"port"`
+	Username string   `mapstructure:"username" yaml:"username"`
+	Password string   `mapstructure:"password" yaml:"password"`
+	From     string   `mapstructure:"from" yaml:"from"`
+	To       []string `mapstructure:"to" yaml:"to"`
 }
 
 // Profile represents an API profile
+// Keychain is set when the API key is kept in the OS keychain instead of api_key
+// Settings holds the config sections the profile overrides (e.g. output.format: json)
 type Profile struct {
-	APIKey string `mapstructure:"api_key"`
-	Mode   string `mapstructure:"mode"`
+	APIKey   string                 `mapstructure:"api_key" yaml:"api_key"`
+	Mode     string                 `mapstructure:"mode" yaml:"mode"`
+	Keychain bool                   `mapstructure:"keychain" yaml:"keychain,omitempty"`
+	Settings map[string]interface{} `mapstructure:",remain" yaml:",inline"`
 }
 
 var (
 	cfg        *Config
 	configPath string
+	// corruptErr is why the config file could not be loaded; the defaults are used meanwhile
+	corruptErr error
 )
 
 // DefaultConfigDir returns the default configuration directory
@@ -55,6 +160,22 @@ func DefaultConfigPath() string {
 	return filepath.Join(DefaultConfigDir(), "config.yaml")
 }
 
+// ConfigDir returns the directory of the configuration file in use
+func ConfigDir() string {
+	if configPath == "" {
+		return DefaultConfigDir()
+	}
+	return filepath.Dir(configPath)
+}
+
+// ConfigFilePath returns the path of the configuration file in use
+func ConfigFilePath() string {
+	if configPath == "" {
+		return DefaultConfigPath()
+	}
+	return configPath
+}
+
 // Init initializes the configuration
 func Init(cfgFile string) error {
 	if cfgFile != "" {
@@ -80,27 +201,49 @@ func Init(cfgFile string) error {
 	viper.SetDefault("retry.max_count", 3)
 	viper.SetDefault("retry.initial_delay", 2)
 	viper.SetDefault("retry.max_delay", 32)
+	viper.SetDefault("http.max_idle_conns_per_host", 32)
+	viper.SetDefault("http.idle_conn_timeout", 90)
+	viper.SetDefault("http.dial_timeout", 10)
+	viper.SetDefault("http.tls_handshake_timeout", 10)
+	viper.SetDefault("http.response_header_timeout", 60)
+	viper.SetDefault("stats.usage", true)
 
-	// Read environment variables
-	viper.SetEnvPrefix("PAYJP")
-	viper.AutomaticEnv()
+	// Environment variables are read by the settings they override (e.g. PAYJP_OUTPUT in
+	// GetOutputFormat) rather than by viper, so that PAYJP_OUTPUT cannot replace the whole
+	// output section of the config file
 
 	// Read config file if exists
+	// A malformed file does not stop commands: the defaults and environment variables are
+	// used instead until it is fixed with config repair
+	corruptErr = nil
 	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		switch err.(type) {
+		case viper.ConfigParseError:
+			corruptErr = err
+		case viper.ConfigFileNotFoundError:
+		default:
 			return fmt.Errorf("error reading config file: %w", err)
 		}
 	}
 
+	resolved = nil
 	cfg = &Config{}
 	if err := viper.Unmarshal(cfg); err != nil {
-		return fmt.Errorf("error unmarshaling config: %w", err)
+		corruptErr = err
+		cfg = nil
+		Get()
 	}
 
-	return nil
+	return checkProfiles(cfg.Profiles)
+}
+
+// Corrupt returns why the config file could not be loaded, or nil when it was loaded
+func Corrupt() error {
+	return corruptErr
 }
 
-// Get returns the current configuration
+// Get returns the configuration as stored in the config file
+// Settings are read through Resolved, which applies the current profile
 func Get() *Config {
 	if cfg == nil {
 		cfg = &Config{
@@ -114,6 +257,14 @@ func Get() *Config {
 				InitialDelay: 2,
 				MaxDelay:     32,
 			},
+			HTTP: HTTPConfig{
+				MaxIdleConnsPerHost:   32,
+				IdleConnTimeout:       90,
+				DialTimeout:           10,
+				TLSHandshakeTimeout:   10,
+				ResponseHeaderTimeout: 60,
+			},
+			Stats:    StatsConfig{Usage: true},
 			Profiles: make(map[string]Profile),
 			Aliases:  make(map[string]string),
 		}
@@ -121,6 +272,50 @@ func Get() *Config {
 	return cfg
 }
 
+// APIKeySource describes where the API key was resolved from
+type APIKeySource struct {
+	Key     string
+	Source  string
+	Profile string
+}
+
+// ResolveAPIKey returns the API key from the environment or profile along with its source
+func ResolveAPIKey() APIKeySource {
+	if apiKey := os.Getenv("PAYJP_API_KEY"); apiKey != "" {
+		return APIKeySource{Key: apiKey, Source: "env (PAYJP_API_KEY)"}
+	}
+
+	profileName, profile := GetCurrentProfile()
+	if profile != nil && profile.Keychain {
+		key, _ := ProfileAPIKey(profileName, *profile)
+		return APIKeySource{Key: key, Source: "keychain", Profile: profileName}
+	}
+	if profile != nil && profile.APIKey != "" {
+		return APIKeySource{Key: profile.APIKey, Source: "profile", Profile: profileName}
+	}
+
+	return APIKeySource{Profile: profileName}
+}
+
+// keychainKeys caches the keys read from the keychain, which may ask the user for access
+var keychainKeys = map[string]string{}
+
+// ProfileAPIKey returns the API key of a profile, reading it from the keychain if it is kept there
+func ProfileAPIKey(name string, profile Profile) (string, error) {
+	if !profile.Keychain {
+		return profile.APIKey, nil
+	}
+	if key, ok := keychainKeys[name]; ok {
+		return key, nil
+	}
+	key, err := keychain.Get(name)
+	if err != nil {
+		return "", err
+	}
+	keychainKeys[name] = key
+	return key, nil
+}
+
 // GetAPIKey returns the API key to use
 func GetAPIKey() string {
 	// Priority: environment variable > profile
@@ -129,25 +324,31 @@ func GetAPIKey() string {
 	}
 
 	cfg := Get()
-	profileName := os.Getenv("PAYJP_PROFILE")
-	if profileName == "" {
-		profileName = cfg.DefaultProfile
-	}
-
-	if profile, ok := cfg.Profiles[profileName]; ok {
-		return profile.APIKey
+	name := CurrentProfileName()
+	if profile, ok := cfg.Profiles[name]; ok {
+		key, _ := ProfileAPIKey(name, profile)
+		return key
 	}
 
 	return ""
 }
 
+// CurrentProfileName returns the profile name to use
+// Priority: PAYJP_PROFILE > project file > default_profile
+func CurrentProfileName() string {
+	if profileName := os.Getenv("PAYJP_PROFILE"); profileName != "" {
+		return profileName
+	}
+	if project != nil && project.Profile != "" {
+		return project.Profile
+	}
+	return Get().DefaultProfile
+}
+
 // GetCurrentProfile returns the current profile
 func GetCurrentProfile() (string, *Profile) {
 	cfg := Get()
-	profileName := os.Getenv("PAYJP_PROFILE")
-	if profileName == "" {
-		profileName = cfg.DefaultProfile
-	}
+	profileName := CurrentProfileName()
 
 	if profile, ok := cfg.Profiles[profileName]; ok {
 		return profileName, &profile
@@ -165,22 +366,39 @@ func SetAPIKey(profileName, apiKey string) error {
 
 	profile := cfg.Profiles[profileName]
 	profile.APIKey = apiKey
-	cfg.Profiles[profileName] = profile
-
-	return Save()
+	return SetProfile(profileName, profile)
 }
 
 // SetProfile creates or updates a profile
+// The API key of a profile kept in the keychain is saved to the keychain, not to the file
 func SetProfile(name string, profile Profile) error {
 	cfg := Get()
 	if cfg.Profiles == nil {
 		cfg.Profiles = make(map[string]Profile)
 	}
 
+	if profile.Keychain && profile.APIKey != "" {
+		if err := keychain.Set(name, profile.APIKey); err != nil {
+			return err
+		}
+		keychainKeys[name] = profile.APIKey
+		profile.APIKey = ""
+	}
+
 	cfg.Profiles[name] = profile
 	return Save()
 }
 
+// MoveKeyToKeychain moves the API key of a profile from the config file to the OS keychain
+func MoveKeyToKeychain(name string) error {
+	profile, ok := Get().Profiles[name]
+	if !ok || profile.APIKey == "" {
+		return fmt.Errorf("profile '%s' has no API key in the config file", name)
+	}
+	profile.Keychain = true
+	return SetProfile(name, profile)
+}
+
 // UseProfile sets the default profile
 func UseProfile(name string) error {
 	cfg := Get()
@@ -203,7 +421,11 @@ func ListProfiles() []string {
 }
 
 // Save saves the configuration to file
+// A corrupt config file is never overwritten; it has to be fixed with Repair first
 func Save() error {
+	if corruptErr != nil {
+		return fmt.Errorf("config file %s is corrupt; run 'payjp config repair' before changing settings", ConfigFilePath())
+	}
 	cfg := Get()
 
 	// Ensure config directory exists with secure permissions
@@ -215,12 +437,26 @@ func Save() error {
 	viper.Set("default_profile", cfg.DefaultProfile)
 	viper.Set("output", cfg.Output)
 	viper.Set("retry", cfg.Retry)
+	viper.Set("http", cfg.HTTP)
 	viper.Set("profiles", cfg.Profiles)
 	viper.Set("aliases", cfg.Aliases)
+	viper.Set("automation", cfg.Automation)
+	viper.Set("limits", cfg.Limits)
+	viper.Set("ranges", cfg.Ranges)
+	viper.Set("notify", cfg.Notify)
+	viper.Set("defaults", cfg.Defaults)
+	viper.Set("theme", cfg.Theme)
+	viper.Set("stats", cfg.Stats)
+	viper.Set("cache", cfg.Cache)
 
 	// Write to a temp file first with secure permissions, then rename
 	// This prevents a race condition where the file is readable before chmod
-	tempFile := configPath + ".tmp"
+	// Keep a config extension on the temp file so that viper can detect the format
+	ext := filepath.Ext(configPath)
+	if ext == "" {
+		ext = ".yaml"
+	}
+	tempFile := strings.TrimSuffix(configPath, filepath.Ext(configPath)) + ".tmp" + ext
 
 	// Create temp file with secure permissions (0600) from the start
 	f, err := os.OpenFile(tempFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
@@ -249,6 +485,7 @@ func Save() error {
 		return fmt.Errorf("error renaming config file: %w", err)
 	}
 
+	resolved = nil
 	return nil
 }
 
@@ -257,7 +494,20 @@ func GetOutputFormat() string {
 	if format := os.Getenv("PAYJP_OUTPUT"); format != "" {
 		return format
 	}
-	return Get().Output.Format
+	if project != nil && project.Output != "" {
+		return project.Output
+	}
+	return Resolved().Output.Format
+}
+
+// GetOutputConfig returns the output configuration
+func GetOutputConfig() OutputConfig {
+	return Resolved().Output
+}
+
+// GetThemeConfig returns the color theme configuration
+func GetThemeConfig() ThemeConfig {
+	return Resolved().Theme
 }
 
 // IsLiveMode returns true if live mode is enabled
@@ -274,7 +524,88 @@ func IsLiveMode() bool {
 
 // GetRetryConfig returns the retry configuration
 func GetRetryConfig() RetryConfig {
-	return Get().Retry
+	return Resolved().Retry
+}
+
+// GetHTTPConfig returns the HTTP connection configuration
+func GetHTTPConfig() HTTPConfig {
+	return Resolved().HTTP
+}
+
+// IsNonInteractive returns true if non-interactive mode is enabled via environment
+func IsNonInteractive() bool {
+	return os.Getenv("PAYJP_NON_INTERACTIVE") == "true"
+}
+
+// IsAllowedNonInteractive returns true if the command may run without confirmation in non-interactive mode
+func IsAllowedNonInteractive(command string) bool {
+	for _, allowed := range Resolved().Automation.Allowlist {
+		if allowed == command {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageStatsEnabled returns true if API requests are counted for stats usage
+// Counting is turned off with stats.usage: false, PAYJP_NO_USAGE_STATS=true or DO_NOT_TRACK=1
+func UsageStatsEnabled() bool {
+	if os.Getenv("PAYJP_NO_USAGE_STATS") == "true" || os.Getenv("DO_NOT_TRACK") == "1" {
+		return false
+	}
+	return Resolved().Stats.Usage
+}
+
+// GetCacheConfig returns the cache configuration
+func GetCacheConfig() CacheConfig {
+	return Resolved().Cache
+}
+
+// GetLimitsConfig returns the spending limits configuration
+func GetLimitsConfig() LimitsConfig {
+	return Resolved().Limits
+}
+
+// GetNotifyConfig returns the notification configuration
+func GetNotifyConfig() NotifyConfig {
+	return Resolved().Notify
+}
+
+// CommandDefaults returns the flag defaults configured for a command path (e.g. "charges create")
+// They are nested by command name in the config file, e.g. defaults.charges.create.currency: jpy
+func CommandDefaults(command string) map[string]string {
+	node := Get().Defaults
+	for _, name := range strings.Fields(command) {
+		child, ok := node[name].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+
+	defaults := map[string]string{}
+	for name, value := range node {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			// Defaults of a subcommand
+			continue
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			defaults[name] = strings.Join(items, ",")
+		default:
+			defaults[name] = fmt.Sprint(v)
+		}
+	}
+	return defaults
+}
+
+// GetRange returns a named date range preset (e.g. "2024-04-01..2024-06-30")
+func GetRange(name string) (string, bool) {
+	r, ok := Get().Ranges[name]
+	return r, ok
 }
 
 // ResolveAlias resolves a command alias
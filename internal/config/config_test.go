@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSave(t *testing.T) {
+	key := "sk_test_" + strings.Repeat("a1B2", 6)
+	for _, name := range []string{"config.yaml", "payjp.yml"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte("default_profile: default\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			viper.Reset()
+			if err := Init(path); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if err := SetAPIKey("default", key); err != nil {
+				t.Fatalf("SetAPIKey() error = %v", err)
+			}
+			if err := Save(); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			// The temp file is renamed over the config file
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != name {
+				t.Errorf("files after Save() = %v, want only %s", entries, name)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("config file permissions = %o, want 600", perm)
+			}
+
+			viper.Reset()
+			if err := Init(path); err != nil {
+				t.Fatalf("Init() after Save() error = %v", err)
+			}
+			if got := Get().Profiles["default"].APIKey; got != key {
+				t.Errorf("saved api_key = %q, want %q", got, key)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigValidate(t *testing.T) {
+	valid := HTTPConfig{
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90,
+		DialTimeout:           10,
+		TLSHandshakeTimeout:   10,
+		ResponseHeaderTimeout: 60,
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *HTTPConfig)
+		ok     bool
+	}{
+		{"defaults", func(c *HTTPConfig) {}, true},
+		{"no response header timeout", func(c *HTTPConfig) { c.ResponseHeaderTimeout = 0 }, true},
+		{"negative response header timeout", func(c *HTTPConfig) { c.ResponseHeaderTimeout = -1 }, false},
+		{"zero dial timeout", func(c *HTTPConfig) { c.DialTimeout = 0 }, false},
+		{"negative dial timeout", func(c *HTTPConfig) { c.DialTimeout = -5 }, false},
+		{"zero idle connections", func(c *HTTPConfig) { c.MaxIdleConnsPerHost = 0 }, false},
+		{"zero idle timeout", func(c *HTTPConfig) { c.IdleConnTimeout = 0 }, false},
+		{"zero TLS handshake timeout", func(c *HTTPConfig) { c.TLSHandshakeTimeout = 0 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.change(&c)
+			if err := c.Validate(); (err == nil) != tt.ok {
+				t.Errorf("Validate() error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
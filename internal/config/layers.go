@@ -0,0 +1,322 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are resolved in layers, each overriding the ones before it:
+// built-in defaults < config file < current profile < project file < environment < flags
+// Only the sections in profileSections can be overridden per profile; aliases, ranges
+// and command defaults come from the config file alone
+
+// Setting sources, from the lowest to the highest precedence
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceProfile = "profile"
+	SourceProject = "project"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// profileSections are the config sections a profile can override
+var profileSections = []string{"output", "theme", "retry", "http", "limits", "notify", "automation", "stats", "cache"}
+
+// layeredSetting is a setting that the project file, an environment variable or a
+// global flag can also set
+// flag is also the name of the setting in the flags section of the project file
+type layeredSetting struct {
+	key  string
+	env  string
+	flag string
+}
+
+var layeredSettings = []layeredSetting{
+	{key: "output.format", env: "PAYJP_OUTPUT", flag: "output"},
+	{key: "output.color", env: "NO_COLOR", flag: "no-color"},
+	{key: "output.sort_keys", flag: "sort-keys"},
+	{key: "output.expand_maps", flag: "expand-maps"},
+	{key: "output.locale", flag: "locale"},
+	{key: "output.time_format", flag: "time-format"},
+	{key: "theme.name", flag: "theme"},
+	{key: "stats.usage", env: "PAYJP_NO_USAGE_STATS"},
+}
+
+// resolved is the config file with the current profile applied, see Resolved
+var resolved *Config
+
+// Resolved returns the configuration with the settings of the current profile applied
+// Use Get for the settings as they are stored in the config file
+func Resolved() *Config {
+	if resolved != nil {
+		return resolved
+	}
+
+	base := *Get()
+	// Copy the slices a profile may replace, so that the config file settings stay untouched
+	base.Automation.Allowlist = append([]string(nil), base.Automation.Allowlist...)
+	base.Notify.SMTP.To = append([]string(nil), base.Notify.SMTP.To...)
+
+	if _, profile := GetCurrentProfile(); profile != nil && len(profile.Settings) > 0 {
+		v := viper.New()
+		for key, value := range profile.Settings {
+			v.Set(key, value)
+		}
+		// The settings were checked by checkProfiles, so this cannot fail
+		v.Unmarshal(&base)
+	}
+	resolved = &base
+	return resolved
+}
+
+// checkProfiles returns an error if a profile overrides something it cannot
+func checkProfiles(profiles map[string]Profile) error {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for key, value := range profiles[name].Settings {
+			if !isProfileSection(key) {
+				return fmt.Errorf("profile %s: %s cannot be set per profile (use one of %s)", name, key, strings.Join(profileSections, ", "))
+			}
+			if !fitsConfig(key, value) {
+				return fmt.Errorf("profile %s: invalid value for %s", name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// isProfileSection reports whether a profile can override a config section
+func isProfileSection(key string) bool {
+	for _, section := range profileSections {
+		if key == section {
+			return true
+		}
+	}
+	return false
+}
+
+// FlagEnv returns the environment variable that overrides the project file for a global flag
+func FlagEnv(flag string) string {
+	for _, s := range layeredSettings {
+		if s.flag == flag {
+			return s.env
+		}
+	}
+	return ""
+}
+
+// Setting is a resolved setting with the layer it came from
+// Detail names the profile, file, environment variable or flag of the source
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+// TableColumns returns the fields shown in list view
+func (Setting) TableColumns() []string {
+	return []string{"Key", "Value", "Source", "Detail"}
+}
+
+// FlagSetting is a setting given by a global flag on the command line
+type FlagSetting struct {
+	Flag  string
+	Value interface{}
+}
+
+// Effective returns every layered setting with its resolved value and source
+// flags are the settings given on the command line, keyed by setting (e.g. output.format)
+// Secrets are masked
+func Effective(flags map[string]FlagSetting) ([]Setting, error) {
+	values, err := flatten(Resolved())
+	if err != nil {
+		return nil, err
+	}
+
+	profileName, profile := GetCurrentProfile()
+	var profileValues map[string]interface{}
+	if profile != nil {
+		profileValues = flattenMap(profile.Settings, "")
+	}
+
+	settings := make([]Setting, 0, len(values)+3)
+	for key, value := range values {
+		s := Setting{Key: key, Value: value, Source: SourceDefault}
+		if viper.InConfig(key) {
+			s.Source, s.Detail = SourceConfig, ConfigFilePath()
+		}
+		if _, ok := profileValues[key]; ok {
+			s.Source, s.Detail = SourceProfile, profileName
+		}
+		settings = append(settings, s)
+	}
+
+	for i := range settings {
+		s := &settings[i]
+		for _, layer := range layeredSettings {
+			if layer.key != s.Key {
+				continue
+			}
+			if project != nil {
+				if value, ok := projectValue(layer); ok {
+					s.Value, s.Source, s.Detail = value, SourceProject, project.Path
+				}
+			}
+			if value, env, ok := envValue(layer); ok {
+				s.Value, s.Source, s.Detail = value, SourceEnv, env
+			}
+		}
+		if f, ok := flags[s.Key]; ok {
+			s.Value, s.Source, s.Detail = f.Value, SourceFlag, "--"+f.Flag
+		}
+		if isSecret(s.Key) && s.Value != "" {
+			s.Value = "********"
+		}
+	}
+
+	settings = append(settings, profileSetting(), modeSetting(profileName, profile, flags))
+	if f, ok := flags["non_interactive"]; ok {
+		settings = append(settings, Setting{Key: "non_interactive", Value: f.Value, Source: SourceFlag, Detail: "--" + f.Flag})
+	} else if IsNonInteractive() {
+		settings = append(settings, Setting{Key: "non_interactive", Value: true, Source: SourceEnv, Detail: "PAYJP_NON_INTERACTIVE"})
+	} else {
+		settings = append(settings, Setting{Key: "non_interactive", Value: false, Source: SourceDefault})
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+	return settings, nil
+}
+
+// profileSetting returns the profile in use with its source
+func profileSetting() Setting {
+	s := Setting{Key: "profile", Value: Get().DefaultProfile, Source: SourceDefault}
+	if viper.InConfig("default_profile") {
+		s.Source, s.Detail = SourceConfig, ConfigFilePath()
+	}
+	if project != nil && project.Profile != "" {
+		s.Value, s.Source, s.Detail = project.Profile, SourceProject, project.Path
+	}
+	if name := os.Getenv("PAYJP_PROFILE"); name != "" {
+		s.Value, s.Source, s.Detail = name, SourceEnv, "PAYJP_PROFILE"
+	}
+	return s
+}
+
+// modeSetting returns the API mode (test or live) with its source
+func modeSetting(profileName string, profile *Profile, flags map[string]FlagSetting) Setting {
+	s := Setting{Key: "mode", Value: "test", Source: SourceDefault}
+	if profile != nil && profile.Mode != "" {
+		s.Value, s.Source, s.Detail = profile.Mode, SourceProfile, profileName
+	}
+	if project != nil && project.Flags["live"] == "true" {
+		s.Value, s.Source, s.Detail = "live", SourceProject, project.Path
+	}
+	if os.Getenv("PAYJP_LIVE") == "true" {
+		s.Value, s.Source, s.Detail = "live", SourceEnv, "PAYJP_LIVE"
+	}
+	if f, ok := flags["mode"]; ok {
+		s.Value, s.Source, s.Detail = f.Value, SourceFlag, "--"+f.Flag
+	}
+	return s
+}
+
+// projectValue returns the value the project file gives a setting
+func projectValue(layer layeredSetting) (interface{}, bool) {
+	if layer.key == "output.format" && project.Output != "" {
+		return project.Output, true
+	}
+	value, ok := project.Flags[layer.flag]
+	if !ok {
+		return nil, false
+	}
+	switch layer.flag {
+	case "no-color":
+		if value != "true" {
+			return nil, false
+		}
+		return false, true
+	case "sort-keys", "expand-maps":
+		if value != "true" {
+			return nil, false
+		}
+		return true, true
+	}
+	return value, true
+}
+
+// envValue returns the value an environment variable gives a setting and the variable
+func envValue(layer layeredSetting) (interface{}, string, bool) {
+	switch layer.env {
+	case "":
+		return nil, "", false
+	case "NO_COLOR":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, layer.env, true
+		}
+		return nil, "", false
+	case "PAYJP_NO_USAGE_STATS":
+		if os.Getenv("PAYJP_NO_USAGE_STATS") == "true" {
+			return false, layer.env, true
+		}
+		if os.Getenv("DO_NOT_TRACK") == "1" {
+			return false, "DO_NOT_TRACK", true
+		}
+		return nil, "", false
+	}
+	if value := os.Getenv(layer.env); value != "" {
+		return value, layer.env, true
+	}
+	return nil, "", false
+}
+
+// isSecret reports whether a setting holds a credential
+func isSecret(key string) bool {
+	return key == "notify.slack_webhook_url" || key == "notify.smtp.password"
+}
+
+// flatten returns the layered sections of a configuration as dot separated keys
+func flatten(c *Config) (map[string]interface{}, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := yaml.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	sections := map[string]interface{}{}
+	for _, section := range profileSections {
+		sections[section] = all[section]
+	}
+	return flattenMap(sections, ""), nil
+}
+
+// flattenMap returns the leaves of nested maps keyed by their dot separated path
+func flattenMap(m map[string]interface{}, prefix string) map[string]interface{} {
+	leaves := map[string]interface{}{}
+	for key, value := range m {
+		if children, ok := value.(map[string]interface{}); ok {
+			for k, v := range flattenMap(children, prefix+key+".") {
+				leaves[k] = v
+			}
+			continue
+		}
+		leaves[prefix+key] = value
+	}
+	return leaves
+}
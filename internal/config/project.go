@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the name of the per-project configuration file
+const ProjectFileName = ".payjp.yaml"
+
+// ProjectConfig represents per-project settings discovered from the working directory
+type ProjectConfig struct {
+	// Path is the file the settings were loaded from
+	Path string `yaml:"-"`
+
+	Profile string `yaml:"profile"`
+	Output  string `yaml:"output"`
+	// Flags are default values for global flags (e.g. locale: ja-JP)
+	Flags map[string]string `yaml:"flags"`
+	// Commands are default flag values per command path (e.g. "charges list": {limit: 50})
+	Commands map[string]map[string]string `yaml:"commands"`
+}
+
+var project *ProjectConfig
+
+// FindProjectFile searches for the project file from dir upward to the filesystem root
+func FindProjectFile(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadProject discovers and loads the project file for the current working directory
+// Discovery is disabled by setting PAYJP_NO_PROJECT=true
+func LoadProject() (*ProjectConfig, error) {
+	project = nil
+	resolved = nil
+	if os.Getenv("PAYJP_NO_PROJECT") == "true" {
+		return nil, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, nil
+	}
+	path, ok := FindProjectFile(wd)
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading project file: %w", err)
+	}
+
+	p := &ProjectConfig{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("error parsing project file %s: %w", path, err)
+	}
+	p.Path = path
+
+	project = p
+	resolved = nil
+	return p, nil
+}
+
+// Project returns the loaded project settings, or nil if there is no project file
+func Project() *ProjectConfig {
+	return project
+}
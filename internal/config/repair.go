@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Recovery is what could be salvaged from a corrupt config file
+// Recovered and Dropped are dot separated keys (e.g. profiles.live); a dropped entry
+// that has no readable key is named by its line number instead
+type Recovery struct {
+	Values    map[string]interface{}
+	Recovered []string
+	Dropped   []string
+}
+
+// Recover salvages the readable settings of a config file
+// The file is split into blocks by indentation; a block that does not parse is split
+// into its child blocks, so one broken line only loses the setting it belongs to.
+// Settings whose values do not fit the configuration (e.g. a string for retry.max_count)
+// are dropped as well
+func Recover(data []byte) *Recovery {
+	rec := &Recovery{Values: map[string]interface{}{}}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	values := recoverBlocks(lines, 1, "", rec)
+
+	for key, value := range values {
+		if fitsConfig(key, value) {
+			rec.Values[key] = value
+			continue
+		}
+		// Keep the entries of a section that fit, e.g. the valid profiles
+		children, ok := value.(map[string]interface{})
+		if !ok {
+			rec.Dropped = append(rec.Dropped, key)
+			continue
+		}
+		kept := map[string]interface{}{}
+		for name, child := range children {
+			if fitsConfig(key, map[string]interface{}{name: child}) {
+				kept[name] = child
+			} else {
+				rec.Dropped = append(rec.Dropped, key+"."+name)
+			}
+		}
+		if len(kept) > 0 {
+			rec.Values[key] = kept
+		}
+	}
+
+	rec.Recovered = leafKeys(rec.Values, "")
+	sort.Strings(rec.Recovered)
+	sort.Strings(rec.Dropped)
+	return rec
+}
+
+// recoverBlocks parses the blocks of lines at the outermost indentation
+// firstLine is the line number of lines[0] and prefix the key path of the enclosing block
+func recoverBlocks(lines []string, firstLine int, prefix string, rec *Recovery) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, b := range splitBlocks(lines) {
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal([]byte(strings.Join(b.lines, "\n")), &parsed); err == nil {
+			for key, value := range parsed {
+				values[key] = value
+			}
+			continue
+		}
+
+		line := firstLine + b.start
+		key, rest, ok := strings.Cut(strings.TrimSpace(b.lines[0]), ":")
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		if !ok || key == "" || strings.TrimSpace(rest) != "" || len(b.lines) == 1 {
+			rec.Dropped = append(rec.Dropped, droppedName(prefix, key, ok, line))
+			continue
+		}
+		if children := recoverBlocks(b.lines[1:], line+1, prefix+key+".", rec); len(children) > 0 {
+			values[key] = children
+		}
+	}
+	return values
+}
+
+// droppedName names a block that could not be read
+func droppedName(prefix, key string, hasKey bool, line int) string {
+	if !hasKey || key == "" {
+		return fmt.Sprintf("line %d", line)
+	}
+	return fmt.Sprintf("%s%s (line %d)", prefix, key, line)
+}
+
+// block is a key line with the more indented lines that follow it
+type block struct {
+	start int
+	lines []string
+}
+
+// splitBlocks splits lines into blocks at the smallest indentation
+// Blank lines and comments belong to the preceding block
+func splitBlocks(lines []string) []block {
+	indent := -1
+	for _, line := range lines {
+		if isContent(line) && (indent < 0 || indentOf(line) < indent) {
+			indent = indentOf(line)
+		}
+	}
+
+	var blocks []block
+	for i, line := range lines {
+		switch {
+		case isContent(line) && indentOf(line) == indent:
+			blocks = append(blocks, block{start: i, lines: []string{line[indent:]}})
+		case len(blocks) > 0:
+			last := &blocks[len(blocks)-1]
+			if len(line) >= indent {
+				line = line[indent:]
+			}
+			last.lines = append(last.lines, line)
+		}
+	}
+	return blocks
+}
+
+// isContent reports whether a line is neither blank nor a comment
+func isContent(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && !strings.HasPrefix(trimmed, "#")
+}
+
+// indentOf returns the number of leading spaces and tabs of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// fitsConfig reports whether a top-level setting decodes into the configuration
+func fitsConfig(key string, value interface{}) bool {
+	v := viper.New()
+	v.Set(key, value)
+	return v.Unmarshal(&Config{}) == nil
+}
+
+// leafKeys returns the dot separated keys of the sections and settings in values
+// Only the top-level key is listed for free-form sections whose entries are user defined
+func leafKeys(values map[string]interface{}, prefix string) []string {
+	var keys []string
+	for key, value := range values {
+		children, ok := value.(map[string]interface{})
+		if !ok || len(children) == 0 || (prefix == "" && freeForm(key)) {
+			keys = append(keys, prefix+key)
+			continue
+		}
+		keys = append(keys, leafKeys(children, prefix+key+".")...)
+	}
+	return keys
+}
+
+// freeForm reports whether the entries of a top-level section are user defined
+func freeForm(key string) bool {
+	switch key {
+	case "aliases", "ranges", "defaults":
+		return true
+	}
+	return false
+}
+
+// Repair backs up the config file and rewrites it with the recovered settings
+// It returns the path of the backup
+func Repair(rec *Recovery) (string, error) {
+	path := ConfigFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading config file: %w", err)
+	}
+	backup := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return "", fmt.Errorf("error writing config backup: %w", err)
+	}
+
+	// Replace what was read from the file so that dropped settings are not written back
+	if err := viper.ReadConfig(bytes.NewReader(nil)); err != nil {
+		return backup, fmt.Errorf("error resetting config: %w", err)
+	}
+	for key, value := range rec.Values {
+		viper.Set(key, value)
+	}
+	cfg = &Config{}
+	if err := viper.Unmarshal(cfg); err != nil {
+		return backup, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+	corruptErr = nil
+
+	return backup, Save()
+}
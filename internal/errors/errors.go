@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"net/http"
+
+	"github.com/payjp/payjp-go/v1"
+	"golang.org/x/text/language"
+)
+
+// Hint is a troubleshooting hint for an API error in Japanese and English
+type Hint struct {
+	Ja string
+	En string
+}
+
+// Message returns the hint in the language of the given locale
+// Japanese is used for ja locales, English otherwise
+func (h Hint) Message(locale language.Tag) string {
+	if base, _ := locale.Base(); base.String() == "ja" {
+		return h.Ja
+	}
+	return h.En
+}
+
+// codeHints maps PAY.JP error codes to hints
+var codeHints = map[string]Hint{
+	"card_declined": {
+		Ja: "カード発行会社により決済が拒否されました。別のカードを使用するか、カード会社に問い合わせるよう顧客に案内してください",
+		En: "The card issuer declined the charge. Ask the customer to use another card or contact their card issuer",
+	},
+	"expired_card": {
+		Ja: "カードの有効期限が切れています。新しいカードでトークンを作成し直してください",
+		En: "The card has expired. Create a new token with an up-to-date card",
+	},
+	"invalid_number": {
+		Ja: "カード番号が正しくありません。入力された番号を確認してください",
+		En: "The card number is invalid. Check the number that was entered",
+	},
+	"invalid_cvc": {
+		Ja: "セキュリティコードが正しくありません。カード裏面の3桁または4桁の番号を確認してください",
+		En: "The CVC is invalid. Check the 3 or 4 digit security code on the card",
+	},
+	"invalid_expiry_month": {
+		Ja: "有効期限の月が正しくありません。1〜12で指定してください",
+		En: "The expiry month is invalid. Use a value from 1 to 12",
+	},
+	"invalid_expiry_year": {
+		Ja: "有効期限の年が正しくありません。4桁の年で指定してください",
+		En: "The expiry year is invalid. Use a four digit year",
+	},
+	"invalid_expiration_date": {
+		Ja: "有効期限が正しくありません。月と年の組み合わせを確認してください",
+		En: "The expiration date is invalid. Check the month and year",
+	},
+	"incorrect_card_data": {
+		Ja: "カード情報が一致しません。カード番号・有効期限・セキュリティコードを確認してください",
+		En: "The card details are incorrect. Check the number, expiry date and CVC",
+	},
+	"token_already_used": {
+		Ja: "このトークンは既に使用されています。トークンは一度しか使えないため、新しいトークンを作成してください",
+		En: "Check that the token has not already been used. Tokens can only be used once, so create a new one",
+	},
+	"invalid_id": {
+		Ja: "IDの形式が正しくありません。リソースの種類に合ったID（ch_, cus_, tok_ など）を指定しているか確認してください",
+		En: "The ID is invalid. Check that the ID matches the resource type (ch_, cus_, tok_, etc.)",
+	},
+	"already_refunded": {
+		Ja: "この支払いは既に全額返金されています",
+		En: "The charge has already been fully refunded",
+	},
+	"already_captured": {
+		Ja: "この支払いは既に確定されています",
+		En: "The charge has already been captured",
+	},
+	"charge_expired": {
+		Ja: "与信の有効期限が切れています。新しく支払いを作成してください",
+		En: "The authorization has expired. Create a new charge",
+	},
+	"capture_amount_gt_net": {
+		Ja: "確定金額が与信金額を超えています。--amount を与信額以下にしてください",
+		En: "The capture amount exceeds the authorized amount. Use an --amount up to the authorized amount",
+	},
+	"refund_amount_gt_net": {
+		Ja: "返金額が返金可能額を超えています。既に返金された額を確認してください",
+		En: "The refund amount exceeds the refundable amount. Check how much has already been refunded",
+	},
+	"test_card_on_livemode": {
+		Ja: "本番モードでテストカードは使用できません",
+		En: "Test cards cannot be used in live mode",
+	},
+	"not_activated_account": {
+		Ja: "本番モードが有効化されていません。PAY.JPの管理画面から本番利用申請を行ってください",
+		En: "Live mode is not activated. Apply for live mode from the PAY.JP dashboard",
+	},
+	"already_subscribed": {
+		Ja: "この顧客は既に同じプランを定期課金しています",
+		En: "The customer is already subscribed to this plan",
+	},
+	"three_d_secure_incompleted": {
+		Ja: "3Dセキュア認証が完了していません。認証完了後に charges tds-finish を実行してください",
+		En: "3-D Secure authentication is not complete. Run charges tds-finish after the customer completes it",
+	},
+	"over_capacity": {
+		Ja: "リクエストが集中しています。しばらく待ってから再試行するか、--max-wait を指定してください",
+		En: "Too many requests. Wait a moment and retry, or set --max-wait",
+	},
+}
+
+// Hints for errors without a specific code, keyed by HTTP status
+var statusHints = map[int]Hint{
+	http.StatusUnauthorized: {
+		Ja: "APIキーが正しくありません。payjp debug auth でどのキーが使われているか確認してください",
+		En: "The API key is invalid. Run payjp debug auth to see which key is being used",
+	},
+	http.StatusNotFound: {
+		Ja: "リソースが見つかりません。IDとテスト/本番モードが一致しているか確認してください",
+		En: "The resource was not found. Check the ID and that it belongs to the current test/live mode",
+	},
+	http.StatusTooManyRequests: {
+		Ja: "レートリミットに達しました。しばらく待ってから再試行するか、--max-wait を指定してください",
+		En: "The rate limit was reached. Wait a moment and retry, or set --max-wait",
+	},
+}
+
+// serverHint is shown for 5xx errors
+var serverHint = Hint{
+	Ja: "PAY.JP側でエラーが発生しました。時間をおいて再試行し、解決しない場合はステータスページを確認してください",
+	En: "PAY.JP returned a server error. Retry later and check the status page if it persists",
+}
+
+// HintFor returns a troubleshooting hint for the error, if one is known
+func HintFor(err error) (Hint, bool) {
+	payjpErr, ok := err.(*payjp.Error)
+	if !ok {
+		return Hint{}, false
+	}
+
+	if hint, ok := codeHints[payjpErr.Code]; ok {
+		return hint, true
+	}
+	if hint, ok := statusHints[payjpErr.Status]; ok {
+		return hint, true
+	}
+	if payjpErr.Status >= 500 {
+		return serverHint, true
+	}
+	return Hint{}, false
+}
@@ -0,0 +1,181 @@
+package eventschema
+
+import (
+	"sort"
+	"strings"
+)
+
+// Fields are the common payload fields of an event, flattened for tabular output
+// Amount is nil when the payload has no amount
+type Fields struct {
+	Object     string
+	ResourceID string
+	Amount     *int64
+	Currency   string
+	Status     string
+	Customer   string
+}
+
+// Schema describes where the common fields are found in the payload of one kind of object
+// Paths are dot separated keys into the event data (e.g. plan.amount); an empty path leaves
+// the field empty. Status, when set, derives the status instead of reading StatusPath
+type Schema struct {
+	Object       string
+	AmountPath   string
+	CurrencyPath string
+	StatusPath   string
+	CustomerPath string
+	Status       func(data map[string]interface{}) string
+}
+
+// registry holds the schemas keyed by the object type of the event data
+var registry = map[string]Schema{
+	"charge": {
+		AmountPath:   "amount",
+		CurrencyPath: "currency",
+		CustomerPath: "customer",
+		Status:       chargeStatus,
+	},
+	"customer": {
+		CustomerPath: "id",
+	},
+	"card": {
+		CustomerPath: "customer",
+	},
+	"plan": {
+		AmountPath:   "amount",
+		CurrencyPath: "currency",
+	},
+	"subscription": {
+		AmountPath:   "plan.amount",
+		CurrencyPath: "plan.currency",
+		StatusPath:   "status",
+		CustomerPath: "customer",
+	},
+	"transfer": {
+		AmountPath:   "amount",
+		CurrencyPath: "currency",
+		StatusPath:   "status",
+	},
+	"tenant_transfer": {
+		AmountPath:   "amount",
+		CurrencyPath: "currency",
+		StatusPath:   "status",
+	},
+	"token": {},
+}
+
+// Lookup returns the schema of an object type
+func Lookup(object string) (Schema, bool) {
+	schema, ok := registry[object]
+	schema.Object = object
+	return schema, ok
+}
+
+// Schemas returns every registered schema sorted by object type
+func Schemas() []Schema {
+	schemas := make([]Schema, 0, len(registry))
+	for object := range registry {
+		schema, _ := Lookup(object)
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Object < schemas[j].Object
+	})
+	return schemas
+}
+
+// Flatten extracts the common fields from the data of an event
+// The object type is read from the data, falling back to the event type prefix
+// (customer.card.created is a card event); unknown objects only get the resource ID
+func Flatten(eventType string, data map[string]interface{}) Fields {
+	object := stringAt(data, "object")
+	if object == "" {
+		object = objectOf(eventType)
+	}
+
+	fields := Fields{Object: object, ResourceID: stringAt(data, "id")}
+	schema, ok := Lookup(object)
+	if !ok {
+		return fields
+	}
+	fields.Amount = intAt(data, schema.AmountPath)
+	fields.Currency = stringAt(data, schema.CurrencyPath)
+	fields.Customer = stringAt(data, schema.CustomerPath)
+	if schema.Status != nil {
+		fields.Status = schema.Status(data)
+	} else {
+		fields.Status = stringAt(data, schema.StatusPath)
+	}
+	return fields
+}
+
+// objectOf returns the object type named by an event type, e.g. card for customer.card.created
+func objectOf(eventType string) string {
+	parts := strings.Split(eventType, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
+
+// valueAt returns the value at a dot separated path, or nil
+func valueAt(data map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var value interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// stringAt returns the string at a path, or an empty string
+func stringAt(data map[string]interface{}, path string) string {
+	s, _ := valueAt(data, path).(string)
+	return s
+}
+
+// intAt returns the number at a path, or nil
+func intAt(data map[string]interface{}, path string) *int64 {
+	f, ok := valueAt(data, path).(float64)
+	if !ok {
+		return nil
+	}
+	n := int64(f)
+	return &n
+}
+
+// boolAt returns the boolean at a path, or false
+func boolAt(data map[string]interface{}, path string) bool {
+	b, _ := valueAt(data, path).(bool)
+	return b
+}
+
+// chargeStatus summarizes the state of a charge payload like charges list --group-by status
+func chargeStatus(data map[string]interface{}) string {
+	var amount, refunded int64
+	if n := intAt(data, "amount"); n != nil {
+		amount = *n
+	}
+	if n := intAt(data, "amount_refunded"); n != nil {
+		refunded = *n
+	}
+	switch {
+	case !boolAt(data, "paid"):
+		return "failed"
+	case boolAt(data, "refunded") || (amount > 0 && refunded >= amount):
+		return "refunded"
+	case refunded > 0:
+		return "partially_refunded"
+	case !boolAt(data, "captured"):
+		return "authorized"
+	default:
+		return "succeeded"
+	}
+}
@@ -0,0 +1,64 @@
+// Package examples holds the curated examples of commands built into the CLI
+// The catalog is shown by payjp examples and included in payjp meta commands --json,
+// so that documentation generated from the command surface shows the same examples
+package examples
+
+import (
+	_ "embed"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// embedded holds the example catalog of this build
+//
+//go:embed examples.yaml
+var embedded []byte
+
+// Example is a runnable command line with a description in Japanese and English
+type Example struct {
+	Ja  string `yaml:"ja" json:"ja"`
+	En  string `yaml:"en" json:"en"`
+	Run string `yaml:"run" json:"run"`
+}
+
+// Description returns the description in Japanese or English
+func (e Example) Description(japanese bool) string {
+	if japanese && e.Ja != "" {
+		return e.Ja
+	}
+	return e.En
+}
+
+// Entry is the examples of a command
+type Entry struct {
+	// Command is the command path without the root command name (e.g. "charges create")
+	Command  string    `yaml:"command"`
+	Examples []Example `yaml:"examples"`
+}
+
+// Embedded returns the examples built into the CLI in catalog order
+func Embedded() ([]Entry, error) {
+	return Parse(embedded)
+}
+
+// Parse parses an example catalog in the format of examples.yaml
+func Parse(data []byte) ([]Entry, error) {
+	var catalog struct {
+		Commands []Entry `yaml:"commands"`
+	}
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("invalid example catalog: %w", err)
+	}
+	return catalog.Commands, nil
+}
+
+// For returns the examples of a command
+func For(entries []Entry, command string) []Example {
+	for _, e := range entries {
+		if e.Command == command {
+			return e.Examples
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Package filelock serializes processes that read, change and write the same file in the config
+// directory, such as scripts that create charges at the same time
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lock is an exclusive lock on a file
+type Lock struct {
+	file *os.File
+}
+
+// Acquire waits for and takes the exclusive lock of the file at path
+// The lock is a separate file next to it, since the file itself is replaced when it is saved
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("error creating config directory: %w", err)
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{file: f}, nil
+}
+
+// Release releases the lock
+func (l *Lock) Release() error {
+	unlockFile(l.file)
+	return l.file.Close()
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// FileName is the name of the history file in the config directory
+const FileName = "history.jsonl"
+
+// Entry represents an executed CLI command
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Args     []string  `json:"args"`
+	Profile  string    `json:"profile"`
+	ExitCode int       `json:"exit_code"`
+	// APIKeyRemoved is set when --api-key was dropped from Args, so that the command is not
+	// re-run with the key of the profile, which may belong to another account
+	APIKeyRemoved bool `json:"api_key_removed,omitempty"`
+}
+
+// Path returns the history file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Append appends an entry to the history file
+func Append(path string, entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening history file: %w", err)
+	}
+	defer f.Close()
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("error writing history file: %w", err)
+	}
+	return nil
+}
+
+// Load reads all entries from the history file
+// Lines that cannot be parsed are skipped
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening history file: %w", err)
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// MaskArgs returns a copy of args with API key flags removed and secret values masked
+func MaskArgs(args []string) []string {
+	masked := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// Drop API key overrides entirely so that the key is never written to the history
+		if isAPIKeyFlag(arg) {
+			if arg == "--api-key" || arg == "-k" {
+				i++
+			}
+			continue
+		}
+
+		if IsSecret(arg) {
+			arg = util.MaskAPIKey(arg)
+		}
+		masked = append(masked, arg)
+	}
+	return masked
+}
+
+// HasAPIKeyFlag reports whether args set the API key, which MaskArgs drops
+func HasAPIKeyFlag(args []string) bool {
+	for _, arg := range args {
+		if isAPIKeyFlag(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAPIKeyFlag reports whether arg is --api-key or -k, with or without the key in it
+func isAPIKeyFlag(arg string) bool {
+	return arg == "--api-key" || strings.HasPrefix(arg, "--api-key=") ||
+		strings.HasPrefix(arg, "-k") && !strings.HasPrefix(arg, "--")
+}
+
+// IsSecret reports whether a value looks like a secret API key
+func IsSecret(s string) bool {
+	return strings.HasPrefix(s, "sk_") || strings.HasPrefix(s, "pk_")
+}
+
+// HasMaskedSecret reports whether args contain a masked secret and cannot be re-run as-is
+func HasMaskedSecret(args []string) bool {
+	for _, arg := range args {
+		if strings.Contains(arg, "****") {
+			return true
+		}
+	}
+	return false
+}
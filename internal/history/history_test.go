@@ -0,0 +1,31 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaskArgs(t *testing.T) {
+	key := "sk_test_0123456789abcdef01234567"
+	tests := []struct {
+		args    []string
+		want    []string
+		removed bool
+	}{
+		{[]string{"charges", "list"}, []string{"charges", "list"}, false},
+		{[]string{"charges", "list", "--api-key", key}, []string{"charges", "list"}, true},
+		{[]string{"--api-key=" + key, "charges", "list"}, []string{"charges", "list"}, true},
+		{[]string{"-k", key, "charges", "list"}, []string{"charges", "list"}, true},
+		{[]string{"-k" + key, "charges", "list"}, []string{"charges", "list"}, true},
+		{[]string{"--api-key-stdin", "charges", "list"}, []string{"--api-key-stdin", "charges", "list"}, false},
+		{[]string{"config", "set", "api_key", key}, []string{"config", "set", "api_key", "sk_test****4567"}, false},
+	}
+	for _, tt := range tests {
+		if got := MaskArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MaskArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+		if got := HasAPIKeyFlag(tt.args); got != tt.removed {
+			t.Errorf("HasAPIKeyFlag(%q) = %v, want %v", tt.args, got, tt.removed)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	mu       sync.RWMutex
+	japanese bool
+)
+
+// SetLocale sets the locale used for messages
+// Japanese is used for ja locales, English otherwise
+func SetLocale(locale language.Tag) {
+	base, _ := locale.Base()
+	mu.Lock()
+	japanese = base.String() == "ja"
+	mu.Unlock()
+}
+
+// translate returns the translation of an English format string for the current locale
+// Formats without a translation are returned unchanged
+func translate(format string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if japanese {
+		if t, ok := ja[format]; ok {
+			return t
+		}
+	}
+	return format
+}
+
+// Sprintf formats a message using the translation of format for the current locale
+func Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(translate(format), args...)
+}
+
+// Errorf returns an error using the translation of format for the current locale
+// Like fmt.Errorf, a %w verb wraps the corresponding error
+func Errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(translate(format), args...)
+}
+
+// cobraMessage translates an error message produced by cobra or pflag
+type cobraMessage struct {
+	pattern *regexp.Regexp
+	ja      string
+}
+
+// cobraMessages are the argument and flag errors of cobra and pflag
+var cobraMessages = []cobraMessage{
+	{regexp.MustCompile(`^accepts (\d+) arg\(s\), received (\d+)$`), "引数は $1 個必要ですが $2 個指定されました"},
+	{regexp.MustCompile(`^accepts at most (\d+) arg\(s\), received (\d+)$`), "引数は最大 $1 個ですが $2 個指定されました"},
+	{regexp.MustCompile(`^requires at least (\d+) arg\(s\), only received (\d+)$`), "引数は $1 個以上必要ですが $2 個しか指定されていません"},
+	{regexp.MustCompile(`^accepts between (\d+) and (\d+) arg\(s\), received (\d+)$`), "引数は $1〜$2 個必要ですが $3 個指定されました"},
+	{regexp.MustCompile(`^unknown flag: (.+)$`), "不明なフラグです: $1"},
+	{regexp.MustCompile(`^unknown shorthand flag: '(.)' in (.+)$`), "不明な短縮フラグです: '$1'（$2）"},
+	{regexp.MustCompile(`^flag needs an argument: (.+)$`), "フラグに値が必要です: $1"},
+	{regexp.MustCompile(`(?s)^invalid argument "(.*)" for "(.+)" flag: (.+)$`), "フラグ $2 の値 \"$1\" が正しくありません: $3"},
+	{regexp.MustCompile(`^required flag\(s\) (.+) not set$`), "必須のフラグが指定されていません: $1"},
+}
+
+// Localize translates argument and flag errors produced by cobra and pflag
+// Other errors, and all errors in English locales, are returned unchanged
+func Localize(err error) error {
+	if err == nil {
+		return nil
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	if !japanese {
+		return err
+	}
+	for _, m := range cobraMessages {
+		if m.pattern.MatchString(err.Error()) {
+			return errors.New(m.pattern.ReplaceAllString(err.Error(), m.ja))
+		}
+	}
+	return err
+}
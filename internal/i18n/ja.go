@@ -0,0 +1,174 @@
+package i18n
+
+// ja maps English message formats to Japanese
+// Verbs must match the English format; use explicit indexes (%[2]s) to reorder arguments
+var ja = map[string]string{
+	// Global flags and configuration
+	"--silent and --verbose cannot be used together":                                       "--silent と --verbose は同時に指定できません",
+	"--raw is only supported by get and list commands":                                     "--raw は get と list コマンドでのみ使用できます",
+	"--raw cannot be used with --quiet or --fields":                                        "--raw は --quiet や --fields と同時に指定できません",
+	"a .path argument cannot be used with --quiet, --raw or --fields":                      ".path 引数は --quiet、--raw、--fields と同時に指定できません",
+	"invalid locale: %s (use a language tag such as ja-JP or en-US)":                       "ロケールが正しくありません: %s（ja-JP や en-US のような言語タグを指定してください）",
+	"--notify cannot be used with config commands":                                         "--notify は config コマンドでは使用できません",
+	"--notify requires notify.slack_webhook_url or notify.smtp in the config file":         "--notify を使用するには設定ファイルに notify.slack_webhook_url または notify.smtp を設定してください",
+	"--api-key and --api-key-stdin cannot be used together":                                "--api-key と --api-key-stdin は同時に指定できません",
+	"PAYJP_API_KEY and PAYJP_API_KEY_FILE cannot both be set":                              "PAYJP_API_KEY と PAYJP_API_KEY_FILE は同時に設定できません",
+	"%s: api-key cannot be set in a project file (use profile instead)":                    "%s: プロジェクトファイルには api-key を設定できません（profile を使用してください）",
+	"%s: unknown flag '%s' for '%s'":                                                       "%[1]s: '%[3]s' に不明なフラグ '%[2]s' が指定されています",
+	"%s: invalid value for '%s': %w":                                                       "%s: '%s' の値が正しくありません: %w",
+	"'%s' requires confirmation and is not in automation.allowlist (non-interactive mode)": "'%s' は確認が必要ですが automation.allowlist に含まれていません（非対話モード）",
+	"'%s' requires confirmation and there is no terminal to ask on; pass --yes to confirm": "'%s' は確認が必要ですが確認できる端末がありません。確認なしで実行するには --yes を指定してください",
+	"invalid output format: %s (use json, table, yaml, csv, or ndjson)":                    "出力形式が正しくありません: %s（json, table, yaml, csv, ndjson のいずれかを指定してください）",
+	"unknown configuration key: %s":                                                        "不明な設定キーです: %s",
+	"--api-key is required":                                                                "--api-key を指定してください",
+	"invalid mode: %s (use 'test' or 'live')":                                              "モードが正しくありません: %s（'test' または 'live' を指定してください）",
+	"API key %s was rejected by PAY.JP; it was not saved":                                  "APIキー %s はPAY.JPで拒否されたため保存されませんでした",
+	"failed to verify API key (use --no-verify to save it without checking): %w":           "APIキーを確認できませんでした（確認せずに保存するには --no-verify を指定してください）: %w",
+	"API key is required. Set it via --api-key or --api-key-stdin flag, PAYJP_API_KEY or PAYJP_API_KEY_FILE environment variable, or config file": "APIキーが必要です。--api-key または --api-key-stdin フラグ、環境変数 PAYJP_API_KEY または PAYJP_API_KEY_FILE、設定ファイルのいずれかで設定してください",
+	"invalid config file: %v":                                                           "設定ファイルの値が不正です: %v",
+	"failed to read API key: input is empty":                                            "APIキーを読み込めませんでした: 入力が空です",
+	"API key is empty":                                                                  "APIキーが空です",
+	"%s is a public key; use a secret key (sk_test_ or sk_live_)":                       "%s は公開鍵です。秘密鍵（sk_test_ または sk_live_）を指定してください",
+	"invalid API key: must start with sk_test_ or sk_live_":                             "APIキーが正しくありません: sk_test_ または sk_live_ で始まる必要があります",
+	"invalid API key: contains %q (check for whitespace or quotes pasted with the key)": "APIキーが正しくありません: %q が含まれています（空白や引用符が一緒に貼り付けられていないか確認してください）",
+	"invalid API key: unexpected length (check that the whole key was copied)":          "APIキーが正しくありません: 長さが不正です（キー全体がコピーされているか確認してください）",
+
+	// Dates, ranges and durations
+	"invalid timestamp format: %s (use Unix timestamp, RFC3339 or YYYY-MM-DD)": "日時の形式が正しくありません: %s（Unixタイムスタンプ、RFC3339、YYYY-MM-DD のいずれかで指定してください）",
+	"invalid range: %s (use start..end, e.g. 2024-04-01..2024-06-30)":          "期間が正しくありません: %s（2024-04-01..2024-06-30 のように 開始..終了 の形式で指定してください）",
+	"invalid range: %s (start or end is required)":                             "期間が正しくありません: %s（開始または終了を指定してください）",
+	"invalid range: %s (start is after end)":                                   "期間が正しくありません: %s（開始が終了より後になっています）",
+	"--range cannot be used with --since or --until":                           "--range は --since や --until と同時に指定できません",
+	"unknown range: %s (define it under ranges in the config file)":            "不明な期間です: %s（設定ファイルの ranges に定義してください）",
+	"invalid month: %s (use YYYY-MM)":                                          "月の指定が正しくありません: %s（YYYY-MM の形式で指定してください）",
+	"empty duration":                                                           "期間の長さが指定されていません",
+	"invalid duration: %s (use e.g. 30d, 2w, 12h)":                             "期間の長さが正しくありません: %s（30d、2w、12h のように指定してください）",
+	"invalid amount range: %s (use min..max, e.g. 1000..5000)":                 "金額の範囲が正しくありません: %s（1000..5000 のように 最小..最大 の形式で指定してください）",
+	"invalid amount range: %s (min or max is required)":                        "金額の範囲が正しくありません: %s（最小または最大を指定してください）",
+	"invalid amount range: %s (amounts must be non-negative integers)":         "金額の範囲が正しくありません: %s（金額には0以上の整数を指定してください）",
+	"invalid amount range: %s (min is greater than max)":                       "金額の範囲が正しくありません: %s（最小が最大より大きくなっています）",
+
+	// Charges
+	"amount must be greater than 0":                                                    "金額には0より大きい値を指定してください",
+	"invalid currency: %s (supported: jpy, usd)":                                       "通貨が正しくありません: %s（指定可能: jpy, usd）",
+	"invalid reason code: %s (supported: %s)":                                          "理由コードが正しくありません: %s（指定可能: %s）",
+	"--card is required":                                                               "--card を指定してください",
+	"--customer and --customer-email cannot be used together":                          "--customer と --customer-email は同時に指定できません",
+	"no customer found with email %s":                                                  "メールアドレス %s の顧客が見つかりません",
+	"no customer found with email %s; --card is required to create one":                "メールアドレス %s の顧客が見つかりません。顧客を作成するには --card を指定してください",
+	"multiple customers found with email %s: %s (use --customer)":                      "メールアドレス %s の顧客が複数見つかりました: %s（--customer を指定してください）",
+	"amount %d exceeds limits.max_charge_amount (%d); use --override-limit to proceed": "金額 %d が limits.max_charge_amount（%d）を超えています。実行するには --override-limit を指定してください",
+	"charging %d %s would exceed limits.daily_charge_total (%d charged today of %d) for profile '%s'; use --override-limit to proceed": "%[1]d %[2]s を課金するとプロファイル '%[5]s' の limits.daily_charge_total を超えます（本日の %[2]s の課金額 %[3]d / 上限 %[4]d）。実行するには --override-limit を指定してください",
+	"charge %s has no fee_rate (use --fee-rate)":                                                               "支払い %s に fee_rate がありません（--fee-rate を指定してください）",
+	"invalid fee rate: %s (use a percentage such as 3.6)":                                                      "手数料率が正しくありません: %s（3.6 のようにパーセントで指定してください）",
+	"invalid group-by: %s (supported: %s)":                                                                     "group-by の値が正しくありません: %s（指定可能: %s）",
+	"--group-by cannot be used with --all, --offset or --limit":                                                "--group-by は --all、--offset、--limit と同時に指定できません",
+	"invalid metadata filter: %s (use key=value or key=prefix*)":                                               "メタデータの条件が正しくありません: %s（key=value または key=prefix* の形式で指定してください）",
+	"charge %s is already captured":                                                                            "支払い %s は既に確定されています",
+	"capture amount %d exceeds authorized amount %d":                                                           "確定金額 %d が与信金額 %d を超えています",
+	"--count cannot be used with --quiet, --raw, --fields or --output":                                         "--count は --quiet、--raw、--fields、--output と同時に指定できません",
+	"--count cannot be used with --group-by":                                                                   "--count は --group-by と同時に指定できません",
+	"--tenant is not supported by '%s'":                                                                        "'%s' では --tenant を指定できません",
+	"invalid theme: %s (use %s)":                                                                               "テーマが正しくありません: %s（%s のいずれかを指定してください）",
+	"invalid color for theme.%s: %s (use a name such as red or bold blue, or SGR parameters such as 38;5;208)": "theme.%s の色が正しくありません: %s（red や bold blue などの色名、または 38;5;208 などのSGRパラメータを指定してください）",
+	"--count must be at least 1":                                                                               "--count には1以上を指定してください",
+	"--expiry-days must be between 1 and 60":                                                                   "--expiry-days には1〜60を指定してください",
+	"charge %s is already captured; use charges refund to return the money":                                    "支払い %s は既に確定されています。返金する場合は charges refund を使用してください",
+	"the authorization of charge %s has already expired":                                                       "支払い %s の与信は既に期限切れです",
+	"charge %s was not authorized":                                                                             "支払い %s は与信されていません",
+	"charge %s has already been voided":                                                                        "支払い %s の与信は既に取り消されています",
+	"charge %s was not made with a customer's card and cannot be charged again":                                "支払い %s は顧客のカードによる支払いではないため、再度与信できません",
+	"--resume cannot be used with --checkpoint":                                                                "--resume と --checkpoint は同時に指定できません",
+	"concurrency must be at least 1":                                                                           "concurrency には1以上を指定してください",
+	"--concurrency must be at least 1":                                                                         "--concurrency には1以上を指定してください",
+
+	// Plans and subscriptions
+	"invalid interval: %s (supported: month, year)":                           "課金間隔が正しくありません: %s（指定可能: month, year）",
+	"invalid interval: %s (use month or year)":                                "課金間隔が正しくありません: %s（month または year を指定してください）",
+	"billing-day must be between 1 and 31":                                    "billing-day には1〜31を指定してください",
+	"%d plan(s) change immutable fields; create a plan with a new id instead": "%d 件のプランで変更できない項目が変更されています。新しいIDでプランを作成してください",
+
+	// Long-running commands
+	"--interval must be greater than 0": "--interval は0より大きい値を指定してください",
+	"-o %s cannot be used with commands that run until interrupted; use table, json, ndjson or csv": "-o %s は中断するまで実行するコマンドには指定できません。table、json、ndjson、csv のいずれかを指定してください",
+	"failed to listen on %s: %v":                                         "%s で待ち受けできませんでした: %v",
+	"fixture directory not found: %s":                                    "フィクスチャのディレクトリが見つかりません: %s",
+	"--envelope cannot be used with commands that run until interrupted": "--envelope は中断するまで実行するコマンドには指定できません",
+
+	// Other commands
+	"live mode is not enabled for account %s":                                                      "アカウント %s では本番モードが有効になっていません",
+	"card brands not accepted: %s":                                                                 "受け付けていないカードブランドがあります: %s",
+	"cannot detect the resource type from the IDs (use --type)":                                    "IDからリソースの種類を判定できません（--type を指定してください）",
+	"cannot compare a %s with a %s":                                                                "%s と %s は比較できません",
+	"unsupported resource type: %s (supported: %s)":                                                "対応していないリソースの種類です: %s（指定可能: %s）",
+	"no handled event types given (use --handled or --handled-file)":                               "対応済みのイベントの種類が指定されていません（--handled または --handled-file を指定してください）",
+	"invalid history number: %s":                                                                   "履歴番号が正しくありません: %s",
+	"history entry %d not found":                                                                   "履歴 %d が見つかりません",
+	"history entry %d contains a masked secret and cannot be re-run":                               "履歴 %d にはマスクされた秘密情報が含まれているため再実行できません",
+	"history entry %d was run with --api-key, which is not recorded, and cannot be re-run":         "履歴 %d は --api-key を指定して実行されましたが、APIキーは記録されないため再実行できません",
+	"%s is not protected":                                                                          "%s は保護されていません",
+	"%s is protected%s (use --force to override, or 'payjp protect remove %s')":                    "%s は保護されています%s（無視するには --force を指定するか、'payjp protect remove %s' を実行してください）",
+	"update file is empty":                                                                         "更新ファイルにデータがありません",
+	"unknown column: %s (supported: customer_id, email, description, metadata.<key>)":              "不明な列です: %s（指定可能: customer_id, email, description, metadata.<key>）",
+	"the update file has no customer_id column":                                                    "更新ファイルに customer_id 列がありません",
+	"row %d: customer_id is empty":                                                                 "%d 行目: customer_id が空です",
+	"row %d: customer %s is already updated on row %d":                                             "%d 行目: 顧客 %s は %d 行目で既に更新対象になっています",
+	"row %d: invalid email: %s":                                                                    "%d 行目: メールアドレスが正しくありません: %s",
+	"%d customer(s) failed to delete":                                                              "%d 件の顧客の削除に失敗しました",
+	"the ID file has no customer IDs":                                                              "IDファイルに顧客IDがありません",
+	"%d customer(s) failed to update":                                                              "%d 件の顧客の更新に失敗しました",
+	"specify statement IDs or --all":                                                               "明細IDまたは --all を指定してください",
+	"%d statement(s) failed to download":                                                           "%d 件の明細のダウンロードに失敗しました",
+	"--deadline must be positive":                                                                  "--deadline には正の時間を指定してください",
+	"deadline reached with %d item(s) remaining":                                                   "期限に達したため %d 件が未処理のまま残っています",
+	"unsupported event type: %s (use --list to see supported types)":                               "対応していないイベントの種類です: %s（--list で対応している種類を確認できます）",
+	"trigger is only available in test mode":                                                       "trigger はテストモードでのみ使用できます",
+	"--limit must be between 1 and 100":                                                            "--limit には1〜100を指定してください",
+	"pick cannot be used in non-interactive mode":                                                  "pick は非対話モードでは使用できません",
+	"no %s to pick from":                                                                           "選択できる %s がありません",
+	"pick requires a terminal":                                                                     "pick には端末が必要です",
+	"nothing was picked":                                                                           "何も選択されませんでした",
+	"profile '%s' has no API key (use 'payjp config set-profile' to create it)":                    "プロファイル '%s' にAPIキーがありません（'payjp config set-profile' で作成してください）",
+	"the new API key is the same as the current one":                                               "新しいAPIキーが現在のキーと同じです",
+	"the new API key is a %s key but profile '%s' uses a %s key":                                   "新しいAPIキーは %s のキーですが、プロファイル '%s' は %s のキーを使用しています",
+	"the current API key %s was rejected; give the expected account ID with --account":             "現在のAPIキー %s は拒否されました。--account でアカウントIDを指定してください",
+	"failed to check the current API key: %w":                                                      "現在のAPIキーを確認できませんでした: %w",
+	"failed to verify API key: %w":                                                                 "APIキーを確認できませんでした: %w",
+	"the new API key belongs to account %s, not %s; it was not saved":                              "新しいAPIキーはアカウント %s のもので、%s のものではありません。保存されませんでした",
+	"invalid last4: %s (use the last 4 digits of the card number)":                                 "下4桁が正しくありません: %s（カード番号の下4桁を指定してください）",
+	"invalid card country: %s (use a 2-letter ISO country code such as JP)":                        "カードの発行国が正しくありません: %s（JP などの2文字のISO国コードを指定してください）",
+	"specify --out or --upload":                                                                    "--out または --upload を指定してください",
+	"invalid version: %s (use a release version such as v1.2.0)":                                   "バージョンが正しくありません: %s（v1.2.0 のようなリリースのバージョンを指定してください）",
+	"no recent charge has a fee_rate (use --fee-rate)":                                             "最近の支払いに fee_rate がありません（--fee-rate を指定してください）",
+	"invalid time: %s (use a Unix timestamp, RFC3339, YYYY-MM-DD, +14d, +3h, now or end-of-month)": "日時の形式が正しくありません: %s（Unixタイムスタンプ、RFC3339、YYYY-MM-DD、+14d、+3h、now、end-of-month のいずれかを指定してください）",
+	"%s already exists (use --overwrite to replace it)":                                            "%s は既に存在します（置き換えるには --overwrite を指定してください）",
+	"duration must be greater than 0":                                                              "期間には0より大きい値を指定してください",
+	"subscription %s is %s; only trial and active subscriptions can be extended":                   "定期課金 %s のステータスは %s です。延長できるのはトライアル中または有効な定期課金のみです",
+	"invalid 3D Secure status: %s (use %s)":                                                        "3Dセキュアのステータスが正しくありません: %s（%s のいずれかを指定してください）",
+	"--has-3ds cannot be used with --3ds-status none":                                              "--has-3ds と --3ds-status none は同時に指定できません",
+	"invalid include: %s (supported: %s)":                                                          "include の値が正しくありません: %s（指定可能: %s）",
+	"idempotency key %s already created %s for %s; use a new key for a different charge":           "冪等キー %s ではすでに %s（%s）が作成されています。別の支払いには新しいキーを使用してください",
+	"--requests and --concurrency must be at least 1":                                              "--requests と --concurrency には1以上を指定してください",
+	"every request failed: %v":                                                                     "すべてのリクエストが失敗しました: %v",
+	"--dir is only used for CSV output with --with-charges":                                        "--dir は --with-charges を指定したCSV出力でのみ使用できます",
+	"CSV output with --with-charges is written to two files; give the directory with --dir (or use -o json or ndjson)":                "--with-charges を指定したCSV出力は2つのファイルに書き出されます。--dir で出力先のディレクトリを指定してください（または -o json か ndjson を使用してください）",
+	"--machine cannot be used with table output; use -o json, ndjson, csv or yaml":                                                    "--machine はテーブル出力と併用できません。-o json、ndjson、csv または yaml を指定してください",
+	"%s is not supported by your account or the API (GET /v1%s returned %d); run 'payjp debug capabilities' to see what is available": "%s はこのアカウントまたはAPIでは利用できません（GET /v1%s が %d を返しました）。payjp debug capabilities で利用できる機能を確認してください",
+	"--by must not be 0":                                                         "--by に0は指定できません",
+	"%d subscription(s) failed to shift":                                         "%d 件の定期課金のトライアル終了日を変更できませんでした",
+	"invalid --by: %s (supported: %s)":                                           "--by の値が正しくありません: %s（指定可能: %s）",
+	"--spike-factor must be greater than 0":                                      "--spike-factor には0より大きい値を指定してください",
+	"invalid country code: %s (use an ISO 3166-1 alpha-2 code such as JP or US)": "国コードが正しくありません: %s（JP や US のようなISO 3166-1 alpha-2のコードを指定してください）",
+	"invalid postal code for %s: %s (e.g. %s)":                                   "%s の郵便番号として正しくありません: %s（例: %s）",
+	"invalid postal code: %s":                                                    "郵便番号が正しくありません: %s",
+	"unknown command: %s":                                                        "不明なコマンドです: %s",
+	"no examples for %s; see payjp %s --help":                                    "%s の例はありません。payjp %s --help を参照してください",
+	"timed out waiting for %s (state: %s)":                                       "%s の待機がタイムアウトしました（状態: %s）",
+	"--fee-breakdown cannot be used with --group-by or --offset":                 "--fee-breakdown は --group-by や --offset と同時に指定できません",
+	"--totals requires --fee-breakdown":                                          "--totals は --fee-breakdown と一緒に指定してください",
+	"--envelope cannot be used with --quiet, --count, --raw or a .path argument": "--envelope は --quiet・--count・--raw・.path 引数と同時に指定できません",
+	"--envelope requires JSON output; remove -o %s":                              "--envelope はJSON出力でのみ使えます。-o %s を外してください",
+	"--%s cannot be used with --all":                                             "--%s は --all と同時に指定できません",
+	"--raw cannot be used with %s, which filter the fetched results":             "--raw は取得後に絞り込む %s と同時に指定できません",
+	"%s: %s cannot be set in a project file (give it on the command line)":       "%s: プロジェクトファイルには %s を設定できません（コマンドラインで指定してください）",
+}
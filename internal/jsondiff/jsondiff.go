@@ -0,0 +1,107 @@
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Operations of a change, named after JSON Patch (RFC 6902)
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// Change is a difference at a JSON Pointer path
+type Change struct {
+	Op   string
+	Path string
+	From interface{}
+	To   interface{}
+}
+
+// PatchOp is a JSON Patch operation
+type PatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Compare parses two JSON documents and returns the changes turning a into b
+// Objects are compared key by key; arrays and scalars are replaced as a whole when they differ
+func Compare(a, b []byte) ([]Change, error) {
+	var left, right interface{}
+	if err := json.Unmarshal(a, &left); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := json.Unmarshal(b, &right); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	changes := []Change{}
+	diff("", left, right, &changes)
+	return changes, nil
+}
+
+// diff appends the changes between two decoded values at path
+func diff(path string, a, b interface{}, changes *[]Change) {
+	left, lok := a.(map[string]interface{})
+	right, rok := b.(map[string]interface{})
+	if !lok || !rok {
+		if !reflect.DeepEqual(a, b) {
+			*changes = append(*changes, Change{Op: OpReplace, Path: path, From: a, To: b})
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(left)+len(right))
+	for key := range left {
+		keys = append(keys, key)
+	}
+	for key := range right {
+		if _, ok := left[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := path + "/" + escape(key)
+		lv, lok := left[key]
+		rv, rok := right[key]
+		switch {
+		case !lok:
+			*changes = append(*changes, Change{Op: OpAdd, Path: child, To: rv})
+		case !rok:
+			*changes = append(*changes, Change{Op: OpRemove, Path: child, From: lv})
+		default:
+			diff(child, lv, rv, changes)
+		}
+	}
+}
+
+// escape escapes a key for use in a JSON Pointer
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// Patch returns the changes as JSON Patch operations
+func Patch(changes []Change) ([]PatchOp, error) {
+	ops := make([]PatchOp, 0, len(changes))
+	for _, change := range changes {
+		op := PatchOp{Op: change.Op, Path: change.Path}
+		if change.Op != OpRemove {
+			// Encode the value here so that null, false and 0 are kept
+			value, err := json.Marshal(change.To)
+			if err != nil {
+				return nil, err
+			}
+			op.Value = value
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
@@ -0,0 +1,122 @@
+package jsondiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Change
+	}{
+		{
+			name: "identical",
+			a:    `{"id":"pln_1","amount":500,"metadata":{"tier":"basic"}}`,
+			b:    `{"amount":500,"id":"pln_1","metadata":{"tier":"basic"}}`,
+			want: []Change{},
+		},
+		{
+			name: "scalar replaced",
+			a:    `{"amount":500,"currency":"jpy"}`,
+			b:    `{"amount":1000,"currency":"jpy"}`,
+			want: []Change{{Op: OpReplace, Path: "/amount", From: 500.0, To: 1000.0}},
+		},
+		{
+			name: "added and removed in key order",
+			a:    `{"b":1,"name":"basic"}`,
+			b:    `{"a":true,"b":1}`,
+			want: []Change{
+				{Op: OpAdd, Path: "/a", To: true},
+				{Op: OpRemove, Path: "/name", From: "basic"},
+			},
+		},
+		{
+			name: "nested objects compared by key",
+			a:    `{"metadata":{"tier":"basic","region":"jp"}}`,
+			b:    `{"metadata":{"tier":"pro","region":"jp"}}`,
+			want: []Change{{Op: OpReplace, Path: "/metadata/tier", From: "basic", To: "pro"}},
+		},
+		{
+			name: "arrays replaced as a whole",
+			a:    `{"ids":[1,2,3]}`,
+			b:    `{"ids":[1,3]}`,
+			want: []Change{{Op: OpReplace, Path: "/ids", From: []interface{}{1.0, 2.0, 3.0}, To: []interface{}{1.0, 3.0}}},
+		},
+		{
+			name: "object replaced by null",
+			a:    `{"card":{"id":"car_1"}}`,
+			b:    `{"card":null}`,
+			want: []Change{{Op: OpReplace, Path: "/card", From: map[string]interface{}{"id": "car_1"}, To: nil}},
+		},
+		{
+			name: "pointer escaping",
+			a:    `{"a/b":1,"c~d":1}`,
+			b:    `{"a/b":2,"c~d":2}`,
+			want: []Change{
+				{Op: OpReplace, Path: "/a~1b", From: 1.0, To: 2.0},
+				{Op: OpReplace, Path: "/c~0d", From: 1.0, To: 2.0},
+			},
+		},
+		{
+			name: "documents that are not objects",
+			a:    `"a"`,
+			b:    `"b"`,
+			want: []Change{{Op: OpReplace, Path: "", From: "a", To: "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare([]byte(tt.a), []byte(tt.b))
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareInvalid(t *testing.T) {
+	if _, err := Compare([]byte(`{"a":`), []byte(`{}`)); err == nil {
+		t.Error("Compare() error = nil, want error for invalid JSON on the left")
+	}
+	if _, err := Compare([]byte(`{}`), []byte(`not json`)); err == nil {
+		t.Error("Compare() error = nil, want error for invalid JSON on the right")
+	}
+}
+
+func TestPatch(t *testing.T) {
+	changes := []Change{
+		{Op: OpAdd, Path: "/livemode", To: false},
+		{Op: OpReplace, Path: "/amount", From: 500.0, To: 0.0},
+		{Op: OpReplace, Path: "/card", From: "car_1", To: nil},
+		{Op: OpRemove, Path: "/name", From: "basic"},
+		{Op: OpReplace, Path: "/metadata/tier", From: "basic", To: "pro"},
+	}
+
+	ops, err := Patch(changes)
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+
+	// null, false and 0 are kept as values; remove has none
+	want := []struct{ op, path, value string }{
+		{"add", "/livemode", "false"},
+		{"replace", "/amount", "0"},
+		{"replace", "/card", "null"},
+		{"remove", "/name", ""},
+		{"replace", "/metadata/tier", `"pro"`},
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("Patch() returned %d ops, want %d", len(ops), len(want))
+	}
+	for i, w := range want {
+		if ops[i].Op != w.op || ops[i].Path != w.path || string(ops[i].Value) != w.value {
+			t.Errorf("op %d = {%s %s %s}, want {%s %s %s}", i, ops[i].Op, ops[i].Path, ops[i].Value, w.op, w.path, w.value)
+		}
+	}
+}
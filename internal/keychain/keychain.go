@@ -0,0 +1,112 @@
+// Package keychain stores API keys in the OS credential store instead of the config file
+// It uses the security command on macOS and secret-tool (libsecret) on Linux
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service is the name the keys are stored under; the account is the profile name
+const service = "payjp-cli"
+
+// ErrUnavailable is returned when no supported credential store is installed
+var ErrUnavailable = errors.New("no OS keychain is available (macOS security or Linux secret-tool is required)")
+
+// Available reports whether a supported credential store can be used
+func Available() bool {
+	_, err := tool()
+	return err == nil
+}
+
+// tool returns the credential store command of the OS
+func tool() (string, error) {
+	var name string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "security"
+	case "linux":
+		name = "secret-tool"
+	default:
+		return "", ErrUnavailable
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", ErrUnavailable
+	}
+	return path, nil
+}
+
+// Get returns the key stored for a profile
+func Get(profile string) (string, error) {
+	path, err := tool()
+	if err != nil {
+		return "", err
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command(path, "find-generic-password", "-s", service, "-a", profile, "-w")
+	} else {
+		cmd = exec.Command(path, "lookup", "service", service, "account", profile)
+	}
+	out, err := run(cmd, "")
+	if err != nil {
+		return "", fmt.Errorf("error reading the API key of profile '%s' from the keychain: %w", profile, err)
+	}
+	key := strings.TrimSpace(out)
+	if key == "" {
+		return "", fmt.Errorf("the keychain has no API key for profile '%s'", profile)
+	}
+	return key, nil
+}
+
+// Set stores the key of a profile, replacing an earlier one
+func Set(profile, key string) error {
+	path, err := tool()
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	input := ""
+	if runtime.GOOS == "darwin" {
+		// security only takes the password as an argument, which other users can read with ps,
+		// so the command is passed on stdin to its interactive mode; -U updates an existing item
+		if strings.ContainsAny(profile+key, "\"\\\n") {
+			return fmt.Errorf("the API key of profile '%s' cannot be saved to the keychain: the profile name or key contains a quote, backslash or newline", profile)
+		}
+		cmd = exec.Command(path, "-i")
+		input = fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", service, profile, key)
+	} else {
+		cmd = exec.Command(path, "store", "--label", "PAY.JP CLI ("+profile+")", "service", service, "account", profile)
+		input = key
+	}
+	if _, err := run(cmd, input); err != nil {
+		return fmt.Errorf("error saving the API key of profile '%s' to the keychain: %w", profile, err)
+	}
+	// The interactive mode of security exits successfully even when a command fails, so the key
+	// is read back
+	if stored, err := Get(profile); err != nil || stored != key {
+		return fmt.Errorf("error saving the API key of profile '%s' to the keychain: the stored key does not match", profile)
+	}
+	return nil
+}
+
+// run executes a credential store command and returns its output
+// The error includes the message the command printed
+func run(cmd *exec.Cmd, input string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,147 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/filelock"
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// FileName is the name of the idempotency ledger in the config directory
+const FileName = "ledger.json"
+
+// Entry records the charge created with an idempotency key
+type Entry struct {
+	Profile   string    `json:"profile"`
+	Key       string    `json:"key"`
+	ChargeID  string    `json:"charge_id"`
+	Amount    int       `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableColumns returns the fields shown in list view
+func (Entry) TableColumns() []string {
+	return []string{"Profile", "Key", "ChargeID", "Amount", "Currency", "CreatedAt"}
+}
+
+// Matches reports whether the entry is a charge of amount in currency
+// Currency codes are compared ignoring case, since --currency is sent as given
+func (e Entry) Matches(amount int, currency string) bool {
+	return e.Amount == amount && strings.EqualFold(e.Currency, currency)
+}
+
+// Ledger maps idempotency keys to the charges created with them, per profile
+// It lets scripts that are run again find the charge a key already created, also after the
+// API stops remembering the key
+type Ledger struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Path returns the ledger file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads the ledger file, returning an empty ledger if it does not exist
+func Load(path string) (*Ledger, error) {
+	l := &Ledger{Entries: []Entry{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, fmt.Errorf("error reading idempotency ledger: %w", err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("error parsing idempotency ledger %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Save writes the ledger file through a temp file and rename
+func (l *Ledger) Save(path string) error {
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		return l.Entries[i].CreatedAt.Before(l.Entries[j].CreatedAt)
+	})
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := util.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing idempotency ledger: %w", err)
+	}
+	return nil
+}
+
+// Update loads the ledger at path under an exclusive lock, applies change and saves it, so that
+// scripts run at the same time do not lose each other's entries
+func Update(path string, change func(l *Ledger)) error {
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return fmt.Errorf("error locking idempotency ledger: %w", err)
+	}
+	defer lock.Release()
+
+	l, err := Load(path)
+	if err != nil {
+		return err
+	}
+	change(l)
+	return l.Save(path)
+}
+
+// Get returns the entry for a key of a profile
+func (l *Ledger) Get(profile, key string) (Entry, bool) {
+	for _, entry := range l.Entries {
+		if entry.Profile == profile && entry.Key == key {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Add records an entry, replacing an earlier entry for the same key of the profile
+func (l *Ledger) Add(entry Entry) {
+	for i := range l.Entries {
+		if l.Entries[i].Profile == entry.Profile && l.Entries[i].Key == entry.Key {
+			l.Entries[i] = entry
+			return
+		}
+	}
+	l.Entries = append(l.Entries, entry)
+}
+
+// List returns the entries of a profile, or of every profile when profile is ""
+func (l *Ledger) List(profile string) []Entry {
+	entries := []Entry{}
+	for _, entry := range l.Entries {
+		if profile == "" || entry.Profile == profile {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Prune removes the entries of a profile (every profile when profile is "") created before
+// the given time and returns them
+func (l *Ledger) Prune(profile string, before time.Time) []Entry {
+	removed := []Entry{}
+	kept := l.Entries[:0]
+	for _, entry := range l.Entries {
+		if (profile == "" || entry.Profile == profile) && entry.CreatedAt.Before(before) {
+			removed = append(removed, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	l.Entries = kept
+	return removed
+}
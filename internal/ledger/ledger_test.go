@@ -0,0 +1,148 @@
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddReplacesKeyOfProfile(t *testing.T) {
+	l := &Ledger{}
+	l.Add(Entry{Profile: "default", Key: "order-1", ChargeID: "ch_1"})
+	l.Add(Entry{Profile: "live", Key: "order-1", ChargeID: "ch_2"})
+	l.Add(Entry{Profile: "default", Key: "order-1", ChargeID: "ch_3"})
+
+	if got, ok := l.Get("default", "order-1"); !ok || got.ChargeID != "ch_3" {
+		t.Errorf("Get(default, order-1) = %+v, %v, want ch_3", got, ok)
+	}
+	if got, ok := l.Get("live", "order-1"); !ok || got.ChargeID != "ch_2" {
+		t.Errorf("Get(live, order-1) = %+v, %v, want ch_2", got, ok)
+	}
+	if _, ok := l.Get("default", "order-2"); ok {
+		t.Error("Get(default, order-2) found an entry")
+	}
+	if got := len(l.List("")); got != 2 {
+		t.Errorf("len(List(\"\")) = %d, want 2", got)
+	}
+	if got := l.List("live"); len(got) != 1 || got[0].ChargeID != "ch_2" {
+		t.Errorf("List(live) = %+v, want ch_2", got)
+	}
+}
+
+func TestEntryMatches(t *testing.T) {
+	entry := Entry{Amount: 1000, Currency: "jpy"}
+	tests := []struct {
+		amount   int
+		currency string
+		want     bool
+	}{
+		{1000, "jpy", true},
+		{1000, "JPY", true},
+		{1000, "Jpy", true},
+		{1001, "jpy", false},
+		{1000, "usd", false},
+	}
+	for _, tt := range tests {
+		if got := entry.Matches(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("Matches(%d, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	l := &Ledger{Entries: []Entry{
+		{Profile: "default", Key: "old", CreatedAt: now.Add(-48 * time.Hour)},
+		{Profile: "default", Key: "new", CreatedAt: now},
+		{Profile: "live", Key: "old", CreatedAt: now.Add(-48 * time.Hour)},
+	}}
+
+	removed := l.Prune("default", now.Add(-24*time.Hour))
+	if len(removed) != 1 || removed[0].Profile != "default" || removed[0].Key != "old" {
+		t.Errorf("Prune(default) removed %+v, want the old key of default", removed)
+	}
+	if len(l.Entries) != 2 {
+		t.Errorf("entries after Prune(default) = %+v", l.Entries)
+	}
+
+	if removed := l.Prune("", now.Add(-24*time.Hour)); len(removed) != 1 || removed[0].Profile != "live" {
+		t.Errorf("Prune(\"\") removed %+v, want the old key of live", removed)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", FileName)
+
+	l, err := Load(path)
+	if err != nil || len(l.Entries) != 0 {
+		t.Fatalf("Load() of a missing file = %+v, %v, want an empty ledger", l, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	l.Add(Entry{Profile: "default", Key: "b", ChargeID: "ch_2", CreatedAt: now})
+	l.Add(Entry{Profile: "default", Key: "a", ChargeID: "ch_1", CreatedAt: now.Add(-time.Minute)})
+	if err := l.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("ledger permissions = %o, want 600", perm)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// Entries are kept in the order they were created
+	if len(loaded.Entries) != 2 || loaded.Entries[0].ChargeID != "ch_1" || loaded.Entries[1].ChargeID != "ch_2" {
+		t.Errorf("loaded entries = %+v", loaded.Entries)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of a corrupt file succeeded")
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Update(path, func(l *Ledger) {
+				l.Add(Entry{Profile: "default", Key: fmt.Sprintf("key-%d", i), CreatedAt: time.Now()})
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Entries) != n {
+		t.Errorf("entries after %d concurrent updates = %d, want every entry kept", n, len(l.Entries))
+	}
+}
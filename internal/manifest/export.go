@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-go/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Customer is an exported customer
+// Customers are exported for review and history only; apply does not manage them
+type Customer struct {
+	ID          string            `yaml:"id"`
+	Email       string            `yaml:"email,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	DefaultCard string            `yaml:"default_card,omitempty"`
+	Metadata    map[string]string `yaml:"metadata,omitempty"`
+}
+
+// Subscription is an exported subscription
+// Subscriptions are exported for review and history only; apply does not manage them
+type Subscription struct {
+	ID       string            `yaml:"id"`
+	Customer string            `yaml:"customer"`
+	Plan     string            `yaml:"plan"`
+	Status   string            `yaml:"status"`
+	TrialEnd string            `yaml:"trial_end,omitempty"`
+	Metadata map[string]string `yaml:"metadata,omitempty"`
+}
+
+// customerFile and subscriptionFile are the documents customers and subscriptions are exported in
+type customerFile struct {
+	Customers []Customer `yaml:"customers"`
+}
+
+type subscriptionFile struct {
+	Subscriptions []Subscription `yaml:"subscriptions"`
+}
+
+// PlanManifest returns a manifest with a single plan, in the form apply reads
+func PlanManifest(plan *payjp.PlanResponse) *Manifest {
+	return &Manifest{Plans: []Plan{{
+		ID:         plan.ID,
+		Name:       plan.Name,
+		Amount:     plan.Amount,
+		Currency:   plan.Currency,
+		Interval:   plan.Interval,
+		TrialDays:  plan.TrialDays,
+		BillingDay: plan.BillingDay,
+		Metadata:   plan.Metadata,
+	}}}
+}
+
+// CustomerDocument returns the export document of a customer
+func CustomerDocument(customer *payjp.CustomerResponse) interface{} {
+	return &customerFile{Customers: []Customer{{
+		ID:          customer.ID,
+		Email:       customer.Email,
+		Description: customer.Description,
+		DefaultCard: customer.DefaultCard,
+		Metadata:    customer.Metadata,
+	}}}
+}
+
+// SubscriptionDocument returns the export document of a subscription
+// The trial end is in RFC 3339 so that the files do not depend on the local time zone
+func SubscriptionDocument(sub *payjp.SubscriptionResponse) interface{} {
+	s := Subscription{
+		ID:       sub.ID,
+		Customer: sub.Customer,
+		Plan:     sub.Plan.ID,
+		Status:   string(sub.Status),
+		Metadata: sub.Metadata,
+	}
+	if sub.TrialEnd != nil {
+		s.TrialEnd = sub.TrialEndAt.UTC().Format(time.RFC3339)
+	}
+	return &subscriptionFile{Subscriptions: []Subscription{s}}
+}
+
+// FileName returns the file name a resource is exported to
+// IDs that are not a plain file name are rejected, so that a file is never written outside the directory
+func FileName(id string) (string, error) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) || filepath.Base(id) != id {
+		return "", fmt.Errorf("%s cannot be used as a file name", id)
+	}
+	return id + ".yaml", nil
+}
+
+// Write states
+const (
+	WriteCreated   = "created"
+	WriteUpdated   = "updated"
+	WriteUnchanged = "unchanged"
+	WriteRemoved   = "removed"
+)
+
+// WriteFile writes a document as YAML and reports whether the file was created, updated
+// or left unchanged
+// Files with the same content are not rewritten, so that repeated exports leave no diff
+func WriteFile(path string, doc interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	encoder.Close()
+
+	state := WriteCreated
+	if existing, err := os.ReadFile(path); err == nil {
+		if bytes.Equal(existing, buf.Bytes()) {
+			return WriteUnchanged, nil
+		}
+		state = WriteUpdated
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("error writing %s: %w", path, err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return "", fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return state, nil
+}
@@ -0,0 +1,257 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/util"
+	"github.com/payjp/payjp-go/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes the desired state of declaratively managed resources
+type Manifest struct {
+	Plans []Plan `yaml:"plans"`
+}
+
+// Plan is the desired state of a plan
+type Plan struct {
+	ID         string            `yaml:"id"`
+	Name       string            `yaml:"name"`
+	Amount     int               `yaml:"amount"`
+	Currency   string            `yaml:"currency"`
+	Interval   string            `yaml:"interval"`
+	TrialDays  int               `yaml:"trial_days"`
+	BillingDay int               `yaml:"billing_day"`
+	Metadata   map[string]string `yaml:"metadata"`
+}
+
+// Change actions
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionConflict = "conflict"
+)
+
+// Change is a difference between the desired and actual state of a resource
+type Change struct {
+	Action string   `json:"action"`
+	Kind   string   `json:"kind"`
+	ID     string   `json:"id"`
+	Fields []string `json:"fields,omitempty"`
+	Status string   `json:"status,omitempty"`
+	Error  string   `json:"error,omitempty"`
+
+	// Plan is the desired plan for create and update changes
+	Plan *Plan `json:"-"`
+	// RemovedMetadata lists metadata keys to remove on update
+	RemovedMetadata []string `json:"-"`
+}
+
+// Load reads and validates a manifest file ("-" reads from stdin)
+// A directory is read as one manifest made of every .yaml and .yml file directly in it,
+// such as the files written by plans dump
+// Unknown keys are rejected so that typos are not silently ignored
+func Load(path string) (*Manifest, error) {
+	if path != "-" {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return loadDir(path)
+		}
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+	return parse(data)
+}
+
+// loadDir reads the manifest files in a directory in name order
+func loadDir(dir string) (*Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %w", err)
+	}
+
+	m := &Manifest{}
+	files := map[string]string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading manifest: %w", err)
+		}
+		part, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, plan := range part.Plans {
+			if other, ok := files[plan.ID]; ok {
+				return nil, fmt.Errorf("%s: duplicate id %s (also in %s)", path, plan.ID, other)
+			}
+			files[plan.ID] = path
+		}
+		m.Plans = append(m.Plans, part.Plans...)
+	}
+	return m, nil
+}
+
+// parse decodes and validates a manifest document
+func parse(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(m); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error parsing manifest: %w", err)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validate checks required fields, applies defaults and rejects duplicate IDs
+func (m *Manifest) validate() error {
+	seen := map[string]bool{}
+	for i := range m.Plans {
+		plan := &m.Plans[i]
+		if plan.ID == "" {
+			return fmt.Errorf("plans[%d]: id is required", i)
+		}
+		if seen[plan.ID] {
+			return fmt.Errorf("plans[%d]: duplicate id %s", i, plan.ID)
+		}
+		seen[plan.ID] = true
+
+		if plan.Currency == "" {
+			plan.Currency = "jpy"
+		}
+		if plan.Interval == "" {
+			plan.Interval = "month"
+		}
+		if err := util.ValidateAmount(plan.Amount); err != nil {
+			return fmt.Errorf("plans[%d] (%s): %w", i, plan.ID, err)
+		}
+		if err := util.ValidateCurrency(plan.Currency); err != nil {
+			return fmt.Errorf("plans[%d] (%s): %w", i, plan.ID, err)
+		}
+		if err := util.ValidateInterval(plan.Interval); err != nil {
+			return fmt.Errorf("plans[%d] (%s): %w", i, plan.ID, err)
+		}
+	}
+	return nil
+}
+
+// DiffPlans compares desired plans against existing plans
+// Changes to immutable fields (amount, currency, interval, trial_days, billing_day) are reported as conflicts
+// The name is only compared when the manifest sets one
+// Plans that only exist remotely are deleted when prune is set
+func DiffPlans(desired []Plan, actual []*payjp.PlanResponse, prune bool) []Change {
+	existing := make(map[string]*payjp.PlanResponse, len(actual))
+	for _, plan := range actual {
+		existing[plan.ID] = plan
+	}
+
+	changes := []Change{}
+	for i := range desired {
+		want := &desired[i]
+		have, ok := existing[want.ID]
+		if !ok {
+			changes = append(changes, Change{Action: ActionCreate, Kind: "plan", ID: want.ID, Plan: want})
+			continue
+		}
+		delete(existing, want.ID)
+
+		immutable := []string{}
+		if want.Amount != have.Amount {
+			immutable = append(immutable, "amount")
+		}
+		if !strings.EqualFold(want.Currency, have.Currency) {
+			immutable = append(immutable, "currency")
+		}
+		if want.Interval != have.Interval {
+			immutable = append(immutable, "interval")
+		}
+		if want.TrialDays != have.TrialDays {
+			immutable = append(immutable, "trial_days")
+		}
+		if want.BillingDay != have.BillingDay {
+			immutable = append(immutable, "billing_day")
+		}
+		if len(immutable) > 0 {
+			changes = append(changes, Change{Action: ActionConflict, Kind: "plan", ID: want.ID, Fields: immutable, Plan: want})
+			continue
+		}
+
+		// A plan without a name in the manifest keeps its current name
+		fields := []string{}
+		if want.Name != "" && want.Name != have.Name {
+			fields = append(fields, "name")
+		}
+		removed := []string{}
+		for key := range have.Metadata {
+			if _, ok := want.Metadata[key]; !ok {
+				removed = append(removed, key)
+			}
+		}
+		sort.Strings(removed)
+		if len(removed) > 0 || !metadataContains(have.Metadata, want.Metadata) {
+			fields = append(fields, "metadata")
+		}
+		if len(fields) > 0 {
+			// The update always sends the name, so the current one is sent when the manifest has none
+			plan := want
+			if plan.Name == "" {
+				named := *want
+				named.Name = have.Name
+				plan = &named
+			}
+			changes = append(changes, Change{Action: ActionUpdate, Kind: "plan", ID: want.ID, Fields: fields, Plan: plan, RemovedMetadata: removed})
+		}
+	}
+
+	if prune {
+		ids := make([]string, 0, len(existing))
+		for id := range existing {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			changes = append(changes, Change{Action: ActionDelete, Kind: "plan", ID: id})
+		}
+	}
+
+	return changes
+}
+
+// metadataContains reports whether have contains every key and value of want
+func metadataContains(have, want map[string]string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	subset := make(map[string]string, len(want))
+	for key := range want {
+		if value, ok := have[key]; ok {
+			subset[key] = value
+		}
+	}
+	return reflect.DeepEqual(subset, want)
+}
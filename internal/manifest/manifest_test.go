@@ -0,0 +1,153 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/payjp/payjp-go/v1"
+)
+
+// existingPlan returns a monthly JPY plan as returned by the API
+func existingPlan(id, name string, metadata map[string]string) *payjp.PlanResponse {
+	return &payjp.PlanResponse{ID: id, Name: name, Amount: 500, Currency: "jpy", Interval: "month", Metadata: metadata}
+}
+
+// desiredPlan returns the manifest plan matching existingPlan
+func desiredPlan(id, name string, metadata map[string]string) Plan {
+	return Plan{ID: id, Name: name, Amount: 500, Currency: "jpy", Interval: "month", Metadata: metadata}
+}
+
+func TestDiffPlansCreateAndDelete(t *testing.T) {
+	desired := []Plan{desiredPlan("basic", "Basic", nil), desiredPlan("new", "New", nil)}
+	actual := []*payjp.PlanResponse{existingPlan("basic", "Basic", nil), existingPlan("old_b", "", nil), existingPlan("old_a", "", nil)}
+
+	changes := DiffPlans(desired, actual, false)
+	if len(changes) != 1 || changes[0].Action != ActionCreate || changes[0].ID != "new" || changes[0].Plan.Name != "New" {
+		t.Fatalf("DiffPlans() = %+v, want only the creation of new", changes)
+	}
+
+	// Plans that only exist remotely are deleted in ID order with prune
+	changes = DiffPlans(desired, actual, true)
+	got := []string{}
+	for _, change := range changes {
+		got = append(got, change.Action+" "+change.ID)
+	}
+	want := []string{"create new", "delete old_a", "delete old_b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffPlans() with prune = %v, want %v", got, want)
+	}
+}
+
+func TestDiffPlansNoChanges(t *testing.T) {
+	desired := []Plan{desiredPlan("basic", "Basic", map[string]string{"tier": "1"})}
+	actual := []*payjp.PlanResponse{existingPlan("basic", "Basic", map[string]string{"tier": "1"})}
+	if changes := DiffPlans(desired, actual, true); len(changes) != 0 {
+		t.Errorf("DiffPlans() = %+v, want no changes", changes)
+	}
+}
+
+func TestDiffPlansUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired Plan
+		have    *payjp.PlanResponse
+		fields  []string
+		removed []string
+	}{
+		{
+			name:    "name",
+			desired: desiredPlan("basic", "Basic+", nil),
+			have:    existingPlan("basic", "Basic", nil),
+			fields:  []string{"name"},
+			removed: []string{},
+		},
+		{
+			name:    "metadata value",
+			desired: desiredPlan("basic", "Basic", map[string]string{"tier": "2"}),
+			have:    existingPlan("basic", "Basic", map[string]string{"tier": "1"}),
+			fields:  []string{"metadata"},
+			removed: []string{},
+		},
+		{
+			name:    "removed metadata",
+			desired: desiredPlan("basic", "Basic", map[string]string{"tier": "1"}),
+			have:    existingPlan("basic", "Basic", map[string]string{"tier": "1", "b": "x", "a": "y"}),
+			fields:  []string{"metadata"},
+			removed: []string{"a", "b"},
+		},
+		{
+			name:    "name and metadata",
+			desired: desiredPlan("basic", "Basic+", map[string]string{"tier": "1"}),
+			have:    existingPlan("basic", "Basic", nil),
+			fields:  []string{"name", "metadata"},
+			removed: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := DiffPlans([]Plan{tt.desired}, []*payjp.PlanResponse{tt.have}, false)
+			if len(changes) != 1 || changes[0].Action != ActionUpdate {
+				t.Fatalf("DiffPlans() = %+v, want one update", changes)
+			}
+			change := changes[0]
+			if !reflect.DeepEqual(change.Fields, tt.fields) {
+				t.Errorf("Fields = %v, want %v", change.Fields, tt.fields)
+			}
+			if !reflect.DeepEqual(change.RemovedMetadata, tt.removed) {
+				t.Errorf("RemovedMetadata = %v, want %v", change.RemovedMetadata, tt.removed)
+			}
+			if change.Plan.Name != tt.desired.Name {
+				t.Errorf("Plan.Name = %q, want %q", change.Plan.Name, tt.desired.Name)
+			}
+		})
+	}
+}
+
+func TestDiffPlansNameOnlyWhenSet(t *testing.T) {
+	// A plan without a name in the manifest keeps its current name
+	desired := []Plan{desiredPlan("basic", "", nil)}
+	actual := []*payjp.PlanResponse{existingPlan("basic", "Basic", nil)}
+	if changes := DiffPlans(desired, actual, false); len(changes) != 0 {
+		t.Fatalf("DiffPlans() = %+v, want no changes", changes)
+	}
+
+	// An update of another field sends the current name
+	desired = []Plan{desiredPlan("basic", "", map[string]string{"tier": "1"})}
+	changes := DiffPlans(desired, actual, false)
+	if len(changes) != 1 || !reflect.DeepEqual(changes[0].Fields, []string{"metadata"}) {
+		t.Fatalf("DiffPlans() = %+v, want an update of metadata", changes)
+	}
+	if changes[0].Plan.Name != "Basic" {
+		t.Errorf("Plan.Name = %q, want the current name %q", changes[0].Plan.Name, "Basic")
+	}
+	if desired[0].Name != "" {
+		t.Errorf("desired plan name = %q, want it left unchanged", desired[0].Name)
+	}
+}
+
+func TestDiffPlansConflict(t *testing.T) {
+	desired := desiredPlan("basic", "Basic+", nil)
+	desired.Amount = 1000
+	desired.Interval = "year"
+	desired.TrialDays = 7
+	desired.BillingDay = 1
+	actual := []*payjp.PlanResponse{existingPlan("basic", "Basic", nil)}
+
+	changes := DiffPlans([]Plan{desired}, actual, false)
+	if len(changes) != 1 || changes[0].Action != ActionConflict {
+		t.Fatalf("DiffPlans() = %+v, want one conflict", changes)
+	}
+	want := []string{"amount", "interval", "trial_days", "billing_day"}
+	if !reflect.DeepEqual(changes[0].Fields, want) {
+		t.Errorf("Fields = %v, want %v", changes[0].Fields, want)
+	}
+}
+
+func TestDiffPlansCurrencyIgnoresCase(t *testing.T) {
+	desired := desiredPlan("basic", "Basic", nil)
+	desired.Currency = "JPY"
+	actual := []*payjp.PlanResponse{existingPlan("basic", "Basic", nil)}
+	if changes := DiffPlans([]Plan{desired}, actual, false); len(changes) != 0 {
+		t.Errorf("DiffPlans() = %+v, want no changes", changes)
+	}
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, r *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return b.String()
+}
+
+func TestWriteCounter(t *testing.T) {
+	r := New()
+	c := r.Counter("payjp_events_processed_total", "Events processed by type", "type")
+	c.Inc("charge.succeeded")
+	c.Inc("charge.succeeded")
+	c.Inc("customer.created")
+
+	want := `# HELP payjp_events_processed_total Events processed by type
+# TYPE payjp_events_processed_total counter
+payjp_events_processed_total{type="charge.succeeded"} 2
+payjp_events_processed_total{type="customer.created"} 1
+`
+	if got := write(t, r); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteEscapesLabelsAndHelp(t *testing.T) {
+	r := New()
+	c := r.Counter("payjp_test_total", "Help with \\ and\nnewline", "value")
+	c.Inc("a\"b\\c\nd")
+
+	want := `# HELP payjp_test_total Help with \\ and\nnewline
+# TYPE payjp_test_total counter
+payjp_test_total{value="a\"b\\c\nd"} 1
+`
+	if got := write(t, r); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteHistogram(t *testing.T) {
+	r := New()
+	h := r.Histogram("payjp_api_request_duration_seconds", "Latency", []float64{0.1, 0.5, 1}, "endpoint")
+	h.Observe(0.05, "GET /v1/charges")
+	h.Observe(0.5, "GET /v1/charges")
+	h.Observe(2, "GET /v1/charges")
+
+	want := `# HELP payjp_api_request_duration_seconds Latency
+# TYPE payjp_api_request_duration_seconds histogram
+payjp_api_request_duration_seconds_bucket{endpoint="GET /v1/charges",le="0.1"} 1
+payjp_api_request_duration_seconds_bucket{endpoint="GET /v1/charges",le="0.5"} 2
+payjp_api_request_duration_seconds_bucket{endpoint="GET /v1/charges",le="1"} 2
+payjp_api_request_duration_seconds_bucket{endpoint="GET /v1/charges",le="+Inf"} 3
+payjp_api_request_duration_seconds_sum{endpoint="GET /v1/charges"} 2.55
+payjp_api_request_duration_seconds_count{endpoint="GET /v1/charges"} 3
+`
+	if got := write(t, r); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteHistogramWithoutLabels(t *testing.T) {
+	r := New()
+	h := r.Histogram("payjp_forward_duration_seconds", "Latency", []float64{1})
+	h.Observe(0.25)
+
+	want := `# HELP payjp_forward_duration_seconds Latency
+# TYPE payjp_forward_duration_seconds histogram
+payjp_forward_duration_seconds_bucket{le="1"} 1
+payjp_forward_duration_seconds_bucket{le="+Inf"} 1
+payjp_forward_duration_seconds_sum 0.25
+payjp_forward_duration_seconds_count 1
+`
+	if got := write(t, r); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteOrder(t *testing.T) {
+	r := New()
+	b := r.Counter("payjp_b_total", "B", "status")
+	a := r.Counter("payjp_a_total", "A")
+	r.Counter("payjp_c_total", "C", "reason")
+	b.Inc("500")
+	b.Inc("200")
+	b.Inc("404")
+	a.Inc()
+
+	want := `# HELP payjp_a_total A
+# TYPE payjp_a_total counter
+payjp_a_total 1
+# HELP payjp_b_total B
+# TYPE payjp_b_total counter
+payjp_b_total{status="200"} 1
+payjp_b_total{status="404"} 1
+payjp_b_total{status="500"} 1
+# HELP payjp_c_total C
+# TYPE payjp_c_total counter
+`
+	first := write(t, r)
+	if first != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", first, want)
+	}
+	for i := 0; i < 10; i++ {
+		if got := write(t, r); got != first {
+			t.Fatalf("Write() is not stable:\n%s\nthen\n%s", first, got)
+		}
+	}
+}
+
+func TestWriteUnlabeledCounterStartsAtZero(t *testing.T) {
+	r := New()
+	r.Counter("payjp_forward_errors_total", "Errors")
+
+	want := `# HELP payjp_forward_errors_total Errors
+# TYPE payjp_forward_errors_total counter
+payjp_forward_errors_total 0
+`
+	if got := write(t, r); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegisterReturnsExistingMetric(t *testing.T) {
+	r := New()
+	r.Counter("payjp_requests_total", "Requests", "status").Inc("200")
+	r.Counter("payjp_requests_total", "Requests", "status").Inc("200")
+
+	if got := write(t, r); !strings.Contains(got, `payjp_requests_total{status="200"} 2`) {
+		t.Errorf("Write() =\n%s\nwant both increments in one series", got)
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	// A nil registry returns nil metrics that record nothing
+	r.Counter("payjp_events_processed_total", "Events", "type").Inc("charge.succeeded")
+	r.Histogram("payjp_forward_duration_seconds", "Latency", DefaultBuckets).Observe(1)
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+)
+
+// timeout bounds how long a notification may delay the command exit
+const timeout = 10 * time.Second
+
+// Message describes the outcome of a finished command
+type Message struct {
+	Command  string
+	Profile  string
+	ExitCode int
+	Duration time.Duration
+	Summary  []string
+	Error    string
+}
+
+// Success returns true if the command exited with code 0
+func (m Message) Success() bool {
+	return m.ExitCode == 0
+}
+
+// Subject returns a one-line description of the outcome
+func (m Message) Subject() string {
+	if m.Success() {
+		return fmt.Sprintf("payjp %s succeeded", m.Command)
+	}
+	return fmt.Sprintf("payjp %s failed (exit code %d)", m.Command, m.ExitCode)
+}
+
+// Body returns the full notification text
+func (m Message) Body() string {
+	var b strings.Builder
+	b.WriteString(m.Subject() + "\n")
+	if m.Profile != "" {
+		fmt.Fprintf(&b, "Profile: %s\n", m.Profile)
+	}
+	fmt.Fprintf(&b, "Duration: %s\n", m.Duration.Round(time.Second))
+	for _, line := range m.Summary {
+		b.WriteString(line + "\n")
+	}
+	if m.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", m.Error)
+	}
+	return b.String()
+}
+
+// Configured returns true if at least one destination is set
+func Configured(cfg config.NotifyConfig) bool {
+	return cfg.SlackWebhookURL != "" || cfg.SMTP.Host != ""
+}
+
+// Send delivers the message to every configured destination
+// All destinations are attempted; their errors are joined
+func Send(cfg config.NotifyConfig, m Message) error {
+	var errs []error
+	if cfg.SlackWebhookURL != "" {
+		if err := sendSlack(cfg.SlackWebhookURL, m); err != nil {
+			errs = append(errs, fmt.Errorf("slack: %w", err))
+		}
+	}
+	if cfg.SMTP.Host != "" {
+		if err := sendMail(cfg.SMTP, m); err != nil {
+			errs = append(errs, fmt.Errorf("smtp: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendSlack posts the message to a Slack incoming webhook
+func sendSlack(url string, m Message) error {
+	icon := ":white_check_mark:"
+	if !m.Success() {
+		icon = ":x:"
+	}
+	payload, err := json.Marshal(map[string]string{
+		"text": icon + " " + m.Body(),
+	})
+	if err != nil {
+		return err
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// sendMail sends the message as a plain text email
+func sendMail(cfg config.SMTPConfig, m Message) error {
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return fmt.Errorf("notify.smtp.from and notify.smtp.to are required")
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", m.Subject())
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(m.Body(), "\n", "\r\n"))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, cfg.From, cfg.To, []byte(msg.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out connecting to %s", addr)
+	}
+}
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// defaultLocale is used when no locale is configured or detected
+const defaultLocale = "en-US"
+
+// Locale returns the language tag from the options or the environment
+// Machine output always uses the default locale
+func Locale() language.Tag {
+	if options.Machine {
+		return language.MustParse(defaultLocale)
+	}
+	candidates := []string{options.Locale, os.Getenv("LC_ALL"), os.Getenv("LC_MONETARY"), os.Getenv("LANG")}
+	for _, c := range candidates {
+		// Strip encoding and modifiers, e.g. ja_JP.UTF-8
+		if i := strings.IndexAny(c, ".@"); i >= 0 {
+			c = c[:i]
+		}
+		c = strings.ReplaceAll(c, "_", "-")
+		if c == "" || c == "C" || c == "POSIX" {
+			continue
+		}
+		if tag, err := language.Parse(c); err == nil {
+			return tag
+		}
+	}
+	return language.MustParse(defaultLocale)
+}
+
+// FormatCurrency formats an amount in the smallest currency unit for display
+// For example 12345 jpy is shown as ¥12,345 and 1050 usd as $10.50
+func FormatCurrency(amount int64, code string) string {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return message.NewPrinter(Locale()).Sprintf("%d", amount)
+	}
+
+	scale, _ := currency.Standard.Rounding(unit)
+	value := float64(amount) / math.Pow10(scale)
+
+	printer := message.NewPrinter(Locale())
+	s := printer.Sprint(currency.Symbol(unit.Amount(value)))
+	// Drop the separator between the symbol and the number, but not the spaces that group digits
+	// in locales such as fr-FR; the number is the ISO format without the currency code
+	number := strings.TrimLeft(strings.TrimPrefix(printer.Sprint(currency.ISO(unit.Amount(value))), unit.String()), " \u00a0")
+	symbol, ok := strings.CutSuffix(s, number)
+	if !ok {
+		return s
+	}
+	return strings.TrimRight(symbol, " \u00a0") + number
+}
+
+// isAmountField checks if a field name indicates a monetary amount
+func isAmountField(fieldName string) bool {
+	name := strings.ToLower(fieldName)
+	return strings.Contains(name, "amount") || name == "net"
+}
+
+// tableAmount formats an amount field of a struct using its Currency field
+// It returns false when the field is not an amount or the struct has no currency
+func tableAmount(item reflect.Value, fieldName string) (string, bool) {
+	if item.Kind() != reflect.Struct || !isAmountField(fieldName) {
+		return "", false
+	}
+
+	cur := item.FieldByName("Currency")
+	if !cur.IsValid() || cur.Kind() != reflect.String || cur.String() == "" {
+		return "", false
+	}
+
+	v := indirect(item.FieldByName(fieldName))
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return FormatCurrency(v.Int(), cur.String()), true
+	}
+	return "", false
+}
@@ -0,0 +1,32 @@
+package output
+
+import "testing"
+
+func TestFormatCurrency(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+
+	tests := []struct {
+		locale string
+		amount int64
+		code   string
+		want   string
+	}{
+		{"ja-JP", 1234567, "jpy", "￥1,234,567"},
+		{"ja-JP", 1234567, "usd", "$12,345.67"},
+		{"en-US", 1234567, "jpy", "¥1,234,567"},
+		{"en-US", 1050, "usd", "$10.50"},
+		{"en-US", -500, "jpy", "¥-500"},
+		// Digits are grouped with no-break spaces
+		{"fr-FR", 1234567, "jpy", "JPY1\u00a0234\u00a0567"},
+		{"fr-FR", 1234567, "usd", "$US12\u00a0345,67"},
+		{"de-DE", 1234567, "jpy", "¥1.234.567"},
+		{"de-DE", 1234567, "usd", "$12.345,67"},
+		{"en-US", 1234567, "abc", "1,234,567"},
+	}
+	for _, tt := range tests {
+		options = Options{Locale: tt.locale}
+		if got := FormatCurrency(tt.amount, tt.code); got != tt.want {
+			t.Errorf("FormatCurrency(%d, %q) in %s = %q, want %q", tt.amount, tt.code, tt.locale, got, tt.want)
+		}
+	}
+}
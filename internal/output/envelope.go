@@ -0,0 +1,46 @@
+package output
+
+import "reflect"
+
+// envelope holds the results of a command in envelope mode, where JSON output is written once
+// when the command finishes, wrapped in a standard envelope
+var envelope struct {
+	enabled bool
+	results []interface{}
+}
+
+// SetEnvelope turns envelope mode on or off
+func SetEnvelope(enabled bool) {
+	envelope.enabled = enabled
+}
+
+// EnvelopeData returns the results kept in envelope mode: nil without results, the result of
+// a command that output once, or the results in order otherwise
+func EnvelopeData() interface{} {
+	switch len(envelope.results) {
+	case 0:
+		return nil
+	case 1:
+		return envelope.results[0]
+	default:
+		return envelope.results
+	}
+}
+
+// EnvelopeCount returns the number of items in the data of an envelope
+// A list counts its items, nil counts zero and any other result counts one
+func EnvelopeCount(data interface{}) int {
+	if data == nil {
+		return 0
+	}
+	v := indirect(reflect.ValueOf(data))
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		return v.Len()
+	}
+	return 1
+}
+
+// WriteEnvelope writes an envelope to stdout as JSON
+func WriteEnvelope(e interface{}) error {
+	return writeJSON(e)
+}
@@ -1,14 +1,20 @@
 package output
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/payjp/payjp-cli/internal/pager"
 	"gopkg.in/yaml.v3"
 )
 
@@ -16,12 +22,43 @@ import (
 type Format string
 
 const (
-	FormatJSON  Format = "json"
-	FormatTable Format = "table"
-	FormatYAML  Format = "yaml"
-	FormatQuiet Format = "quiet"
+	FormatJSON   Format = "json"
+	FormatTable  Format = "table"
+	FormatYAML   Format = "yaml"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatQuiet  Format = "quiet"
 )
 
+// Options represents output settings shared by all formatters
+type Options struct {
+	// Machine keeps output stable for scripts: timestamps are always RFC 3339 in UTC and the
+	// locale and time format settings are ignored
+	Machine    bool
+	Color      bool
+	SortKeys   bool
+	Theme      Theme
+	ExpandMaps bool
+	Locale     string
+	TimeFormat string
+	NoHeaders  bool
+	Delimiter  string
+	// Pager shows tables longer than the terminal one screen at a time when stdout is a terminal
+	Pager bool
+}
+
+// plain reports whether table output should be written as plain delimited rows
+func (o Options) plain() bool {
+	return o.NoHeaders || o.Delimiter != ""
+}
+
+var options Options
+
+// SetOptions sets the output settings
+func SetOptions(o Options) {
+	options = o
+}
+
 // Formatter is the interface for output formatters
 type Formatter interface {
 	Format(data interface{}) error
@@ -34,6 +71,10 @@ func NewFormatter(format Format) Formatter {
 		return &JSONFormatter{}
 	case FormatYAML:
 		return &YAMLFormatter{}
+	case FormatCSV:
+		return &CSVFormatter{}
+	case FormatNDJSON:
+		return &NDJSONFormatter{}
 	case FormatQuiet:
 		return &QuietFormatter{}
 	default:
@@ -57,10 +98,53 @@ func OutputQuiet(data interface{}) error {
 type JSONFormatter struct{}
 
 // Format formats the data as JSON
+// Output is highlighted when color is enabled and stdout is a terminal
+// In envelope mode the data is kept for the envelope instead
 func (f *JSONFormatter) Format(data interface{}) error {
-	encoder := json.NewEncoder(os.Stdout)
+	if envelope.enabled {
+		envelope.results = append(envelope.results, data)
+		return nil
+	}
+	return writeJSON(data)
+}
+
+// writeJSON writes the data as indented JSON to stdout
+func writeJSON(data interface{}) error {
+	if options.SortKeys {
+		sorted, err := toGeneric(data)
+		if err != nil {
+			return err
+		}
+		data = sorted
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
+
+	if useColor(os.Stdout) {
+		return highlightJSON(os.Stdout, buf.Bytes())
+	}
+
+	_, err := os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// toGeneric converts data to maps and slices through its JSON representation
+// Maps are encoded with sorted keys, so this also sorts struct fields
+func toGeneric(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
 }
 
 // YAMLFormatter formats output as YAML
@@ -74,6 +158,160 @@ func (f *YAMLFormatter) Format(data interface{}) error {
 	return encoder.Encode(data)
 }
 
+// NDJSONFormatter formats output as newline delimited JSON
+type NDJSONFormatter struct{}
+
+// Format writes each item of a slice as one JSON line, or a single item as one line
+func (f *NDJSONFormatter) Format(data interface{}) error {
+	v := indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Slice {
+		return json.NewEncoder(os.Stdout).Encode(data)
+	}
+	return (&ndjsonStreamWriter{encoder: json.NewEncoder(os.Stdout)}).WritePage(data)
+}
+
+// CSVFormatter formats output as CSV
+type CSVFormatter struct{}
+
+// Format formats the data as CSV with a header row
+func (f *CSVFormatter) Format(data interface{}) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	// Treat a single item as a one-row table
+	if v.Kind() != reflect.Slice {
+		v = reflect.Append(reflect.MakeSlice(reflect.SliceOf(v.Type()), 0, 1), v)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	if v.Len() == 0 {
+		w.Flush()
+		return w.Error()
+	}
+
+	headers, keys := getCSVHeaders(indirect(v.Index(0)))
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		item := indirect(v.Index(i))
+		row := make([]string, len(keys))
+		for j, key := range keys {
+			row[j] = csvValue(lookupField(item, key), key)
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// indirect dereferences pointers and interfaces
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// rowFields returns the fields of a struct with the fields of embedded structs in place of them,
+// so that a row type that embeds an API object and adds derived fields lists them all
+func rowFields(t reflect.Type) []reflect.StructField {
+	fields := []reflect.StructField{}
+	for _, field := range reflect.VisibleFields(t) {
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && embedded.Kind() == reflect.Struct {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// getCSVHeaders returns headers and lookup keys for all exported fields or map keys
+func getCSVHeaders(v reflect.Value) ([]string, []string) {
+	headers := []string{}
+	keys := []string{}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, field := range rowFields(v.Type()) {
+			if !field.IsExported() || strings.HasPrefix(field.Name, "Raw") {
+				continue
+			}
+			headers = append(headers, getFieldName(field))
+			keys = append(keys, field.Name)
+		}
+	case reflect.Map:
+		mapKeys := v.MapKeys()
+		for _, k := range mapKeys {
+			keys = append(keys, fmt.Sprintf("%v", k.Interface()))
+		}
+		sort.Strings(keys)
+		headers = append(headers, keys...)
+	}
+
+	return headers, keys
+}
+
+// lookupField returns a struct field or map value by key
+func lookupField(v reflect.Value, key string) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		return v.FieldByName(key)
+	case reflect.Map:
+		return v.MapIndex(reflect.ValueOf(key))
+	}
+	return reflect.Value{}
+}
+
+// csvValue formats a value for CSV output without truncation
+func csvValue(v reflect.Value, fieldName string) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Map, reflect.Slice:
+		if v.Len() == 0 {
+			return ""
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			if t.Unix() <= 0 {
+				return ""
+			}
+			return formatTime(t, time.RFC3339)
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	default:
+		return formatFieldValueWithName(v, fieldName)
+	}
+}
+
 // QuietFormatter outputs only the ID
 type QuietFormatter struct{}
 
@@ -130,40 +368,62 @@ func (f *TableFormatter) Format(data interface{}) error {
 // formatSlice formats a slice of items as a table
 func (f *TableFormatter) formatSlice(v reflect.Value) error {
 	if v.Len() == 0 {
-		fmt.Println("No items found.")
+		if !options.plain() {
+			fmt.Println("No items found.")
+		}
 		return nil
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetBorder(true)
-	table.SetRowLine(false)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-
 	// Get headers from first element
-	first := v.Index(0)
-	if first.Kind() == reflect.Ptr {
-		first = first.Elem()
-	}
+	// Buffered pages hold their items as interface values, so those are unwrapped as well
+	first := indirect(v.Index(0))
 
 	headers, keys := getTableHeaders(first)
-	table.SetHeader(headers)
 
 	// Add rows
+	rows := make([][]string, 0, v.Len())
 	for i := 0; i < v.Len(); i++ {
-		item := v.Index(i)
-		if item.Kind() == reflect.Ptr {
-			item = item.Elem()
-		}
-		row := getTableRow(item, keys)
-		table.Append(row)
+		rows = append(rows, getTableRow(v.Index(i), keys))
+	}
+
+	if options.plain() {
+		return writePlain(headers, rows)
 	}
 
+	var buf bytes.Buffer
+	table := newTable(&buf)
+	table.SetHeader(headers)
+	table.AppendBulk(rows)
 	table.Render()
+
+	var err error
+	if options.Pager && isTerminal(os.Stdout) {
+		err = pageTable(buf.String())
+	} else {
+		_, err = os.Stdout.Write(buf.Bytes())
+	}
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Total: %d items\n", v.Len())
 	return nil
 }
 
+// pageTable shows a rendered table through the pager, keeping the header and the closing border
+// on every screen
+func pageTable(table string) error {
+	lines := strings.Split(strings.TrimSuffix(table, "\n"), "\n")
+	// The header ends at the border line that follows the column names
+	headerEnd := 1
+	for headerEnd < len(lines) && !strings.HasPrefix(lines[headerEnd], "+") {
+		headerEnd++
+	}
+	if headerEnd+1 >= len(lines) {
+		return pager.Page(os.Stdout, lines, nil, nil)
+	}
+	return pager.Page(os.Stdout, lines[:headerEnd+1], lines[headerEnd+1:len(lines)-1], lines[len(lines)-1:])
+}
+
 // formatSingle formats a single item as a table
 func (f *TableFormatter) formatSingle(v reflect.Value) error {
 	if v.Kind() == reflect.Ptr {
@@ -174,33 +434,91 @@ func (f *TableFormatter) formatSingle(v reflect.Value) error {
 		return fmt.Errorf("expected struct, got %v", v.Kind())
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetBorder(true)
-	table.SetRowLine(false)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetHeader([]string{"FIELD", "VALUE"})
-
-	t := v.Type()
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		value := v.Field(i)
+	headers := []string{"FIELD", "VALUE"}
+	rows := [][]string{}
 
-		// Skip unexported fields
+	for _, field := range rowFields(v.Type()) {
+		// Skip unexported fields and fields of a nil embedded struct
 		if !field.IsExported() {
 			continue
 		}
+		value, err := v.FieldByIndexErr(field.Index)
+		if err != nil {
+			continue
+		}
 
 		fieldName := getFieldName(field)
-		fieldValue := formatFieldValueWithName(value, field.Name)
+		fieldValue, ok := tableAmount(v, field.Name)
+		if ok {
+			fieldValue = paintCell(RoleAmount, fieldValue)
+		} else if field.Name == "ID" {
+			fieldValue = paintCell(RoleID, formatFieldValueWithName(value, field.Name))
+		} else {
+			fieldValue = formatFieldValueWithName(value, field.Name)
+		}
+
+		rows = append(rows, []string{fieldName, fieldValue})
+	}
 
-		table.Append([]string{fieldName, fieldValue})
+	if options.plain() {
+		return writePlain(headers, rows)
 	}
 
+	table := newTable(os.Stdout)
+	table.SetHeader(headers)
+	table.AppendBulk(rows)
 	table.Render()
 	return nil
 }
 
+// paintCell colors a table cell; plain rows are never colored so that they stay easy to parse
+func paintCell(role Role, s string) string {
+	if options.plain() {
+		return s
+	}
+	return Colorize(os.Stdout, role, s)
+}
+
+// newTable creates a bordered table writer
+func newTable(w io.Writer) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetBorder(true)
+	table.SetRowLine(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	return table
+}
+
+// writePlain writes rows separated by the delimiter without borders or padding
+// The header row is omitted with --no-headers
+func writePlain(headers []string, rows [][]string) error {
+	delimiter := options.Delimiter
+	if delimiter == "" {
+		delimiter = "\t"
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if !options.NoHeaders {
+		fmt.Fprintln(w, strings.Join(headers, delimiter))
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			// Keep one record per line
+			cells[i] = strings.NewReplacer("\r\n", " ", "\n", " ").Replace(cell)
+		}
+		fmt.Fprintln(w, strings.Join(cells, delimiter))
+	}
+	return w.Flush()
+}
+
+// Columns is implemented by row types that choose the fields shown in list view,
+// such as report rows whose fields are not those of an API object
+type Columns interface {
+	// TableColumns returns the names of the struct fields to show, in order
+	TableColumns() []string
+}
+
 // getTableHeaders returns headers for a table
 func getTableHeaders(v reflect.Value) ([]string, []string) {
 	if v.Kind() != reflect.Struct {
@@ -208,11 +526,22 @@ func getTableHeaders(v reflect.Value) ([]string, []string) {
 	}
 
 	t := v.Type()
+	fields := rowFields(t)
 	headers := []string{}
 	keys := []string{}
 
+	if columns, ok := reflect.Zero(t).Interface().(Columns); ok {
+		for _, name := range columns.TableColumns() {
+			if field, ok := t.FieldByName(name); ok && field.IsExported() {
+				headers = append(headers, strings.ToUpper(getFieldName(field)))
+				keys = append(keys, name)
+			}
+		}
+		return headers, keys
+	}
+
 	// Common fields to display in list view
-	commonFields := []string{"ID", "Amount", "Currency", "Status", "Paid", "Captured", "Refunded", "Email", "Description", "Name", "Interval", "CreatedAt", "Created"}
+	commonFields := []string{"ID", "Amount", "Net", "Currency", "Status", "Paid", "Captured", "Refunded", "Email", "Description", "Name", "Interval", "CreatedAt", "Created"}
 
 	for _, fieldName := range commonFields {
 		field, ok := t.FieldByName(fieldName)
@@ -222,10 +551,18 @@ func getTableHeaders(v reflect.Value) ([]string, []string) {
 		}
 	}
 
+	// Expanded maps make metadata readable in list view
+	if options.ExpandMaps && len(headers) > 0 {
+		if field, ok := t.FieldByName("Metadata"); ok && field.IsExported() {
+			headers = append(headers, strings.ToUpper(getFieldName(field)))
+			keys = append(keys, "Metadata")
+		}
+	}
+
 	// If no common fields found, use first few fields
 	if len(headers) == 0 {
-		for i := 0; i < t.NumField() && i < 6; i++ {
-			field := t.Field(i)
+		for i := 0; i < len(fields) && i < 6; i++ {
+			field := fields[i]
 			if field.IsExported() {
 				headers = append(headers, strings.ToUpper(getFieldName(field)))
 				keys = append(keys, field.Name)
@@ -238,11 +575,16 @@ func getTableHeaders(v reflect.Value) ([]string, []string) {
 
 // getTableRow returns a row for a table
 func getTableRow(v reflect.Value, keys []string) []string {
+	v = indirect(v)
 	row := []string{}
 
 	for _, key := range keys {
 		field := v.FieldByName(key)
-		if field.IsValid() {
+		if amount, ok := tableAmount(v, key); ok {
+			row = append(row, paintCell(RoleAmount, amount))
+		} else if key == "ID" && field.IsValid() {
+			row = append(row, paintCell(RoleID, formatFieldValueWithName(field, key)))
+		} else if field.IsValid() {
 			row = append(row, formatFieldValueWithName(field, key))
 		} else {
 			row = append(row, "")
@@ -298,8 +640,7 @@ func formatFieldValueWithName(v reflect.Value, fieldName string) string {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		// Check if field name indicates it's a timestamp
 		if isTimestampField(fieldName) && v.Int() > 0 {
-			t := time.Unix(v.Int(), 0)
-			return t.Format("2006-01-02 15:04:05")
+			return formatTime(time.Unix(v.Int(), 0), defaultTimeLayout)
 		}
 		return fmt.Sprintf("%d", v.Int())
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -315,19 +656,41 @@ func formatFieldValueWithName(v reflect.Value, fieldName string) string {
 	case reflect.Struct:
 		// Handle time.Time
 		if t, ok := v.Interface().(time.Time); ok {
-			return t.Format("2006-01-02 15:04:05")
+			return formatTime(t, defaultTimeLayout)
 		}
 		return "{...}"
 	case reflect.Map, reflect.Slice:
 		if v.Len() == 0 {
 			return ""
 		}
+		if v.Kind() == reflect.Map && options.ExpandMaps {
+			return formatMapLines(v)
+		}
 		return fmt.Sprintf("[%d items]", v.Len())
 	default:
 		return fmt.Sprintf("%v", v.Interface())
 	}
 }
 
+// formatMapLines formats a map as key=value lines sorted by key
+func formatMapLines(v reflect.Value) string {
+	values := make(map[string]string, v.Len())
+	keys := make([]string, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key := fmt.Sprintf("%v", iter.Key().Interface())
+		values[key] = fmt.Sprintf("%v", iter.Value().Interface())
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + values[key]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // toSnakeCase converts a CamelCase string to snake_case
 func toSnakeCase(s string) string {
 	var result strings.Builder
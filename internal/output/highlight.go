@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// colorReset resets all ANSI attributes
+const colorReset = "\x1b[0m"
+
+// useColor reports whether ANSI colors may be written to f
+// All colored output must go through here: colors need Options.Color (output.color
+// without --no-color), no NO_COLOR environment variable, a terminal other than
+// TERM=dumb, and f being a terminal, so that pipes and captures never get escape codes
+func useColor(f *os.File) bool {
+	if !options.Color || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether the file is a character device (a TTY)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// highlightJSON writes JSON with ANSI syntax highlighting in the colors of the theme
+// The input must be valid JSON; whitespace is copied as-is
+func highlightJSON(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	theme := options.Theme
+	paint := func(color string, token []byte) {
+		if color == "" {
+			buf.Write(token)
+			return
+		}
+		buf.WriteString(sgr(color))
+		buf.Write(token)
+		buf.WriteString(colorReset)
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := scanString(data, i)
+			color := theme.String
+			if isKey(data, end) {
+				color = theme.Key
+			}
+			paint(color, data[i:end])
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(data) && bytes.IndexByte([]byte("0123456789.eE+-"), data[end]) >= 0 {
+				end++
+			}
+			paint(theme.Number, data[i:end])
+			i = end
+		case c == 't' || c == 'f' || c == 'n':
+			end := i + 1
+			for end < len(data) && data[end] >= 'a' && data[end] <= 'z' {
+				end++
+			}
+			paint(theme.Literal, data[i:end])
+			i = end
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// scanString returns the index just past the closing quote of the string starting at start
+func scanString(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// isKey reports whether the next non-space character after pos is a colon
+func isKey(data []byte, pos int) bool {
+	for i := pos; i < len(data); i++ {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// progressWidth is the width of the progress bar in characters
+const progressWidth = 30
+
+// Progress draws a single-line progress bar on stderr
+// Nothing is drawn when disabled or when stderr is not a terminal
+type Progress struct {
+	mu      sync.Mutex
+	label   string
+	total   int
+	done    int
+	enabled bool
+}
+
+// NewProgress creates a progress bar for total items
+func NewProgress(label string, total int, enabled bool) *Progress {
+	p := &Progress{label: label, total: total, enabled: enabled && total > 0 && isTerminal(os.Stderr)}
+	p.draw()
+	return p
+}
+
+// Increment marks one more item as done and redraws the bar
+func (p *Progress) Increment() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.draw()
+}
+
+// Finish ends the progress line
+func (p *Progress) Finish() {
+	if p.enabled {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// draw redraws the bar; the caller must hold the lock except during construction
+func (p *Progress) draw() {
+	if !p.enabled {
+		return
+	}
+	filled := progressWidth * p.done / p.total
+	bar := strings.Repeat("#", filled) + strings.Repeat(".", progressWidth-filled)
+	fmt.Fprintf(os.Stderr, "\r%s [%s] %d/%d", p.label, bar, p.done, p.total)
+}
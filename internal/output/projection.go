@@ -0,0 +1,112 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldSet represents a set of fields to keep, with optional nested selections
+// A nil value means the whole field is kept
+type FieldSet map[string]FieldSet
+
+// ParseFields parses a field projection specification
+// Format: id,amount,card{brand,last4}
+func ParseFields(spec string) (FieldSet, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	fields, rest, err := parseFieldList(spec)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid fields: unexpected '%s'", rest)
+	}
+
+	return fields, nil
+}
+
+// parseFieldList parses a comma separated list of fields until a closing brace or end of input
+func parseFieldList(s string) (FieldSet, string, error) {
+	fields := FieldSet{}
+
+	for {
+		s = strings.TrimSpace(s)
+
+		// Read field name
+		end := strings.IndexAny(s, ",{}")
+		if end < 0 {
+			end = len(s)
+		}
+		name := strings.TrimSpace(s[:end])
+		if name == "" {
+			return nil, "", fmt.Errorf("invalid fields: empty field name")
+		}
+		s = strings.TrimSpace(s[end:])
+
+		// Read nested selection
+		var nested FieldSet
+		if strings.HasPrefix(s, "{") {
+			var err error
+			nested, s, err = parseFieldList(s[1:])
+			if err != nil {
+				return nil, "", err
+			}
+			if !strings.HasPrefix(s, "}") {
+				return nil, "", fmt.Errorf("invalid fields: missing '}' after '%s'", name)
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+		fields[name] = nested
+
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+			continue
+		}
+		return fields, s, nil
+	}
+}
+
+// Project returns the data pruned down to the given fields
+// The data is converted to generic maps and slices through its JSON representation
+func Project(data interface{}, fields FieldSet) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	generic, err := toGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return prune(generic, fields), nil
+}
+
+// prune removes all keys not in fields from maps, recursing into slices and nested selections
+func prune(v interface{}, fields FieldSet) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(fields))
+		for name, nested := range fields {
+			child, ok := val[name]
+			if !ok {
+				continue
+			}
+			if nested != nil {
+				child = prune(child, nested)
+			}
+			result[name] = child
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = prune(item, fields)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+)
+
+// StreamWriter writes list results page by page
+// Streaming formats (csv, ndjson) flush each page immediately; other formats buffer until Close
+type StreamWriter interface {
+	WritePage(page interface{}) error
+	Close() error
+}
+
+// NewStreamWriter creates a stream writer for the given format
+func NewStreamWriter(format Format) StreamWriter {
+	switch format {
+	case FormatCSV:
+		return &csvStreamWriter{w: csv.NewWriter(os.Stdout)}
+	case FormatNDJSON:
+		return &ndjsonStreamWriter{encoder: json.NewEncoder(os.Stdout)}
+	default:
+		return &bufferedStreamWriter{format: format}
+	}
+}
+
+// NewCSVWriter creates a stream writer that writes CSV rows to w
+// Unlike the stdout writer, the header is also written for an empty first page of structs, so
+// that a file without rows can still be imported
+func NewCSVWriter(w io.Writer) StreamWriter {
+	return &csvStreamWriter{w: csv.NewWriter(w), emptyHeader: true}
+}
+
+// NewNDJSONWriter creates a stream writer that writes one JSON document per line to w
+func NewNDJSONWriter(w io.Writer) StreamWriter {
+	return &ndjsonStreamWriter{encoder: json.NewEncoder(w)}
+}
+
+// csvStreamWriter writes CSV rows per page, emitting the header once
+type csvStreamWriter struct {
+	w           *csv.Writer
+	keys        []string
+	emptyHeader bool
+}
+
+// WritePage writes the page rows and flushes them
+func (s *csvStreamWriter) WritePage(page interface{}) error {
+	v := indirect(reflect.ValueOf(page))
+	if v.Len() == 0 && s.keys == nil && s.emptyHeader && v.Type().Elem().Kind() == reflect.Struct {
+		var headers []string
+		headers, s.keys = getCSVHeaders(reflect.Zero(v.Type().Elem()))
+		if err := s.w.Write(headers); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < v.Len(); i++ {
+		item := indirect(v.Index(i))
+		if s.keys == nil {
+			var headers []string
+			headers, s.keys = getCSVHeaders(item)
+			if err := s.w.Write(headers); err != nil {
+				return err
+			}
+		}
+		row := make([]string, len(s.keys))
+		for j, key := range s.keys {
+			row[j] = csvValue(lookupField(item, key), key)
+		}
+		if err := s.w.Write(row); err != nil {
+			return err
+		}
+	}
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// Close flushes remaining output
+func (s *csvStreamWriter) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// ndjsonStreamWriter writes one JSON document per line
+type ndjsonStreamWriter struct {
+	encoder *json.Encoder
+}
+
+// WritePage writes each item of the page as a line
+func (s *ndjsonStreamWriter) WritePage(page interface{}) error {
+	v := indirect(reflect.ValueOf(page))
+	for i := 0; i < v.Len(); i++ {
+		if err := s.encoder.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close is a no-op since each line is written immediately
+func (s *ndjsonStreamWriter) Close() error {
+	return nil
+}
+
+// bufferedStreamWriter accumulates pages and formats them on Close
+type bufferedStreamWriter struct {
+	format Format
+	items  []interface{}
+}
+
+// WritePage buffers the page items
+func (s *bufferedStreamWriter) WritePage(page interface{}) error {
+	v := indirect(reflect.ValueOf(page))
+	for i := 0; i < v.Len(); i++ {
+		s.items = append(s.items, v.Index(i).Interface())
+	}
+	return nil
+}
+
+// Close formats all buffered items
+func (s *bufferedStreamWriter) Close() error {
+	items := s.items
+	if items == nil {
+		items = []interface{}{}
+	}
+	return NewFormatter(s.format).Format(items)
+}
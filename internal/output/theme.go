@@ -0,0 +1,172 @@
+package output
+
+import (
+	"os"
+	"strings"
+)
+
+// Role is a kind of text that is colored by the theme
+type Role int
+
+// Roles that can be colored through the theme section of the config file
+const (
+	RoleSuccess Role = iota
+	RoleWarn
+	RoleError
+	RoleID
+	RoleAmount
+)
+
+// Theme holds the ANSI SGR parameters (e.g. "34;1") used for each kind of text
+// An empty value leaves the text uncolored
+type Theme struct {
+	Success string
+	Warn    string
+	Error   string
+	ID      string
+	Amount  string
+
+	// JSON syntax highlighting
+	Key     string
+	String  string
+	Number  string
+	Literal string
+}
+
+// themes are the built-in themes selectable with --theme or theme.name
+var themes = map[string]Theme{
+	"dark": {
+		Success: "32",
+		Warn:    "33",
+		Error:   "31;1",
+		ID:      "36",
+		Amount:  "1",
+		Key:     "34;1",
+		String:  "32",
+		Number:  "33",
+		Literal: "35",
+	},
+	// Yellow and cyan are hard to read on a light background
+	"light": {
+		Success: "32",
+		Warn:    "35",
+		Error:   "31;1",
+		ID:      "34",
+		Amount:  "1",
+		Key:     "34;1",
+		String:  "32",
+		Number:  "35",
+		Literal: "31",
+	},
+	"none": {},
+}
+
+// ThemeNames lists the built-in themes
+var ThemeNames = []string{"dark", "light", "none"}
+
+// LookupTheme returns a built-in theme; an empty name selects the dark theme
+func LookupTheme(name string) (Theme, bool) {
+	if name == "" {
+		name = "dark"
+	}
+	theme, ok := themes[strings.ToLower(name)]
+	return theme, ok
+}
+
+// With returns a copy of the theme with the color of a role replaced
+func (t Theme) With(role Role, color string) Theme {
+	switch role {
+	case RoleSuccess:
+		t.Success = color
+	case RoleWarn:
+		t.Warn = color
+	case RoleError:
+		t.Error = color
+	case RoleID:
+		t.ID = color
+	case RoleAmount:
+		t.Amount = color
+	}
+	return t
+}
+
+// color returns the SGR parameters of a role
+func (t Theme) color(role Role) string {
+	switch role {
+	case RoleSuccess:
+		return t.Success
+	case RoleWarn:
+		return t.Warn
+	case RoleError:
+		return t.Error
+	case RoleID:
+		return t.ID
+	case RoleAmount:
+		return t.Amount
+	}
+	return ""
+}
+
+// colorNames maps color names to SGR parameters
+var colorNames = map[string]string{
+	"black":          "30",
+	"red":            "31",
+	"green":          "32",
+	"yellow":         "33",
+	"blue":           "34",
+	"magenta":        "35",
+	"cyan":           "36",
+	"white":          "37",
+	"bright-black":   "90",
+	"gray":           "90",
+	"bright-red":     "91",
+	"bright-green":   "92",
+	"bright-yellow":  "93",
+	"bright-blue":    "94",
+	"bright-magenta": "95",
+	"bright-cyan":    "96",
+	"bright-white":   "97",
+	"bold":           "1",
+	"dim":            "2",
+	"italic":         "3",
+	"underline":      "4",
+}
+
+// ParseColor converts a color such as "red", "bold blue" or raw SGR parameters ("38;5;208")
+// into SGR parameters; "none" disables the color
+func ParseColor(spec string) (string, bool) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "none" {
+		return "", true
+	}
+
+	params := []string{}
+	for _, word := range strings.Fields(spec) {
+		if code, ok := colorNames[word]; ok {
+			params = append(params, code)
+			continue
+		}
+		if strings.Trim(word, "0123456789;") != "" || strings.Trim(word, ";") == "" {
+			return "", false
+		}
+		params = append(params, strings.Trim(word, ";"))
+	}
+	if len(params) == 0 {
+		return "", false
+	}
+	return strings.Join(params, ";"), true
+}
+
+// Colorize wraps s in the theme color of the role when color output to f is enabled
+func Colorize(f *os.File, role Role, s string) string {
+	color := options.Theme.color(role)
+	if color == "" || s == "" || !useColor(f) {
+		return s
+	}
+	return sgr(color) + s + colorReset
+}
+
+// sgr returns the escape sequence that sets the SGR parameters
+func sgr(params string) string {
+	return "\x1b[" + params + "m"
+}
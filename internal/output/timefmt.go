@@ -0,0 +1,85 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Time formats accepted by --time-format and output.time_format
+// Any other value is used as a Go time layout
+const (
+	TimeFormatUnix     = "unix"
+	TimeFormatRFC3339  = "rfc3339"
+	TimeFormatRelative = "relative"
+)
+
+// defaultTimeLayout is used in table output when no time format is configured
+const defaultTimeLayout = "2006-01-02 15:04:05"
+
+// ValidateTimeFormat checks that a time format is a known name or a Go time layout
+func ValidateTimeFormat(format string) error {
+	switch format {
+	case "", TimeFormatUnix, TimeFormatRFC3339, TimeFormatRelative:
+		return nil
+	}
+	// A layout without any reference time elements formats to itself
+	if time.Unix(0, 0).UTC().Format(format) == format {
+		return fmt.Errorf("invalid time format: %s (use unix, rfc3339, relative, or a Go layout such as 2006-01-02T15:04:05Z07:00)", format)
+	}
+	return nil
+}
+
+// formatTime formats a timestamp using the configured time format
+// fallback is the layout used when no time format is configured
+func formatTime(t time.Time, fallback string) string {
+	if options.Machine {
+		return t.UTC().Format(time.RFC3339)
+	}
+	switch options.TimeFormat {
+	case "":
+		return t.Format(fallback)
+	case TimeFormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeFormatRFC3339:
+		return t.Format(time.RFC3339)
+	case TimeFormatRelative:
+		return relativeTime(t, time.Now())
+	default:
+		return t.Format(options.TimeFormat)
+	}
+}
+
+// relativeTime describes t relative to now, e.g. "3 days ago" or "in 2 hours"
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int(d/(365*24*time.Hour)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ValuePath is a parsed dot path such as .card.last4 or .refunds[0].amount
+// Each element is either a map key (string) or a slice index (int)
+type ValuePath []interface{}
+
+// ParseValuePath parses a dot path; "." selects the whole value
+func ParseValuePath(s string) (ValuePath, error) {
+	if !strings.HasPrefix(s, ".") {
+		return nil, fmt.Errorf("invalid path '%s': must start with '.'", s)
+	}
+
+	path := ValuePath{}
+	rest := s
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				if rest == "" && len(path) == 0 {
+					return path, nil
+				}
+				return nil, fmt.Errorf("invalid path '%s': empty field name", s)
+			}
+			path = append(path, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': missing ']'", s)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path '%s': index must be a non-negative integer", s)
+			}
+			path = append(path, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path '%s': unexpected '%s'", s, rest)
+		}
+	}
+	return path, nil
+}
+
+// String returns the path in its dot notation
+func (p ValuePath) String() string {
+	if len(p) == 0 {
+		return "."
+	}
+	var b strings.Builder
+	for _, element := range p {
+		if index, ok := element.(int); ok {
+			fmt.Fprintf(&b, "[%d]", index)
+		} else {
+			b.WriteString("." + element.(string))
+		}
+	}
+	return b.String()
+}
+
+// Extract returns the value at the path
+// The data is converted to generic maps and slices through its JSON representation
+func (p ValuePath) Extract(data interface{}) (interface{}, error) {
+	value, err := toGeneric(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, element := range p {
+		switch key := element.(type) {
+		case string:
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", p[:i])
+			}
+			if value, ok = m[key]; !ok {
+				return nil, fmt.Errorf("%s not found", p[:i+1])
+			}
+		case int:
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an array", p[:i])
+			}
+			if key >= len(list) {
+				return nil, fmt.Errorf("%s is out of range (length %d)", p[:i+1], len(list))
+			}
+			value = list[key]
+		}
+	}
+	return value, nil
+}
+
+// OutputValue prints a single value for use in scripts
+// Strings are printed without quotes, objects and arrays as indented JSON
+func OutputValue(value interface{}) error {
+	if s, ok := value.(string); ok {
+		_, err := fmt.Fprintln(os.Stdout, s)
+		return err
+	}
+
+	var b []byte
+	var err error
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err = json.MarshalIndent(value, "", "  ")
+	default:
+		b, err = json.Marshal(value)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(b))
+	return err
+}
@@ -0,0 +1,261 @@
+// Package pager shows long table output one screen at a time on interactive terminals
+package pager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/payjp/payjp-cli/internal/tty"
+)
+
+// pager is the state of the pager
+type pager struct {
+	header    []string
+	body      []string
+	footer    []string
+	offset    int
+	searching bool
+	query     []rune
+	last      string
+	message   string
+	quit      bool
+}
+
+// Page writes the header, body and footer lines to w, or shows them one screen at a time
+// on the controlling terminal when they do not fit in it
+// The header and footer (e.g. the column names and the closing border of a table) stay on
+// every screen. n/Space and p/b page forward and back, Up/Down scroll by a line, g/G jump
+// to the start and end, / searches forward and q, Esc or Ctrl-C quits. Nothing is written
+// to w once the pager has been shown, so the scrollback is left untouched.
+// Without a terminal, or when everything fits, the lines are written to w as they are
+func Page(w io.Writer, header, body, footer []string) error {
+	term, err := tty.Open()
+	if err != nil {
+		return writeLines(w, header, body, footer)
+	}
+	defer term.Close()
+
+	_, height := term.Size()
+	if len(header)+len(body)+len(footer) < height {
+		return writeLines(w, header, body, footer)
+	}
+
+	// Use the alternate screen and turn off line wrapping so that wide tables are clipped
+	fmt.Fprint(term, "\x1b[?1049h\x1b[?7l")
+	defer fmt.Fprint(term, "\x1b[?7h\x1b[?1049l")
+
+	p := &pager{header: header, body: body, footer: footer}
+	buf := make([]byte, 256)
+	for {
+		width, height := term.Size()
+		term.Write(p.render(width, height))
+
+		n, err := term.Read(buf)
+		if err != nil {
+			return err
+		}
+		p.handleInput(buf[:n], p.pageHeight(height))
+		if p.quit {
+			return nil
+		}
+	}
+}
+
+// writeLines writes every line to w
+func writeLines(w io.Writer, groups ...[]string) error {
+	for _, lines := range groups {
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pageHeight returns the number of body lines shown on a screen of height lines
+func (p *pager) pageHeight(height int) int {
+	header, footer := p.frame(height)
+	return max(height-len(header)-len(footer)-1, 1)
+}
+
+// frame returns the header and footer lines that fit on a screen of height lines along with a
+// body line and the status line; the footer is clipped first, then the header
+func (p *pager) frame(height int) ([]string, []string) {
+	room := max(height-2, 0)
+	header := p.header[:min(len(p.header), room)]
+	footer := p.footer[:min(len(p.footer), room-len(header))]
+	return header, footer
+}
+
+// scroll moves the first shown body line by delta, keeping the last screen full
+func (p *pager) scroll(delta, page int) {
+	p.offset = max(min(p.offset+delta, len(p.body)-page), 0)
+}
+
+// handleInput applies the keys in b
+func (p *pager) handleInput(b []byte, page int) {
+	for len(b) > 0 && !p.quit {
+		if p.searching {
+			b = p.handleSearchInput(b, page)
+			continue
+		}
+		p.message = ""
+		switch c := b[0]; c {
+		case 0x1b:
+			if len(b) == 1 {
+				p.quit = true
+				return
+			}
+			b = p.handleEscape(b, page)
+			continue
+		case 'q', 0x03: // Ctrl-C
+			p.quit = true
+		case 'n', ' ', 'f', 0x06: // Ctrl-F
+			p.scroll(page, page)
+		case 'p', 'b', 0x02: // Ctrl-B
+			p.scroll(-page, page)
+		case 'j', '\r', '\n', 0x0e: // Ctrl-N
+			p.scroll(1, page)
+		case 'k', 0x10: // Ctrl-P
+			p.scroll(-1, page)
+		case 'g':
+			p.offset = 0
+		case 'G':
+			p.scroll(len(p.body), page)
+		case '/':
+			p.searching = true
+			p.query = nil
+		}
+		b = b[1:]
+	}
+}
+
+// handleSearchInput applies the keys in b to the search prompt and returns the rest
+func (p *pager) handleSearchInput(b []byte, page int) []byte {
+	switch c := b[0]; {
+	case c == '\r' || c == '\n':
+		p.searching = false
+		if len(p.query) > 0 {
+			p.last = string(p.query)
+		}
+		p.search(p.last, page)
+	case c == 0x1b || c == 0x03 || c == 0x07: // Esc, Ctrl-C, Ctrl-G
+		p.searching = false
+		// Drop the rest of an escape sequence such as an arrow key
+		return nil
+	case c == 0x7f || c == 0x08: // Backspace
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+		}
+	case c >= 0x20:
+		r, size := utf8.DecodeRune(b)
+		if r != utf8.RuneError {
+			p.query = append(p.query, r)
+		}
+		return b[size:]
+	}
+	return b[1:]
+}
+
+// search shows the next body line after the first shown one that contains query, ignoring case
+// The search wraps around to the start
+func (p *pager) search(query string, page int) {
+	if query == "" {
+		return
+	}
+	needle := strings.ToLower(query)
+	for i := 1; i <= len(p.body); i++ {
+		line := (p.offset + i) % len(p.body)
+		if strings.Contains(strings.ToLower(stripEscapes(p.body[line])), needle) {
+			p.offset = line
+			p.scroll(0, page)
+			return
+		}
+	}
+	p.message = "Not found: " + query
+}
+
+// handleEscape applies an escape sequence at the start of b and returns the rest
+func (p *pager) handleEscape(b []byte, page int) []byte {
+	if b[1] != '[' && b[1] != 'O' {
+		return b[1:]
+	}
+	end := 2
+	for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+		end++
+	}
+	if end == len(b) {
+		return nil
+	}
+	switch string(b[2 : end+1]) {
+	case "A":
+		p.scroll(-1, page)
+	case "B":
+		p.scroll(1, page)
+	case "5~":
+		p.scroll(-page, page)
+	case "6~":
+		p.scroll(page, page)
+	case "H", "1~":
+		p.offset = 0
+	case "F", "4~":
+		p.scroll(len(p.body), page)
+	}
+	return b[end+1:]
+}
+
+// render draws the header, the shown body lines, the footer and the status line
+func (p *pager) render(width, height int) []byte {
+	page := p.pageHeight(height)
+	p.scroll(0, page)
+	header, footer := p.frame(height)
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[?25l\x1b[H")
+	for _, line := range header {
+		buf.WriteString(line + "\x1b[K\r\n")
+	}
+	for i := 0; i < page; i++ {
+		if line := p.offset + i; line < len(p.body) {
+			buf.WriteString(p.body[line])
+		}
+		buf.WriteString("\x1b[K\r\n")
+	}
+	for _, line := range footer {
+		buf.WriteString(line + "\x1b[K\r\n")
+	}
+
+	if p.searching {
+		fmt.Fprintf(&buf, "/%s\x1b[K\x1b[?25h", string(p.query))
+		return buf.Bytes()
+	}
+	status := p.message
+	if status == "" {
+		last := min(p.offset+page, len(p.body))
+		status = fmt.Sprintf("Lines %d-%d of %d  n/p: page  /: search  q: quit", p.offset+1, last, len(p.body))
+	}
+	if len(status) > width {
+		status = status[:width]
+	}
+	fmt.Fprintf(&buf, "\x1b[7m%s\x1b[0m\x1b[K", status)
+	return buf.Bytes()
+}
+
+// stripEscapes removes ANSI escape sequences such as cell colors
+func stripEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,237 @@
+package pager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newPager returns a pager of n body lines "line 0" to "line n-1"
+func newPager(n int) *pager {
+	p := &pager{header: []string{"+----+", "| ID |"}, footer: []string{"+----+"}}
+	for i := 0; i < n; i++ {
+		p.body = append(p.body, fmt.Sprintf("line %d", i))
+	}
+	return p
+}
+
+func TestHandleInputPaging(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		keys   string
+		want   int
+	}{
+		{"next page", 0, "n", 5},
+		{"next page with space", 0, " ", 5},
+		{"next page stops at the last screen", 10, "n", 15},
+		{"next page at the end", 15, "nn", 15},
+		{"previous page", 10, "p", 5},
+		{"previous page stops at the start", 3, "p", 0},
+		{"previous page at the start", 0, "pb", 0},
+		{"line down", 0, "jj", 2},
+		{"line up at the start", 0, "k", 0},
+		{"line down at the end", 15, "j", 15},
+		{"start", 12, "g", 0},
+		{"end", 0, "G", 15},
+		{"arrow down", 0, "\x1b[B\x1b[B", 2},
+		{"arrow up", 4, "\x1b[A", 3},
+		{"page down key", 0, "\x1b[6~", 5},
+		{"page up key", 7, "\x1b[5~", 2},
+		{"home", 9, "\x1b[H", 0},
+		{"end key", 0, "\x1b[F", 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPager(20)
+			p.offset = tt.offset
+			p.handleInput([]byte(tt.keys), 5)
+			if p.offset != tt.want {
+				t.Errorf("offset after %q = %d, want %d", tt.keys, p.offset, tt.want)
+			}
+			if p.quit {
+				t.Errorf("quit after %q", tt.keys)
+			}
+		})
+	}
+}
+
+func TestHandleInputShortBody(t *testing.T) {
+	// A body shorter than a page never scrolls
+	p := newPager(3)
+	p.handleInput([]byte("nGj"), 5)
+	if p.offset != 0 {
+		t.Errorf("offset = %d, want 0", p.offset)
+	}
+}
+
+func TestHandleInputQuit(t *testing.T) {
+	for _, keys := range []string{"q", "\x03", "\x1b", "nqn"} {
+		p := newPager(20)
+		p.handleInput([]byte(keys), 5)
+		if !p.quit {
+			t.Errorf("quit after %q = false, want true", keys)
+		}
+		if keys == "nqn" && p.offset != 5 {
+			t.Errorf("offset after %q = %d, want keys after q ignored", keys, p.offset)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	p := newPager(20)
+	p.body[3] = "\x1b[32mch_MATCH\x1b[0m"
+	p.body[12] = "ch_match"
+
+	p.handleInput([]byte("/match\r"), 5)
+	if p.offset != 3 {
+		t.Fatalf("offset after search = %d, want 3", p.offset)
+	}
+	if p.searching || p.last != "match" {
+		t.Errorf("searching = %v, last = %q after Enter", p.searching, p.last)
+	}
+
+	// An empty query repeats the last search
+	p.handleInput([]byte("/\r"), 5)
+	if p.offset != 12 {
+		t.Fatalf("offset after repeated search = %d, want 12", p.offset)
+	}
+
+	// The search wraps around to the start
+	p.handleInput([]byte("/\r"), 5)
+	if p.offset != 3 {
+		t.Errorf("offset after wrapping search = %d, want 3", p.offset)
+	}
+}
+
+func TestSearchKeepsLastScreenFull(t *testing.T) {
+	p := newPager(20)
+	p.body[18] = "match"
+	p.handleInput([]byte("/match\r"), 5)
+	if p.offset != 15 {
+		t.Errorf("offset = %d, want 15", p.offset)
+	}
+}
+
+func TestSearchNotFound(t *testing.T) {
+	p := newPager(20)
+	p.offset = 4
+	p.handleInput([]byte("/nothing\r"), 5)
+	if p.offset != 4 {
+		t.Errorf("offset = %d, want 4", p.offset)
+	}
+	if p.message != "Not found: nothing" {
+		t.Errorf("message = %q, want %q", p.message, "Not found: nothing")
+	}
+
+	// The next key clears the message
+	p.handleInput([]byte("j"), 5)
+	if p.message != "" {
+		t.Errorf("message after a key = %q, want empty", p.message)
+	}
+}
+
+func TestSearchPrompt(t *testing.T) {
+	p := newPager(20)
+	p.handleInput([]byte("/lini\x7fe 7"), 5)
+	if !p.searching || string(p.query) != "line 7" {
+		t.Fatalf("searching = %v, query = %q, want prompt with %q", p.searching, string(p.query), "line 7")
+	}
+
+	// Esc cancels the search, dropping the rest of the input
+	p.handleInput([]byte("\x1bn"), 5)
+	if p.searching || p.offset != 0 || p.last != "" {
+		t.Errorf("searching = %v, offset = %d, last = %q after Esc", p.searching, p.offset, p.last)
+	}
+}
+
+func TestPageHeight(t *testing.T) {
+	tests := []struct {
+		height int
+		want   int
+	}{
+		{24, 20},
+		{5, 1},
+		// The header and footer are clipped so that a body line is always shown
+		{4, 1},
+		{2, 1},
+		{1, 1},
+	}
+	for _, tt := range tests {
+		if got := newPager(20).pageHeight(tt.height); got != tt.want {
+			t.Errorf("pageHeight(%d) = %d, want %d", tt.height, got, tt.want)
+		}
+	}
+}
+
+// screen returns the lines drawn by render without escape sequences
+func screen(b []byte) []string {
+	return strings.Split(stripEscapes(strings.NewReplacer("\x1b[K", "", "\x1b[?25l", "", "\x1b[?25h", "", "\x1b[H", "").Replace(string(b))), "\r\n")
+}
+
+func TestRender(t *testing.T) {
+	p := newPager(20)
+	p.offset = 2
+	got := screen(p.render(80, 7))
+	want := []string{
+		"+----+",
+		"| ID |",
+		"line 2",
+		"line 3",
+		"line 4",
+		"+----+",
+		"Lines 3-5 of 20  n/p: page  /: search  q: quit",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("render() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRenderLastScreen(t *testing.T) {
+	p := newPager(4)
+	p.offset = 10
+	got := screen(p.render(80, 9))
+	// The offset is moved back and the rest of the screen is left empty
+	want := []string{
+		"+----+",
+		"| ID |",
+		"line 0",
+		"line 1",
+		"line 2",
+		"line 3",
+		"",
+		"+----+",
+		"Lines 1-4 of 4  n/p: page  /: search  q: quit",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("render() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRenderClipsHeaderAndFooter(t *testing.T) {
+	tests := []struct {
+		height int
+		want   []string
+	}{
+		// The footer is dropped first
+		{4, []string{"+----+", "| ID |", "line 0", "Lines 1-1 of 20"}},
+		// Then the end of the header
+		{3, []string{"+----+", "line 0", "Lines 1-1 of 20"}},
+		{2, []string{"line 0", "Lines 1-1 of 20"}},
+	}
+	for _, tt := range tests {
+		got := screen(newPager(20).render(15, tt.height))
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("render(15, %d) =\n%s\nwant\n%s", tt.height, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestRenderSearchPrompt(t *testing.T) {
+	p := newPager(20)
+	p.handleInput([]byte("/ch_"), 5)
+	got := screen(p.render(80, 7))
+	if last := got[len(got)-1]; last != "/ch_" {
+		t.Errorf("status line = %q, want the search prompt", last)
+	}
+}
@@ -0,0 +1,114 @@
+package picker
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// match is an item that matches the query, with the rune positions of the label that matched
+type match struct {
+	item      int
+	score     int
+	positions map[int]bool
+}
+
+// filter returns the items whose labels match every space separated term of the query
+// Better matches come first; items with equal scores keep their original order
+func filter(items []Item, query string) []match {
+	terms := strings.Fields(query)
+	matches := []match{}
+	for i, item := range items {
+		m := match{item: i, positions: map[int]bool{}}
+		ok := true
+		for _, term := range terms {
+			score, positions, found := matchTerm([]rune(term), []rune(item.Label))
+			if !found {
+				ok = false
+				break
+			}
+			m.score += score
+			for _, p := range positions {
+				m.positions[p] = true
+			}
+		}
+		if ok {
+			matches = append(matches, m)
+		}
+	}
+	if len(terms) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+	}
+	return matches
+}
+
+// matchTerm reports whether the runes of term appear in order in label, like fzf
+// Matching ignores case unless the term contains an upper case letter. A substring match is
+// preferred over a scattered one, and matches at the start of words score higher
+func matchTerm(term, label []rune) (int, []int, bool) {
+	foldCase := true
+	for _, r := range term {
+		if unicode.IsUpper(r) {
+			foldCase = false
+			break
+		}
+	}
+	equal := func(a, b rune) bool {
+		if foldCase {
+			return unicode.ToLower(a) == unicode.ToLower(b)
+		}
+		return a == b
+	}
+
+	positions := []int{}
+	if start := indexRunes(label, term, equal); start >= 0 {
+		for i := range term {
+			positions = append(positions, start+i)
+		}
+	} else {
+		j := 0
+		for i := 0; i < len(label) && j < len(term); i++ {
+			if equal(label[i], term[j]) {
+				positions = append(positions, i)
+				j++
+			}
+		}
+		if j < len(term) {
+			return 0, nil, false
+		}
+	}
+
+	score := 0
+	for i, p := range positions {
+		score++
+		if i > 0 && p == positions[i-1]+1 {
+			score += 4
+		}
+		if p == 0 || strings.ContainsRune(" _-/:.@", label[p-1]) {
+			score += 3
+		}
+	}
+	if len(positions) > 0 {
+		score -= (positions[len(positions)-1] - positions[0] + 1 - len(positions)) / 4
+	}
+	return score, positions, true
+}
+
+// indexRunes returns the index of the first occurrence of sub in s, or -1
+func indexRunes(s, sub []rune, equal func(a, b rune) bool) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		found := true
+		for j := range sub {
+			if !equal(s[i+j], sub[j]) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,283 @@
+package picker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/payjp/payjp-cli/internal/tty"
+)
+
+// Item is a line of the picker
+type Item struct {
+	ID      string
+	Label   string
+	Preview string
+}
+
+// Options configures the picker
+type Options struct {
+	// Query is the initial query
+	Query string
+	// Highlight colors the matched characters of a label; nil leaves them uncolored
+	Highlight func(s string) string
+}
+
+var (
+	// ErrCanceled is returned when the picker is closed without selecting an item
+	ErrCanceled = errors.New("canceled")
+	// ErrNoTerminal is returned when there is no terminal to draw the picker on
+	ErrNoTerminal = tty.ErrNoTerminal
+)
+
+// minPreviewWidth is the terminal width below which the preview pane is hidden
+const minPreviewWidth = 80
+
+// picker is the state of the picker
+type picker struct {
+	items    []Item
+	opts     Options
+	query    []rune
+	matches  []match
+	cursor   int
+	offset   int
+	selected bool
+	canceled bool
+}
+
+// Pick shows the items on the terminal and returns the one selected with Enter
+// The picker is drawn on the controlling terminal rather than stdout so that the caller can
+// print the result to stdout, e.g. inside $(...). Typing filters the items with fuzzy matching,
+// Up/Down (Ctrl-P/Ctrl-N) move the cursor and Esc or Ctrl-C cancels
+func Pick(items []Item, opts Options) (Item, error) {
+	term, err := tty.Open()
+	if err != nil {
+		return Item{}, err
+	}
+	defer term.Close()
+
+	// Use the alternate screen so that the picker leaves the scrollback untouched
+	fmt.Fprint(term, "\x1b[?1049h")
+	defer fmt.Fprint(term, "\x1b[?1049l")
+
+	p := &picker{items: items, opts: opts, query: []rune(opts.Query)}
+	p.refilter()
+
+	buf := make([]byte, 256)
+	for {
+		width, height := term.Size()
+		term.Write(p.render(width, height))
+
+		n, err := term.Read(buf)
+		if err != nil {
+			return Item{}, err
+		}
+		p.handleInput(buf[:n], height)
+
+		if p.canceled {
+			return Item{}, ErrCanceled
+		}
+		if p.selected {
+			return items[p.matches[p.cursor].item], nil
+		}
+	}
+}
+
+// refilter matches the items against the query and moves the cursor to the best match
+func (p *picker) refilter() {
+	p.matches = filter(p.items, string(p.query))
+	p.cursor = 0
+	p.offset = 0
+}
+
+// handleInput applies the keys in b
+func (p *picker) handleInput(b []byte, height int) {
+	page := max(height-2, 1)
+	for len(b) > 0 && !p.selected && !p.canceled {
+		switch c := b[0]; {
+		case c == 0x1b:
+			if len(b) == 1 {
+				p.canceled = true
+				return
+			}
+			b = p.handleEscape(b, page)
+			continue
+		case c == 0x03 || c == 0x07: // Ctrl-C, Ctrl-G
+			p.canceled = true
+		case c == 0x04: // Ctrl-D
+			if len(p.query) == 0 {
+				p.canceled = true
+			}
+		case c == '\r':
+			if len(p.matches) > 0 {
+				p.selected = true
+			}
+		case c == 0x10 || c == 0x0b: // Ctrl-P, Ctrl-K
+			p.move(-1)
+		case c == 0x0e || c == '\n': // Ctrl-N, Ctrl-J
+			p.move(1)
+		case c == 0x7f || c == 0x08: // Backspace
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.refilter()
+			}
+		case c == 0x15: // Ctrl-U
+			p.query = nil
+			p.refilter()
+		case c == 0x17: // Ctrl-W
+			q := strings.TrimRight(string(p.query), " ")
+			p.query = []rune(q[:strings.LastIndex(q, " ")+1])
+			p.refilter()
+		case c >= 0x20:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				p.query = append(p.query, r)
+				p.refilter()
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+}
+
+// handleEscape applies an escape sequence at the start of b and returns the rest
+func (p *picker) handleEscape(b []byte, page int) []byte {
+	if b[1] != '[' && b[1] != 'O' {
+		return b[1:]
+	}
+	end := 2
+	for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+		end++
+	}
+	if end == len(b) {
+		return nil
+	}
+	switch string(b[2 : end+1]) {
+	case "A":
+		p.move(-1)
+	case "B":
+		p.move(1)
+	case "5~":
+		p.move(-page)
+	case "6~":
+		p.move(page)
+	}
+	return b[end+1:]
+}
+
+// move moves the cursor by delta, staying within the matches
+func (p *picker) move(delta int) {
+	p.cursor = min(max(p.cursor+delta, 0), max(len(p.matches)-1, 0))
+}
+
+// render draws the prompt, the matching items and the preview of the item under the cursor
+func (p *picker) render(width, height int) []byte {
+	listHeight := max(height-2, 1)
+	listWidth := width
+	previewWidth := 0
+	if width >= minPreviewWidth {
+		listWidth = width / 2
+		previewWidth = width - listWidth - 3
+	}
+
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+listHeight {
+		p.offset = p.cursor - listHeight + 1
+	}
+
+	var preview []string
+	if previewWidth > 0 && len(p.matches) > 0 {
+		preview = strings.Split(p.items[p.matches[p.cursor].item].Preview, "\n")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[?25l\x1b[H")
+	fmt.Fprintf(&buf, "> %s\x1b[K\r\n", runewidth.Truncate(sanitize(string(p.query)), width-2, ""))
+	fmt.Fprintf(&buf, "  %d/%d\x1b[K", len(p.matches), len(p.items))
+
+	for row := 0; row < listHeight; row++ {
+		buf.WriteString("\r\n")
+		line := ""
+		if i := p.offset + row; i < len(p.matches) {
+			marker := "  "
+			if i == p.cursor {
+				marker = "> "
+			}
+			m := p.matches[i]
+			line = marker + p.label(p.items[m.item].Label, m.positions, listWidth-2)
+		}
+		if previewWidth > 0 {
+			line = padRight(line, listWidth)
+			line += " │ "
+			if row < len(preview) {
+				line += runewidth.Truncate(sanitize(preview[row]), previewWidth, "")
+			}
+		}
+		buf.WriteString(line)
+		buf.WriteString("\x1b[K")
+	}
+
+	// Leave the cursor at the end of the query
+	fmt.Fprintf(&buf, "\x1b[1;%dH\x1b[?25h", min(3+runewidth.StringWidth(sanitize(string(p.query))), width))
+	return buf.Bytes()
+}
+
+// label truncates a label to width columns and highlights the matched runes
+func (p *picker) label(label string, positions map[int]bool, width int) string {
+	var b strings.Builder
+	used := 0
+	for i, r := range []rune(sanitize(label)) {
+		w := runewidth.RuneWidth(r)
+		if used+w > width {
+			break
+		}
+		used += w
+		if positions[i] && p.opts.Highlight != nil {
+			b.WriteString(p.opts.Highlight(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// padRight pads a list line to width columns; the line is already truncated to fit
+// Escape sequences added by highlighting take no columns, so the width is measured on the
+// line without them
+func padRight(line string, width int) string {
+	return line + strings.Repeat(" ", max(width-runewidth.StringWidth(stripEscapes(line)), 0))
+}
+
+// stripEscapes removes ANSI escape sequences
+func stripEscapes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// sanitize replaces control characters so that labels and previews cannot move the cursor
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		if r < 0x20 || r == 0x7f {
+			return '?'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,103 @@
+package protect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// FileName is the name of the protected resources registry in the config directory
+const FileName = "protected.json"
+
+// Entry is a protected resource
+type Entry struct {
+	ID      string    `json:"id"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// TableColumns returns the fields shown in list view
+func (Entry) TableColumns() []string {
+	return []string{"ID", "Note", "AddedAt"}
+}
+
+// Registry is the local list of resources that destructive commands must not touch without --force
+type Registry struct {
+	Resources []Entry `json:"resources"`
+}
+
+// Path returns the registry file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load reads the registry file, returning an empty registry if it does not exist
+func Load(path string) (*Registry, error) {
+	r := &Registry{Resources: []Entry{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("error reading protected resources: %w", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("error parsing protected resources %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Save writes the registry file through a temp file and rename
+func (r *Registry) Save(path string) error {
+	sort.Slice(r.Resources, func(i, j int) bool {
+		return r.Resources[i].ID < r.Resources[j].ID
+	})
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := util.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing protected resources: %w", err)
+	}
+	return nil
+}
+
+// Get returns the entry for a protected ID
+func (r *Registry) Get(id string) (Entry, bool) {
+	for _, entry := range r.Resources {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Add protects an ID, updating the note if it is already protected
+func (r *Registry) Add(id, note string) {
+	for i := range r.Resources {
+		if r.Resources[i].ID == id {
+			if note != "" {
+				r.Resources[i].Note = note
+			}
+			return
+		}
+	}
+	r.Resources = append(r.Resources, Entry{ID: id, Note: note, AddedAt: time.Now()})
+}
+
+// Remove removes an ID and reports whether it was protected
+func (r *Registry) Remove(id string) bool {
+	for i, entry := range r.Resources {
+		if entry.ID == id {
+			r.Resources = append(r.Resources[:i], r.Resources[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
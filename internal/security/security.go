@@ -0,0 +1,239 @@
+// Package security finds risky ways of storing and passing API keys
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/config"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// Checks
+const (
+	CheckConfigPermissions    = "config_permissions"
+	CheckKeyInShellFile       = "key_in_shell_file"
+	CheckKeyOnCommandLine     = "key_on_command_line"
+	CheckLiveKeyInTestProfile = "live_key_in_test_profile"
+)
+
+// Checks lists every check in the order they are reported
+var Checks = []string{CheckConfigPermissions, CheckKeyInShellFile, CheckKeyOnCommandLine, CheckLiveKeyInTestProfile}
+
+// Issue is a problem found by a check
+type Issue struct {
+	Check   string `json:"check"`
+	Target  string `json:"target"`
+	Message string `json:"message"`
+	Fix     string `json:"fix"`
+
+	// Mode is the permission that fixes a config_permissions issue
+	Mode os.FileMode `json:"-"`
+}
+
+// ID identifies an issue, so that the same issue is only warned about once
+func (i Issue) ID() string {
+	return i.Check + ":" + i.Target
+}
+
+// shellFiles are the shell history and startup files that keys are commonly leaked into,
+// relative to the home directory
+var shellFiles = []string{
+	".bash_history", ".zsh_history", ".history", ".local/share/fish/fish_history",
+	".bashrc", ".bash_profile", ".zshrc", ".zprofile", ".profile",
+}
+
+// scanLimit is how much of the end of a shell file is searched
+const scanLimit = 1 << 20
+
+// secretKeyPattern matches a PAY.JP secret API key
+var secretKeyPattern = regexp.MustCompile(`sk_(live|test)_[0-9A-Za-z]{8,}`)
+
+// Check runs every check and returns the issues found
+// keyOnCommandLine is whether the API key was given with --api-key
+func Check(keyOnCommandLine bool) []Issue {
+	issues := checkPermissions()
+	issues = append(issues, checkShellFiles()...)
+	issues = append(issues, CommandLineIssues(keyOnCommandLine)...)
+	return append(issues, checkProfiles()...)
+}
+
+// CommandLineIssues returns the issues of how the running command was invoked, which are found
+// without reading any file
+func CommandLineIssues(keyOnCommandLine bool) []Issue {
+	if !keyOnCommandLine {
+		return nil
+	}
+	return []Issue{{
+		Check:   CheckKeyOnCommandLine,
+		Target:  "--api-key",
+		Message: "the API key was given with --api-key and is saved in the shell history",
+		Fix:     "use --api-key-stdin, PAYJP_API_KEY_FILE or a profile instead",
+	}}
+}
+
+// checkPermissions reports a config file or directory that other users can access
+// Windows does not use Unix permissions, so nothing is reported there
+func checkPermissions() []Issue {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	issues := []Issue{}
+	targets := []struct {
+		path string
+		mode os.FileMode
+	}{
+		{config.ConfigDir(), 0700},
+		{config.ConfigFilePath(), 0600},
+	}
+	for _, target := range targets {
+		info, err := os.Stat(target.path)
+		if err != nil || info.Mode().Perm()&0077 == 0 {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   CheckConfigPermissions,
+			Target:  target.path,
+			Message: fmt.Sprintf("%s can be read by other users (mode %04o)", target.path, info.Mode().Perm()),
+			Fix:     fmt.Sprintf("chmod %04o %s (payjp doctor --fix)", target.mode, target.path),
+			Mode:    target.mode,
+		})
+	}
+	return issues
+}
+
+// checkShellFiles reports shell history and startup files that contain a secret key
+func checkShellFiles() []Issue {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	issues := []Issue{}
+	for _, name := range shellFiles {
+		path := filepath.Join(home, name)
+		if !containsSecretKey(path) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   CheckKeyInShellFile,
+			Target:  path,
+			Message: fmt.Sprintf("%s contains a secret API key", path),
+			Fix:     "remove the key from the file, roll it in the dashboard and save the new key with payjp config rotate-key",
+		})
+	}
+	return issues
+}
+
+// containsSecretKey reports whether the end of a file contains a secret key
+func containsSecretKey(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > scanLimit {
+		f.Seek(-scanLimit, io.SeekEnd)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, scanLimit))
+	if err != nil {
+		return false
+	}
+	return secretKeyPattern.Match(data)
+}
+
+// checkProfiles reports live keys saved in profiles that are used in test mode
+// Keys kept in the keychain are not read, since that may ask the user for access
+func checkProfiles() []Issue {
+	profiles := config.Get().Profiles
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	issues := []Issue{}
+	for _, name := range names {
+		profile := profiles[name]
+		if profile.Mode == "live" || !strings.HasPrefix(profile.APIKey, "sk_live_") {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   CheckLiveKeyInTestProfile,
+			Target:  name,
+			Message: fmt.Sprintf("profile '%s' is in test mode but has a live API key", name),
+			Fix:     fmt.Sprintf("save a test key, or set the mode with payjp config set-profile %s --api-key - --mode live", name),
+		})
+	}
+	return issues
+}
+
+// Fix applies the fix of a config_permissions issue
+func Fix(issue Issue) error {
+	if issue.Check != CheckConfigPermissions {
+		return fmt.Errorf("%s cannot be fixed automatically", issue.Check)
+	}
+	return os.Chmod(issue.Target, issue.Mode)
+}
+
+// WarnedFileName is the name of the file in the config directory that records the issues
+// already warned about
+const WarnedFileName = "security.json"
+
+// Warned maps issue IDs to when they were first warned about
+// The time of the last full check is kept under lastCheckKey, which is not an issue ID
+type Warned map[string]time.Time
+
+// lastCheckKey records when the files were last checked
+const lastCheckKey = "last_check"
+
+// CheckInterval is how often commands other than doctor check the files, since reading
+// the shell history on every command would slow every command down
+const CheckInterval = 24 * time.Hour
+
+// CheckDue reports whether the files were not checked in the last CheckInterval
+func (w Warned) CheckDue(now time.Time) bool {
+	last, ok := w[lastCheckKey]
+	return !ok || now.Sub(last) >= CheckInterval || now.Before(last)
+}
+
+// MarkChecked records that the files were checked at now
+func (w Warned) MarkChecked(now time.Time) {
+	w[lastCheckKey] = now
+}
+
+// WarnedPath returns the warned issues file path in the given directory
+func WarnedPath(dir string) string {
+	return filepath.Join(dir, WarnedFileName)
+}
+
+// LoadWarned reads the warned issues, returning none if the file does not exist or is unreadable
+func LoadWarned(path string) Warned {
+	warned := Warned{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return warned
+	}
+	json.Unmarshal(data, &warned)
+	return warned
+}
+
+// Save writes the warned issues
+func (w Warned) Save(path string) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := util.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package spend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/filelock"
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// FileName is the name of the spend ledger file in the config directory
+const FileName = "spend.json"
+
+// retentionDays is how many days of totals are kept in the ledger
+const retentionDays = 7
+
+// Ledger holds charged totals keyed by day (YYYY-MM-DD), profile name and currency
+// Amounts of different currencies are never added together
+type Ledger map[string]map[string]map[string]int
+
+// legacyCurrency is the currency assigned to the totals of ledgers written before they were kept
+// per currency; jpy is the default currency of charges
+const legacyCurrency = "jpy"
+
+// Path returns the ledger file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Day returns the ledger key for the given time
+func Day(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// Load reads the ledger file, returning an empty ledger if it does not exist
+func Load(path string) (Ledger, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Ledger{}, nil
+		}
+		return nil, fmt.Errorf("error reading spend ledger: %w", err)
+	}
+
+	ledger := Ledger{}
+	if err := json.Unmarshal(b, &ledger); err != nil {
+		// Ledgers written before totals were kept per currency have one total per profile
+		legacy := map[string]map[string]int{}
+		if json.Unmarshal(b, &legacy) != nil {
+			return nil, fmt.Errorf("error parsing spend ledger: %w", err)
+		}
+		ledger = Ledger{}
+		for day, profiles := range legacy {
+			for profile, amount := range profiles {
+				ledger.add(day, profile, legacyCurrency, amount)
+			}
+		}
+	}
+	return ledger, nil
+}
+
+// Total returns the amount charged in the currency on the given day for the profile
+func (l Ledger) Total(day, profile, currency string) int {
+	return l[day][profile][strings.ToLower(currency)]
+}
+
+// add adds an amount to the total of a day, profile and currency
+func (l Ledger) add(day, profile, currency string, amount int) {
+	if l[day] == nil {
+		l[day] = map[string]map[string]int{}
+	}
+	if l[day][profile] == nil {
+		l[day][profile] = map[string]int{}
+	}
+	l[day][profile][strings.ToLower(currency)] += amount
+}
+
+// Locked is the ledger held under an exclusive lock, so that checking a limit against the ledger
+// and recording the charge that passed the check cannot interleave with another process
+type Locked struct {
+	path string
+	lock *filelock.Lock
+}
+
+// Lock waits for and takes the exclusive lock of the ledger at path
+func Lock(path string) (*Locked, error) {
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return nil, fmt.Errorf("error locking spend ledger: %w", err)
+	}
+	return &Locked{path: path, lock: lock}, nil
+}
+
+// Load reads the locked ledger
+func (l *Locked) Load() (Ledger, error) {
+	return Load(l.path)
+}
+
+// Record adds an amount charged today in the currency for the profile and saves the ledger
+func (l *Locked) Record(profile, currency string, amount int) error {
+	ledger, err := Load(l.path)
+	if err != nil {
+		return err
+	}
+
+	ledger.add(Day(time.Now()), profile, currency, amount)
+
+	// Drop old days
+	cutoff := Day(time.Now().AddDate(0, 0, -retentionDays))
+	for d := range ledger {
+		if d < cutoff {
+			delete(ledger, d)
+		}
+	}
+
+	return save(l.path, ledger)
+}
+
+// Unlock releases the lock
+func (l *Locked) Unlock() error {
+	return l.lock.Release()
+}
+
+// Record adds an amount charged today in the currency for the profile under the lock of the ledger
+func Record(path, profile, currency string, amount int) error {
+	locked, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer locked.Unlock()
+	return locked.Record(profile, currency, amount)
+}
+
+// save writes the ledger with secure permissions
+func save(path string, ledger Ledger) error {
+	b, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := util.WriteFileAtomic(path, b); err != nil {
+		return fmt.Errorf("error writing spend ledger: %w", err)
+	}
+	return nil
+}
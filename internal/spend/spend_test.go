@@ -0,0 +1,160 @@
+package spend
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTotal(t *testing.T) {
+	ledger := Ledger{}
+	ledger.add("2026-10-15", "default", "JPY", 1000)
+	ledger.add("2026-10-15", "default", "jpy", 500)
+	ledger.add("2026-10-15", "default", "usd", 20)
+	ledger.add("2026-10-15", "live", "jpy", 7000)
+	ledger.add("2026-10-16", "default", "jpy", 300)
+
+	tests := []struct {
+		day, profile, currency string
+		want                   int
+	}{
+		{"2026-10-15", "default", "jpy", 1500},
+		{"2026-10-15", "default", "JPY", 1500},
+		{"2026-10-15", "default", "usd", 20},
+		{"2026-10-15", "live", "jpy", 7000},
+		{"2026-10-16", "default", "jpy", 300},
+		{"2026-10-16", "default", "usd", 0},
+		{"2026-10-17", "default", "jpy", 0},
+		{"2026-10-15", "staging", "jpy", 0},
+	}
+	for _, tt := range tests {
+		if got := ledger.Total(tt.day, tt.profile, tt.currency); got != tt.want {
+			t.Errorf("Total(%q, %q, %q) = %d, want %d", tt.day, tt.profile, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestDay(t *testing.T) {
+	// The day changes at midnight in the local time zone
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 15, 23, 59, 59, 0, jst), "2026-10-15"},
+		{time.Date(2026, 10, 16, 0, 0, 0, 0, jst), "2026-10-16"},
+		{time.Date(2026, 12, 31, 23, 59, 59, 0, jst), "2026-12-31"},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, jst), "2027-01-01"},
+	}
+	for _, tt := range tests {
+		if got := Day(tt.t); got != tt.want {
+			t.Errorf("Day(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	ledger, err := Load(Path(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(ledger) != 0 {
+		t.Errorf("Load() = %v, want empty ledger", ledger)
+	}
+}
+
+func TestLoadLegacy(t *testing.T) {
+	path := Path(t.TempDir())
+	// Ledgers written before totals were kept per currency
+	if err := os.WriteFile(path, []byte(`{"2026-10-16": {"default": 1200, "live": 300}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := ledger.Total("2026-10-16", "default", "jpy"); got != 1200 {
+		t.Errorf("Total(default, jpy) = %d, want 1200", got)
+	}
+	if got := ledger.Total("2026-10-16", "live", "jpy"); got != 300 {
+		t.Errorf("Total(live, jpy) = %d, want 300", got)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	path := Path(t.TempDir())
+	if err := os.WriteFile(path, []byte(`{"2026-10-16": [`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() error = nil, want error for a corrupt ledger")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	path := Path(t.TempDir())
+	today := Day(time.Now())
+	yesterday := Day(time.Now().AddDate(0, 0, -1))
+	kept := Day(time.Now().AddDate(0, 0, -retentionDays))
+	dropped := Day(time.Now().AddDate(0, 0, -retentionDays-1))
+
+	ledger := `{
+  "` + yesterday + `": {"default": {"jpy": 5000}},
+  "` + kept + `": {"default": {"jpy": 700}},
+  "` + dropped + `": {"default": {"jpy": 800}}
+}`
+	if err := os.WriteFile(path, []byte(ledger), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Record(path, "default", "JPY", 1000); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := Record(path, "default", "jpy", 250); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// The day rolled over since yesterday's charges, so today starts from zero
+	if total := got.Total(today, "default", "jpy"); total != 1250 {
+		t.Errorf("today's total = %d, want 1250", total)
+	}
+	if total := got.Total(yesterday, "default", "jpy"); total != 5000 {
+		t.Errorf("yesterday's total = %d, want 5000", total)
+	}
+	if _, ok := got[kept]; !ok {
+		t.Errorf("day %s within the retention was dropped", kept)
+	}
+	if _, ok := got[dropped]; ok {
+		t.Errorf("day %s older than the retention was kept", dropped)
+	}
+}
+
+func TestRecordConcurrent(t *testing.T) {
+	path := Path(t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Record(path, "default", "jpy", 100); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ledger, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if total := ledger.Total(Day(time.Now()), "default", "jpy"); total != 2000 {
+		t.Errorf("total = %d, want 2000 (no charge lost)", total)
+	}
+}
@@ -0,0 +1,79 @@
+package syncstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// State records how far an incremental sync has got
+// Items are synced in ascending creation order; LastIDs holds the IDs created at LastCreated
+// that are already synced, since more items may be created in the same second
+type State struct {
+	Resource    string    `json:"resource"`
+	LastCreated int64     `json:"last_created"`
+	LastIDs     []string  `json:"last_ids"`
+	Total       int       `json:"total"`
+	SyncedAt    time.Time `json:"synced_at"`
+}
+
+// Load reads the state file, returning an empty state if it does not exist
+// A state written for another resource is rejected
+func Load(path, resource string) (*State, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{Resource: resource}, nil
+		}
+		return nil, fmt.Errorf("error reading sync state: %w", err)
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("error parsing sync state %s: %w", path, err)
+	}
+	if state.Resource != resource {
+		return nil, fmt.Errorf("sync state %s was written for %s, not %s", path, state.Resource, resource)
+	}
+	return state, nil
+}
+
+// IsNew reports whether the state has never been saved
+func (s *State) IsNew() bool {
+	return s.SyncedAt.IsZero()
+}
+
+// Synced reports whether the item created at created with the ID is already synced
+func (s *State) Synced(created int64, id string) bool {
+	if created != s.LastCreated {
+		return created < s.LastCreated
+	}
+	return slices.Contains(s.LastIDs, id)
+}
+
+// Advance records an item as synced; items must be given in ascending creation order
+func (s *State) Advance(created int64, id string) {
+	if created != s.LastCreated {
+		s.LastCreated = created
+		s.LastIDs = nil
+	}
+	s.LastIDs = append(s.LastIDs, id)
+	s.Total++
+}
+
+// Save writes the state file atomically
+func (s *State) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := util.WriteFileAtomic(path, b); err != nil {
+		return fmt.Errorf("error writing sync state: %w", err)
+	}
+	return nil
+}
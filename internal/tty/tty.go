@@ -0,0 +1,23 @@
+// Package tty gives raw access to the controlling terminal for interactive screens
+// such as the picker and the table pager, which draw on /dev/tty rather than stdout
+package tty
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrNoTerminal is returned when there is no controlling terminal to draw on
+var ErrNoTerminal = errors.New("no terminal")
+
+// Terminal is the controlling terminal in raw mode
+type Terminal struct {
+	*os.File
+	restore func()
+}
+
+// Close restores the terminal mode and closes the terminal
+func (t *Terminal) Close() error {
+	t.restore()
+	return t.File.Close()
+}
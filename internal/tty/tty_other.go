@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package tty
+
+// Open reports that raw terminal access is not supported on this platform
+func Open() (*Terminal, error) {
+	return nil, ErrNoTerminal
+}
+
+// Size returns a default terminal size
+func (t *Terminal) Size() (int, int) {
+	return 80, 24
+}
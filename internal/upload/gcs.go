@@ -0,0 +1,147 @@
+package upload
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// gcsScope is the OAuth scope needed to write objects
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// serviceAccountKey is the part of a service account key file used to get a token
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// gcsToken returns an OAuth access token from GOOGLE_OAUTH_ACCESS_TOKEN, or exchanges a signed
+// JWT for one using the service account key file in GOOGLE_APPLICATION_CREDENTIALS
+// The GCE metadata server and the user credentials of gcloud application-default login are not
+// supported; a token from gcloud auth print-access-token can be used instead
+func gcsToken(client *http.Client) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return "", errors.New("no Google Cloud credentials (set GOOGLE_APPLICATION_CREDENTIALS to a service account key file, or GOOGLE_OAUTH_ACCESS_TOKEN)")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Google Cloud credentials: %w", err)
+	}
+	var key serviceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", fmt.Errorf("failed to parse Google Cloud credentials: %w", err)
+	}
+	if key.Type != "service_account" {
+		return "", fmt.Errorf("unsupported Google Cloud credentials type: %s (use a service account key)", key.Type)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	assertion, err := signJWT(key, time.Now())
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.PostForm(key.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get a Google Cloud access token: %w", responseError(resp, body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", errors.New("failed to get a Google Cloud access token: invalid response")
+	}
+	return token.AccessToken, nil
+}
+
+// signJWT creates the RS256 signed assertion of the JWT bearer grant
+func signJWT(key serviceAccountKey, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid private key in Google Cloud credentials")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key in Google Cloud credentials: %w", err)
+	}
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("invalid private key in Google Cloud credentials: not an RSA key")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": key.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   key.ClientEmail,
+		"scope": gcsScope,
+		"aud":   key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// putGCS uploads data with a simple media upload of the JSON API
+func putGCS(client *http.Client, t Target, data []byte, contentType string) error {
+	token, err := gcsToken(client)
+	if err != nil {
+		return err
+	}
+
+	endpoint := "https://storage.googleapis.com/upload/storage/v1/b/" + url.PathEscape(t.Bucket) +
+		"/o?uploadType=media&name=" + url.QueryEscape(t.Key)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, body)
+	}
+	return nil
+}
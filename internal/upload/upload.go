@@ -0,0 +1,80 @@
+package upload
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Supported storage schemes
+const (
+	SchemeS3  = "s3"
+	SchemeGCS = "gs"
+)
+
+// Target is an object in S3 or Google Cloud Storage
+// A key that is empty or ends with "/" is a prefix; see WithName
+type Target struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// Parse parses an upload URL such as s3://bucket/path/file.csv or gs://bucket/prefix/
+func Parse(raw string) (Target, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return Target{}, fmt.Errorf("invalid upload URL: %s (use s3://bucket/key or gs://bucket/key)", raw)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != SchemeS3 && scheme != SchemeGCS {
+		return Target{}, fmt.Errorf("unsupported upload URL scheme: %s (use s3:// or gs://)", u.Scheme)
+	}
+	return Target{Scheme: scheme, Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}, nil
+}
+
+// WithName returns the target with name appended when the key is a prefix
+func (t Target) WithName(name string) Target {
+	if t.Key == "" || strings.HasSuffix(t.Key, "/") {
+		t.Key += name
+	}
+	return t
+}
+
+// String returns the URL of the target
+func (t Target) String() string {
+	return t.Scheme + "://" + t.Bucket + "/" + t.Key
+}
+
+// File uploads a local file to the target, replacing an existing object
+// Credentials are read from the standard AWS or Google Cloud environment
+func File(client *http.Client, t Target, path, contentType string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read upload file: %w", err)
+	}
+
+	switch t.Scheme {
+	case SchemeS3:
+		err = putS3(client, t, data, contentType)
+	case SchemeGCS:
+		err = putGCS(client, t, data, contentType)
+	default:
+		err = fmt.Errorf("unsupported upload URL scheme: %s", t.Scheme)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload to %s: %w", t, err)
+	}
+	return nil
+}
+
+// responseError describes an unsuccessful storage API response
+func responseError(resp *http.Response, body []byte) error {
+	message := strings.TrimSpace(string(body))
+	if len(message) > 500 {
+		message = message[:500] + "..."
+	}
+	return fmt.Errorf("%s: %s", resp.Status, message)
+}
@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/payjp/payjp-cli/internal/util"
+)
+
+// FileName is the name of the usage counter file in the config directory
+// The counters are only read by stats usage and are never sent anywhere
+const FileName = "usage.json"
+
+// retentionMonths is how many months of counters are kept
+const retentionMonths = 12
+
+// Stats holds request counts keyed by month (YYYY-MM) and endpoint (e.g. "GET /v1/charges/{id}")
+type Stats map[string]map[string]int
+
+// Path returns the usage file path in the given directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Month returns the stats key for the given time
+func Month(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// Endpoint returns the counter key of a request with the resource IDs in the path replaced by {id}
+// API paths alternate between names and IDs (/v1/customers/{id}/cards/{id}), so every
+// second segment after the version is an ID
+func Endpoint(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 2; i < len(segments); i += 2 {
+		segments[i] = "{id}"
+	}
+	return method + " /" + strings.Join(segments, "/")
+}
+
+// Counter counts the requests made by the process until they are recorded
+type Counter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewCounter creates an empty counter
+func NewCounter() *Counter {
+	return &Counter{counts: map[string]int{}}
+}
+
+// Add counts one request
+func (c *Counter) Add(method, path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[Endpoint(method, path)]++
+}
+
+// Take returns the counts since the last call and clears them
+func (c *Counter) Take() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.counts
+	c.counts = map[string]int{}
+	return counts
+}
+
+// Load reads the usage file, returning empty stats if it does not exist
+func Load(path string) (Stats, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Stats{}, nil
+		}
+		return nil, fmt.Errorf("error reading usage stats: %w", err)
+	}
+
+	stats := Stats{}
+	if err := json.Unmarshal(b, &stats); err != nil {
+		return nil, fmt.Errorf("error parsing usage stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Record adds request counts to the current month and saves the stats
+func Record(path string, counts map[string]int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	stats, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	month := Month(time.Now())
+	if stats[month] == nil {
+		stats[month] = make(map[string]int)
+	}
+	for endpoint, n := range counts {
+		stats[month][endpoint] += n
+	}
+
+	// Drop old months
+	cutoff := Month(time.Now().AddDate(0, -retentionMonths, 0))
+	for m := range stats {
+		if m < cutoff {
+			delete(stats, m)
+		}
+	}
+
+	return save(path, stats)
+}
+
+// save writes the stats with secure permissions
+func save(path string, stats Stats) error {
+	b, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := util.WriteFileAtomic(path, b); err != nil {
+		return fmt.Errorf("error writing usage stats: %w", err)
+	}
+	return nil
+}
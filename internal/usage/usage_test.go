@@ -0,0 +1,24 @@
+package usage
+
+import "testing"
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/v1/charges", "GET /v1/charges"},
+		{"GET", "/v1/charges/ch_xxxxx", "GET /v1/charges/{id}"},
+		{"POST", "/v1/charges/ch_xxxxx/refund", "POST /v1/charges/{id}/refund"},
+		{"GET", "/v1/customers/cus_xxxxx/cards/car_xxxxx", "GET /v1/customers/{id}/cards/{id}"},
+		{"DELETE", "/v1/customers/cus_xxxxx/", "DELETE /v1/customers/{id}"},
+		{"GET", "/v1/", "GET /v1"},
+		{"GET", "/", "GET /"},
+	}
+	for _, tt := range tests {
+		if got := Endpoint(tt.method, tt.path); got != tt.want {
+			t.Errorf("Endpoint(%s, %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
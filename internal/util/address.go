@@ -0,0 +1,56 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"golang.org/x/text/language"
+)
+
+// postalCodePatterns are the postal code formats checked for a country
+// Other countries only get the generic check of postalCodeGeneric
+var postalCodePatterns = map[string]*regexp.Regexp{
+	"JP": regexp.MustCompile(`^[0-9]{3}-?[0-9]{4}$`),
+	"US": regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Za-z][0-9][A-Za-z] ?[0-9][A-Za-z][0-9]$`),
+	"GB": regexp.MustCompile(`^[A-Za-z]{1,2}[0-9][A-Za-z0-9]? ?[0-9][A-Za-z]{2}$`),
+}
+
+// postalCodeGeneric accepts the characters postal codes are made of in any country
+var postalCodeGeneric = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{1,9}$`)
+
+// postalCodeExamples are shown in errors for the countries with a pattern
+var postalCodeExamples = map[string]string{
+	"JP": "100-0001 or 1000001",
+	"US": "94105 or 94105-1234",
+	"CA": "K1A 0B1",
+	"GB": "SW1A 1AA",
+}
+
+// NormalizeCountryCode validates an ISO 3166-1 alpha-2 country code and returns it in upper case
+func NormalizeCountryCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 2 && code[0] >= 'A' && code[0] <= 'Z' && code[1] >= 'A' && code[1] <= 'Z' {
+		if region, err := language.ParseRegion(code); err == nil && region.IsCountry() && region.Canonicalize().String() == code {
+			return code, nil
+		}
+	}
+	return "", i18n.Errorf("invalid country code: %s (use an ISO 3166-1 alpha-2 code such as JP or US)", code)
+}
+
+// ValidatePostalCode validates a postal code for a country code
+// Without a country only the generic check is applied, since digits alone do not tell a
+// Japanese postal code from a US ZIP code
+func ValidatePostalCode(zip, country string) error {
+	if pattern, ok := postalCodePatterns[country]; ok {
+		if !pattern.MatchString(zip) {
+			return i18n.Errorf("invalid postal code for %s: %s (e.g. %s)", country, zip, postalCodeExamples[country])
+		}
+		return nil
+	}
+	if !postalCodeGeneric.MatchString(zip) {
+		return i18n.Errorf("invalid postal code: %s", zip)
+	}
+	return nil
+}
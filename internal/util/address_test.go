@@ -0,0 +1,73 @@
+package util
+
+import "testing"
+
+func TestNormalizeCountryCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"JP", "JP", true},
+		{"jp", "JP", true},
+		{" us ", "US", true},
+		{"GB", "GB", true},
+		{"", "", false},
+		{"J", "", false},
+		{"JPN", "", false},
+		{"J1", "", false},
+		// Not assigned to a country
+		{"ZZ", "", false},
+		{"EU", "", false},
+		// Deprecated codes are rejected in favor of their successors (GB)
+		{"UK", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeCountryCode(tt.input)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeCountryCode(%q) = %q, %v, want %q, ok %v", tt.input, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidatePostalCode(t *testing.T) {
+	tests := []struct {
+		zip     string
+		country string
+		ok      bool
+	}{
+		{"100-0001", "JP", true},
+		{"1000001", "JP", true},
+		{"100-001", "JP", false},
+		{"94105", "JP", false},
+		{"94105", "US", true},
+		{"94105-1234", "US", true},
+		{"9410", "US", false},
+		{"100-0001", "US", false},
+		{"K1A 0B1", "CA", true},
+		{"K1A0B1", "CA", true},
+		{"12345", "CA", false},
+		{"SW1A 1AA", "GB", true},
+		{"M1 1AE", "GB", true},
+		{"SW1A", "GB", false},
+		// Other countries only get the generic check
+		{"75008", "FR", true},
+		{"10115", "DE", true},
+		{"1", "FR", false},
+		{"75008!", "FR", false},
+		// Without a country, neither a JP nor a US code is rejected
+		{"1000001", "", true},
+		{"100-0001", "", true},
+		{"94105", "", true},
+		{"94105-1234", "", true},
+		{"K1A 0B1", "", true},
+		{"", "", false},
+		{"-12345", "", false},
+		{"12345678901", "", false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePostalCode(tt.zip, tt.country); (err == nil) != tt.ok {
+			t.Errorf("ValidatePostalCode(%q, %q) error = %v, want ok %v", tt.zip, tt.country, err, tt.ok)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package util
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/payjp/payjp-cli/internal/i18n"
+	"github.com/payjp/payjp-cli/internal/output"
 	"github.com/payjp/payjp-go/v1"
 )
 
@@ -39,7 +42,7 @@ func HandleError(err error) ExitCode {
 
 	// Check if it's a PAY.JP error
 	if payjpErr, ok := err.(*payjp.Error); ok {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", payjpErr.Message)
+		fmt.Fprintf(os.Stderr, "%s %s\n", errorPrefix(), payjpErr.Message)
 		fmt.Fprintf(os.Stderr, "  Status: %d\n", payjpErr.Status)
 		fmt.Fprintf(os.Stderr, "  Type: %s\n", payjpErr.Type)
 		if payjpErr.Code != "" {
@@ -68,10 +71,15 @@ func HandleError(err error) ExitCode {
 		}
 	}
 
-	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	fmt.Fprintf(os.Stderr, "%s %v\n", errorPrefix(), err)
 	return ExitGeneralError
 }
 
+// errorPrefix returns "Error:" in the error color of the theme
+func errorPrefix() string {
+	return output.Colorize(os.Stderr, output.RoleError, "Error:")
+}
+
 // ParseMetadata parses a metadata string into a map
 // Format: key1=value1,key2=value2
 func ParseMetadata(s string) map[string]string {
@@ -100,8 +108,30 @@ func ParseMetadata(s string) map[string]string {
 	return metadata
 }
 
+// MatchMetadata reports whether metadata satisfies all filter conditions
+// A filter value ending with "*" matches by prefix, otherwise the value must match exactly
+func MatchMetadata(metadata map[string]string, filter map[string]string) bool {
+	for key, want := range filter {
+		got, ok := metadata[key]
+		if !ok {
+			return false
+		}
+		if strings.HasSuffix(want, "*") {
+			if !strings.HasPrefix(got, strings.TrimSuffix(want, "*")) {
+				return false
+			}
+		} else if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// dateLayout is the layout for date-only timestamps, interpreted in local time
+const dateLayout = "2006-01-02"
+
 // ParseTimestamp parses a timestamp string
-// Accepts Unix timestamp or RFC3339 format
+// Accepts Unix timestamp, RFC3339 or a date (YYYY-MM-DD, start of day in local time)
 func ParseTimestamp(s string) (int64, error) {
 	if s == "" {
 		return 0, nil
@@ -113,14 +143,135 @@ func ParseTimestamp(s string) (int64, error) {
 	}
 
 	// Try RFC3339 format
-	t, err := time.Parse(time.RFC3339, s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Unix(), nil
+	}
+
+	// Try date format
+	t, err := time.ParseInLocation(dateLayout, s, time.Local)
 	if err != nil {
-		return 0, fmt.Errorf("invalid timestamp format: %s (use Unix timestamp or RFC3339)", s)
+		return 0, i18n.Errorf("invalid timestamp format: %s (use Unix timestamp, RFC3339 or YYYY-MM-DD)", s)
 	}
 
 	return t.Unix(), nil
 }
 
+// ParseRelativeTime parses a time that may be given relative to now
+// Accepts +<duration> (e.g. +14d, +3h), now, end-of-month (the last second of the month
+// in local time) and everything ParseTimestamp accepts
+func ParseRelativeTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "now":
+		return now, nil
+	case s == "end-of-month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start.AddDate(0, 1, 0).Add(-time.Second), nil
+	case strings.HasPrefix(s, "+"):
+		d, err := ParseDuration(s[1:])
+		if err != nil || d <= 0 {
+			return time.Time{}, i18n.Errorf("invalid time: %s (use a Unix timestamp, RFC3339, YYYY-MM-DD, +14d, +3h, now or end-of-month)", s)
+		}
+		return now.Add(d), nil
+	}
+
+	ts, err := ParseTimestamp(s)
+	if err != nil || s == "" {
+		return time.Time{}, i18n.Errorf("invalid time: %s (use a Unix timestamp, RFC3339, YYYY-MM-DD, +14d, +3h, now or end-of-month)", s)
+	}
+	return time.Unix(ts, 0), nil
+}
+
+// ParseDateRange parses a range in the form "start..end"
+// Either side may be omitted. A date-only end includes the whole day
+func ParseDateRange(s string) (int64, int64, error) {
+	parts := strings.SplitN(s, "..", 2)
+	if len(parts) != 2 {
+		return 0, 0, i18n.Errorf("invalid range: %s (use start..end, e.g. 2024-04-01..2024-06-30)", s)
+	}
+
+	start, end := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if start == "" && end == "" {
+		return 0, 0, i18n.Errorf("invalid range: %s (start or end is required)", s)
+	}
+
+	since, err := ParseTimestamp(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	until, err := ParseTimestamp(end)
+	if err != nil {
+		return 0, 0, err
+	}
+	if t, err := time.ParseInLocation(dateLayout, end, time.Local); err == nil {
+		until = t.AddDate(0, 0, 1).Unix() - 1
+	}
+
+	if since > 0 && until > 0 && since > until {
+		return 0, 0, i18n.Errorf("invalid range: %s (start is after end)", s)
+	}
+	return since, until, nil
+}
+
+// ParseAmountRange parses an amount range in the form "min..max"
+// Either side may be omitted; an omitted max is returned as -1
+func ParseAmountRange(s string) (int, int, error) {
+	parts := strings.SplitN(s, "..", 2)
+	if len(parts) != 2 {
+		return 0, 0, i18n.Errorf("invalid amount range: %s (use min..max, e.g. 1000..5000)", s)
+	}
+
+	start, end := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if start == "" && end == "" {
+		return 0, 0, i18n.Errorf("invalid amount range: %s (min or max is required)", s)
+	}
+
+	min, max := 0, -1
+	var err error
+	if start != "" {
+		if min, err = strconv.Atoi(start); err != nil || min < 0 {
+			return 0, 0, i18n.Errorf("invalid amount range: %s (amounts must be non-negative integers)", s)
+		}
+	}
+	if end != "" {
+		if max, err = strconv.Atoi(end); err != nil || max < 0 {
+			return 0, 0, i18n.Errorf("invalid amount range: %s (amounts must be non-negative integers)", s)
+		}
+		if min > max {
+			return 0, 0, i18n.Errorf("invalid amount range: %s (min is greater than max)", s)
+		}
+	}
+	return min, max, nil
+}
+
+// ParseDuration parses a duration string
+// Accepts day and week units (e.g. 60d, 2w) in addition to Go durations (e.g. 12h)
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, i18n.Errorf("empty duration")
+	}
+
+	unit := s[len(s)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, i18n.Errorf("invalid duration: %s (use e.g. 30d, 2w, 12h)", s)
+		}
+		days := n
+		if unit == 'w' {
+			days = n * 7
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, i18n.Errorf("invalid duration: %s (use e.g. 30d, 2w, 12h)", s)
+	}
+	return d, nil
+}
+
 // FormatTimestamp formats a Unix timestamp as a string
 func FormatTimestamp(ts int64) string {
 	if ts == 0 {
@@ -173,11 +324,54 @@ func MaskAPIKey(key string) string {
 	return key[:7] + "****" + key[len(key)-4:]
 }
 
+// NormalizeAPIKey cleans up a pasted API key and validates its format
+// Surrounding whitespace and quotes are removed; the key must be a secret key
+// (sk_test_ or sk_live_) followed by letters and digits only
+func NormalizeAPIKey(s string) (string, error) {
+	key := strings.TrimSpace(s)
+	for len(key) >= 2 && strings.ContainsAny(key[:1], "\"'`") && key[len(key)-1] == key[0] {
+		key = strings.TrimSpace(key[1 : len(key)-1])
+	}
+
+	if key == "" {
+		return "", i18n.Errorf("API key is empty")
+	}
+	if strings.HasPrefix(key, "pk_") {
+		return "", i18n.Errorf("%s is a public key; use a secret key (sk_test_ or sk_live_)", MaskAPIKey(key))
+	}
+
+	var suffix string
+	switch {
+	case strings.HasPrefix(key, "sk_test_"):
+		suffix = strings.TrimPrefix(key, "sk_test_")
+	case strings.HasPrefix(key, "sk_live_"):
+		suffix = strings.TrimPrefix(key, "sk_live_")
+	default:
+		return "", i18n.Errorf("invalid API key: must start with sk_test_ or sk_live_")
+	}
+
+	for _, r := range suffix {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", i18n.Errorf("invalid API key: contains %q (check for whitespace or quotes pasted with the key)", r)
+		}
+	}
+	if len(suffix) < 16 || len(suffix) > 64 {
+		return "", i18n.Errorf("invalid API key: unexpected length (check that the whole key was copied)")
+	}
+	return key, nil
+}
+
 // ConfirmAction prompts for confirmation
 func ConfirmAction(message string) bool {
-	fmt.Printf("%s [y/N]: ", message)
+	return ConfirmFrom(os.Stdin, message)
+}
+
+// ConfirmFrom prompts for confirmation and reads the answer from in, such as the terminal when
+// stdin carries the input of the command
+func ConfirmFrom(in io.Reader, message string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", message)
 	var response string
-	fmt.Scanln(&response)
+	fmt.Fscanln(in, &response)
 	response = strings.ToLower(strings.TrimSpace(response))
 	return response == "y" || response == "yes"
 }
@@ -197,30 +391,47 @@ func TruncateString(s string, maxLen int) string {
 // ValidateAmount validates an amount
 func ValidateAmount(amount int) error {
 	if amount <= 0 {
-		return fmt.Errorf("amount must be greater than 0")
+		return i18n.Errorf("amount must be greater than 0")
 	}
 	return nil
 }
 
+// Currencies are the supported currency codes
+var Currencies = []string{"jpy", "usd"}
+
 // ValidateCurrency validates a currency code
 func ValidateCurrency(currency string) error {
 	currency = strings.ToLower(currency)
-	validCurrencies := []string{"jpy", "usd"}
-	for _, c := range validCurrencies {
+	for _, c := range Currencies {
 		if currency == c {
 			return nil
 		}
 	}
-	return fmt.Errorf("invalid currency: %s (supported: jpy, usd)", currency)
+	return i18n.Errorf("invalid currency: %s (supported: jpy, usd)", currency)
 }
 
+// Intervals are the supported subscription intervals
+var Intervals = []string{"month", "year"}
+
 // ValidateInterval validates a subscription interval
 func ValidateInterval(interval string) error {
-	validIntervals := []string{"month", "year"}
-	for _, i := range validIntervals {
+	for _, i := range Intervals {
 		if interval == i {
 			return nil
 		}
 	}
-	return fmt.Errorf("invalid interval: %s (supported: month, year)", interval)
+	return i18n.Errorf("invalid interval: %s (supported: month, year)", interval)
+}
+
+// RefundReasonCodes is the controlled vocabulary for refund reason codes
+var RefundReasonCodes = []string{"duplicate", "fraud", "customer_request", "other"}
+
+// ValidateRefundReasonCode validates a refund reason code
+func ValidateRefundReasonCode(code string) error {
+	for _, c := range RefundReasonCodes {
+		if code == c {
+			return nil
+		}
+	}
+	return i18n.Errorf("invalid reason code: %s (supported: %s)", code, strings.Join(RefundReasonCodes, ", "))
 }
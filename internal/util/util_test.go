@@ -0,0 +1,43 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAPIKey(t *testing.T) {
+	key := "sk_test_" + strings.Repeat("a1B2", 6)
+	tests := []struct {
+		input   string
+		want    string
+		wantErr string
+	}{
+		{input: key, want: key},
+		{input: "  " + key + "\n", want: key},
+		{input: `"` + key + `"`, want: key},
+		{input: "'" + key + "'", want: key},
+		{input: "`\"" + key + "\"`", want: key},
+		{input: "sk_live_" + strings.Repeat("x", 16), want: "sk_live_" + strings.Repeat("x", 16)},
+		{input: "", wantErr: "empty"},
+		{input: `""`, wantErr: "empty"},
+		{input: "pk_test_" + strings.Repeat("a", 24), wantErr: "public key"},
+		{input: "tk_test_" + strings.Repeat("a", 24), wantErr: "must start with"},
+		{input: key + `"`, wantErr: "contains"},
+		{input: `"` + key, wantErr: "must start with"},
+		{input: "sk_test_abc def" + strings.Repeat("a", 16), wantErr: "contains"},
+		{input: "sk_test_" + strings.Repeat("a", 15), wantErr: "length"},
+		{input: "sk_test_" + strings.Repeat("a", 65), wantErr: "length"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeAPIKey(tt.input)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NormalizeAPIKey(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeAPIKey(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
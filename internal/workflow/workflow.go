@@ -0,0 +1,200 @@
+// Package workflow runs operations made of several API requests as an explicit plan of steps
+// Completed steps are recorded in a journal so that a failed run can be resumed, and steps that
+// can be undone are rolled back in reverse order when a later step fails
+package workflow
+
+import (
+	"fmt"
+)
+
+// Step is one step of a workflow, usually a single API request
+type Step struct {
+	// Name identifies the step in the journal and must be unique in the workflow
+	Name string
+	// Description is shown in the plan and in progress messages
+	Description string
+	// Run performs the step and returns a value to remember, such as the ID of a created object
+	Run func() (string, error)
+	// Compensate undoes the completed step given the value returned by Run
+	// A step without Compensate cannot be rolled back, and neither can the steps before it
+	Compensate func(value string) error
+	// Optional steps only warn when they fail and do not stop the workflow
+	Optional bool
+}
+
+// Journal records completed steps of a workflow
+// *checkpoint.Checkpoint implements it
+type Journal interface {
+	Value(key string) (string, bool)
+	MarkValue(key, value string) error
+	Unmark(key string) error
+}
+
+// Workflow is a plan of steps run in order
+type Workflow struct {
+	// ID distinguishes runs of a workflow in the journal, e.g. the ID of the object operated on
+	ID    string
+	Steps []Step
+	// Journal records completed steps; without it a workflow cannot be resumed
+	Journal Journal
+	// Logf reports progress and warnings
+	Logf func(format string, args ...interface{})
+
+	values map[string]string
+}
+
+// New returns a workflow of the steps
+func New(id string, steps ...Step) *Workflow {
+	return &Workflow{ID: id, Steps: steps, values: map[string]string{}}
+}
+
+// Error is returned by Run when a step fails
+type Error struct {
+	// Step is the name of the failed step
+	Step string
+	Err  error
+	// RolledBack are the steps undone after the failure, in the order they were undone
+	RolledBack []string
+	// Kept are the completed steps that were not undone, because rollback was not requested,
+	// the step or a later one cannot be rolled back or rolling it back failed
+	Kept []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Plan returns one line per step describing what Run will do
+func (w *Workflow) Plan() []string {
+	lines := make([]string, 0, len(w.Steps))
+	for i, step := range w.Steps {
+		line := fmt.Sprintf("%d. %s", i+1, step.Description)
+		switch {
+		case w.done(step):
+			line += " (done)"
+		case step.Compensate != nil:
+			line += " (can be rolled back)"
+		case step.Optional:
+			line += " (optional)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Started reports whether the journal records a completed step of the workflow
+func (w *Workflow) Started() bool {
+	for _, step := range w.Steps {
+		if w.done(step) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the value returned by a completed step, including one completed in a previous run
+func (w *Workflow) Value(name string) string {
+	if value, ok := w.values[name]; ok {
+		return value
+	}
+	if w.Journal != nil {
+		value, _ := w.Journal.Value(w.key(name))
+		return value
+	}
+	return ""
+}
+
+// Run runs the steps in order, skipping the steps completed in a previous run
+// A step fails when it returns an error or its completion cannot be recorded in the journal
+// When a step fails and rollback is set, the completed steps are rolled back in reverse order back
+// to the last one that cannot be undone; the others are kept so that the run can be resumed from
+// the journal
+func (w *Workflow) Run(rollback bool) error {
+	completed := []Step{}
+	for _, step := range w.Steps {
+		if w.done(step) {
+			w.logf("Skipping %s (done in a previous run)", step.Description)
+			completed = append(completed, step)
+			continue
+		}
+
+		value, err := step.Run()
+		if err != nil {
+			if step.Optional {
+				w.logf("Warning: %s failed: %v", step.Description, err)
+				continue
+			}
+			return w.fail(step, err, completed, rollback)
+		}
+		w.values[step.Name] = value
+		completed = append(completed, step)
+		if w.Journal != nil {
+			// A step missing from the journal would run again on resume, so it fails the run and
+			// is rolled back with the other completed steps when it can be undone
+			if err := w.Journal.MarkValue(w.key(step.Name), value); err != nil {
+				return w.fail(step, fmt.Errorf("%s completed but could not be recorded: %w", step.Description, err), completed, rollback)
+			}
+		}
+	}
+	return nil
+}
+
+// fail rolls back the completed steps if requested and returns the error of the failed step
+// A completed step without Compensate is a point of no return: the steps before it are kept, since
+// undoing them would leave its effect in place without what it relied on
+func (w *Workflow) fail(failed Step, err error, completed []Step, rollback bool) error {
+	result := &Error{Step: failed.Name, Err: err}
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if rollback && step.Compensate == nil {
+			w.logf("Not rolling back the steps up to %s, which cannot be undone", step.Description)
+			rollback = false
+		}
+		if !rollback {
+			result.Kept = append(result.Kept, step.Name)
+			continue
+		}
+
+		w.logf("Rolling back: %s", step.Description)
+		if err := step.Compensate(w.Value(step.Name)); err != nil {
+			w.logf("Warning: failed to roll back %s: %v", step.Description, err)
+			result.Kept = append(result.Kept, step.Name)
+			continue
+		}
+		result.RolledBack = append(result.RolledBack, step.Name)
+		delete(w.values, step.Name)
+		if w.Journal != nil {
+			if err := w.Journal.Unmark(w.key(step.Name)); err != nil {
+				w.logf("Warning: %v", err)
+			}
+		}
+	}
+	return result
+}
+
+// done reports whether the step was completed in this or a previous run
+func (w *Workflow) done(step Step) bool {
+	if _, ok := w.values[step.Name]; ok {
+		return true
+	}
+	if w.Journal == nil {
+		return false
+	}
+	_, ok := w.Journal.Value(w.key(step.Name))
+	return ok
+}
+
+// key returns the journal key of a step
+func (w *Workflow) key(name string) string {
+	return w.ID + "/" + name
+}
+
+func (w *Workflow) logf(format string, args ...interface{}) {
+	if w.Logf != nil {
+		w.Logf(format, args...)
+	}
+}
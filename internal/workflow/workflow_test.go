@@ -0,0 +1,260 @@
+package workflow
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memoryJournal is a journal kept in memory that can be made to fail
+type memoryJournal struct {
+	values  map[string]string
+	failFor string
+}
+
+func newMemoryJournal() *memoryJournal {
+	return &memoryJournal{values: map[string]string{}}
+}
+
+func (j *memoryJournal) Value(key string) (string, bool) {
+	value, ok := j.values[key]
+	return value, ok
+}
+
+func (j *memoryJournal) MarkValue(key, value string) error {
+	if key == j.failFor {
+		return errors.New("disk full")
+	}
+	j.values[key] = value
+	return nil
+}
+
+func (j *memoryJournal) Unmark(key string) error {
+	delete(j.values, key)
+	return nil
+}
+
+// recorder builds steps that record the order they ran and were compensated in
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) step(name string, err error, compensate bool) Step {
+	step := Step{
+		Name:        name,
+		Description: "step " + name,
+		Run: func() (string, error) {
+			r.events = append(r.events, "run "+name)
+			if err != nil {
+				return "", err
+			}
+			return name + "-value", nil
+		},
+	}
+	if compensate {
+		step.Compensate = func(value string) error {
+			r.events = append(r.events, "undo "+value)
+			return nil
+		}
+	}
+	return step
+}
+
+func TestRun(t *testing.T) {
+	r := &recorder{}
+	journal := newMemoryJournal()
+	w := New("ch_1", r.step("a", nil, true), r.step("b", nil, false))
+	w.Journal = journal
+
+	if err := w.Run(true); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if want := []string{"run a", "run b"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if want := map[string]string{"ch_1/a": "a-value", "ch_1/b": "b-value"}; !reflect.DeepEqual(journal.values, want) {
+		t.Errorf("journal = %v, want %v", journal.values, want)
+	}
+	if got := w.Value("b"); got != "b-value" {
+		t.Errorf("Value(b) = %q, want b-value", got)
+	}
+}
+
+func TestRunRollsBackInReverseOrder(t *testing.T) {
+	r := &recorder{}
+	journal := newMemoryJournal()
+	boom := errors.New("boom")
+	w := New("ch_1", r.step("a", nil, true), r.step("b", nil, true), r.step("c", boom, true))
+	w.Journal = journal
+
+	err := w.Run(true)
+	var failed *Error
+	if !errors.As(err, &failed) {
+		t.Fatalf("Run() = %v, want *Error", err)
+	}
+	if failed.Step != "c" || !errors.Is(err, boom) {
+		t.Errorf("Run() = %v, want step c failing with boom", err)
+	}
+	if want := []string{"run a", "run b", "run c", "undo b-value", "undo a-value"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(failed.RolledBack, want) {
+		t.Errorf("RolledBack = %v, want %v", failed.RolledBack, want)
+	}
+	if len(failed.Kept) != 0 {
+		t.Errorf("Kept = %v, want none", failed.Kept)
+	}
+	if len(journal.values) != 0 {
+		t.Errorf("journal = %v, want empty", journal.values)
+	}
+}
+
+func TestRunStopsRollbackAtIrreversibleStep(t *testing.T) {
+	r := &recorder{}
+	journal := newMemoryJournal()
+	w := New("ch_1", r.step("a", nil, true), r.step("b", nil, false), r.step("c", nil, true), r.step("d", errors.New("boom"), true))
+	w.Journal = journal
+
+	var failed *Error
+	if err := w.Run(true); !errors.As(err, &failed) {
+		t.Fatalf("Run() = %v, want *Error", err)
+	}
+	// a is kept because b, which cannot be undone, completed after it
+	if want := []string{"run a", "run b", "run c", "run d", "undo c-value"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if want := []string{"c"}; !reflect.DeepEqual(failed.RolledBack, want) {
+		t.Errorf("RolledBack = %v, want %v", failed.RolledBack, want)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(failed.Kept, want) {
+		t.Errorf("Kept = %v, want %v", failed.Kept, want)
+	}
+	if want := map[string]string{"ch_1/a": "a-value", "ch_1/b": "b-value"}; !reflect.DeepEqual(journal.values, want) {
+		t.Errorf("journal = %v, want %v", journal.values, want)
+	}
+}
+
+func TestRunWithoutRollbackKeepsSteps(t *testing.T) {
+	r := &recorder{}
+	w := New("ch_1", r.step("a", nil, true), r.step("b", errors.New("boom"), true))
+	w.Journal = newMemoryJournal()
+
+	var failed *Error
+	if err := w.Run(false); !errors.As(err, &failed) {
+		t.Fatalf("Run() = %v, want *Error", err)
+	}
+	if len(failed.RolledBack) != 0 {
+		t.Errorf("RolledBack = %v, want none", failed.RolledBack)
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(failed.Kept, want) {
+		t.Errorf("Kept = %v, want %v", failed.Kept, want)
+	}
+}
+
+func TestRunOptionalStepFailure(t *testing.T) {
+	r := &recorder{}
+	optional := r.step("b", errors.New("boom"), false)
+	optional.Optional = true
+	w := New("ch_1", r.step("a", nil, false), optional, r.step("c", nil, false))
+	warnings := 0
+	w.Logf = func(format string, args ...interface{}) { warnings++ }
+
+	if err := w.Run(true); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if want := []string{"run a", "run b", "run c"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if warnings != 1 {
+		t.Errorf("warnings = %d, want 1", warnings)
+	}
+}
+
+func TestRunResumesFromJournal(t *testing.T) {
+	journal := newMemoryJournal()
+	journal.values["ch_1/a"] = "a-previous"
+
+	r := &recorder{}
+	w := New("ch_1", r.step("a", nil, true), r.step("b", errors.New("boom"), false))
+	w.Journal = journal
+	if !w.Started() {
+		t.Error("Started() = false, want true")
+	}
+
+	var failed *Error
+	if err := w.Run(true); !errors.As(err, &failed) {
+		t.Fatalf("Run() = %v, want *Error", err)
+	}
+	// The step of the previous run is rolled back with the value it recorded
+	if want := []string{"run b", "undo a-previous"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if len(journal.values) != 0 {
+		t.Errorf("journal = %v, want empty", journal.values)
+	}
+}
+
+func TestRunJournalFailureRollsBackTheStep(t *testing.T) {
+	r := &recorder{}
+	journal := newMemoryJournal()
+	journal.failFor = "ch_1/b"
+	w := New("ch_1", r.step("a", nil, true), r.step("b", nil, true), r.step("c", nil, true))
+	w.Journal = journal
+
+	var failed *Error
+	if err := w.Run(true); !errors.As(err, &failed) {
+		t.Fatalf("Run() = %v, want *Error", err)
+	}
+	if failed.Step != "b" {
+		t.Errorf("Step = %s, want b", failed.Step)
+	}
+	if want := []string{"run a", "run b", "undo b-value", "undo a-value"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(failed.RolledBack, want) {
+		t.Errorf("RolledBack = %v, want %v", failed.RolledBack, want)
+	}
+}
+
+func TestRunJournalFailureOfIrreversibleStep(t *testing.T) {
+	r := &recorder{}
+	journal := newMemoryJournal()
+	journal.failFor = "ch_1/b"
+	w := New("ch_1", r.step("a", nil, true), r.step("b", nil, false), r.step("c", nil, true))
+	w.Journal = journal
+
+	var failed *Error
+	err := w.Run(true)
+	if !errors.As(err, &failed) {
+		t.Fatalf("Run() = %v, want *Error", err)
+	}
+	if failed.Step != "b" || !strings.Contains(err.Error(), "could not be recorded") {
+		t.Errorf("Run() = %v, want step b failing to be recorded", err)
+	}
+	// b took effect, so undoing a would leave b without what it relied on
+	if want := []string{"run a", "run b"}; !reflect.DeepEqual(r.events, want) {
+		t.Errorf("events = %v, want %v", r.events, want)
+	}
+	if len(failed.RolledBack) != 0 {
+		t.Errorf("RolledBack = %v, want none", failed.RolledBack)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(failed.Kept, want) {
+		t.Errorf("Kept = %v, want %v", failed.Kept, want)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	journal := newMemoryJournal()
+	journal.values["ch_1/a"] = ""
+	r := &recorder{}
+	optional := r.step("c", nil, false)
+	optional.Optional = true
+	w := New("ch_1", r.step("a", nil, true), r.step("b", nil, true), optional)
+	w.Journal = journal
+
+	want := []string{"1. step a (done)", "2. step b (can be rolled back)", "3. step c (optional)"}
+	if got := w.Plan(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %v, want %v", got, want)
+	}
+}